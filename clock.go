@@ -1,6 +1,8 @@
 package loadingcache
 
 import (
+	"context"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,3 +21,55 @@ func (f ClockFunc) Now() time.Time {
 
 // SystemClock is the default clock that uses time.Now.
 var SystemClock Clock = ClockFunc(time.Now)
+
+// CachedClock is a Clock that caches the current time of the underlying clock.
+// It is useful to avoid calling the underlying clock for each read in high-frequency paths.
+//
+// The cached time is refreshed by Refresh, or periodically by the background updater.
+// So the time returned by Now lags behind the underlying clock by at most the refresh interval
+// (plus the scheduling delay of the background updater), and the expiration checks using it are late by the same bound.
+type CachedClock struct {
+	clock Clock
+	now   atomic.Pointer[time.Time]
+}
+
+// NewCachedClock creates a new CachedClock that caches the current time of the given clock.
+// The cached time is initialized with the current time of the clock.
+func NewCachedClock(clock Clock) *CachedClock {
+	c := &CachedClock{clock: clock}
+	c.Refresh()
+	return c
+}
+
+// Now returns the cached time.
+func (c *CachedClock) Now() time.Time {
+	return *c.now.Load()
+}
+
+// Refresh updates the cached time with the current time of the underlying clock.
+func (c *CachedClock) Refresh() {
+	now := c.clock.Now()
+	c.now.Store(&now)
+}
+
+// LaunchBackgroundUpdater starts the background updater that refreshes the cached time at the given interval.
+// The background updater can be stopped by canceling the context passed to LaunchBackgroundUpdater.
+func (c *CachedClock) LaunchBackgroundUpdater(ctx context.Context, interval time.Duration) {
+	go c.poll(ctx, interval)
+}
+
+// poll refreshes the cached time at the fixed interval.
+func (c *CachedClock) poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			c.Refresh()
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package loadingcache_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+func TestCachedClock(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var elapsed atomic.Int64
+	clock := loadingcache.NewCachedClock(loadingcache.ClockFunc(func() time.Time {
+		return base.Add(time.Duration(elapsed.Load()))
+	}))
+	if got := clock.Now(); !got.Equal(base) {
+		t.Errorf("expected the initial time %v, got %v", base, got)
+	}
+
+	elapsed.Store(int64(time.Second))
+	if got := clock.Now(); !got.Equal(base) {
+		t.Errorf("expected the cached time %v before refresh, got %v", base, got)
+	}
+
+	clock.Refresh()
+	if got, want := clock.Now(), base.Add(time.Second); !got.Equal(want) {
+		t.Errorf("expected the refreshed time %v, got %v", want, got)
+	}
+}
+
+func TestCachedClock_LaunchBackgroundUpdater(t *testing.T) {
+	t.Parallel()
+
+	clock := loadingcache.NewCachedClock(loadingcache.SystemClock)
+	clock.LaunchBackgroundUpdater(t.Context(), time.Millisecond)
+
+	initial := clock.Now()
+	deadline := time.Now().Add(time.Second)
+	for !clock.Now().After(initial) {
+		if time.Now().After(deadline) {
+			t.Fatal("the cached time is not refreshed by the background updater")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -3,6 +3,8 @@ package expiration
 import (
 	"math/rand/v2"
 	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
 )
 
 // ExpirationPolicy is the interface for the expiration time checker.
@@ -13,6 +15,53 @@ type ExpirationPolicy interface {
 	IsExpired(now, expiresAt time.Time) bool
 }
 
+// PerEntryExpirationPolicy is an optional interface for ExpirationPolicy that decides the expiration per entry.
+// The storages supporting it (e.g. memstorage) call IsEntryExpired instead of IsExpired for the stored entries,
+// so the policy can vary the behavior by the key, the value, or whether the entry is a negative cache.
+type PerEntryExpirationPolicy[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	ExpirationPolicy
+
+	// IsEntryExpired returns true if the entry is expired at now.
+	// The entry must not be modified.
+	IsEntryExpired(now time.Time, entry *loadingcache.CacheEntry[K, V]) bool
+}
+
+// AccessObserver is an optional interface for ExpirationPolicy that extends the expiration time of the entries on access.
+// The storages supporting it (e.g. memstorage) call OnAccess for each live entry returned by the reads,
+// and store the entry with the returned expiration time.
+type AccessObserver interface {
+	ExpirationPolicy
+
+	// OnAccess returns the new expiration time of the entry accessed at now, and whether the entry should be updated.
+	// The expiresAt and the negativeCache parameters are the current expiration time and the kind of the entry.
+	OnAccess(now, expiresAt time.Time, negativeCache bool) (time.Time, bool)
+}
+
+// NegativeCacheSplitPolicy is a policy that applies different policies to the negative caches and the other entries.
+// It is useful to expire the negative caches more aggressively than the positive ones, or vice versa.
+type NegativeCacheSplitPolicy[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Positive is the policy for the entries that are not negative caches.
+	Positive ExpirationPolicy
+
+	// Negative is the policy for the negative caches.
+	Negative ExpirationPolicy
+}
+
+var _ PerEntryExpirationPolicy[uint8, struct{}] = (*NegativeCacheSplitPolicy[uint8, struct{}])(nil)
+
+// IsExpired checks the expiration by the Positive policy, since the kind of the entry is unknown.
+func (p *NegativeCacheSplitPolicy[K, V]) IsExpired(now, expiresAt time.Time) bool {
+	return p.Positive.IsExpired(now, expiresAt)
+}
+
+// IsEntryExpired checks the expiration by the Negative policy for the negative caches, and by the Positive policy otherwise.
+func (p *NegativeCacheSplitPolicy[K, V]) IsEntryExpired(now time.Time, entry *loadingcache.CacheEntry[K, V]) bool {
+	if entry.NegativeCache {
+		return p.Negative.IsExpired(now, entry.ExpiresAt)
+	}
+	return p.Positive.IsExpired(now, entry.ExpiresAt)
+}
+
 // GeneralExpirationPolicy is a policy that expires a value at a specific time.
 // It implements the standard time-based expiration check where a value is
 // considered expired if the current time is after the expiration time.
@@ -38,6 +87,41 @@ func (NeverExpirationPolicy) IsExpired(now, expiresAt time.Time) bool {
 	return false
 }
 
+// SlidingExpirationPolicy is a policy that keeps the entries alive as long as they keep being read.
+// Each read of a live entry extends its expiration time to IdleTimeout after the read, so an entry expires
+// at the later of the expiration time it was stored with and IdleTimeout after its last read.
+// The expiration times are never shortened by the reads.
+//
+// It requires the storage supporting AccessObserver (e.g. memstorage); other storages treat it as GeneralExpirationPolicy.
+type SlidingExpirationPolicy struct {
+	// IdleTimeout is how long the entries are kept alive after each read.
+	IdleTimeout time.Duration
+
+	// SlideNegativeCaches makes the reads of the negative caches extend their expiration times as well.
+	// By default, the negative caches expire at the time they were stored with regardless of the reads,
+	// so that the frequently requested missing keys are still reloaded from the source periodically.
+	SlideNegativeCaches bool
+}
+
+var _ AccessObserver = (*SlidingExpirationPolicy)(nil)
+
+// IsExpired returns true if the current time is after the specified expiration time, in the same way as GeneralExpirationPolicy.
+func (p *SlidingExpirationPolicy) IsExpired(now, expiresAt time.Time) bool {
+	return !expiresAt.After(now)
+}
+
+// OnAccess returns IdleTimeout after now as the new expiration time if it is later than the current one.
+// It never extends the negative caches unless SlideNegativeCaches is set.
+func (p *SlidingExpirationPolicy) OnAccess(now, expiresAt time.Time, negativeCache bool) (time.Time, bool) {
+	if negativeCache && !p.SlideNegativeCaches {
+		return expiresAt, false
+	}
+	if extended := now.Add(p.IdleTimeout); extended.After(expiresAt) {
+		return extended, true
+	}
+	return expiresAt, false
+}
+
 // EarlyExpirationPolicy is a policy that can expire a value before its actual expiration time.
 // This policy is useful for preventing cache stampedes by introducing randomness in the
 // expiration process, causing different cache clients to refresh their values at different times.
@@ -80,3 +164,64 @@ func (p *EarlyExpirationPolicy) randFloat64() float64 {
 	}
 	return p.Random.Float64()
 }
+
+// ScheduleExpirationPolicy is a policy that expires a value at fixed times of day regardless of when it was cached.
+// It is useful for reference data that is updated at fixed times daily (e.g. a daily batch).
+//
+// The policy cannot see when a value was stored, so it derives the storage time as expiresAt - TTL.
+// It is accurate only when every value is stored with the same TTL.
+type ScheduleExpirationPolicy struct {
+	// Times are the scheduled boundaries as offsets from midnight (e.g. 4*time.Hour+30*time.Minute for 04:30).
+	// A value stored before the most recent boundary is expired once the boundary has come.
+	Times []time.Duration
+
+	// Location is the timezone of the scheduled times.
+	// If not set, time.UTC is used.
+	Location *time.Location
+
+	// TTL is the time to live that values are stored with.
+	// It is used to derive the storage time of the value from its expiration time.
+	TTL time.Duration
+}
+
+var _ ExpirationPolicy = (*ScheduleExpirationPolicy)(nil)
+
+// IsExpired returns true if the value is expired in the same way as GeneralExpirationPolicy,
+// or if a scheduled boundary has come since the value was stored.
+func (p *ScheduleExpirationPolicy) IsExpired(now, expiresAt time.Time) bool {
+	if !expiresAt.After(now) {
+		return true
+	}
+
+	boundary, ok := p.lastBoundary(now)
+	if !ok {
+		return false
+	}
+	storedAt := expiresAt.Add(-p.TTL)
+	return storedAt.Before(boundary)
+}
+
+// lastBoundary returns the most recent scheduled boundary at or before now.
+func (p *ScheduleExpirationPolicy) lastBoundary(now time.Time) (time.Time, bool) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var last time.Time
+	var found bool
+	year, month, day := now.In(loc).Date()
+	for _, d := range []int{day, day - 1} {
+		for _, offset := range p.Times {
+			// time.Date normalizes the offset as a wall clock time, so the boundary follows DST transitions.
+			boundary := time.Date(year, month, d, 0, 0, 0, int(offset), loc)
+			if boundary.After(now) {
+				continue
+			}
+			if !found || boundary.After(last) {
+				last, found = boundary, true
+			}
+		}
+	}
+	return last, found
+}
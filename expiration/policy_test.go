@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/expiration"
 )
 
@@ -177,3 +178,192 @@ func TestEarlyExpirationPolicy(t *testing.T) {
 		}
 	})
 }
+
+func TestScheduleExpirationPolicy(t *testing.T) {
+	t.Parallel()
+
+	jst := time.FixedZone("JST", 9*60*60)
+	policy := &expiration.ScheduleExpirationPolicy{
+		Times:    []time.Duration{4 * time.Hour, 16*time.Hour + 30*time.Minute},
+		Location: jst,
+		TTL:      24 * time.Hour,
+	}
+	boundary := time.Date(2023, 1, 2, 4, 0, 0, 0, jst)
+
+	tests := []struct {
+		name     string
+		storedAt time.Time
+		now      time.Time
+		want     bool
+	}{
+		{
+			name:     "not expired before the boundary",
+			storedAt: boundary.Add(-time.Hour),
+			now:      boundary.Add(-1),
+			want:     false,
+		},
+		{
+			name:     "expired exactly at the boundary",
+			storedAt: boundary.Add(-time.Hour),
+			now:      boundary,
+			want:     true,
+		},
+		{
+			name:     "expired after the boundary",
+			storedAt: boundary.Add(-time.Hour),
+			now:      boundary.Add(time.Hour),
+			want:     true,
+		},
+		{
+			name:     "not expired when stored exactly at the boundary",
+			storedAt: boundary,
+			now:      boundary.Add(time.Hour),
+			want:     false,
+		},
+		{
+			name:     "not expired when stored after the boundary",
+			storedAt: boundary.Add(time.Minute),
+			now:      boundary.Add(time.Hour),
+			want:     false,
+		},
+		{
+			name:     "expired at the next boundary of the day",
+			storedAt: boundary.Add(time.Minute),
+			now:      time.Date(2023, 1, 2, 16, 30, 0, 0, jst),
+			want:     true,
+		},
+		{
+			name:     "expired at the boundary of the previous day in the timezone",
+			storedAt: time.Date(2023, 1, 1, 16, 0, 0, 0, jst),
+			now:      time.Date(2023, 1, 1, 16, 0, 0, 0, jst).Add(12 * time.Hour).In(time.UTC),
+			want:     true,
+		},
+		{
+			name:     "expired when the TTL has passed",
+			storedAt: boundary.Add(time.Minute),
+			now:      boundary.Add(time.Minute + 24*time.Hour),
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			expiresAt := tt.storedAt.Add(policy.TTL)
+			if got := policy.IsExpired(tt.now, expiresAt); got != tt.want {
+				t.Errorf("ScheduleExpirationPolicy.IsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleExpirationPolicy_NoTimes(t *testing.T) {
+	t.Parallel()
+
+	policy := &expiration.ScheduleExpirationPolicy{TTL: time.Hour}
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	if policy.IsExpired(now, now.Add(1)) {
+		t.Error("Should not be expired when expiry is in future")
+	}
+	if !policy.IsExpired(now, now) {
+		t.Error("Should be expired when expiry is exactly now")
+	}
+}
+
+func TestNegativeCacheSplitPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &expiration.NegativeCacheSplitPolicy[uint8, string]{
+		Positive: expiration.GeneralExpirationPolicy{},
+		Negative: &expiration.EarlyExpirationPolicy{Duration: 10 * time.Minute, Percentage: 1},
+	}
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		expiresAt     time.Time
+		negativeCache bool
+		want          bool
+	}{
+		{name: "positive entry before expiry", expiresAt: now.Add(5 * time.Minute), want: false},
+		{name: "positive entry after expiry", expiresAt: now.Add(-time.Second), want: true},
+		{name: "negative cache within early expiration", expiresAt: now.Add(5 * time.Minute), negativeCache: true, want: true},
+		{name: "negative cache before early expiration", expiresAt: now.Add(15 * time.Minute), negativeCache: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry := &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: 1}, ExpiresAt: tt.expiresAt, NegativeCache: tt.negativeCache}
+			if got := policy.IsEntryExpired(now, entry); got != tt.want {
+				t.Errorf("IsEntryExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if policy.IsExpired(now, now.Add(5*time.Minute)) {
+		t.Error("IsExpired must follow the Positive policy")
+	}
+}
+
+func TestSlidingExpirationPolicy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		policy        *expiration.SlidingExpirationPolicy
+		expiresAt     time.Time
+		negativeCache bool
+		want          time.Time
+		wantOK        bool
+	}{
+		{
+			name:      "extends the entry expiring within the idle timeout",
+			policy:    &expiration.SlidingExpirationPolicy{IdleTimeout: time.Minute},
+			expiresAt: now.Add(time.Second),
+			want:      now.Add(time.Minute),
+			wantOK:    true,
+		},
+		{
+			name:      "never shortens the entry expiring after the idle timeout",
+			policy:    &expiration.SlidingExpirationPolicy{IdleTimeout: time.Minute},
+			expiresAt: now.Add(time.Hour),
+			want:      now.Add(time.Hour),
+			wantOK:    false,
+		},
+		{
+			name:          "does not extend the negative cache by default",
+			policy:        &expiration.SlidingExpirationPolicy{IdleTimeout: time.Minute},
+			expiresAt:     now.Add(time.Second),
+			negativeCache: true,
+			want:          now.Add(time.Second),
+			wantOK:        false,
+		},
+		{
+			name:          "extends the negative cache with SlideNegativeCaches",
+			policy:        &expiration.SlidingExpirationPolicy{IdleTimeout: time.Minute, SlideNegativeCaches: true},
+			expiresAt:     now.Add(time.Second),
+			negativeCache: true,
+			want:          now.Add(time.Minute),
+			wantOK:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := tt.policy.OnAccess(now, tt.expiresAt, tt.negativeCache)
+			if !got.Equal(tt.want) || ok != tt.wantOK {
+				t.Errorf("OnAccess() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	policy := &expiration.SlidingExpirationPolicy{IdleTimeout: time.Minute}
+	if !policy.IsExpired(now, now) || policy.IsExpired(now, now.Add(1)) {
+		t.Error("IsExpired must follow GeneralExpirationPolicy")
+	}
+}
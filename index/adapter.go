@@ -2,6 +2,7 @@ package index
 
 import (
 	"context"
+	"iter"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 )
@@ -28,3 +29,96 @@ var _ loadingcache.IndexSource[uint8, uint8] = (*FunctionIndexSource[uint8, uint
 func (f FunctionIndexSource[SecondaryKey, PrimaryKey]) GetAll(ctx context.Context) (map[SecondaryKey][]PrimaryKey, error) {
 	return f(ctx)
 }
+
+// FunctionExpiringIndexSource is an index source that uses a function to retrieve all associations with their expiration times.
+type FunctionExpiringIndexSource[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] func(context.Context) (map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey], error)
+
+var _ loadingcache.ExpiringIndexSource[uint8, uint8] = (*FunctionExpiringIndexSource[uint8, uint8])(nil)
+
+// GetAll calls the function and returns the primary keys without the expiration times.
+// It does not drop expired associations.
+func (f FunctionExpiringIndexSource[SecondaryKey, PrimaryKey]) GetAll(ctx context.Context) (map[SecondaryKey][]PrimaryKey, error) {
+	entries, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[SecondaryKey][]PrimaryKey, len(entries))
+	for sk, es := range entries {
+		pks := make([]PrimaryKey, len(es))
+		for i, e := range es {
+			pks[i] = e.PrimaryKey
+		}
+		m[sk] = pks
+	}
+	return m, nil
+}
+
+// GetAllWithExpiry calls the function.
+func (f FunctionExpiringIndexSource[SecondaryKey, PrimaryKey]) GetAllWithExpiry(ctx context.Context) (map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey], error) {
+	return f(ctx)
+}
+
+// FunctionsKeyedIndexSource is an index source that uses functions to retrieve all associations or those of a single secondary key.
+type FunctionsKeyedIndexSource[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
+	GetAllFunc            func(context.Context) (map[SecondaryKey][]PrimaryKey, error)
+	GetBySecondaryKeyFunc func(context.Context, SecondaryKey) ([]PrimaryKey, error)
+}
+
+var _ loadingcache.KeyedIndexSource[uint8, uint8] = (*FunctionsKeyedIndexSource[uint8, uint8])(nil)
+
+// GetAll calls the GetAllFunc function.
+func (f *FunctionsKeyedIndexSource[SecondaryKey, PrimaryKey]) GetAll(ctx context.Context) (map[SecondaryKey][]PrimaryKey, error) {
+	return f.GetAllFunc(ctx)
+}
+
+// GetBySecondaryKey calls the GetBySecondaryKeyFunc function.
+func (f *FunctionsKeyedIndexSource[SecondaryKey, PrimaryKey]) GetBySecondaryKey(ctx context.Context, sk SecondaryKey) ([]PrimaryKey, error) {
+	return f.GetBySecondaryKeyFunc(ctx, sk)
+}
+
+// FunctionStreamingIndexSource is an index source that uses a function to stream all associations.
+// The function must call yield for each secondary key and its primary keys, and stop when yield returns false.
+type FunctionStreamingIndexSource[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] func(ctx context.Context, yield func(SecondaryKey, []PrimaryKey) bool) error
+
+var _ loadingcache.StreamingIndexSource[uint8, uint8] = (*FunctionStreamingIndexSource[uint8, uint8])(nil)
+
+// GetAll calls the function and collects all the associations into a map.
+func (f FunctionStreamingIndexSource[SecondaryKey, PrimaryKey]) GetAll(ctx context.Context) (map[SecondaryKey][]PrimaryKey, error) {
+	m := map[SecondaryKey][]PrimaryKey{}
+	if err := f(ctx, func(sk SecondaryKey, pks []PrimaryKey) bool {
+		m[sk] = append(m[sk], pks...)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Stream returns an iterator that calls the function.
+func (f FunctionStreamingIndexSource[SecondaryKey, PrimaryKey]) Stream(ctx context.Context) (iter.Seq2[SecondaryKey, []PrimaryKey], func() error) {
+	var err error
+	seq := iter.Seq2[SecondaryKey, []PrimaryKey](func(yield func(SecondaryKey, []PrimaryKey) bool) {
+		err = f(ctx, yield)
+	})
+	return seq, func() error { return err }
+}
+
+// NoopRefreshIndex is an index that implements loadingcache.RefreshIndex without refreshing anything.
+// It allows the static indexes to be used where refreshable indexes are expected.
+type NoopRefreshIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
+	loadingcache.Index[SecondaryKey, PrimaryKey]
+}
+
+var _ loadingcache.Index[uint8, uint8] = (*NoopRefreshIndex[uint8, uint8])(nil)
+var _ loadingcache.RefreshIndex = (*NoopRefreshIndex[uint8, uint8])(nil)
+
+// NoopRefresh wraps the index to implement loadingcache.RefreshIndex with a no-op Refresh.
+func NoopRefresh[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](idx loadingcache.Index[SecondaryKey, PrimaryKey]) *NoopRefreshIndex[SecondaryKey, PrimaryKey] {
+	return &NoopRefreshIndex[SecondaryKey, PrimaryKey]{Index: idx}
+}
+
+// Refresh does nothing and returns nil.
+func (*NoopRefreshIndex[SecondaryKey, PrimaryKey]) Refresh(context.Context) error {
+	return nil
+}
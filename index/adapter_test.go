@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/index"
 )
 
@@ -222,3 +224,79 @@ func TestFunctionIndex_GetMulti(t *testing.T) {
 		})
 	}
 }
+
+func TestFunctionExpiringIndexSource_GetAll(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	indexSource := index.FunctionExpiringIndexSource[uint8, uint8](
+		func(context.Context) (map[uint8][]loadingcache.IndexEntry[uint8], error) {
+			return map[uint8][]loadingcache.IndexEntry[uint8]{
+				1: {{PrimaryKey: 10, ExpiresAt: expiresAt}, {PrimaryKey: 11}},
+				2: {{PrimaryKey: 20}},
+			}, nil
+		},
+	)
+
+	gotResult, gotErr := indexSource.GetAll(t.Context())
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %v", gotErr)
+	}
+	if diff := cmp.Diff(map[uint8][]uint8{1: {10, 11}, 2: {20}}, gotResult); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	gotEntries, gotErr := indexSource.GetAllWithExpiry(t.Context())
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %v", gotErr)
+	}
+	if diff := cmp.Diff(map[uint8][]loadingcache.IndexEntry[uint8]{
+		1: {{PrimaryKey: 10, ExpiresAt: expiresAt}, {PrimaryKey: 11}},
+		2: {{PrimaryKey: 20}},
+	}, gotEntries); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestNoopRefresh(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	idx := index.NoopRefresh[string, int](&index.FunctionsIndex[string, int]{
+		GetFunc: func(_ context.Context, key string) ([]int, error) {
+			calls++
+			return []int{1, 2}, nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []string) (map[string][]int, error) {
+			calls++
+			return map[string][]int{"a": {1, 2}}, nil
+		},
+	})
+
+	var refresher loadingcache.RefreshIndex = idx
+	if err := refresher.Refresh(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Refresh must not call the underlying index, but called %d times", calls)
+	}
+
+	pks, err := idx.Get(t.Context(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1, 2}, pks); diff != "" {
+		t.Errorf("Get: unexpected result (-want +got):\n%s", diff)
+	}
+
+	m, err := idx.GetMulti(t.Context(), []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string][]int{"a": {1, 2}}, m); diff != "" {
+		t.Errorf("GetMulti: unexpected result (-want +got):\n%s", diff)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls to the underlying index, got %d", calls)
+	}
+}
@@ -117,6 +117,11 @@ func (i *OrIndex[LeftSecondaryKey, RightSecondaryKey, PrimaryKey]) GetMulti(ctx
 type AndIndex[LeftSecondaryKey loadingcache.KeyConstraint, RightSecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
 	Left  loadingcache.Index[LeftSecondaryKey, PrimaryKey]
 	Right loadingcache.Index[RightSecondaryKey, PrimaryKey]
+
+	// Intersection is an optional strategy to intersect the primary keys of Left and Right.
+	// The default is HashSetIntersection. SortedMergeIntersection or BitsetIntersection may allocate less
+	// for the huge results if their preconditions are met.
+	Intersection IntersectionStrategy[PrimaryKey]
 }
 
 var _ loadingcache.Index[Keys[uint8, uint8], uint8] = (*AndIndex[uint8, uint8, uint8])(nil)
@@ -157,7 +162,7 @@ func (i *AndIndex[LeftSecondaryKey, RightSecondaryKey, PrimaryKey]) Get(ctx cont
 		}
 		return nil, nil
 	default:
-		pks := slices.Collect(iterutil.Intersection(slices.Values(leftPks), slices.Values(rightPks)))
+		pks := i.intersect(leftPks, rightPks)
 		return pks, nil
 	}
 }
@@ -219,8 +224,16 @@ func (i *AndIndex[LeftSecondaryKey, RightSecondaryKey, PrimaryKey]) GetMulti(ctx
 				result[key] = right
 			}
 		default:
-			result[key] = slices.Collect(iterutil.Intersection(slices.Values(left), slices.Values(right)))
+			result[key] = i.intersect(left, right)
 		}
 	}
 	return result, nil
 }
+
+// intersect returns the intersection of the primary keys by the strategy.
+func (i *AndIndex[LeftSecondaryKey, RightSecondaryKey, PrimaryKey]) intersect(left, right []PrimaryKey) []PrimaryKey {
+	if i.Intersection == nil {
+		return HashSetIntersection(left, right)
+	}
+	return i.Intersection(left, right)
+}
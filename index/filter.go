@@ -0,0 +1,74 @@
+package index
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// FilterIndex is an index that filters the primary keys retrieved from the underlying index by a predicate.
+// It is useful to exclude the primary keys by an external rule (e.g. soft-deleted IDs) before loading them.
+//
+// Keep is called once per distinct primary key for each Get or GetMulti call, so its cost grows with the number of
+// the retrieved primary keys. Expensive predicates should be backed by a cache or a batch lookup.
+type FilterIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
+	// Index is the underlying index.
+	Index loadingcache.Index[SecondaryKey, PrimaryKey]
+
+	// Keep reports whether the primary key should be kept in the results.
+	Keep func(context.Context, PrimaryKey) (bool, error)
+}
+
+var _ loadingcache.Index[uint8, uint8] = (*FilterIndex[uint8, uint8])(nil)
+
+// Get retrieves primary keys by secondary key and filters them by Keep.
+func (i *FilterIndex[SecondaryKey, PrimaryKey]) Get(ctx context.Context, key SecondaryKey) ([]PrimaryKey, error) {
+	pks, err := i.Index.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return i.filter(ctx, pks, map[PrimaryKey]bool{})
+}
+
+// GetMulti retrieves primary keys by multiple secondary keys and filters them by Keep.
+// The secondary keys whose primary keys are all filtered out are omitted from the result.
+func (i *FilterIndex[SecondaryKey, PrimaryKey]) GetMulti(ctx context.Context, keys []SecondaryKey) (map[SecondaryKey][]PrimaryKey, error) {
+	m, err := i.Index.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := map[PrimaryKey]bool{}
+	result := make(map[SecondaryKey][]PrimaryKey, len(m))
+	for sk, pks := range m {
+		pks, err := i.filter(ctx, pks, kept)
+		if err != nil {
+			return nil, err
+		}
+		if len(pks) != 0 {
+			result[sk] = pks
+		}
+	}
+	return result, nil
+}
+
+// filter returns the primary keys kept by Keep.
+// The decisions are memoized in kept to call Keep once per primary key.
+func (i *FilterIndex[SecondaryKey, PrimaryKey]) filter(ctx context.Context, pks []PrimaryKey, kept map[PrimaryKey]bool) ([]PrimaryKey, error) {
+	var result []PrimaryKey
+	for _, pk := range pks {
+		keep, ok := kept[pk]
+		if !ok {
+			var err error
+			keep, err = i.Keep(ctx, pk)
+			if err != nil {
+				return nil, err
+			}
+			kept[pk] = keep
+		}
+		if keep {
+			result = append(result, pk)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package index_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/loading-cache/index"
+)
+
+func TestFilterIndex(t *testing.T) {
+	t.Parallel()
+
+	m := map[string][]int{
+		"a": {1, 2, 3},
+		"b": {2, 4},
+		"c": {4},
+	}
+	base := &index.FunctionsIndex[string, int]{
+		GetFunc: func(_ context.Context, key string) ([]int, error) {
+			return m[key], nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []string) (map[string][]int, error) {
+			result := map[string][]int{}
+			for _, key := range keys {
+				if pks, ok := m[key]; ok {
+					result[key] = pks
+				}
+			}
+			return result, nil
+		},
+	}
+
+	newIndex := func(calls map[int]int) *index.FilterIndex[string, int] {
+		return &index.FilterIndex[string, int]{
+			Index: base,
+			Keep: func(_ context.Context, pk int) (bool, error) {
+				calls[pk]++
+				// drop even primary keys
+				return pk%2 != 0, nil
+			},
+		}
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		t.Parallel()
+
+		calls := map[int]int{}
+		pks, err := newIndex(calls).Get(t.Context(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]int{1, 3}, pks); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("GetMulti", func(t *testing.T) {
+		t.Parallel()
+
+		calls := map[int]int{}
+		result, err := newIndex(calls).GetMulti(t.Context(), []string{"a", "b", "c", "d"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string][]int{"a": {1, 3}}, result); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(map[int]int{1: 1, 2: 1, 3: 1, 4: 1}, calls); diff != "" {
+			t.Errorf("Keep must be called once per primary key (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		keepErr := errors.New("keep error")
+		i := &index.FilterIndex[string, int]{
+			Index: base,
+			Keep: func(context.Context, int) (bool, error) {
+				return false, keepErr
+			},
+		}
+		if _, err := i.Get(t.Context(), "a"); !errors.Is(err, keepErr) {
+			t.Errorf("Get: expected %v, got %v", keepErr, err)
+		}
+		if _, err := i.GetMulti(t.Context(), []string{"a"}); !errors.Is(err, keepErr) {
+			t.Errorf("GetMulti: expected %v, got %v", keepErr, err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package index
+
+import (
+	"cmp"
+	"math/bits"
+	"slices"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/internal/iterutil"
+)
+
+// IntersectionStrategy is a function that returns the primary keys present in both results of Index.Get.
+// It returns nil if there are no such primary keys.
+// Each result does not contain duplicated primary keys, as Index.Get guarantees.
+type IntersectionStrategy[PrimaryKey loadingcache.KeyConstraint] func(left, right []PrimaryKey) []PrimaryKey
+
+// Integer is a constraint for the primary keys that BitsetIntersection can handle.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// HashSetIntersection is the default IntersectionStrategy of AndIndex.
+// It has no preconditions on the results, but it allocates a hash set of the primary keys of both results.
+// The primary keys are in the order of the right result.
+func HashSetIntersection[PrimaryKey loadingcache.KeyConstraint](left, right []PrimaryKey) []PrimaryKey {
+	return slices.Collect(iterutil.Intersection(slices.Values(left), slices.Values(right)))
+}
+
+// SortedMergeIntersection is an IntersectionStrategy that merges the results without any allocation but the result.
+// Both results must be sorted in ascending order, e.g. by the index source, otherwise the result is incorrect.
+// The primary keys are in ascending order.
+func SortedMergeIntersection[PrimaryKey cmp.Ordered](left, right []PrimaryKey) []PrimaryKey {
+	var pks []PrimaryKey
+	for i, j := 0, 0; i < len(left) && j < len(right); {
+		switch c := cmp.Compare(left[i], right[j]); {
+		case c < 0:
+			i++
+		case c > 0:
+			j++
+		default:
+			pks = append(pks, left[i])
+			i++
+			j++
+		}
+	}
+	return pks
+}
+
+// BitsetIntersection is an IntersectionStrategy that marks the primary keys of the left result in a bitset.
+// It is suitable for the dense small non-negative integer primary keys,
+// since the bitset has a bit for each integer up to the largest primary key of the left result.
+// The negative primary keys are never in the result.
+// The primary keys are in the order of the right result.
+func BitsetIntersection[PrimaryKey Integer](left, right []PrimaryKey) []PrimaryKey {
+	var maxPk PrimaryKey
+	for _, pk := range left {
+		maxPk = max(maxPk, pk)
+	}
+
+	set := make([]uint, uint64(maxPk)/bits.UintSize+1)
+	for _, pk := range left {
+		if pk >= 0 {
+			set[uint64(pk)/bits.UintSize] |= 1 << (uint64(pk) % bits.UintSize)
+		}
+	}
+
+	var pks []PrimaryKey
+	for _, pk := range right {
+		if pk < 0 || pk > maxPk {
+			continue
+		}
+		if set[uint64(pk)/bits.UintSize]&(1<<(uint64(pk)%bits.UintSize)) != 0 {
+			pks = append(pks, pk)
+		}
+	}
+	return pks
+}
@@ -0,0 +1,148 @@
+package index_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/loading-cache/index"
+)
+
+func TestIntersectionStrategies(t *testing.T) {
+	t.Parallel()
+
+	strategies := []struct {
+		name       string
+		intersect  index.IntersectionStrategy[int32]
+		sortsInput bool
+	}{
+		{name: "HashSet", intersect: index.HashSetIntersection[int32]},
+		{name: "SortedMerge", intersect: index.SortedMergeIntersection[int32], sortsInput: true},
+		{name: "Bitset", intersect: index.BitsetIntersection[int32]},
+	}
+	tests := []struct {
+		name        string
+		left, right []int32
+		want        []int32
+	}{
+		{name: "both empty", want: nil},
+		{name: "left empty", right: []int32{1, 2}, want: nil},
+		{name: "right empty", left: []int32{1, 2}, want: nil},
+		{name: "overlapping", left: []int32{5, 1, 3, 7}, right: []int32{3, 4, 5, 6}, want: []int32{3, 5}},
+		{name: "disjoint", left: []int32{1, 3}, right: []int32{2, 4}, want: nil},
+		{name: "same", left: []int32{2, 1, 0}, right: []int32{0, 1, 2}, want: []int32{0, 1, 2}},
+		{name: "across words", left: []int32{0, 63, 64, 65, 200}, right: []int32{64, 200, 201}, want: []int32{64, 200}},
+		{name: "right beyond left", left: []int32{1, 2}, right: []int32{2, 1000}, want: []int32{2}},
+	}
+
+	for _, strategy := range strategies {
+		t.Run(strategy.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, tt := range tests {
+				left, right := slices.Clone(tt.left), slices.Clone(tt.right)
+				if strategy.sortsInput {
+					slices.Sort(left)
+					slices.Sort(right)
+				}
+
+				got := strategy.intersect(left, right)
+				slices.Sort(got)
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("%s: unexpected result (-want +got):\n%s", tt.name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestBitsetIntersection_Negative(t *testing.T) {
+	t.Parallel()
+
+	got := index.BitsetIntersection([]int8{-1, 1, 2}, []int8{-1, 2})
+	if diff := cmp.Diff([]int8{2}, got); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestAndIndex_Intersection(t *testing.T) {
+	t.Parallel()
+
+	left := &index.FunctionsIndex[int8, uint16]{
+		GetFunc: func(context.Context, int8) ([]uint16, error) {
+			return []uint16{1, 3, 5, 7}, nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []int8) (map[int8][]uint16, error) {
+			return map[int8][]uint16{keys[0]: {1, 3, 5, 7}}, nil
+		},
+	}
+	right := &index.FunctionsIndex[uint8, uint16]{
+		GetFunc: func(context.Context, uint8) ([]uint16, error) {
+			return []uint16{3, 4, 5}, nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []uint8) (map[uint8][]uint16, error) {
+			return map[uint8][]uint16{keys[0]: {3, 4, 5}}, nil
+		},
+	}
+	key := index.Keys[int8, uint8]{Left: index.MaybeKey[int8]{Key: 1}, Right: index.MaybeKey[uint8]{Key: 2}}
+
+	var calls int
+	andIndex := &index.AndIndex[int8, uint8, uint16]{
+		Left:  left,
+		Right: right,
+		Intersection: func(left, right []uint16) []uint16 {
+			calls++
+			return index.SortedMergeIntersection(left, right)
+		},
+	}
+
+	got, err := andIndex.Get(t.Context(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint16{3, 5}, got); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	gotMulti, err := andIndex.GetMulti(t.Context(), []index.Keys[int8, uint8]{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[index.Keys[int8, uint8]][]uint16{key: {3, 5}}, gotMulti); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if calls != 2 {
+		t.Errorf("expected the strategy to be called twice, got %d", calls)
+	}
+}
+
+func BenchmarkIntersectionStrategies(b *testing.B) {
+	const n = 1 << 20
+	left := make([]uint32, 0, n)
+	right := make([]uint32, 0, n)
+	for pk := range uint32(2 * n) {
+		if pk%2 == 0 {
+			left = append(left, pk)
+		}
+		if pk%3 == 0 && len(right) < n {
+			right = append(right, pk)
+		}
+	}
+
+	for _, bc := range []struct {
+		name      string
+		intersect index.IntersectionStrategy[uint32]
+	}{
+		{name: "HashSet", intersect: index.HashSetIntersection[uint32]},
+		{name: "SortedMerge", intersect: index.SortedMergeIntersection[uint32]},
+		{name: "Bitset", intersect: index.BitsetIntersection[uint32]},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bc.intersect(left, right)
+			}
+		})
+	}
+}
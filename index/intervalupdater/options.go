@@ -0,0 +1,28 @@
+package intervalupdater
+
+import "context"
+
+// Option is the interface for the options of IntervalIndexUpdater.
+type Option interface {
+	apply(*IntervalIndexUpdater)
+}
+
+type optionFunc func(*IntervalIndexUpdater)
+
+func (f optionFunc) apply(u *IntervalIndexUpdater) {
+	f(u)
+}
+
+// ChangeDetector is a function that reports whether the data of the index may have changed since its last call.
+// It is expected to be much cheaper than the refresh of the index, e.g. a query of the last modified time.
+type ChangeDetector func(ctx context.Context) (bool, error)
+
+// WithChangeDetector makes the updater ask the detector before each periodic refresh, and skip the refresh
+// if the detector reports no change. It avoids rebuilding the large indexes unnecessarily.
+// The first refresh on launch is always performed to build the index.
+// If the detector returns an error, the error is passed to the background error handler and the refresh is skipped.
+func WithChangeDetector(detector ChangeDetector) Option {
+	return optionFunc(func(u *IntervalIndexUpdater) {
+		u.detector = detector
+	})
+}
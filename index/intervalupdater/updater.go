@@ -14,17 +14,22 @@ type IntervalIndexUpdater struct {
 	index             loadingcache.RefreshIndex
 	interval          time.Duration
 	onBackgroundError func(error)
+	detector          ChangeDetector
 }
 
 // NewIntervalIndexUpdater creates a new IntervalIndexUpdater.
 // The IntervalIndexUpdater includes a callback mechanism for handling errors that occur during
 // background refresh operations. When creating an updater, you must provide an error handler function as a parameter.
-func NewIntervalIndexUpdater(index loadingcache.RefreshIndex, interval time.Duration, onBackgroundError func(error)) *IntervalIndexUpdater {
-	return &IntervalIndexUpdater{
+func NewIntervalIndexUpdater(index loadingcache.RefreshIndex, interval time.Duration, onBackgroundError func(error), opts ...Option) *IntervalIndexUpdater {
+	u := &IntervalIndexUpdater{
 		index:             index,
 		interval:          interval,
 		onBackgroundError: onBackgroundError,
 	}
+	for _, opt := range opts {
+		opt.apply(u)
+	}
+	return u
 }
 
 // LaunchBackgroundUpdater starts the background updater.
@@ -48,9 +53,27 @@ func (u *IntervalIndexUpdater) poll(ctx context.Context) {
 			return
 
 		case <-ticker.C:
+			if !u.changed(ctx) {
+				continue
+			}
 			if err := u.index.Refresh(ctx); err != nil {
 				u.onBackgroundError(err)
 			}
 		}
 	}
 }
+
+// changed reports whether the index should be refreshed by asking the change detector.
+// It always returns true if WithChangeDetector is not specified.
+func (u *IntervalIndexUpdater) changed(ctx context.Context) bool {
+	if u.detector == nil {
+		return true
+	}
+
+	changed, err := u.detector(ctx)
+	if err != nil {
+		u.onBackgroundError(err)
+		return false
+	}
+	return changed
+}
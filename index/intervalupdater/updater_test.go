@@ -92,3 +92,84 @@ func TestLaunchBackgroundUpdater_Error(t *testing.T) {
 		}
 	}()
 }
+
+func TestLaunchBackgroundUpdater_ChangeDetector(t *testing.T) {
+	t.Parallel()
+
+	var refreshCount, detectCount atomic.Uint32
+	var changed atomic.Bool
+	idx := mockRefreshIndex(func(context.Context) error {
+		refreshCount.Add(1)
+		return nil
+	})
+	detector := func(context.Context) (bool, error) {
+		detectCount.Add(1)
+		return changed.Load(), nil
+	}
+
+	var bgErrs []error
+	var mu sync.Mutex
+	updater := intervalupdater.NewIntervalIndexUpdater(idx, 100*time.Millisecond, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		bgErrs = append(bgErrs, err)
+	}, intervalupdater.WithChangeDetector(detector))
+	updater.LaunchBackgroundUpdater(t.Context())
+
+	// the first refresh is always performed, and the following ones are skipped while nothing changes
+	time.Sleep(350 * time.Millisecond)
+	if n := detectCount.Load(); n < 2 {
+		t.Errorf("expect the detector to be asked repeatedly, but asked %d times", n)
+	}
+	if n := refreshCount.Load(); n != 1 {
+		t.Errorf("expect to be refreshed only at first time, but refreshed %d times", n)
+	}
+
+	changed.Store(true)
+	time.Sleep(150 * time.Millisecond)
+	if n := refreshCount.Load(); n < 2 {
+		t.Errorf("expect to be refreshed after the change, but refreshed %d times", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bgErrs) != 0 {
+		t.Errorf("should no background errors, but got: %+v", bgErrs)
+	}
+}
+
+func TestLaunchBackgroundUpdater_ChangeDetectorError(t *testing.T) {
+	t.Parallel()
+
+	var refreshCount atomic.Uint32
+	idx := mockRefreshIndex(func(context.Context) error {
+		refreshCount.Add(1)
+		return nil
+	})
+	detectErr := errors.New("detect error")
+	detector := func(context.Context) (bool, error) {
+		return true, detectErr
+	}
+
+	var bgErrs []error
+	var mu sync.Mutex
+	updater := intervalupdater.NewIntervalIndexUpdater(idx, 200*time.Millisecond, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		bgErrs = append(bgErrs, err)
+	}, intervalupdater.WithChangeDetector(detector))
+	updater.LaunchBackgroundUpdater(t.Context())
+
+	time.Sleep(300 * time.Millisecond)
+	if n := refreshCount.Load(); n != 1 {
+		t.Errorf("expect the refresh to be skipped on the detector error, but refreshed %d times", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if df := cmp.Diff([]error{detectErr}, bgErrs, cmp.Comparer(func(x, y error) bool {
+		return errors.Is(x, y) || errors.Is(y, x)
+	})); df != "" {
+		t.Errorf("unexpected background errors: %+v", bgErrs)
+	}
+}
@@ -1,6 +1,8 @@
 package index
 
 import (
+	"cmp"
+	"fmt"
 	"iter"
 
 	loadingcache "github.com/karupanerura/loading-cache"
@@ -24,6 +26,31 @@ func (k *MaybeKey[K]) Iter() iter.Seq[K] {
 	})
 }
 
+// String returns the string representation of the key for debugging.
+// It returns "<empty>" if the key is not present.
+func (k MaybeKey[K]) String() string {
+	if k.Empty {
+		return "<empty>"
+	}
+	return fmt.Sprint(k.Key)
+}
+
+// CompareMaybeKey compares two MaybeKey instances.
+// The empty key is ordered before any present key.
+// It returns -1 if a is less than b, 0 if a equals b, and +1 if a is greater than b.
+func CompareMaybeKey[K cmp.Ordered](a, b MaybeKey[K]) int {
+	switch {
+	case a.Empty && b.Empty:
+		return 0
+	case a.Empty:
+		return -1
+	case b.Empty:
+		return 1
+	default:
+		return cmp.Compare(a.Key, b.Key)
+	}
+}
+
 // Keys is a struct with two secondary keys used as a key for OrIndex and AndIndex.
 //
 // The secondary keys are stored in the Left and Right fields. (general case)
@@ -43,6 +70,21 @@ type Keys[LeftSecondaryKey loadingcache.KeyConstraint, RightSecondaryKey loading
 	Right MaybeKey[RightSecondaryKey]
 }
 
+// String returns the string representation of the keys for debugging.
+func (k Keys[LeftSecondaryKey, RightSecondaryKey]) String() string {
+	return "{Left: " + k.Left.String() + ", Right: " + k.Right.String() + "}"
+}
+
+// CompareKeys compares two Keys instances by the left keys first, then by the right keys.
+// The empty keys are ordered before any present keys. It can be used with slices.SortFunc for deterministic sorting.
+// It returns -1 if a is less than b, 0 if a equals b, and +1 if a is greater than b.
+func CompareKeys[LeftSecondaryKey cmp.Ordered, RightSecondaryKey cmp.Ordered](a, b Keys[LeftSecondaryKey, RightSecondaryKey]) int {
+	if c := CompareMaybeKey(a.Left, b.Left); c != 0 {
+		return c
+	}
+	return CompareMaybeKey(a.Right, b.Right)
+}
+
 // NewKeys returns a new Keys instance with the given secondary keys.
 func NewKeys[LeftSecondaryKey loadingcache.KeyConstraint, RightSecondaryKey loadingcache.KeyConstraint](left LeftSecondaryKey, right RightSecondaryKey) Keys[LeftSecondaryKey, RightSecondaryKey] {
 	return Keys[LeftSecondaryKey, RightSecondaryKey]{
@@ -1,6 +1,8 @@
 package index_test
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -311,3 +313,65 @@ func TestMaybeKey_Iter_Break(t *testing.T) {
 	}
 	// No panic is expected
 }
+
+func TestCompareKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := []index.Keys[int, string]{
+		index.NewKeys(2, "a"),
+		index.RightKey[int]("b"),
+		index.LeftKey[int, string](1),
+		index.NewKeys(1, "b"),
+		{Left: index.MaybeKey[int]{Empty: true}, Right: index.MaybeKey[string]{Empty: true}},
+		index.NewKeys(1, "a"),
+		index.RightKey[int]("a"),
+	}
+	slices.SortFunc(keys, index.CompareKeys[int, string])
+
+	expected := []index.Keys[int, string]{
+		{Left: index.MaybeKey[int]{Empty: true}, Right: index.MaybeKey[string]{Empty: true}},
+		index.RightKey[int]("a"),
+		index.RightKey[int]("b"),
+		index.LeftKey[int, string](1),
+		index.NewKeys(1, "a"),
+		index.NewKeys(1, "b"),
+		index.NewKeys(2, "a"),
+	}
+	if diff := cmp.Diff(expected, keys); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+
+	for _, tt := range []struct {
+		a, b index.Keys[int, string]
+		want int
+	}{
+		{a: index.NewKeys(1, "a"), b: index.NewKeys(1, "a"), want: 0},
+		{a: index.LeftKey[int, string](1), b: index.LeftKey[int, string](1), want: 0},
+		{a: index.LeftKey[int, string](1), b: index.NewKeys(1, "a"), want: -1},
+		{a: index.NewKeys(1, "a"), b: index.RightKey[int]("a"), want: 1},
+	} {
+		if got := index.CompareKeys(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareKeys(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestKeys_String(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		keys index.Keys[int, string]
+		want string
+	}{
+		{keys: index.NewKeys(1, "a"), want: "{Left: 1, Right: a}"},
+		{keys: index.LeftKey[int, string](1), want: "{Left: 1, Right: <empty>}"},
+		{keys: index.RightKey[int]("a"), want: "{Left: <empty>, Right: a}"},
+	} {
+		if got := tt.keys.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		if got := fmt.Sprint(tt.keys); got != tt.want {
+			t.Errorf("fmt.Sprint() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -46,7 +46,8 @@
 //
 // - Thread-safe for concurrent reads
 // - Atomic index updates via Refresh()
-// - First reads block until index is initialized
+// - Single key updates via RefreshKey() with a loadingcache.KeyedIndexSource
+// - First reads block until index is initialized, up to WithInitializationTimeout if set
 // - All operations respect context cancellation
 // - Copies returned data to prevent mutation
 //
@@ -2,43 +2,94 @@ package omcindex
 
 import (
 	"context"
+	"errors"
+	"maps"
 	"runtime"
+	"slices"
 	"sync"
+	"time"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/internal/ctxsync"
 	"github.com/karupanerura/loading-cache/internal/panicutil"
 )
 
+// ctxCheckInterval is the number of the secondary keys between the checks of the context cancellation in GetMulti
+// and GetMultiWithExpiry. The lookups of fewer keys never check the context after acquiring the lock.
+const ctxCheckInterval = 256
+
+// ErrNoSource is returned by Refresh if the index does not have a source.
+var ErrNoSource = errors.New("the index does not have a source")
+
+// ErrUnkeyedSource is returned by RefreshKey if the source does not implement loadingcache.KeyedIndexSource.
+var ErrUnkeyedSource = errors.New("the source cannot retrieve the associations of a single secondary key")
+
+// ErrIndexNotReady is returned by the reads waiting for the first refresh if it does not complete within
+// the timeout of WithInitializationTimeout. It is the same error as loadingcache.ErrIndexNotReady.
+var ErrIndexNotReady = loadingcache.ErrIndexNotReady
+
 // OnMemoryIndex is an in-memory index that stores the mapping between secondary keys and primary keys.
 type OnMemoryIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
-	source loadingcache.IndexSource[SecondaryKey, PrimaryKey]
+	source   loadingcache.IndexSource[SecondaryKey, PrimaryKey]
+	clock    loadingcache.Clock
+	cloner   func(PrimaryKey) PrimaryKey
+	readOnly bool
+	initWait time.Duration
 
 	mu     sync.RWMutex
 	rl     ctxsync.CtxLocker
 	sc     ctxsync.CtxSyncCond
 	goexit bool
 	m      map[SecondaryKey][]PrimaryKey
+	x      map[SecondaryKey][]time.Time
+	owned  bool
 }
 
 var _ loadingcache.Index[uint8, uint8] = (*OnMemoryIndex[uint8, uint8])(nil)
+var _ loadingcache.ExpiringIndex[uint8, uint8] = (*OnMemoryIndex[uint8, uint8])(nil)
 var _ loadingcache.RefreshIndex = (*OnMemoryIndex[uint8, uint8])(nil)
+var _ loadingcache.MutableIndex[uint8, uint8] = (*OnMemoryIndex[uint8, uint8])(nil)
+var _ loadingcache.ReadinessIndex = (*OnMemoryIndex[uint8, uint8])(nil)
 
 // NewOnMemoryIndex creates a new OnMemoryIndex instance.
-func NewOnMemoryIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](source loadingcache.IndexSource[SecondaryKey, PrimaryKey]) *OnMemoryIndex[SecondaryKey, PrimaryKey] {
+func NewOnMemoryIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](source loadingcache.IndexSource[SecondaryKey, PrimaryKey], opts ...Option[SecondaryKey, PrimaryKey]) *OnMemoryIndex[SecondaryKey, PrimaryKey] {
 	index := &OnMemoryIndex[SecondaryKey, PrimaryKey]{
 		source: source,
 	}
+	for _, opt := range opts {
+		opt.apply(index)
+	}
 	index.rl = ctxsync.CtxLocker{Locker: index.mu.RLocker()}
 	index.sc = ctxsync.CtxSyncCond{Cond: sync.NewCond(index.rl.Locker)}
 	return index
 }
 
+// NewOnMemoryIndexWithData creates a new OnMemoryIndex instance initialized with the given data.
+// The index is ready for reads immediately without calling Refresh.
+// The data must not be modified after it is passed.
+//
+// It does not have a source unless WithSource is specified, and Refresh returns ErrNoSource in that case.
+// If a source is specified, Refresh replaces the data with the entries retrieved from the source.
+func NewOnMemoryIndexWithData[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](data map[SecondaryKey][]PrimaryKey, opts ...Option[SecondaryKey, PrimaryKey]) *OnMemoryIndex[SecondaryKey, PrimaryKey] {
+	index := NewOnMemoryIndex(nil, opts...)
+	if data == nil {
+		data = map[SecondaryKey][]PrimaryKey{}
+	}
+	index.m = data
+	return index
+}
+
 // Refresh refreshes the index entries.
 // It retrieves all the entries from the source and updates the index.
+// If the source implements loadingcache.ExpiringIndexSource, the expiration times of the associations are retrieved as well.
+// If the source implements loadingcache.StreamingIndexSource, the index is built incrementally from the stream.
 // If an error occurs during retrieval, it returns the error.
 // This method is blocking any other calls until the index is refreshed.
 func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Refresh(ctx context.Context) error {
+	if i.source == nil {
+		return ErrNoSource
+	}
+
 	dds := panicutil.DoubleDeferSandwich{
 		OnGoexit: func() {
 			i.mu.Lock()
@@ -50,7 +101,19 @@ func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Refresh(ctx context.Context) e
 	}
 
 	var m map[SecondaryKey][]PrimaryKey
+	var x map[SecondaryKey][]time.Time
 	if err := dds.Invoke(func() (err error) {
+		if source, ok := i.source.(loadingcache.ExpiringIndexSource[SecondaryKey, PrimaryKey]); ok {
+			var entries map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey]
+			entries, err = source.GetAllWithExpiry(ctx)
+			m, x = splitIndexEntries(entries)
+			return
+		}
+		if source, ok := i.source.(loadingcache.StreamingIndexSource[SecondaryKey, PrimaryKey]); ok {
+			m, err = collectStream(ctx, source)
+			return
+		}
+
 		m, err = i.source.GetAll(ctx)
 		return
 	}); err != nil {
@@ -61,61 +124,430 @@ func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Refresh(ctx context.Context) e
 	defer i.mu.Unlock()
 
 	i.m = m
+	i.x = x
+	i.owned = false
 	i.sc.Broadcast()
 	return nil
 }
 
+// RefreshKey refreshes the associations of a single secondary key.
+// It retrieves the primary keys of the secondary key by GetBySecondaryKey of the source,
+// which must implement loadingcache.KeyedIndexSource, otherwise ErrUnkeyedSource is returned.
+// The other secondary keys are untouched, and the secondary key is removed if the source returns no primary keys.
+// The refreshed associations never expire, and they are replaced by the next Refresh.
+// The readers never observe the partially updated associations, since the stored slices are replaced rather than mutated.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) RefreshKey(ctx context.Context, sk SecondaryKey) error {
+	if i.source == nil {
+		return ErrNoSource
+	}
+	source, ok := i.source.(loadingcache.KeyedIndexSource[SecondaryKey, PrimaryKey])
+	if !ok {
+		return ErrUnkeyedSource
+	}
+
+	// note: the source is called without the lock, so that the reads are not blocked during the retrieval.
+	pks, err := source.GetBySecondaryKey(ctx, sk)
+	if err != nil {
+		return err
+	}
+
+	if err := i.lockInitialized(ctx); err != nil {
+		return err
+	}
+	defer i.mu.Unlock()
+
+	if len(pks) == 0 {
+		delete(i.m, sk)
+		if i.x != nil {
+			delete(i.x, sk)
+		}
+		return nil
+	}
+
+	// note: the slice is owned by the source, so it must be copied.
+	i.m[sk] = slices.Clone(pks)
+	if i.x != nil {
+		// note: the zero expiration time means the association never expires.
+		i.x[sk] = make([]time.Time, len(pks))
+	}
+	return nil
+}
+
+// Ready reports whether the index is initialized by Refresh, Import or NewOnMemoryIndexWithData.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Ready() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.m != nil
+}
+
+// Export returns the snapshot of the live associations of the index.
+// The expiration times of the associations are not included.
+// It returns nil if the index is not initialized yet, without waiting for the initialization.
+// The returned map is a copy, so the caller may modify it.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Export() map[SecondaryKey][]PrimaryKey {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.m == nil {
+		return nil
+	}
+
+	now := i.now()
+	m := make(map[SecondaryKey][]PrimaryKey, len(i.m))
+	for sk := range i.m {
+		if pks := i.get(sk, now); pks != nil {
+			m[sk] = pks
+		}
+	}
+	return m
+}
+
+// Import replaces the index entries with the snapshot atomically as Refresh does.
+// It is useful to warm up a new index with the snapshot exported from another one, without waiting for a slow Refresh.
+// The imported associations never expire, and they are replaced by the next Refresh.
+// The snapshot must not be modified after it is passed.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Import(snapshot map[SecondaryKey][]PrimaryKey) {
+	if snapshot == nil {
+		snapshot = map[SecondaryKey][]PrimaryKey{}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.m = snapshot
+	i.x = nil
+	i.owned = false
+	i.sc.Broadcast()
+}
+
+// collectStream builds the index entries by consuming the stream of the source.
+func collectStream[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](ctx context.Context, source loadingcache.StreamingIndexSource[SecondaryKey, PrimaryKey]) (map[SecondaryKey][]PrimaryKey, error) {
+	seq, errFn := source.Stream(ctx)
+
+	m := map[SecondaryKey][]PrimaryKey{}
+	for sk, pks := range seq {
+		// note: the yielded slice may be reused by the source, so it must be copied.
+		m[sk] = append(m[sk], pks...)
+	}
+	if err := errFn(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// splitIndexEntries splits the index entries into the primary keys and the expiration times.
+func splitIndexEntries[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](entries map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey]) (map[SecondaryKey][]PrimaryKey, map[SecondaryKey][]time.Time) {
+	if entries == nil {
+		return nil, nil
+	}
+
+	m := make(map[SecondaryKey][]PrimaryKey, len(entries))
+	x := make(map[SecondaryKey][]time.Time, len(entries))
+	for sk, es := range entries {
+		pks := make([]PrimaryKey, len(es))
+		expiresAt := make([]time.Time, len(es))
+		for j, e := range es {
+			pks[j] = e.PrimaryKey
+			expiresAt[j] = e.ExpiresAt
+		}
+		m[sk] = pks
+		x[sk] = expiresAt
+	}
+	return m, x
+}
+
 // Goexit calls the Goexit method from waiting for the refresh operation to complete.
 func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Goexit() {
 }
 
-// Get retrieves primary keys by secondary key.
-// This method is blocked until the index is initialized.
-func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Get(ctx context.Context, sk SecondaryKey) ([]PrimaryKey, error) {
+// rlockInitialized acquires the read lock after the index is initialized.
+// The caller must release the read lock if no error is returned.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) rlockInitialized(ctx context.Context) error {
 	if err := i.rl.LockCtx(ctx); err != nil {
-		return nil, err
+		return err
+	}
+	if i.m == nil && i.initWait > 0 {
+		waitCtx, cancel := context.WithTimeoutCause(ctx, i.initWait, ErrIndexNotReady)
+		defer cancel()
+
+		if err := i.waitInitialized(waitCtx); err != nil {
+			// note: the caller's context error takes precedence over the initialization timeout.
+			if ctx.Err() == nil && errors.Is(context.Cause(waitCtx), ErrIndexNotReady) {
+				return ErrIndexNotReady
+			}
+			return err
+		}
+		return nil
 	}
+	return i.waitInitialized(ctx)
+}
+
+// waitInitialized waits for the index to be initialized with the read lock held.
+// The read lock is released if an error is returned.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) waitInitialized(ctx context.Context) error {
 	for i.m == nil {
 		if i.goexit {
 			runtime.Goexit()
 		}
 		if err := i.sc.WaitCtx(ctx); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	defer i.rl.Unlock()
+	return nil
+}
 
-	if i.m[sk] == nil {
-		return nil, nil
+// now returns the current time to drop expired associations.
+// It returns the zero time if dropping expired associations is disabled.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) now() time.Time {
+	if i.clock == nil || i.x == nil {
+		return time.Time{}
 	}
+	return i.clock.Now()
+}
 
-	pks := make([]PrimaryKey, len(i.m[sk]))
-	copy(pks, i.m[sk])
-	return pks, nil
+// collect returns the live associations of the secondary key.
+// The yield function is called with the primary key and its expiration time for each live association.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) collect(sk SecondaryKey, now time.Time, yield func(PrimaryKey, time.Time)) {
+	expiresAt := i.x[sk]
+	for j, pk := range i.m[sk] {
+		var t time.Time
+		if expiresAt != nil {
+			t = expiresAt[j]
+		}
+		if !now.IsZero() && !t.IsZero() && !t.After(now) {
+			continue
+		}
+		yield(i.clonePrimaryKey(pk), t)
+	}
+}
+
+// clonePrimaryKey returns the clone of the primary key by the cloner of WithPrimaryKeyCloner.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) clonePrimaryKey(pk PrimaryKey) PrimaryKey {
+	if i.cloner == nil {
+		return pk
+	}
+	return i.cloner(pk)
+}
+
+// view returns the stored primary keys to be returned to the caller.
+// It returns them without copying if WithReadOnlyResults is specified, and the copy of them otherwise.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) view(stored []PrimaryKey) []PrimaryKey {
+	if stored == nil {
+		return nil
+	}
+	if i.readOnly {
+		return stored[:len(stored):len(stored)]
+	}
+
+	pks := make([]PrimaryKey, len(stored))
+	if i.cloner == nil {
+		copy(pks, stored)
+		return pks
+	}
+	for j, pk := range stored {
+		pks[j] = i.cloner(pk)
+	}
+	return pks
+}
+
+// get returns the live primary keys of the secondary key.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) get(sk SecondaryKey, now time.Time) []PrimaryKey {
+	if now.IsZero() {
+		return i.view(i.m[sk])
+	}
+
+	// note: expired associations are dropped, so the result may be shorter than the stored one.
+	var pks []PrimaryKey
+	i.collect(sk, now, func(pk PrimaryKey, _ time.Time) {
+		pks = append(pks, pk)
+	})
+	return pks
+}
+
+// Get retrieves primary keys by secondary key.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Get(ctx context.Context, sk SecondaryKey) ([]PrimaryKey, error) {
+	if err := i.rlockInitialized(ctx); err != nil {
+		return nil, err
+	}
+	defer i.rl.Unlock()
+
+	return i.get(sk, i.now()), nil
 }
 
 // GetMulti retrieves primary keys by multiple secondary keys.
 // This method is blocked until the index is initialized.
 func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) GetMulti(ctx context.Context, sks []SecondaryKey) (map[SecondaryKey][]PrimaryKey, error) {
-	if err := i.rl.LockCtx(ctx); err != nil {
+	if err := i.rlockInitialized(ctx); err != nil {
 		return nil, err
 	}
-	for i.m == nil {
-		if i.goexit {
-			runtime.Goexit()
-		}
-		if err := i.sc.WaitCtx(ctx); err != nil {
+	defer i.rl.Unlock()
+
+	now := i.now()
+	m := make(map[SecondaryKey][]PrimaryKey, len(sks))
+	for j, sk := range sks {
+		if err := checkCtx(ctx, j); err != nil {
 			return nil, err
 		}
+
+		pks, ok := i.m[sk]
+		if !ok {
+			continue
+		}
+
+		if now.IsZero() {
+			m[sk] = i.view(pks)
+		} else if live := i.get(sk, now); live != nil {
+			m[sk] = live
+		}
+	}
+	return m, nil
+}
+
+// GetWithExpiry retrieves primary keys with the expiration times of their associations by secondary key.
+// The expiration times are zero if the source does not implement loadingcache.ExpiringIndexSource.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) GetWithExpiry(ctx context.Context, sk SecondaryKey) ([]loadingcache.IndexEntry[PrimaryKey], error) {
+	if err := i.rlockInitialized(ctx); err != nil {
+		return nil, err
 	}
 	defer i.rl.Unlock()
 
-	m := make(map[SecondaryKey][]PrimaryKey, len(sks))
-	for _, sk := range sks {
-		if pks, ok := i.m[sk]; ok {
-			m[sk] = make([]PrimaryKey, len(pks))
-			copy(m[sk], pks)
+	var entries []loadingcache.IndexEntry[PrimaryKey]
+	i.collect(sk, i.now(), func(pk PrimaryKey, expiresAt time.Time) {
+		entries = append(entries, loadingcache.IndexEntry[PrimaryKey]{PrimaryKey: pk, ExpiresAt: expiresAt})
+	})
+	return entries, nil
+}
+
+// GetMultiWithExpiry retrieves primary keys with the expiration times of their associations by multiple secondary keys.
+// The expiration times are zero if the source does not implement loadingcache.ExpiringIndexSource.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) GetMultiWithExpiry(ctx context.Context, sks []SecondaryKey) (map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey], error) {
+	if err := i.rlockInitialized(ctx); err != nil {
+		return nil, err
+	}
+	defer i.rl.Unlock()
+
+	now := i.now()
+	m := make(map[SecondaryKey][]loadingcache.IndexEntry[PrimaryKey], len(sks))
+	for j, sk := range sks {
+		if err := checkCtx(ctx, j); err != nil {
+			return nil, err
+		}
+
+		var entries []loadingcache.IndexEntry[PrimaryKey]
+		i.collect(sk, now, func(pk PrimaryKey, expiresAt time.Time) {
+			entries = append(entries, loadingcache.IndexEntry[PrimaryKey]{PrimaryKey: pk, ExpiresAt: expiresAt})
+		})
+		if entries != nil {
+			m[sk] = entries
 		}
 	}
 	return m, nil
 }
+
+// checkCtx returns the context error at every ctxCheckInterval-th secondary key of a multi lookup.
+// It lets the lookups of many keys return promptly after the context is canceled.
+func checkCtx(ctx context.Context, n int) error {
+	if n == 0 || n%ctxCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// lockInitialized acquires the write lock after the index is initialized.
+// The caller must release the write lock if no error is returned.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) lockInitialized(ctx context.Context) error {
+	if err := i.rlockInitialized(ctx); err != nil {
+		return err
+	}
+	i.rl.Unlock()
+
+	// note: the index is never uninitialized once initialized, so it is safe to acquire the write lock here.
+	i.mu.Lock()
+
+	// note: the maps may be owned by the source, so they must be copied before the first modification.
+	if !i.owned {
+		i.m = maps.Clone(i.m)
+		if i.x != nil {
+			i.x = maps.Clone(i.x)
+		}
+		i.owned = true
+	}
+	return nil
+}
+
+// Add associates the primary keys with the secondary key.
+// The primary keys already associated with the secondary key are ignored.
+// The added associations never expire, and they are discarded by the next Refresh unless the source returns them.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Add(ctx context.Context, sk SecondaryKey, pks ...PrimaryKey) error {
+	if err := i.lockInitialized(ctx); err != nil {
+		return err
+	}
+	defer i.mu.Unlock()
+
+	// note: the slices may be shared with the readers of the previous snapshot, so they must be copied on write.
+	current := i.m[sk]
+	added := slices.Clone(current)
+	for _, pk := range pks {
+		if !slices.Contains(added, pk) {
+			added = append(added, pk)
+		}
+	}
+	if len(added) == len(current) {
+		return nil
+	}
+
+	i.m[sk] = added
+	if i.x != nil {
+		// note: the zero expiration time means the association never expires.
+		i.x[sk] = append(slices.Clone(i.x[sk]), make([]time.Time, len(added)-len(current))...)
+	}
+	return nil
+}
+
+// Remove dissociates the primary keys from the secondary key.
+// The primary keys not associated with the secondary key are ignored.
+// The removed associations are restored by the next Refresh if the source returns them.
+// This method is blocked until the index is initialized.
+func (i *OnMemoryIndex[SecondaryKey, PrimaryKey]) Remove(ctx context.Context, sk SecondaryKey, pks ...PrimaryKey) error {
+	if err := i.lockInitialized(ctx); err != nil {
+		return err
+	}
+	defer i.mu.Unlock()
+
+	current, ok := i.m[sk]
+	if !ok {
+		return nil
+	}
+
+	expiresAt := i.x[sk]
+	var remainingPks []PrimaryKey
+	var remainingExpiresAt []time.Time
+	for j, pk := range current {
+		if slices.Contains(pks, pk) {
+			continue
+		}
+		remainingPks = append(remainingPks, pk)
+		if expiresAt != nil {
+			remainingExpiresAt = append(remainingExpiresAt, expiresAt[j])
+		}
+	}
+
+	if len(remainingPks) == 0 {
+		delete(i.m, sk)
+		if i.x != nil {
+			delete(i.x, sk)
+		}
+		return nil
+	}
+	i.m[sk] = remainingPks
+	if i.x != nil {
+		i.x[sk] = remainingExpiresAt
+	}
+	return nil
+}
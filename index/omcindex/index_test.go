@@ -3,12 +3,15 @@ package omcindex_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/index"
 	"github.com/karupanerura/loading-cache/index/omcindex"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
 )
 
 type testCase struct {
@@ -319,3 +322,729 @@ func TestOnMemoryInde_GetMulti_Concurrency(t *testing.T) {
 		}
 	})
 }
+
+func TestOnMemoryIndex_DropExpired(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	source := index.FunctionExpiringIndexSource[uint8, uint8](
+		func(ctx context.Context) (map[uint8][]loadingcache.IndexEntry[uint8], error) {
+			return map[uint8][]loadingcache.IndexEntry[uint8]{
+				1: {
+					{PrimaryKey: 10, ExpiresAt: base.Add(time.Hour)},
+					{PrimaryKey: 11, ExpiresAt: base.Add(2 * time.Hour)},
+					{PrimaryKey: 12},
+				},
+				2: {
+					{PrimaryKey: 20, ExpiresAt: base.Add(time.Hour)},
+				},
+			}, nil
+		},
+	)
+
+	clock := &storagetest.FixedClock{Time: base}
+	idx := omcindex.NewOnMemoryIndex[uint8, uint8](source, omcindex.WithDropExpired[uint8, uint8](clock))
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatalf("failed to initialize index: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		expectedGet  []uint8
+		expectedData map[uint8][]uint8
+	}{
+		{
+			name:         "before expiration",
+			now:          base.Add(time.Hour - time.Second),
+			expectedGet:  []uint8{10, 11, 12},
+			expectedData: map[uint8][]uint8{1: {10, 11, 12}, 2: {20}},
+		},
+		{
+			name:         "at expiration",
+			now:          base.Add(time.Hour),
+			expectedGet:  []uint8{11, 12},
+			expectedData: map[uint8][]uint8{1: {11, 12}},
+		},
+		{
+			name:         "after all expiration",
+			now:          base.Add(2 * time.Hour),
+			expectedGet:  []uint8{12},
+			expectedData: map[uint8][]uint8{1: {12}},
+		},
+	}
+	for _, tt := range tests {
+		clock.Time = tt.now
+
+		result, err := idx.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if diff := cmp.Diff(tt.expectedGet, result); diff != "" {
+			t.Errorf("%s: unexpected Get result (-want +got):\n%s", tt.name, diff)
+		}
+
+		results, err := idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if diff := cmp.Diff(tt.expectedData, results); diff != "" {
+			t.Errorf("%s: unexpected GetMulti result (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	clock.Time = base
+	entries, err := idx.GetWithExpiry(t.Context(), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]loadingcache.IndexEntry[uint8]{{PrimaryKey: 20, ExpiresAt: base.Add(time.Hour)}}, entries); diff != "" {
+		t.Errorf("unexpected GetWithExpiry result (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_KeepExpired(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	source := index.FunctionExpiringIndexSource[uint8, uint8](
+		func(ctx context.Context) (map[uint8][]loadingcache.IndexEntry[uint8], error) {
+			return map[uint8][]loadingcache.IndexEntry[uint8]{
+				1: {
+					{PrimaryKey: 10, ExpiresAt: base.Add(-time.Hour)},
+					{PrimaryKey: 11},
+				},
+			}, nil
+		},
+	)
+
+	// Expired associations are kept without WithDropExpired option
+	idx := omcindex.NewOnMemoryIndex[uint8, uint8](source)
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatalf("failed to initialize index: %v", err)
+	}
+
+	result, err := idx.Get(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]uint8{10, 11}, result); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_StreamingSource(t *testing.T) {
+	t.Parallel()
+
+	data := map[uint8][]uint8{
+		1: {10, 11, 12},
+		2: {20, 21},
+		3: {30},
+	}
+
+	t.Run("MatchesGetAll", func(t *testing.T) {
+		t.Parallel()
+
+		var yields int
+		streaming := index.FunctionStreamingIndexSource[uint8, uint8](
+			func(ctx context.Context, yield func(uint8, []uint8) bool) error {
+				buf := make([]uint8, 0, 1)
+				for sk, pks := range data {
+					// yield primary keys one by one with a reused buffer
+					for _, pk := range pks {
+						yields++
+						buf = append(buf[:0], pk)
+						if !yield(sk, buf) {
+							return nil
+						}
+					}
+				}
+				return nil
+			},
+		)
+		plain := index.FunctionIndexSource[uint8, uint8](
+			func(ctx context.Context) (map[uint8][]uint8, error) {
+				return data, nil
+			},
+		)
+
+		streamingIdx := omcindex.NewOnMemoryIndex[uint8, uint8](streaming)
+		if err := streamingIdx.Refresh(t.Context()); err != nil {
+			t.Fatalf("failed to initialize index: %v", err)
+		}
+		plainIdx := omcindex.NewOnMemoryIndex[uint8, uint8](plain)
+		if err := plainIdx.Refresh(t.Context()); err != nil {
+			t.Fatalf("failed to initialize index: %v", err)
+		}
+
+		if yields != 6 {
+			t.Errorf("stream must be fully consumed: got %d yields, want 6", yields)
+		}
+
+		keys := []uint8{1, 2, 3, 4}
+		want, err := plainIdx.GetMulti(t.Context(), keys)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := streamingIdx.GetMulti(t.Context(), keys)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		sourceErr := errors.New("source error")
+		var failing bool
+		streaming := index.FunctionStreamingIndexSource[uint8, uint8](
+			func(ctx context.Context, yield func(uint8, []uint8) bool) error {
+				if !yield(1, []uint8{10}) {
+					return nil
+				}
+				if failing {
+					return sourceErr
+				}
+				return nil
+			},
+		)
+
+		idx := omcindex.NewOnMemoryIndex[uint8, uint8](streaming)
+		if err := idx.Refresh(t.Context()); err != nil {
+			t.Fatalf("failed to initialize index: %v", err)
+		}
+
+		failing = true
+		if err := idx.Refresh(t.Context()); !errors.Is(err, sourceErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the last snapshot must be kept on error
+		got, err := idx.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff([]uint8{10}, got); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestOnMemoryIndex_AddRemove(t *testing.T) {
+	t.Parallel()
+
+	sourceData := map[uint8][]uint8{1: {10, 11}, 2: {20}}
+	idx := omcindex.NewOnMemoryIndex[uint8, uint8](index.FunctionIndexSource[uint8, uint8](func(context.Context) (map[uint8][]uint8, error) {
+		return sourceData, nil
+	}))
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.Add(t.Context(), 1, 11, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Add(t.Context(), 3, 30); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove(t.Context(), 2, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove(t.Context(), 4, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := idx.GetMulti(t.Context(), []uint8{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[uint8][]uint8{1: {10, 11, 12}, 3: {30}}, m); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[uint8][]uint8{1: {10, 11}, 2: {20}}, sourceData); diff != "" {
+		t.Errorf("the data of the source must not be modified (-want +got):\n%s", diff)
+	}
+
+	// the incremental updates are discarded by the next refresh
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	m, err = idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(sourceData, m); diff != "" {
+		t.Errorf("unexpected result after refresh (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_AddRemove_WithExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	idx := omcindex.NewOnMemoryIndex(index.FunctionExpiringIndexSource[uint8, uint8](func(context.Context) (map[uint8][]loadingcache.IndexEntry[uint8], error) {
+		return map[uint8][]loadingcache.IndexEntry[uint8]{
+			1: {{PrimaryKey: 10, ExpiresAt: now.Add(time.Hour)}, {PrimaryKey: 11, ExpiresAt: now.Add(-time.Hour)}},
+		}, nil
+	}), omcindex.WithDropExpired[uint8, uint8](&storagetest.FixedClock{Time: now}))
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.Add(t.Context(), 1, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove(t.Context(), 1, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := idx.GetWithExpiry(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]loadingcache.IndexEntry[uint8]{{PrimaryKey: 12}}, entries); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_RefreshKey(t *testing.T) {
+	t.Parallel()
+
+	sourceData := map[uint8][]uint8{1: {10, 11}, 2: {20}, 3: {30}}
+	var calls []uint8
+	idx := omcindex.NewOnMemoryIndex[uint8, uint8](&index.FunctionsKeyedIndexSource[uint8, uint8]{
+		GetAllFunc: func(context.Context) (map[uint8][]uint8, error) {
+			return sourceData, nil
+		},
+		GetBySecondaryKeyFunc: func(_ context.Context, sk uint8) ([]uint8, error) {
+			calls = append(calls, sk)
+			return sourceData[sk], nil
+		},
+	})
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the source changes all the keys, but only the refreshed keys are reflected
+	sourceData = map[uint8][]uint8{1: {12}, 2: {21}}
+	if err := idx.RefreshKey(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.RefreshKey(t.Context(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint8{1, 3}, calls); diff != "" {
+		t.Errorf("unexpected calls of the source (-want +got):\n%s", diff)
+	}
+
+	m, err := idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[uint8][]uint8{1: {12}, 2: {20}}, m); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	t.Run("SourceError", func(t *testing.T) {
+		t.Parallel()
+
+		sourceErr := errors.New("source error")
+		idx := omcindex.NewOnMemoryIndexWithData(map[uint8][]uint8{1: {10}}, omcindex.WithSource[uint8, uint8](&index.FunctionsKeyedIndexSource[uint8, uint8]{
+			GetBySecondaryKeyFunc: func(context.Context, uint8) ([]uint8, error) {
+				return nil, sourceErr
+			},
+		}))
+		if err := idx.RefreshKey(t.Context(), 1); !errors.Is(err, sourceErr) {
+			t.Errorf("expected the source error, got %v", err)
+		}
+		pks, err := idx.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]uint8{10}, pks); diff != "" {
+			t.Errorf("the associations must be kept on error (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("UnkeyedSource", func(t *testing.T) {
+		t.Parallel()
+
+		idx := omcindex.NewOnMemoryIndex[uint8, uint8](index.FunctionIndexSource[uint8, uint8](func(context.Context) (map[uint8][]uint8, error) {
+			return map[uint8][]uint8{}, nil
+		}))
+		if err := idx.RefreshKey(t.Context(), 1); !errors.Is(err, omcindex.ErrUnkeyedSource) {
+			t.Errorf("expected ErrUnkeyedSource, got %v", err)
+		}
+	})
+
+	t.Run("NoSource", func(t *testing.T) {
+		t.Parallel()
+
+		idx := omcindex.NewOnMemoryIndexWithData[uint8, uint8](nil)
+		if err := idx.RefreshKey(t.Context(), 1); !errors.Is(err, omcindex.ErrNoSource) {
+			t.Errorf("expected ErrNoSource, got %v", err)
+		}
+	})
+}
+
+func TestNewOnMemoryIndexWithData(t *testing.T) {
+	t.Parallel()
+
+	data := map[uint8][]uint8{1: {10, 11}, 2: {20}}
+
+	t.Run("WithoutSource", func(t *testing.T) {
+		t.Parallel()
+
+		idx := omcindex.NewOnMemoryIndexWithData(data)
+		pks, err := idx.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]uint8{10, 11}, pks); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+		if err := idx.Refresh(t.Context()); !errors.Is(err, omcindex.ErrNoSource) {
+			t.Errorf("expected ErrNoSource, got %v", err)
+		}
+
+		m, err := idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(data, m); diff != "" {
+			t.Errorf("the data must be kept after the failed refresh (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("WithSource", func(t *testing.T) {
+		t.Parallel()
+
+		idx := omcindex.NewOnMemoryIndexWithData(data, omcindex.WithSource[uint8, uint8](index.FunctionIndexSource[uint8, uint8](func(context.Context) (map[uint8][]uint8, error) {
+			return map[uint8][]uint8{3: {30}}, nil
+		})))
+		m, err := idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(data, m); diff != "" {
+			t.Errorf("unexpected result before refresh (-want +got):\n%s", diff)
+		}
+
+		if err := idx.Refresh(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		m, err = idx.GetMulti(t.Context(), []uint8{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[uint8][]uint8{3: {30}}, m); diff != "" {
+			t.Errorf("the data must be replaced by refresh (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestOnMemoryIndex_ExportImport(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	source := index.FunctionExpiringIndexSource[uint8, uint8](
+		func(ctx context.Context) (map[uint8][]loadingcache.IndexEntry[uint8], error) {
+			return map[uint8][]loadingcache.IndexEntry[uint8]{
+				1: {{PrimaryKey: 10, ExpiresAt: base.Add(-time.Hour)}, {PrimaryKey: 11}},
+				2: {{PrimaryKey: 20, ExpiresAt: base.Add(-time.Hour)}},
+				3: {{PrimaryKey: 30, ExpiresAt: base.Add(time.Hour)}},
+			}, nil
+		},
+	)
+	clock := &storagetest.FixedClock{Time: base}
+	src := omcindex.NewOnMemoryIndex[uint8, uint8](source, omcindex.WithDropExpired[uint8, uint8](clock))
+	if snapshot := src.Export(); snapshot != nil {
+		t.Errorf("expected nil for the uninitialized index, got %v", snapshot)
+	}
+	if err := src.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := src.Export()
+	expected := map[uint8][]uint8{1: {11}, 3: {30}}
+	if diff := cmp.Diff(expected, snapshot); diff != "" {
+		t.Errorf("the expired associations must not be exported (-want +got):\n%s", diff)
+	}
+
+	// the reads of the fresh index are blocked until the snapshot is imported
+	dst := omcindex.NewOnMemoryIndex[uint8, uint8](nil)
+	done := make(chan []uint8)
+	go func() {
+		pks, err := dst.Get(t.Context(), 3)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- pks
+	}()
+	dst.Import(snapshot)
+	if diff := cmp.Diff([]uint8{30}, <-done); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	m, err := dst.GetMulti(t.Context(), []uint8{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected, m); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if err := dst.Refresh(t.Context()); !errors.Is(err, omcindex.ErrNoSource) {
+		t.Errorf("expected ErrNoSource, got %v", err)
+	}
+
+	// the exported snapshot is a copy
+	exported := dst.Export()
+	exported[1][0] = 99
+	if pks, err := dst.Get(t.Context(), 1); err != nil || pks[0] != 11 {
+		t.Errorf("the index must not be affected by the modification of the export: %v, %v", pks, err)
+	}
+}
+
+func TestOnMemoryIndex_ReadOnlyResults(t *testing.T) {
+	t.Parallel()
+
+	idx := omcindex.NewOnMemoryIndexWithData(map[uint8][]uint8{1: {10, 11}}, omcindex.WithReadOnlyResults[uint8, uint8]())
+
+	first, err := idx.Get(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := idx.Get(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &first[0] != &second[0] {
+		t.Error("the results must share the stored slice without copying")
+	}
+	m, err := idx.GetMulti(t.Context(), []uint8{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &m[1][0] != &first[0] {
+		t.Error("the results of GetMulti must share the stored slice without copying")
+	}
+
+	// appending to the result never writes into the stored slice
+	_ = append(first, 12)
+	if cap(first) != len(first) {
+		t.Errorf("the result must have no extra capacity: len=%d, cap=%d", len(first), cap(first))
+	}
+
+	// the index never mutates the returned slices in place
+	if err := idx.Add(t.Context(), 1, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove(t.Context(), 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint8{10, 11}, first); diff != "" {
+		t.Errorf("the returned slice must not be mutated by the index (-want +got):\n%s", diff)
+	}
+	if pks, err := idx.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff([]uint8{11, 12}, pks); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_PrimaryKeyCloner(t *testing.T) {
+	t.Parallel()
+
+	idx := omcindex.NewOnMemoryIndexWithData(
+		map[uint8][]uint8{1: {10, 11}},
+		omcindex.WithPrimaryKeyCloner[uint8, uint8](func(pk uint8) uint8 { return pk + 100 }),
+	)
+
+	pks, err := idx.Get(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint8{110, 111}, pks); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	entries, err := idx.GetWithExpiry(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]loadingcache.IndexEntry[uint8]{{PrimaryKey: 110}, {PrimaryKey: 111}}, entries); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func BenchmarkOnMemoryIndex_Get(b *testing.B) {
+	data := map[uint8][]uint8{1: make([]uint8, 64)}
+	for _, bc := range []struct {
+		name string
+		opts []omcindex.Option[uint8, uint8]
+	}{
+		{name: "Copy"},
+		{name: "NoCopy", opts: []omcindex.Option[uint8, uint8]{omcindex.WithReadOnlyResults[uint8, uint8]()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			idx := omcindex.NewOnMemoryIndexWithData(data, bc.opts...)
+			ctx := b.Context()
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := idx.Get(ctx, 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// canceledContext is a context that reports the cancellation by Err without closing Done,
+// to test the cancellation checks after the lock is acquired.
+type canceledContext struct {
+	context.Context
+	errCalls atomic.Int32
+}
+
+func (c *canceledContext) Err() error {
+	c.errCalls.Add(1)
+	return context.Canceled
+}
+
+func TestOnMemoryIndex_GetMulti_Cancellation(t *testing.T) {
+	t.Parallel()
+
+	data := make(map[uint16][]uint16, 1024)
+	for sk := range uint16(1024) {
+		data[sk] = []uint16{sk}
+	}
+	idx := omcindex.NewOnMemoryIndexWithData(data)
+
+	small := make([]uint16, 256)
+	for j := range small {
+		small[j] = uint16(j)
+	}
+	large := make([]uint16, 1024)
+	for j := range large {
+		large[j] = uint16(j)
+	}
+
+	t.Run("Small", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := &canceledContext{Context: context.Background()}
+		m, err := idx.GetMulti(ctx, small)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m) != len(small) {
+			t.Errorf("unexpected result size: %d", len(m))
+		}
+		if got := ctx.errCalls.Load(); got != 0 {
+			t.Errorf("the small lookups must not check the context, but checked %d times", got)
+		}
+	})
+
+	t.Run("Large", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := &canceledContext{Context: context.Background()}
+		if _, err := idx.GetMulti(ctx, large); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if got := ctx.errCalls.Load(); got != 1 {
+			t.Errorf("expected to return at the first check, but checked %d times", got)
+		}
+	})
+
+	t.Run("LargeWithExpiry", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := &canceledContext{Context: context.Background()}
+		if _, err := idx.GetMultiWithExpiry(ctx, large); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestOnMemoryIndex_InitializationTimeout(t *testing.T) {
+	t.Parallel()
+
+	// the refresh never completes until the test finishes
+	release := make(chan struct{})
+	source := index.FunctionIndexSource[uint8, uint8](func(ctx context.Context) (map[uint8][]uint8, error) {
+		<-release
+		return map[uint8][]uint8{1: {10}}, nil
+	})
+	const timeout = 50 * time.Millisecond
+	idx := omcindex.NewOnMemoryIndex(source, omcindex.WithInitializationTimeout[uint8, uint8](timeout))
+	refreshed := make(chan error, 1)
+	go func() {
+		refreshed <- idx.Refresh(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+
+	t.Run("Get", func(t *testing.T) {
+		start := time.Now()
+		if _, err := idx.Get(ctx, 1); !errors.Is(err, omcindex.ErrIndexNotReady) {
+			t.Errorf("expected %v, got %v", omcindex.ErrIndexNotReady, err)
+		}
+		if elapsed := time.Since(start); elapsed < timeout || elapsed > 10*time.Second {
+			t.Errorf("expected to time out at %v, but took %v", timeout, elapsed)
+		}
+	})
+	t.Run("GetMulti", func(t *testing.T) {
+		start := time.Now()
+		if _, err := idx.GetMulti(ctx, []uint8{1, 2}); !errors.Is(err, omcindex.ErrIndexNotReady) {
+			t.Errorf("expected %v, got %v", omcindex.ErrIndexNotReady, err)
+		}
+		if elapsed := time.Since(start); elapsed < timeout || elapsed > 10*time.Second {
+			t.Errorf("expected to time out at %v, but took %v", timeout, elapsed)
+		}
+	})
+	t.Run("CallerDeadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond)
+		defer cancel()
+		if _, err := idx.Get(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	close(release)
+	if err := <-refreshed; err != nil {
+		t.Fatal(err)
+	}
+	pks, err := idx.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint8{10}, pks); diff != "" {
+		t.Errorf("unexpected primary keys (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMemoryIndex_Ready(t *testing.T) {
+	t.Parallel()
+
+	idx := omcindex.NewOnMemoryIndex[uint8, uint8](index.FunctionIndexSource[uint8, uint8](func(context.Context) (map[uint8][]uint8, error) {
+		return map[uint8][]uint8{}, nil
+	}))
+	if idx.Ready() {
+		t.Error("the index must not be ready before the first refresh")
+	}
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if !idx.Ready() {
+		t.Error("the index must be ready after the first refresh")
+	}
+
+	if !omcindex.NewOnMemoryIndexWithData[uint8, uint8](nil).Ready() {
+		t.Error("the index with data must be ready immediately")
+	}
+}
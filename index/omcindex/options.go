@@ -0,0 +1,63 @@
+package omcindex
+
+import (
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// Option is the interface for the options of the OnMemoryIndex.
+type Option[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] interface {
+	apply(*OnMemoryIndex[SecondaryKey, PrimaryKey])
+}
+
+type optionFunc[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] func(*OnMemoryIndex[SecondaryKey, PrimaryKey])
+
+func (f optionFunc[SecondaryKey, PrimaryKey]) apply(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+	f(i)
+}
+
+// WithDropExpired enables dropping expired associations at read time.
+// The associations are considered expired when the current time of the clock is not before their expiration time.
+// It takes effect only when the source implements loadingcache.ExpiringIndexSource.
+func WithDropExpired[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](clock loadingcache.Clock) Option[SecondaryKey, PrimaryKey] {
+	return optionFunc[SecondaryKey, PrimaryKey](func(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+		i.clock = clock
+	})
+}
+
+// WithSource sets the source of the index.
+// It is useful with NewOnMemoryIndexWithData to refresh the initial data from the source later.
+func WithSource[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](source loadingcache.IndexSource[SecondaryKey, PrimaryKey]) Option[SecondaryKey, PrimaryKey] {
+	return optionFunc[SecondaryKey, PrimaryKey](func(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+		i.source = source
+	})
+}
+
+// WithReadOnlyResults makes Get and GetMulti return the primary keys stored in the index without copying them.
+// It saves an allocation for each lookup, but the callers must never mutate the returned slices.
+// The index never mutates the stored slices in place, and the returned slices have no extra capacity,
+// so appending to them allocates new slices. The results filtered by WithDropExpired are always newly allocated.
+func WithReadOnlyResults[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint]() Option[SecondaryKey, PrimaryKey] {
+	return optionFunc[SecondaryKey, PrimaryKey](func(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+		i.readOnly = true
+	})
+}
+
+// WithPrimaryKeyCloner sets the function to clone each primary key returned by the index.
+// It is unnecessary for the primary keys without references, and the default is to copy them as they are.
+// It is ignored for the results of Get and GetMulti returned without copying by WithReadOnlyResults.
+func WithPrimaryKeyCloner[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](cloner func(PrimaryKey) PrimaryKey) Option[SecondaryKey, PrimaryKey] {
+	return optionFunc[SecondaryKey, PrimaryKey](func(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+		i.cloner = cloner
+	})
+}
+
+// WithInitializationTimeout sets the maximum time for the reads and the writes to wait for the first refresh.
+// They return ErrIndexNotReady if the index is not initialized within the timeout,
+// even if the deadline of the caller's context is later. Zero or negative means no limit, which is the default.
+func WithInitializationTimeout[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint](timeout time.Duration) Option[SecondaryKey, PrimaryKey] {
+	return optionFunc[SecondaryKey, PrimaryKey](func(i *OnMemoryIndex[SecondaryKey, PrimaryKey]) {
+		i.initWait = timeout
+	})
+}
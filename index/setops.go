@@ -0,0 +1,38 @@
+package index
+
+import (
+	"iter"
+	"slices"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/internal/iterutil"
+)
+
+// IntersectResults returns the primary keys present in all the results of Index.Get.
+// It is the ad-hoc equivalent of AndIndex for the results of separate lookups.
+// The primary keys are in the order of their completion, and nil is returned if there are no such primary keys.
+// Each result must not contain duplicated primary keys, as Index.Get guarantees.
+func IntersectResults[PrimaryKey loadingcache.KeyConstraint](results ...[]PrimaryKey) []PrimaryKey {
+	return slices.Collect(iterutil.Intersection(resultsSeqs(results)...))
+}
+
+// UnionResults returns the primary keys present in any of the results of Index.Get.
+// It is the ad-hoc equivalent of OrIndex for the results of separate lookups.
+// The primary keys are in the order of their first appearance, and nil is returned if there are no primary keys.
+func UnionResults[PrimaryKey loadingcache.KeyConstraint](results ...[]PrimaryKey) []PrimaryKey {
+	return slices.Collect(iterutil.Union(resultsSeqs(results)...))
+}
+
+// DifferenceResults returns the primary keys of the base result that are not present in any of the excluded results.
+// The primary keys are in the order of the base result, and nil is returned if there are no such primary keys.
+func DifferenceResults[PrimaryKey loadingcache.KeyConstraint](base []PrimaryKey, excludes ...[]PrimaryKey) []PrimaryKey {
+	return slices.Collect(iterutil.Difference(slices.Values(base), resultsSeqs(excludes)...))
+}
+
+func resultsSeqs[PrimaryKey loadingcache.KeyConstraint](results [][]PrimaryKey) []iter.Seq[PrimaryKey] {
+	seqs := make([]iter.Seq[PrimaryKey], len(results))
+	for i, result := range results {
+		seqs[i] = slices.Values(result)
+	}
+	return seqs
+}
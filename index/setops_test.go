@@ -0,0 +1,153 @@
+package index_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/loading-cache/index"
+)
+
+func TestIntersectResults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		results [][]uint16
+		want    []uint16
+	}{
+		{
+			name: "no results",
+			want: nil,
+		},
+		{
+			name:    "overlapping results",
+			results: [][]uint16{{10, 11, 12}, {11, 12, 13}},
+			want:    []uint16{11, 12},
+		},
+		{
+			name:    "disjoint results",
+			results: [][]uint16{{10, 11}, {20, 21}},
+			want:    nil,
+		},
+		{
+			name:    "empty result",
+			results: [][]uint16{{10, 11}, nil},
+			want:    nil,
+		},
+		{
+			name:    "three results",
+			results: [][]uint16{{10, 11, 12}, {11, 12, 13}, {12, 13, 14}},
+			want:    []uint16{12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := index.IntersectResults(tt.results...)
+			slices.Sort(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("IntersectResults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnionResults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		results [][]uint16
+		want    []uint16
+	}{
+		{
+			name: "no results",
+			want: nil,
+		},
+		{
+			name:    "overlapping results",
+			results: [][]uint16{{10, 11, 12}, {11, 12, 13}},
+			want:    []uint16{10, 11, 12, 13},
+		},
+		{
+			name:    "disjoint results",
+			results: [][]uint16{{10, 11}, {20, 21}},
+			want:    []uint16{10, 11, 20, 21},
+		},
+		{
+			name:    "empty result",
+			results: [][]uint16{nil, {20, 21}},
+			want:    []uint16{20, 21},
+		},
+		{
+			name:    "all empty results",
+			results: [][]uint16{nil, nil},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := index.UnionResults(tt.results...)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("UnionResults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDifferenceResults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		base     []uint16
+		excludes [][]uint16
+		want     []uint16
+	}{
+		{
+			name:     "overlapping results",
+			base:     []uint16{10, 11, 12},
+			excludes: [][]uint16{{11, 13}},
+			want:     []uint16{10, 12},
+		},
+		{
+			name:     "disjoint results",
+			base:     []uint16{10, 11},
+			excludes: [][]uint16{{20, 21}},
+			want:     []uint16{10, 11},
+		},
+		{
+			name:     "empty base",
+			excludes: [][]uint16{{10, 11}},
+			want:     nil,
+		},
+		{
+			name: "no excludes",
+			base: []uint16{10, 11},
+			want: []uint16{10, 11},
+		},
+		{
+			name:     "everything excluded",
+			base:     []uint16{10, 11},
+			excludes: [][]uint16{{10}, {11}},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := index.DifferenceResults(tt.base, tt.excludes...)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DifferenceResults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package index
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// TimeoutIndex is an index that bounds each lookup of the underlying index by a timeout.
+// Each lookup runs with a child context of the caller's context with the timeout,
+// so the earlier deadline of the caller's context is respected as well.
+// The underlying index must respect the context cancellation.
+type TimeoutIndex[SecondaryKey loadingcache.KeyConstraint, PrimaryKey loadingcache.KeyConstraint] struct {
+	// Index is the underlying index.
+	Index loadingcache.Index[SecondaryKey, PrimaryKey]
+
+	// Timeout is the timeout of each lookup.
+	Timeout time.Duration
+}
+
+var _ loadingcache.Index[uint8, uint8] = (*TimeoutIndex[uint8, uint8])(nil)
+
+// Get retrieves primary keys by secondary key with the timeout.
+// It returns context.DeadlineExceeded if the lookup does not complete in time.
+func (i *TimeoutIndex[SecondaryKey, PrimaryKey]) Get(ctx context.Context, key SecondaryKey) ([]PrimaryKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.Timeout)
+	defer cancel()
+	return i.Index.Get(ctx, key)
+}
+
+// GetMulti retrieves primary keys by multiple secondary keys with the timeout.
+// It returns context.DeadlineExceeded if the lookup does not complete in time.
+func (i *TimeoutIndex[SecondaryKey, PrimaryKey]) GetMulti(ctx context.Context, keys []SecondaryKey) (map[SecondaryKey][]PrimaryKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.Timeout)
+	defer cancel()
+	return i.Index.GetMulti(ctx, keys)
+}
@@ -0,0 +1,87 @@
+package index_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/loading-cache/index"
+)
+
+func TestTimeoutIndex(t *testing.T) {
+	t.Parallel()
+
+	newIndex := func(delay time.Duration, captured *context.Context) *index.TimeoutIndex[string, int] {
+		wait := func(ctx context.Context) error {
+			*captured = ctx
+			select {
+			case <-time.After(delay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return &index.TimeoutIndex[string, int]{
+			Index: &index.FunctionsIndex[string, int]{
+				GetFunc: func(ctx context.Context, key string) ([]int, error) {
+					if err := wait(ctx); err != nil {
+						return nil, err
+					}
+					return []int{1, 2}, nil
+				},
+				GetMultiFunc: func(ctx context.Context, keys []string) (map[string][]int, error) {
+					if err := wait(ctx); err != nil {
+						return nil, err
+					}
+					return map[string][]int{"a": {1, 2}}, nil
+				},
+			},
+			Timeout: 50 * time.Millisecond,
+		}
+	}
+
+	t.Run("Fast", func(t *testing.T) {
+		t.Parallel()
+
+		var captured context.Context
+		idx := newIndex(0, &captured)
+
+		pks, err := idx.Get(t.Context(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]int{1, 2}, pks); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+		if captured.Err() == nil {
+			t.Error("the child context must be canceled on return")
+		}
+
+		m, err := idx.GetMulti(t.Context(), []string{"a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string][]int{"a": {1, 2}}, m); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+		if captured.Err() == nil {
+			t.Error("the child context must be canceled on return")
+		}
+	})
+
+	t.Run("Slow", func(t *testing.T) {
+		t.Parallel()
+
+		var captured context.Context
+		idx := newIndex(time.Minute, &captured)
+
+		if _, err := idx.Get(t.Context(), "a"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		if _, err := idx.GetMulti(t.Context(), []string{"a"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+	})
+}
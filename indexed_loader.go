@@ -1,19 +1,39 @@
 package loadingcache
 
-import "context"
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+)
+
+// DefaultStreamBatchSize is the default number of primary keys loaded at once by StreamBySecondaryKey.
+const DefaultStreamBatchSize = 100
+
+// ErrIndexNotReady is returned by the lookups through the index before it is initialized
+// if WithFailFastWhenIndexNotReady is specified.
+var ErrIndexNotReady = errors.New("the index is not ready")
+
+// ErrImmutableIndex is returned when the index does not implement MutableIndex but the operation requires it.
+var ErrImmutableIndex = errors.New("the index is not mutable")
 
 // IndexedLoadingCache is a LoadingCache with an index.
 type IndexedLoadingCache[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value ValueConstraint] struct {
 	LoadingCache[PrimaryKey, Value]
 	index  Index[SecondaryKey, PrimaryKey]
 	cloner ValueCloner[Value]
+
+	streamBatchSize int
+	loadExpiresAt   func(*CacheEntry[PrimaryKey, Value]) time.Time
+	failFast        bool
 }
 
 // NewIndexedLoadingCache creates a new IndexedLoadingCache.
 func NewIndexedLoadingCache[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value ValueConstraint](cache LoadingCache[PrimaryKey, Value], index Index[SecondaryKey, PrimaryKey], opts ...IndexedLoadingCacheOption[PrimaryKey, SecondaryKey, Value]) *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value] {
 	c := &IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]{
-		LoadingCache: cache,
-		index:        index,
+		LoadingCache:    cache,
+		index:           index,
+		streamBatchSize: DefaultStreamBatchSize,
 	}
 	for _, opt := range opts {
 		opt.apply(c)
@@ -41,21 +61,158 @@ func WithValueCloner[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value
 	})
 }
 
+// WithStreamBatchSize sets the number of primary keys loaded at once by StreamBySecondaryKey.
+// Smaller sizes reduce the memory held at once, but increase the number of calls to the storage and the loader.
+// The default is DefaultStreamBatchSize.
+func WithStreamBatchSize[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value ValueConstraint](size int) IndexedLoadingCacheOption[PrimaryKey, SecondaryKey, Value] {
+	if size <= 0 {
+		panic("size must be positive")
+	}
+	return indexedLoadingCacheOptionFunc[PrimaryKey, SecondaryKey, Value](func(c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) {
+		c.streamBatchSize = size
+	})
+}
+
+// WithLoadExpiresAt sets the function to override the expiration times of the entries loaded by the lookups
+// through the index, e.g. to keep the association-derived data shorter than the default TTL of the source.
+// The function is called with each loaded entry including the negative caches, and returns its new expiration time.
+// The loaded entries are stored by the Loader first, and then overwritten in the storage with the new expiration times.
+// It does not affect the loads by the methods of the embedded LoadingCache.
+func WithLoadExpiresAt[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value ValueConstraint](expiresAt func(*CacheEntry[PrimaryKey, Value]) time.Time) IndexedLoadingCacheOption[PrimaryKey, SecondaryKey, Value] {
+	return indexedLoadingCacheOptionFunc[PrimaryKey, SecondaryKey, Value](func(c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) {
+		c.loadExpiresAt = expiresAt
+	})
+}
+
+// WithFailFastWhenIndexNotReady makes the lookups through the index return ErrIndexNotReady immediately
+// instead of blocking until the index is initialized, e.g. to serve a fallback while the first refresh is in progress.
+// It takes effect only when the index implements ReadinessIndex, and the other indexes are always considered ready.
+func WithFailFastWhenIndexNotReady[PrimaryKey KeyConstraint, SecondaryKey KeyConstraint, Value ValueConstraint]() IndexedLoadingCacheOption[PrimaryKey, SecondaryKey, Value] {
+	return indexedLoadingCacheOptionFunc[PrimaryKey, SecondaryKey, Value](func(c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) {
+		c.failFast = true
+	})
+}
+
+// Put stores the entry in the storage and associates its key with the given secondary keys in the index.
+// If the secondary keys are given, the index must implement MutableIndex, otherwise ErrImmutableIndex is returned
+// without storing the entry.
+// The entry is stored before the index is updated, so the readers never find a secondary key associated with
+// a primary key that is not stored yet.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) Put(ctx context.Context, entry *CacheEntry[PrimaryKey, Value], secondaryKeys []SecondaryKey) error {
+	var index MutableIndex[SecondaryKey, PrimaryKey]
+	if len(secondaryKeys) != 0 {
+		var ok bool
+		index, ok = c.index.(MutableIndex[SecondaryKey, PrimaryKey])
+		if !ok {
+			return ErrImmutableIndex
+		}
+	}
+
+	if err := c.Storage.Set(ctx, entry); err != nil {
+		return err
+	}
+	for _, sk := range secondaryKeys {
+		if err := index.Add(ctx, sk, entry.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidateBySecondaryKeys deletes the entries of all the primary keys associated with the secondary keys from the storage,
+// so they are reloaded from the source by the next reads.
+// The primary keys are resolved by the index and deleted by a single DeleteMulti call of the storage.
+//
+// It does not refresh the index, so the associations themselves are kept as they are.
+// Refresh or update the index separately if they have changed.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) InvalidateBySecondaryKeys(ctx context.Context, sks []SecondaryKey) error {
+	m, err := c.lookupMulti(ctx, sks)
+	if err != nil {
+		return err
+	}
+
+	var keys []PrimaryKey
+	seen := map[PrimaryKey]struct{}{}
+	for _, sk := range sks {
+		for _, pk := range m[sk] {
+			if _, ok := seen[pk]; !ok {
+				seen[pk] = struct{}{}
+				keys = append(keys, pk)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.Storage.DeleteMulti(ctx, keys)
+}
+
 // FindBySecondaryKey retrieves entries by secondary key.
 func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) FindBySecondaryKey(ctx context.Context, sk SecondaryKey) ([]*Entry[PrimaryKey, Value], error) {
-	pks, err := c.index.Get(ctx, sk)
+	pks, err := c.lookup(ctx, sk)
 	if err != nil {
 		return nil, err
 	}
 	if len(pks) == 0 {
 		return nil, nil
 	}
-	return c.GetOrLoadMulti(ctx, pks)
+	return c.indexed().GetOrLoadMulti(ctx, pks)
+}
+
+// StreamBySecondaryKey retrieves entries by secondary key lazily.
+// The primary keys are looked up from the index immediately, and the index error is returned as the error.
+// Then the returned iterator loads the entries in batches of the size set by WithStreamBatchSize and yields them
+// in the order of the primary keys. The missing entries are skipped.
+//
+// If a batch fails to load, the iterator yields the error with a nil entry and stops.
+// If the caller breaks the iteration, the remaining batches are never loaded.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) StreamBySecondaryKey(ctx context.Context, sk SecondaryKey) (iter.Seq2[*Entry[PrimaryKey, Value], error], error) {
+	pks, err := c.lookup(ctx, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(*Entry[PrimaryKey, Value], error) bool) {
+		for start := 0; start < len(pks); start += c.streamBatchSize {
+			end := min(start+c.streamBatchSize, len(pks))
+			entries, err := c.indexed().GetOrLoadMulti(ctx, pks[start:end])
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, entry := range entries {
+				if entry == nil {
+					continue
+				}
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// FindCacheEntriesBySecondaryKey retrieves entries with their expiration times by secondary key.
+// Unlike FindBySecondaryKey, the negative caches are returned as the entries with NegativeCache set to true.
+// See LoadingCache.GetOrLoadMultiCacheEntries for how the expiration times of the loaded entries are resolved.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) FindCacheEntriesBySecondaryKey(ctx context.Context, sk SecondaryKey) ([]*CacheEntry[PrimaryKey, Value], error) {
+	pks, err := c.lookup(ctx, sk)
+	if err != nil {
+		return nil, err
+	}
+	if len(pks) == 0 {
+		return nil, nil
+	}
+
+	return c.indexed().GetOrLoadMultiCacheEntries(ctx, pks)
 }
 
 // FindBySecondaryKeys retrieves entries by secondary keys.
+// The primary keys referenced by multiple secondary keys are retrieved only once, and all the missing primary keys
+// across the secondary keys are loaded by a single loader call.
 func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) FindBySecondaryKeys(ctx context.Context, sks []SecondaryKey) (map[SecondaryKey][]*Entry[PrimaryKey, Value], error) {
-	m, err := c.index.GetMulti(ctx, sks)
+	m, err := c.lookupMulti(ctx, sks)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +231,7 @@ func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) FindBySecondaryKe
 		}
 	}
 
-	entries, err := c.GetOrLoadMulti(ctx, keys)
+	entries, err := c.indexed().GetOrLoadMulti(ctx, keys)
 	if err != nil {
 		return nil, err
 	}
@@ -99,3 +256,103 @@ func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) FindBySecondaryKe
 	}
 	return result, nil
 }
+
+// lookup retrieves the primary keys by the secondary key from the index.
+// It returns ErrIndexNotReady without calling the index if WithFailFastWhenIndexNotReady is specified and the index is not ready.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) lookup(ctx context.Context, sk SecondaryKey) ([]PrimaryKey, error) {
+	if !c.ready() {
+		return nil, ErrIndexNotReady
+	}
+	return c.index.Get(ctx, sk)
+}
+
+// lookupMulti retrieves the primary keys by the secondary keys from the index.
+// It returns ErrIndexNotReady without calling the index if WithFailFastWhenIndexNotReady is specified and the index is not ready.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) lookupMulti(ctx context.Context, sks []SecondaryKey) (map[SecondaryKey][]PrimaryKey, error) {
+	if !c.ready() {
+		return nil, ErrIndexNotReady
+	}
+	return c.index.GetMulti(ctx, sks)
+}
+
+// ready reports whether the lookups through the index may proceed.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) ready() bool {
+	if !c.failFast {
+		return true
+	}
+	index, ok := c.index.(ReadinessIndex)
+	return !ok || index.Ready()
+}
+
+// indexed returns the LoadingCache for the lookups through the index.
+// It overrides the expiration times of the loaded entries if WithLoadExpiresAt is specified.
+func (c *IndexedLoadingCache[PrimaryKey, SecondaryKey, Value]) indexed() *LoadingCache[PrimaryKey, Value] {
+	if c.loadExpiresAt == nil {
+		return &c.LoadingCache
+	}
+
+	cache := c.LoadingCache
+	cache.Loader = &expiresAtOverrideLoader[PrimaryKey, Value]{
+		cache:     &c.LoadingCache,
+		expiresAt: c.loadExpiresAt,
+	}
+	return &cache
+}
+
+// expiresAtOverrideLoader is a SourceLoader that overwrites the loaded entries in the storage with the overridden expiration times.
+// It loads the entries by the loader of the cache, and honors its IgnoreStorageGetErrors as well.
+type expiresAtOverrideLoader[K KeyConstraint, V ValueConstraint] struct {
+	cache     *LoadingCache[K, V]
+	expiresAt func(*CacheEntry[K, V]) time.Time
+}
+
+var _ CacheEntrySourceLoader[uint8, struct{}] = (*expiresAtOverrideLoader[uint8, struct{}])(nil)
+
+func (l *expiresAtOverrideLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*Entry[K, V], error) {
+	entries, err := l.LoadAndStoreMulti(ctx, []K{key})
+	if err != nil {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+func (l *expiresAtOverrideLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*Entry[K, V], error) {
+	cacheEntries, err := l.LoadAndStoreMultiCacheEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry[K, V], len(keys))
+	for i, entry := range cacheEntries {
+		if entry != nil && !entry.NegativeCache {
+			entries[i] = &entry.Entry
+		}
+	}
+	return entries, nil
+}
+
+func (l *expiresAtOverrideLoader[K, V]) LoadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*CacheEntry[K, V], error) {
+	entries, err := l.cache.loadAndStoreMultiCacheEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// note: the loaded entries may be shared with the storage and its readers (e.g. memstorage.WithCopyOnWrite),
+	// so the expiration times are overridden on the shallow copies.
+	results := make([]*CacheEntry[K, V], len(entries))
+	overridden := make([]*CacheEntry[K, V], 0, len(entries))
+	for i, entry := range entries {
+		if entry != nil {
+			copied := *entry
+			copied.ExpiresAt = l.expiresAt(entry)
+			results[i] = &copied
+			overridden = append(overridden, &copied)
+		}
+	}
+	if len(overridden) != 0 {
+		if err := l.cache.Storage.SetMulti(ctx, overridden); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
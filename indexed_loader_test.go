@@ -3,6 +3,7 @@ package loadingcache_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,9 +11,13 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/index"
+	"github.com/karupanerura/loading-cache/index/omcindex"
 	"github.com/karupanerura/loading-cache/loader/pureloader"
+	"github.com/karupanerura/loading-cache/loader/singleflightloader"
 	"github.com/karupanerura/loading-cache/source"
 	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
 )
 
 var (
@@ -499,3 +504,655 @@ func TestIndexedLoadingCache_FindBySecondaryKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexedLoadingCache_FindBySecondaryKeys_LoadOnce(t *testing.T) {
+	t.Parallel()
+
+	idx := &index.FunctionsIndex[string, int]{
+		GetMultiFunc: func(_ context.Context, keys []string) (map[string][]int, error) {
+			return map[string][]int{
+				"category1": {1, 2, 3},
+				"category2": {2, 3, 4},
+				"category3": {3, 4, 5},
+			}, nil
+		},
+	}
+
+	s := &storage.FunctionsStorage[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				// only key 1 is stored
+				if key == 1 {
+					entries[i] = &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value1"}}
+				}
+			}
+			return entries, nil
+		},
+		SetMultiFunc: func(_ context.Context, entries []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	var calls [][]int
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			calls = append(calls, keys)
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+
+	cache := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+	}, idx)
+
+	result, err := cache.FindBySecondaryKeys(t.Context(), []string{"category1", "category2", "category3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("expected the loader to be called once, but called %d times: %v", len(calls), calls)
+	}
+	if diff := cmp.Diff([]int{2, 3, 4, 5}, calls[0], cmpopts.SortSlices(func(i, j int) bool {
+		return i < j
+	})); diff != "" {
+		t.Errorf("each missing primary key must be loaded once (-want +got):\n%s", diff)
+	}
+
+	expected := map[string][]*loadingcache.Entry[int, string]{
+		"category1": {{Key: 1, Value: "value1"}, {Key: 2, Value: "value2"}, {Key: 3, Value: "value3"}},
+		"category2": {{Key: 2, Value: "value2"}, {Key: 3, Value: "value3"}, {Key: 4, Value: "value4"}},
+		"category3": {{Key: 3, Value: "value3"}, {Key: 4, Value: "value4"}, {Key: 5, Value: "value5"}},
+	}
+	if diff := cmp.Diff(expected, result, cmpopts.SortSlices(func(i, j *loadingcache.Entry[int, string]) bool {
+		return i.Key < j.Key
+	})); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestIndexedLoadingCache_FindCacheEntriesBySecondaryKey(t *testing.T) {
+	t.Parallel()
+
+	storedAt := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	loadedAt := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+	idx := &index.FunctionsIndex[string, int]{
+		GetFunc: func(_ context.Context, key string) ([]int, error) {
+			return []int{1, 2, 3, 4}, nil
+		},
+	}
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				switch key {
+				case 3:
+					entries[i] = &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: 3, Value: "value3"}, ExpiresAt: loadedAt}
+				case 4:
+					entries[i] = &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: 4}, ExpiresAt: loadedAt, NegativeCache: true}
+				}
+			}
+			return entries, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		loader func(loadingcache.CacheStorage[int, string]) loadingcache.SourceLoader[int, string]
+		loaded []*loadingcache.CacheEntry[int, string]
+	}{
+		{
+			name: "CacheEntrySourceLoader",
+			loader: func(s loadingcache.CacheStorage[int, string]) loadingcache.SourceLoader[int, string] {
+				return pureloader.NewPureLoader(s, src)
+			},
+			loaded: []*loadingcache.CacheEntry[int, string]{
+				{Entry: loadingcache.Entry[int, string]{Key: 3, Value: "value3"}, ExpiresAt: loadedAt},
+				{Entry: loadingcache.Entry[int, string]{Key: 4}, ExpiresAt: loadedAt, NegativeCache: true},
+			},
+		},
+		{
+			name: "SourceLoader",
+			loader: func(s loadingcache.CacheStorage[int, string]) loadingcache.SourceLoader[int, string] {
+				// hide LoadAndStoreMultiCacheEntries to build the loaded entries from the loaded values
+				return struct {
+					loadingcache.SourceLoader[int, string]
+				}{pureloader.NewPureLoader(s, src)}
+			},
+			loaded: []*loadingcache.CacheEntry[int, string]{
+				{Entry: loadingcache.Entry[int, string]{Key: 3, Value: "value3"}},
+				nil,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(memstorage.WithClock[int, string](&storagetest.FixedClock{Time: storedAt.Add(-time.Hour)}))
+			if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[int, string]{
+				{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value1"}, ExpiresAt: storedAt},
+				{Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: storedAt, NegativeCache: true},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			cache := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+				Loader:  tt.loader(s),
+				Storage: s,
+			}, idx)
+			entries, err := cache.FindCacheEntriesBySecondaryKey(t.Context(), "category")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := append([]*loadingcache.CacheEntry[int, string]{
+				{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value1"}, ExpiresAt: storedAt},
+				{Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: storedAt, NegativeCache: true},
+			}, tt.loaded...)
+			if diff := cmp.Diff(expected, entries); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndexedLoadingCache_Put(t *testing.T) {
+	t.Parallel()
+
+	s := memstorage.NewInMemoryStorage[int, string]()
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(context.Context, []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			return nil, errors.New("should not be called")
+		},
+	}
+	cache := loadingcache.LoadingCache[int, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+	}
+	entry := &loadingcache.CacheEntry[int, string]{
+		Entry:     loadingcache.Entry[int, string]{Key: 1, Value: "value1"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("MutableIndex", func(t *testing.T) {
+		t.Parallel()
+
+		idx := omcindex.NewOnMemoryIndex[string, int](index.FunctionIndexSource[string, int](func(context.Context) (map[string][]int, error) {
+			return map[string][]int{}, nil
+		}))
+		if err := idx.Refresh(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		c := loadingcache.NewIndexedLoadingCache(cache, idx)
+		if err := c.Put(t.Context(), entry, []string{"category1", "category2"}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sk := range []string{"category1", "category2"} {
+			entries, err := c.FindBySecondaryKey(t.Context(), sk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*loadingcache.Entry[int, string]{{Key: 1, Value: "value1"}}, entries); diff != "" {
+				t.Errorf("%s: unexpected entries (-want +got):\n%s", sk, diff)
+			}
+		}
+	})
+
+	t.Run("ImmutableIndex", func(t *testing.T) {
+		t.Parallel()
+
+		c := loadingcache.NewIndexedLoadingCache(cache, &index.FunctionsIndex[string, int]{})
+		if err := c.Put(t.Context(), entry, []string{"category1"}); !errors.Is(err, loadingcache.ErrImmutableIndex) {
+			t.Errorf("expected ErrImmutableIndex, got %v", err)
+		}
+	})
+}
+
+func TestIndexedLoadingCache_StreamBySecondaryKey(t *testing.T) {
+	t.Parallel()
+
+	idx := &index.FunctionsIndex[string, int]{
+		GetFunc: func(_ context.Context, key string) ([]int, error) {
+			if key == "error" {
+				return nil, errors.New("index error")
+			}
+			return []int{1, 2, 3, 4, 5, 6, 7}, nil
+		},
+	}
+	newCache := func(calls *[][]int, failAt int) *loadingcache.IndexedLoadingCache[int, string, string] {
+		src := &source.FunctionsSource[int, string]{
+			GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+				*calls = append(*calls, keys)
+				if len(*calls) == failAt {
+					return nil, errors.New("source error")
+				}
+				entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+				for i, key := range keys {
+					// key 4 is missing
+					if key != 4 {
+						entries[i] = &loadingcache.CacheEntry[int, string]{
+							Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+							ExpiresAt: time.Now().Add(time.Hour),
+						}
+					}
+				}
+				return entries, nil
+			},
+		}
+		s := memstorage.NewInMemoryStorage[int, string]()
+		return loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+			Loader:  pureloader.NewPureLoader(s, src),
+			Storage: s,
+		}, idx, loadingcache.WithStreamBatchSize[int, string, string](3))
+	}
+
+	t.Run("Collect", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]int
+		seq, err := newCache(&calls, 0).StreamBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("the entries must be loaded lazily, but loaded %v", calls)
+		}
+
+		var entries []*loadingcache.Entry[int, string]
+		for entry, err := range seq {
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		if diff := cmp.Diff([]*loadingcache.Entry[int, string]{
+			{Key: 1, Value: "value1"}, {Key: 2, Value: "value2"}, {Key: 3, Value: "value3"},
+			{Key: 5, Value: "value5"}, {Key: 6, Value: "value6"}, {Key: 7, Value: "value7"},
+		}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([][]int{{1, 2, 3}, {4, 5, 6}, {7}}, calls); diff != "" {
+			t.Errorf("unexpected batches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("EarlyBreak", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]int
+		seq, err := newCache(&calls, 0).StreamBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for entry, err := range seq {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Key == 2 {
+				break
+			}
+		}
+		if diff := cmp.Diff([][]int{{1, 2, 3}}, calls); diff != "" {
+			t.Errorf("the batches after the break must not be loaded (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]int
+		seq, err := newCache(&calls, 2).StreamBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []int
+		var errs []error
+		for entry, err := range seq {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			keys = append(keys, entry.Key)
+		}
+		if diff := cmp.Diff([]int{1, 2, 3}, keys); diff != "" {
+			t.Errorf("unexpected keys (-want +got):\n%s", diff)
+		}
+		if len(errs) != 1 {
+			t.Errorf("expected an error, got %v", errs)
+		}
+		if len(calls) != 2 {
+			t.Errorf("the stream must stop on error, but loaded %v", calls)
+		}
+	})
+
+	t.Run("IndexError", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]int
+		if _, err := newCache(&calls, 0).StreamBySecondaryKey(t.Context(), "error"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestIndexedLoadingCache_InvalidateBySecondaryKeys(t *testing.T) {
+	t.Parallel()
+
+	s := memstorage.NewInMemoryStorage[int, string]()
+	loads := map[int]int{}
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				loads[key]++
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d-%d", key, loads[key])},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+	cache := loadingcache.LoadingCache[int, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+	}
+	idx := omcindex.NewOnMemoryIndexWithData(map[string][]int{"a": {1, 2}, "b": {2, 3}, "c": {4}})
+	c := loadingcache.NewIndexedLoadingCache(cache, idx)
+
+	if _, err := c.GetOrLoadMulti(t.Context(), []int{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.InvalidateBySecondaryKeys(t.Context(), []string{"a", "b", "unknown"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the resolved primary keys are deleted, and the others are kept
+	entries, err := s.GetMulti(t.Context(), []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, entry := range entries[:3] {
+		if entry != nil {
+			t.Errorf("the entry of key %d must be deleted, got %+v", i+1, entry)
+		}
+	}
+	if entries[3] == nil {
+		t.Error("the entry of key 4 must be kept")
+	}
+
+	// the deleted entries are reloaded by the next reads
+	got, err := c.FindBySecondaryKey(t.Context(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*loadingcache.Entry[int, string]{{Key: 2, Value: "value2-2"}, {Key: 3, Value: "value3-2"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+
+	t.Run("ReadOnlyStorage", func(t *testing.T) {
+		t.Parallel()
+
+		c := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+			Loader:  cache.Loader,
+			Storage: &storage.ReadOnlyStorage[int, string]{Storage: s},
+		}, idx)
+		if err := c.InvalidateBySecondaryKeys(t.Context(), []string{"a"}); !errors.Is(err, storage.ErrReadOnly) {
+			t.Errorf("expected ErrReadOnly, got %v", err)
+		}
+	})
+}
+
+func TestIndexedLoadingCache_WithLoadExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	idx := &index.FunctionsIndex[string, int]{
+		GetFunc: func(context.Context, string) ([]int, error) {
+			return []int{1, 2}, nil
+		},
+	}
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+					ExpiresAt: now.Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+	newCache := func() (*loadingcache.IndexedLoadingCache[int, string, string], loadingcache.CacheStorage[int, string]) {
+		s := memstorage.NewInMemoryStorage(memstorage.WithClock[int, string](&storagetest.FixedClock{Time: now}))
+		// key 2 is already cached with its own expiration time
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[int, string]{
+			Entry:     loadingcache.Entry[int, string]{Key: 2, Value: "cached2"},
+			ExpiresAt: now.Add(2 * time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		c := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+			Loader:  pureloader.NewPureLoader(s, src),
+			Storage: s,
+		}, idx, loadingcache.WithLoadExpiresAt[int, string, string](func(entry *loadingcache.CacheEntry[int, string]) time.Time {
+			return now.Add(time.Minute)
+		}))
+		return c, s
+	}
+	wantStored := []*loadingcache.CacheEntry[int, string]{
+		{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value1"}, ExpiresAt: now.Add(time.Minute)},
+		{Entry: loadingcache.Entry[int, string]{Key: 2, Value: "cached2"}, ExpiresAt: now.Add(2 * time.Hour)},
+	}
+
+	t.Run("FindBySecondaryKey", func(t *testing.T) {
+		t.Parallel()
+
+		c, s := newCache()
+		entries, err := c.FindBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.Entry[int, string]{{Key: 1, Value: "value1"}, {Key: 2, Value: "cached2"}}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+
+		stored, err := s.GetMulti(t.Context(), []int{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(wantStored, stored); diff != "" {
+			t.Errorf("unexpected stored entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("FindCacheEntriesBySecondaryKey", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := newCache()
+		entries, err := c.FindCacheEntriesBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(wantStored, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("GetOrLoadMulti", func(t *testing.T) {
+		t.Parallel()
+
+		// the loads not through the index keep the expiration times of the source
+		c, s := newCache()
+		if _, err := c.GetOrLoadMulti(t.Context(), []int{1}); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := s.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stored.ExpiresAt.Equal(now.Add(time.Hour)) {
+			t.Errorf("unexpected expiration time: %v", stored.ExpiresAt)
+		}
+	})
+
+	t.Run("CopyOnWrite", func(t *testing.T) {
+		t.Parallel()
+
+		// the entries shared with the readers of the storage must not be mutated by the override
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithClock[int, string](&storagetest.FixedClock{Time: now}),
+			memstorage.WithCopyOnWrite[int, string](),
+		)
+		c := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+			Loader:  singleflightloader.NewSingleFlightLoader(s, src),
+			Storage: s,
+		}, idx, loadingcache.WithLoadExpiresAt[int, string, string](func(entry *loadingcache.CacheEntry[int, string]) time.Time {
+			return now.Add(time.Minute)
+		}))
+
+		done := make(chan struct{})
+		readerDone := make(chan struct{})
+		go func() {
+			defer close(readerDone)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, key := range []int{1, 2} {
+					if entry, err := s.Get(t.Context(), key); err != nil {
+						t.Error(err)
+						return
+					} else if entry != nil {
+						_ = entry.ExpiresAt
+					}
+				}
+			}
+		}()
+		entries, err := c.FindCacheEntriesBySecondaryKey(t.Context(), "category")
+		close(done)
+		<-readerDone
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if !entry.ExpiresAt.Equal(now.Add(time.Minute)) {
+				t.Errorf("unexpected expiration time of key %d: %v", entry.Key, entry.ExpiresAt)
+			}
+		}
+	})
+
+	t.Run("IgnoreStorageGetErrors", func(t *testing.T) {
+		t.Parallel()
+
+		// the loaded entries are returned even if the storage fails to read them
+		s := &storage.FunctionsStorage[int, string]{
+			GetMultiFunc: func(context.Context, []int) ([]*loadingcache.CacheEntry[int, string], error) {
+				return nil, errStorage
+			},
+			SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+				return nil
+			},
+		}
+		var reported [][]int
+		c := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+			Loader:                 singleflightloader.NewSingleFlightLoader(s, src),
+			Storage:                s,
+			IgnoreStorageGetErrors: true,
+			OnStorageGetError: func(keys []int, err error) {
+				if !errors.Is(err, errStorage) {
+					t.Errorf("expected %v, got %v", errStorage, err)
+				}
+				reported = append(reported, keys)
+			},
+		}, idx, loadingcache.WithLoadExpiresAt[int, string, string](func(entry *loadingcache.CacheEntry[int, string]) time.Time {
+			return now.Add(time.Minute)
+		}))
+
+		entries, err := c.FindCacheEntriesBySecondaryKey(t.Context(), "category")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[int, string]{
+			{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value1"}, ExpiresAt: now.Add(time.Minute)},
+			{Entry: loadingcache.Entry[int, string]{Key: 2, Value: "value2"}, ExpiresAt: now.Add(time.Minute)},
+		}
+		if diff := cmp.Diff(want, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([][]int{{1, 2}}, reported); diff != "" {
+			t.Errorf("unexpected reported errors (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestIndexedLoadingCache_WithFailFastWhenIndexNotReady(t *testing.T) {
+	t.Parallel()
+
+	s := memstorage.NewInMemoryStorage[int, string]()
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+	idx := omcindex.NewOnMemoryIndex[string, int](index.FunctionIndexSource[string, int](func(context.Context) (map[string][]int, error) {
+		return map[string][]int{"a": {1, 2}}, nil
+	}))
+	c := loadingcache.NewIndexedLoadingCache(loadingcache.LoadingCache[int, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+	}, idx, loadingcache.WithFailFastWhenIndexNotReady[int, string, string]())
+
+	// note: the lookups would block forever without the option, since the index is never refreshed yet.
+	if _, err := c.FindBySecondaryKey(t.Context(), "a"); !errors.Is(err, loadingcache.ErrIndexNotReady) {
+		t.Errorf("FindBySecondaryKey: expected ErrIndexNotReady, got %v", err)
+	}
+	if _, err := c.FindCacheEntriesBySecondaryKey(t.Context(), "a"); !errors.Is(err, loadingcache.ErrIndexNotReady) {
+		t.Errorf("FindCacheEntriesBySecondaryKey: expected ErrIndexNotReady, got %v", err)
+	}
+	if _, err := c.FindBySecondaryKeys(t.Context(), []string{"a"}); !errors.Is(err, loadingcache.ErrIndexNotReady) {
+		t.Errorf("FindBySecondaryKeys: expected ErrIndexNotReady, got %v", err)
+	}
+	if _, err := c.StreamBySecondaryKey(t.Context(), "a"); !errors.Is(err, loadingcache.ErrIndexNotReady) {
+		t.Errorf("StreamBySecondaryKey: expected ErrIndexNotReady, got %v", err)
+	}
+	if err := c.InvalidateBySecondaryKeys(t.Context(), []string{"a"}); !errors.Is(err, loadingcache.ErrIndexNotReady) {
+		t.Errorf("InvalidateBySecondaryKeys: expected ErrIndexNotReady, got %v", err)
+	}
+	if !errors.Is(omcindex.ErrIndexNotReady, loadingcache.ErrIndexNotReady) {
+		t.Error("the error of omcindex must be the same as loadingcache.ErrIndexNotReady")
+	}
+
+	if err := idx.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := c.FindBySecondaryKey(t.Context(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.Entry[int, string]{{Key: 1, Value: "value1"}, {Key: 2, Value: "value2"}}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
@@ -2,6 +2,7 @@ package loadingcache
 
 import (
 	"context"
+	"iter"
 	"time"
 )
 
@@ -38,6 +39,29 @@ type CacheEntry[K KeyConstraint, V ValueConstraint] struct {
 	// It is used to prevent repeated lookups for non-existent keys.
 	// If NegativeCache is true, the Value field must be the zero value of V.
 	NegativeCache bool
+
+	// NegativeReason is the reason why the key does not exist in the source.
+	// This field is optional and meaningful only if NegativeCache is true.
+	// The sources set it to NegativeCachePermanent for the authoritative misses, so they can be cached longer.
+	NegativeReason NegativeCacheReason
+}
+
+// NegativeCacheReason is the reason of a negative cache.
+type NegativeCacheReason uint8
+
+const (
+	// NegativeCacheTransient means that the key is absent in the source for now, and it may appear later.
+	// It is the default reason of the negative caches.
+	NegativeCacheTransient NegativeCacheReason = iota
+
+	// NegativeCachePermanent means that the key is absent in the source permanently.
+	NegativeCachePermanent
+)
+
+// IsNegative reports whether the entry is a negative cache.
+// It returns false for nil entries, so it can be applied directly to the results of CacheStorage.GetMulti.
+func IsNegative[K KeyConstraint, V ValueConstraint](entry *CacheEntry[K, V]) bool {
+	return entry != nil && entry.NegativeCache
 }
 
 // CacheStorage is an interface for a cache storage backend.
@@ -65,6 +89,39 @@ type CacheStorage[K KeyConstraint, V ValueConstraint] interface {
 	// If a key is cached as a negative cache, it should return a CacheEntry with NegativeCache set to true.
 	// It must clone the returned entries before returning them.
 	GetMulti(context.Context, []K) ([]*CacheEntry[K, V], error)
+
+	// Delete removes the entry by its key, including the negative cache.
+	// Deleting a missing key is a no-op and returns nil.
+	Delete(context.Context, K) error
+
+	// DeleteMulti removes the entries by their keys, including the negative caches.
+	// Deleting missing keys is a no-op and returns nil.
+	DeleteMulti(context.Context, []K) error
+}
+
+// PartialSetCacheStorage is an optional interface for CacheStorage that can report which entries are stored
+// when storing multiple entries fails partway.
+// Implementations must be thread-safe.
+type PartialSetCacheStorage[K KeyConstraint, V ValueConstraint] interface {
+	CacheStorage[K, V]
+
+	// SetMultiPartial stores multiple entries as SetMulti does, and returns the keys of the stored entries.
+	// It returns the keys of all the non-nil entries on success. On failure, it returns the keys of the entries
+	// known to be stored with the error, and the other entries may or may not be stored.
+	// It must clone the given entries before storing them.
+	SetMultiPartial(context.Context, []*CacheEntry[K, V]) ([]K, error)
+}
+
+// StaleCacheStorage is an optional interface for CacheStorage that can return the expired entries.
+// Implementations must be thread-safe.
+type StaleCacheStorage[K KeyConstraint, V ValueConstraint] interface {
+	CacheStorage[K, V]
+
+	// GetStale retrieves a value by its key regardless of its expiration.
+	// If the key is not found, it should return nil as the CacheEntry.
+	// The expired entries may have been removed by the storage, so it is not guaranteed to return them.
+	// It must clone the returned entry before returning it.
+	GetStale(context.Context, K) (*CacheEntry[K, V], error)
 }
 
 // LoadingSource is an interface for loading data from an external source.
@@ -91,6 +148,18 @@ type SourceLoader[K KeyConstraint, V ValueConstraint] interface {
 	LoadAndStoreMulti(context.Context, []K) ([]*Entry[K, V], error)
 }
 
+// CacheEntrySourceLoader is an optional interface for SourceLoader that returns the loaded entries along with
+// their expiration times and the negative cache flags.
+// Implementations must be thread-safe.
+type CacheEntrySourceLoader[K KeyConstraint, V ValueConstraint] interface {
+	SourceLoader[K, V]
+
+	// LoadAndStoreMultiCacheEntries loads multiple entries by keys from the external source and stores them in the cache storage.
+	// It returns the stored entries in the order of the input keys, including the negative caches.
+	// If a key is not found, it returns nil for that key.
+	LoadAndStoreMultiCacheEntries(context.Context, []K) ([]*CacheEntry[K, V], error)
+}
+
 // Index is an interface for indexing data.
 // Implementations must be thread-safe.
 type Index[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
@@ -103,6 +172,44 @@ type Index[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
 	GetMulti(context.Context, []SecondaryKey) (map[SecondaryKey][]PrimaryKey, error)
 }
 
+// MutableIndex is an interface for indexing data that can be updated incrementally.
+// Implementations must be thread-safe.
+type MutableIndex[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
+	Index[SecondaryKey, PrimaryKey]
+
+	// Add associates the primary keys with the secondary key.
+	// The primary keys already associated with the secondary key are ignored.
+	Add(ctx context.Context, sk SecondaryKey, pks ...PrimaryKey) error
+
+	// Remove dissociates the primary keys from the secondary key.
+	// The primary keys not associated with the secondary key are ignored.
+	Remove(ctx context.Context, sk SecondaryKey, pks ...PrimaryKey) error
+}
+
+// IndexEntry is a primary key associated with a secondary key, along with the expiration time of the association.
+type IndexEntry[PrimaryKey KeyConstraint] struct {
+	// PrimaryKey is the primary key associated with the secondary key.
+	PrimaryKey PrimaryKey
+
+	// ExpiresAt is the expiration time of the association.
+	// The zero value means the association never expires.
+	ExpiresAt time.Time
+}
+
+// ExpiringIndex is an interface for indexing data whose associations carry expiration times.
+// Implementations must be thread-safe.
+type ExpiringIndex[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
+	Index[SecondaryKey, PrimaryKey]
+
+	// GetWithExpiry retrieves primary keys with the expiration times of their associations by secondary key.
+	// The primary keys are unique.
+	GetWithExpiry(context.Context, SecondaryKey) ([]IndexEntry[PrimaryKey], error)
+
+	// GetMultiWithExpiry retrieves primary keys with the expiration times of their associations by multiple secondary keys.
+	// The primary keys are unique per secondary key.
+	GetMultiWithExpiry(context.Context, []SecondaryKey) (map[SecondaryKey][]IndexEntry[PrimaryKey], error)
+}
+
 type CompositeIndex[LeftSecondaryKey KeyConstraint, RightSecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
 }
 
@@ -114,8 +221,46 @@ type RefreshIndex interface {
 	Refresh(context.Context) error
 }
 
+// ReadinessIndex is an optional interface for Index that reports whether it is ready without blocking.
+// Implementations must be thread-safe.
+type ReadinessIndex interface {
+	// Ready reports whether the index is initialized, so that its reads never wait for the initialization.
+	Ready() bool
+}
+
 // IndexSource is an interface for indexing data sources.
 type IndexSource[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
 	// GetAll retrieves all secondary keys and their corresponding primary keys.
 	GetAll(context.Context) (map[SecondaryKey][]PrimaryKey, error)
 }
+
+// ExpiringIndexSource is an interface for indexing data sources whose associations carry expiration times.
+type ExpiringIndexSource[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
+	IndexSource[SecondaryKey, PrimaryKey]
+
+	// GetAllWithExpiry retrieves all secondary keys and their corresponding primary keys with the expiration times of the associations.
+	GetAllWithExpiry(context.Context) (map[SecondaryKey][]IndexEntry[PrimaryKey], error)
+}
+
+// KeyedIndexSource is an interface for indexing data sources that can retrieve the associations of a single secondary key.
+// It allows the index to refresh the changed secondary key without retrieving all the entries.
+type KeyedIndexSource[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
+	IndexSource[SecondaryKey, PrimaryKey]
+
+	// GetBySecondaryKey retrieves the primary keys corresponding to the secondary key.
+	// It returns an empty slice or nil if the secondary key has no associations.
+	GetBySecondaryKey(context.Context, SecondaryKey) ([]PrimaryKey, error)
+}
+
+// StreamingIndexSource is an interface for indexing data sources that can stream their entries.
+// It allows the index to be built incrementally without materializing all the entries at once.
+type StreamingIndexSource[SecondaryKey KeyConstraint, PrimaryKey KeyConstraint] interface {
+	IndexSource[SecondaryKey, PrimaryKey]
+
+	// Stream returns an iterator that yields all secondary keys and their corresponding primary keys,
+	// and a function that reports the error that stopped the iteration, if any.
+	// The same secondary key may be yielded multiple times, and its primary keys are concatenated.
+	// The yielded slices may be reused by the source after the yield returns.
+	// The error function must be called after the iteration is finished.
+	Stream(context.Context) (iter.Seq2[SecondaryKey, []PrimaryKey], func() error)
+}
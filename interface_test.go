@@ -0,0 +1,29 @@
+package loadingcache_test
+
+import (
+	"testing"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+func TestIsNegative(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		entry  *loadingcache.CacheEntry[uint8, int8]
+		expect bool
+	}{
+		{name: "Nil", entry: nil, expect: false},
+		{name: "Positive", entry: &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}}, expect: false},
+		{name: "Negative", entry: &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1}, NegativeCache: true}, expect: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := loadingcache.IsNegative(tt.entry); got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
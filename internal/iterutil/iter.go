@@ -36,6 +36,24 @@ func Union[V comparable](iters ...iter.Seq[V]) iter.Seq[V] {
 	})
 }
 
+// Difference returns a new iterator that yields the values of the base iterator that are not present in any of the excluded iterators.
+// The excluded iterators are consumed before the first value is yielded.
+func Difference[V comparable](base iter.Seq[V], excludes ...iter.Seq[V]) iter.Seq[V] {
+	return iter.Seq[V](func(yield func(V) bool) {
+		excluded := map[V]struct{}{}
+		for _, seq := range excludes {
+			for v := range seq {
+				excluded[v] = struct{}{}
+			}
+		}
+		for v := range base {
+			if _, ok := excluded[v]; !ok && !yield(v) {
+				return
+			}
+		}
+	})
+}
+
 // Uniq returns a new iterator that yields the unique values from the input iterator.
 // The order of the output is the same as the input.
 func Uniq[V comparable](seq iter.Seq[V]) iter.Seq[V] {
@@ -498,3 +498,55 @@ func TestFlatMap_Break(t *testing.T) {
 		t.Errorf("unexpected output counter value: %d, should be exactly 9", outputCounter)
 	}
 }
+
+func TestDifference(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		base     []uint8
+		excludes [][]uint8
+		want     []uint8
+	}{
+		{
+			name: "empty",
+			want: nil,
+		},
+		{
+			name:     "empty base",
+			excludes: [][]uint8{{1, 2}},
+			want:     nil,
+		},
+		{
+			name: "no excludes",
+			base: []uint8{1, 2, 3},
+			want: []uint8{1, 2, 3},
+		},
+		{
+			name:     "overlapping",
+			base:     []uint8{1, 2, 3, 4},
+			excludes: [][]uint8{{2, 5}, {4}},
+			want:     []uint8{1, 3},
+		},
+		{
+			name:     "disjoint",
+			base:     []uint8{1, 2},
+			excludes: [][]uint8{{3, 4}},
+			want:     []uint8{1, 2},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			excludes := make([]iter.Seq[uint8], 0, len(tt.excludes))
+			for _, exclude := range tt.excludes {
+				excludes = append(excludes, slices.Values(exclude))
+			}
+
+			got := slices.Collect(iterutil.Difference(slices.Values(tt.base), excludes...))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,12 @@ const (
 	intSize = 32 << (^uint(0) >> 63)
 )
 
+// MaxPooledBufferSize is the maximum capacity of the buffers returned to the pool after hashing string keys.
+// The buffers grown larger than it by long keys are discarded instead of being pooled, so the pool does not retain
+// large amounts of memory. In exchange, hashing keys longer than it allocates a new buffer every time.
+// It must be set before hashing any keys.
+var MaxPooledBufferSize = 64 * 1024
+
 var (
 	// defaultKeyHashMapMutex is a mutex for the defaultKeyHashMap.
 	defaultKeyHashMapMutex = sync.RWMutex{}
@@ -187,12 +193,16 @@ var hash64BufferPool = &resettablePool[hash.Hash64]{
 }
 
 // bytesBufferPool is a pool for bytes.Buffer objects.
+// The buffers larger than MaxPooledBufferSize are discarded.
 var bytesBufferPool = &resettablePool[*bytes.Buffer]{
 	pool: sync.Pool{
 		New: func() any {
-			return bytes.NewBuffer(make([]byte, 4096))
+			return bytes.NewBuffer(make([]byte, 0, 4096))
 		},
 	},
+	discard: func(b *bytes.Buffer) bool {
+		return b.Cap() > MaxPooledBufferSize
+	},
 }
 
 // resetter is an interface that defines a Reset method.
@@ -205,10 +215,17 @@ type resetter interface {
 // It uses a sync.Pool to manage the objects and ensures that they are reset before being reused.
 type resettablePool[H resetter] struct {
 	pool sync.Pool
+
+	// discard is an optional function that reports whether the object should be discarded instead of being pooled.
+	discard func(H) bool
 }
 
 // Put adds an object to the pool after resetting it.
+// The object is discarded if the discard function reports true for it.
 func (p *resettablePool[H]) Put(h H) {
+	if p.discard != nil && p.discard(h) {
+		return
+	}
 	h.Reset()
 	p.pool.Put(h)
 }
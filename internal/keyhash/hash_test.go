@@ -2,6 +2,8 @@ package keyhash_test
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/karupanerura/loading-cache/internal/keyhash"
@@ -36,7 +38,7 @@ func TestGetOrCreateKeyHash(t *testing.T) {
 			{"uint64", keyhash.GetOrCreateKeyHash[uint64](), uint64(42), 0x81e14877},
 			{"float32", keyhash.GetOrCreateKeyHash[float32](), float32(42.0), 0xb4eab2af},
 			{"float64", keyhash.GetOrCreateKeyHash[float64](), float64(42.0), 0x2887997e},
-			{"string", keyhash.GetOrCreateKeyHash[string](), "test", 0xff97b1e5},
+			{"string", keyhash.GetOrCreateKeyHash[string](), "test", 0xafd071e5},
 		}
 	} else {
 		tests = []testCase{
@@ -52,7 +54,7 @@ func TestGetOrCreateKeyHash(t *testing.T) {
 			{"uint64", keyhash.GetOrCreateKeyHash[uint64](), uint64(42), 0xa8c7de32281a0d97},
 			{"float32", keyhash.GetOrCreateKeyHash[float32](), float32(42.0), 0xe64108a69be87c0f},
 			{"float64", keyhash.GetOrCreateKeyHash[float64](), float64(42.0), 0xe17c3355bfbe5a7e},
-			{"string", keyhash.GetOrCreateKeyHash[string](), "test", 0x3bfeed4cdcc96b25},
+			{"string", keyhash.GetOrCreateKeyHash[string](), "test", 0xf9e6e6ef197c2b25},
 		}
 	}
 
@@ -83,3 +85,30 @@ func TestGetOrCreateKeyHash_ReturnsSameFunctionForSameType(t *testing.T) {
 		t.Errorf("expected different functions for different types, but got the same function")
 	}
 }
+
+func TestGetOrCreateKeyHash_String(t *testing.T) {
+	t.Parallel()
+
+	hashFunc := keyhash.GetOrCreateKeyHash[string]()
+	for _, key := range []string{"", "test", strings.Repeat("x", keyhash.MaxPooledBufferSize*2)} {
+		want := hashFunc(key)
+		for range 3 {
+			if got := hashFunc(key); got != want {
+				t.Errorf("the hash of the key with %d bytes must be stable: %x != %x", len(key), got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkGetOrCreateKeyHash_String(b *testing.B) {
+	hashFunc := keyhash.GetOrCreateKeyHash[string]()
+	for _, size := range []int{16, 4096, keyhash.MaxPooledBufferSize * 2} {
+		key := strings.Repeat("x", size)
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				hashFunc(key)
+			}
+		})
+	}
+}
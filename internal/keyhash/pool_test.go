@@ -0,0 +1,24 @@
+package keyhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBytesBufferPool_DiscardsOversizedBuffers(t *testing.T) {
+	oversized := bytes.NewBuffer(make([]byte, 0, MaxPooledBufferSize+1))
+	bytesBufferPool.Put(oversized)
+	for range 100 {
+		b := bytesBufferPool.Get()
+		if b == oversized {
+			t.Fatal("the oversized buffer must not be pooled")
+		}
+		if b.Len() != 0 {
+			t.Errorf("the pooled buffer must be empty, got %d bytes", b.Len())
+		}
+		if b.Cap() > MaxPooledBufferSize {
+			t.Errorf("the pooled buffer must not exceed %d bytes, got %d", MaxPooledBufferSize, b.Cap())
+		}
+		bytesBufferPool.Put(b)
+	}
+}
@@ -44,7 +44,10 @@ func (dds *DoubleDeferSandwich) Invoke(f func() error) (err error) {
 	}()
 	func() {
 		defer func() {
-			panicValue = panics.NewRecovered(2, recover())
+			// capture the stack only on panics, since it is expensive especially for the deep stacks
+			if r := recover(); r != nil {
+				panicValue = panics.NewRecovered(2, r)
+			}
 		}()
 		err = f()
 		normalReturn = true
@@ -0,0 +1,163 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/internal/panicutil"
+)
+
+var errAggregatedLoadGoexit = errors.New("runtime.Goexit is called in the aggregated load")
+
+// AggregatingLoader is a decorator for a loadingcache.SourceLoader that aggregates the keys of the concurrent
+// LoadAndStore calls within a short window into a single LoadAndStoreMulti call of the underlying loader.
+// It reduces the calls to the backend for the bursty single-key traffic such as the misses of LoadingCache.GetOrLoad.
+//
+// Each LoadAndStore call waits up to Window before the aggregated load starts, so it adds the latency
+// bounded by Window to the load.
+// The zero value is not ready to use; Loader and Window must be set, and it must not be copied after first use.
+type AggregatingLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Loader is the underlying loader that this decorator wraps.
+	Loader loadingcache.SourceLoader[K, V]
+
+	// Window is the time to wait for the other keys after the first key of a batch arrives.
+	Window time.Duration
+
+	// MaxKeys is the maximum number of the keys in a batch.
+	// The batch is loaded immediately once it reaches MaxKeys. Zero means no limit.
+	MaxKeys int
+
+	// Cloner is an optional value cloner to clone the loaded values for the callers of the same key other than the first one.
+	// If it is nil, the values are shared among the callers of the same key in a batch.
+	Cloner loadingcache.ValueCloner[V]
+
+	mu      sync.Mutex
+	pending *aggregatedBatch[K, V]
+}
+
+var _ loadingcache.SourceLoader[uint8, struct{}] = (*AggregatingLoader[uint8, struct{}])(nil)
+var _ loadingcache.CacheEntrySourceLoader[uint8, struct{}] = (*AggregatingLoader[uint8, struct{}])(nil)
+
+// aggregatedBatch is the keys aggregated within a window and the results of their load.
+type aggregatedBatch[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	ctx     context.Context
+	keys    []K
+	indexes map[K]int
+	timer   *time.Timer
+	done    chan struct{}
+	entries []*loadingcache.CacheEntry[K, V]
+	err     error
+}
+
+// LoadAndStore adds the key to the current batch, and waits for the batch to be loaded by the underlying loader.
+// The batch is loaded with the context of the call that started it, without its cancellation,
+// so the cancellation of a call stops only its own waiting.
+// Each caller receives its own entry, and the value is cloned by the Cloner for the callers of the same key
+// other than the first one. The panics of the underlying loader are returned as errors to all the callers of the batch.
+func (l *AggregatingLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
+	b, i, first := l.enqueue(ctx, key)
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	cacheEntry := b.entries[i]
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return nil, nil
+	}
+	entry := cacheEntry.Entry
+	if !first && l.Cloner != nil {
+		entry.Value = l.Cloner.CloneValue(entry.Value)
+	}
+	return &entry, nil
+}
+
+// LoadAndStoreMulti calls the LoadAndStoreMulti of the underlying loader directly, since the keys are already aggregated.
+func (l *AggregatingLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
+	return l.Loader.LoadAndStoreMulti(ctx, keys)
+}
+
+// LoadAndStoreMultiCacheEntries calls the LoadAndStoreMultiCacheEntries of the underlying loader directly
+// if it is a loadingcache.CacheEntrySourceLoader.
+// Otherwise, the entries are built from the results of its LoadAndStoreMulti, so their expiration times are zero,
+// and the negative-cached keys are returned as nil like the keys not found.
+func (l *AggregatingLoader[K, V]) LoadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	if loader, ok := l.Loader.(loadingcache.CacheEntrySourceLoader[K, V]); ok {
+		return loader.LoadAndStoreMultiCacheEntries(ctx, keys)
+	}
+
+	entries, err := l.Loader.LoadAndStoreMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	cacheEntries := make([]*loadingcache.CacheEntry[K, V], len(entries))
+	for i, entry := range entries {
+		if entry != nil {
+			cacheEntries[i] = &loadingcache.CacheEntry[K, V]{Entry: *entry}
+		}
+	}
+	return cacheEntries, nil
+}
+
+// enqueue adds the key to the pending batch, starting a new batch if there is none.
+// It returns the batch, the index of the key in it, and whether the caller is the first one of the key in the batch.
+func (l *AggregatingLoader[K, V]) enqueue(ctx context.Context, key K) (*aggregatedBatch[K, V], int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.pending
+	if b == nil {
+		b = &aggregatedBatch[K, V]{
+			ctx:     context.WithoutCancel(ctx),
+			indexes: map[K]int{},
+			done:    make(chan struct{}),
+		}
+		b.timer = time.AfterFunc(l.Window, func() { l.flush(b) })
+		l.pending = b
+	}
+
+	i, ok := b.indexes[key]
+	if !ok {
+		i = len(b.keys)
+		b.indexes[key] = i
+		b.keys = append(b.keys, key)
+	}
+	if l.MaxKeys > 0 && len(b.keys) >= l.MaxKeys && b.timer.Stop() {
+		l.pending = nil
+		go l.load(b)
+	}
+	return b, i, !ok
+}
+
+// flush loads the batch if it is still pending.
+func (l *AggregatingLoader[K, V]) flush(b *aggregatedBatch[K, V]) {
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+	l.load(b)
+}
+
+// load loads the keys of the batch by the underlying loader, and notifies the waiters.
+// The panics and runtime.Goexit of the underlying loader are passed to the waiters as errors.
+func (l *AggregatingLoader[K, V]) load(b *aggregatedBatch[K, V]) {
+	defer close(b.done)
+
+	dds := panicutil.DoubleDeferSandwich{
+		OnGoexit: func() {
+			b.err = errAggregatedLoadGoexit
+		},
+	}
+	b.err = dds.Invoke(func() (err error) {
+		b.entries, err = l.LoadAndStoreMultiCacheEntries(b.ctx, b.keys)
+		return
+	})
+}
@@ -0,0 +1,244 @@
+package loader_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader"
+	"github.com/karupanerura/loading-cache/loader/pureloader"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/sourcegraph/conc/panics"
+)
+
+func TestAggregatingLoader(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	base := &functionsLoader[int, string]{
+		loadAndStore: func(context.Context, int) (*loadingcache.Entry[int, string], error) {
+			t.Error("LoadAndStore of the underlying loader must not be called")
+			return nil, nil
+		},
+		loadAndStoreMulti: func(_ context.Context, keys []int) ([]*loadingcache.Entry[int, string], error) {
+			calls.Add(1)
+			entries := make([]*loadingcache.Entry[int, string], len(keys))
+			for i, key := range keys {
+				if key%10 != 0 {
+					entries[i] = &loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprint(key)}
+				}
+			}
+			return entries, nil
+		},
+	}
+	l := &loader.AggregatingLoader[int, string]{Loader: base, Window: 50 * time.Millisecond}
+
+	const n = 100
+	var wg sync.WaitGroup
+	results := make([]*loadingcache.Entry[int, string], n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := l.LoadAndStore(t.Context(), i%50)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = entry
+		}()
+	}
+	wg.Wait()
+
+	// the concurrent misses collapse into a few multi-loads
+	if got := calls.Load(); got < 1 || got > 3 {
+		t.Errorf("unexpected number of the multi-loads: %d", got)
+	}
+	for i, entry := range results {
+		var want *loadingcache.Entry[int, string]
+		if key := i % 50; key%10 != 0 {
+			want = &loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprint(key)}
+		}
+		if diff := cmp.Diff(want, entry); diff != "" {
+			t.Errorf("unexpected entry for %d (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestAggregatingLoader_MaxKeys(t *testing.T) {
+	t.Parallel()
+
+	var batches atomic.Int32
+	base := &functionsLoader[int, string]{
+		loadAndStoreMulti: func(_ context.Context, keys []int) ([]*loadingcache.Entry[int, string], error) {
+			batches.Add(1)
+			return make([]*loadingcache.Entry[int, string], len(keys)), nil
+		},
+	}
+	l := &loader.AggregatingLoader[int, string]{Loader: base, Window: time.Hour, MaxKeys: 2}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.LoadAndStore(t.Context(), i); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := batches.Load(); got != 2 {
+		t.Errorf("expected 2 batches, got %d", got)
+	}
+}
+
+func TestAggregatingLoader_Error(t *testing.T) {
+	t.Parallel()
+
+	loadErr := errors.New("load error")
+	base := &functionsLoader[int, string]{
+		loadAndStoreMulti: func(context.Context, []int) ([]*loadingcache.Entry[int, string], error) {
+			return nil, loadErr
+		},
+	}
+	l := &loader.AggregatingLoader[int, string]{Loader: base, Window: time.Millisecond}
+
+	if _, err := l.LoadAndStore(t.Context(), 1); !errors.Is(err, loadErr) {
+		t.Errorf("expected %v, got %v", loadErr, err)
+	}
+
+	// the cancellation of the caller stops only its own waiting
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := l.LoadAndStore(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestAggregatingLoader_LoadAndStoreMultiCacheEntries(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				if key != 0 {
+					entries[i] = &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprint(key)}, ExpiresAt: expiresAt}
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+	want := []*loadingcache.CacheEntry[int, string]{
+		{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "1"}, ExpiresAt: expiresAt},
+		nil,
+	}
+
+	t.Run("CacheEntrySourceLoader", func(t *testing.T) {
+		t.Parallel()
+
+		l := &loader.AggregatingLoader[int, string]{Loader: pureloader.NewPureLoader(s, src), Window: time.Millisecond}
+		got, err := l.LoadAndStoreMultiCacheEntries(t.Context(), []int{1, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("SourceLoader", func(t *testing.T) {
+		t.Parallel()
+
+		base := struct {
+			loadingcache.SourceLoader[int, string]
+		}{pureloader.NewPureLoader(s, src)}
+		l := &loader.AggregatingLoader[int, string]{Loader: base, Window: time.Millisecond}
+		got, err := l.LoadAndStoreMultiCacheEntries(t.Context(), []int{1, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the expiration times are unknown without CacheEntrySourceLoader
+		want := []*loadingcache.CacheEntry[int, string]{
+			{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "1"}},
+			nil,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestAggregatingLoader_Panic(t *testing.T) {
+	t.Parallel()
+
+	base := &functionsLoader[int, string]{
+		loadAndStoreMulti: func(context.Context, []int) ([]*loadingcache.Entry[int, string], error) {
+			panic("loader panic")
+		},
+	}
+	l := &loader.AggregatingLoader[int, string]{Loader: base, Window: time.Millisecond}
+
+	var recovered *panics.ErrRecovered
+	if _, err := l.LoadAndStore(t.Context(), 1); !errors.As(err, &recovered) {
+		t.Errorf("expected the recovered panic, got %v", err)
+	}
+}
+
+func TestAggregatingLoader_Cloner(t *testing.T) {
+	t.Parallel()
+
+	base := &functionsLoader[int, []string]{
+		loadAndStoreMulti: func(_ context.Context, keys []int) ([]*loadingcache.Entry[int, []string], error) {
+			entries := make([]*loadingcache.Entry[int, []string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.Entry[int, []string]{Key: key, Value: []string{fmt.Sprint(key)}}
+			}
+			return entries, nil
+		},
+	}
+	l := &loader.AggregatingLoader[int, []string]{Loader: base, Window: 50 * time.Millisecond, Cloner: loadingcache.ValueClonerFunc[[]string](slices.Clone[[]string])}
+
+	const n = 4
+	var wg sync.WaitGroup
+	results := make([]*loadingcache.Entry[int, []string], n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := l.LoadAndStore(t.Context(), 1)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = entry
+		}()
+	}
+	wg.Wait()
+
+	// each caller of the same key receives its own entry and value
+	for i := range n {
+		if diff := cmp.Diff(&loadingcache.Entry[int, []string]{Key: 1, Value: []string{"1"}}, results[i]); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+		for j := range i {
+			if results[i] == results[j] || &results[i].Value[0] == &results[j].Value[0] {
+				t.Errorf("the entries of the callers %d and %d must not share the memory", i, j)
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package loader provides decorators for the loadingcache.SourceLoader implementations.
+//
+// The loader implementations themselves are provided by the subpackages such as pureloader and singleflightloader.
+package loader
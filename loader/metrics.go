@@ -0,0 +1,55 @@
+package loader
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// LoadStats is the statistics of a call of the loader.
+type LoadStats struct {
+	// Keys is the number of the requested keys.
+	Keys int
+
+	// Duration is the time taken by the call.
+	Duration time.Duration
+
+	// Err is the error returned by the call, if any.
+	Err error
+}
+
+// MetricsLoader is a decorator for a loadingcache.SourceLoader that reports the statistics of each call.
+// The results of the underlying loader are passed through as they are.
+type MetricsLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Loader is the underlying loader that this decorator wraps.
+	Loader loadingcache.SourceLoader[K, V]
+
+	// OnLoadAndStore is an optional function that is called after each LoadAndStore call.
+	OnLoadAndStore func(LoadStats)
+
+	// OnLoadAndStoreMulti is an optional function that is called after each LoadAndStoreMulti call.
+	OnLoadAndStoreMulti func(LoadStats)
+}
+
+var _ loadingcache.SourceLoader[uint8, struct{}] = (*MetricsLoader[uint8, struct{}])(nil)
+
+// LoadAndStore calls the LoadAndStore of the underlying loader and reports its statistics.
+func (l *MetricsLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
+	start := time.Now()
+	entry, err := l.Loader.LoadAndStore(ctx, key)
+	if l.OnLoadAndStore != nil {
+		l.OnLoadAndStore(LoadStats{Keys: 1, Duration: time.Since(start), Err: err})
+	}
+	return entry, err
+}
+
+// LoadAndStoreMulti calls the LoadAndStoreMulti of the underlying loader and reports its statistics.
+func (l *MetricsLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
+	start := time.Now()
+	entries, err := l.Loader.LoadAndStoreMulti(ctx, keys)
+	if l.OnLoadAndStoreMulti != nil {
+		l.OnLoadAndStoreMulti(LoadStats{Keys: len(keys), Duration: time.Since(start), Err: err})
+	}
+	return entries, err
+}
@@ -0,0 +1,98 @@
+package loader_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader"
+)
+
+type functionsLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	loadAndStore      func(context.Context, K) (*loadingcache.Entry[K, V], error)
+	loadAndStoreMulti func(context.Context, []K) ([]*loadingcache.Entry[K, V], error)
+}
+
+func (l *functionsLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
+	return l.loadAndStore(ctx, key)
+}
+
+func (l *functionsLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
+	return l.loadAndStoreMulti(ctx, keys)
+}
+
+func TestMetricsLoader(t *testing.T) {
+	t.Parallel()
+
+	loadErr := errors.New("load error")
+	base := &functionsLoader[uint8, string]{
+		loadAndStore: func(_ context.Context, key uint8) (*loadingcache.Entry[uint8, string], error) {
+			time.Sleep(time.Millisecond)
+			if key == 0 {
+				return nil, loadErr
+			}
+			return &loadingcache.Entry[uint8, string]{Key: key, Value: "value"}, nil
+		},
+		loadAndStoreMulti: func(_ context.Context, keys []uint8) ([]*loadingcache.Entry[uint8, string], error) {
+			time.Sleep(time.Millisecond)
+			entries := make([]*loadingcache.Entry[uint8, string], len(keys))
+			for i, key := range keys {
+				if key == 0 {
+					return nil, loadErr
+				}
+				entries[i] = &loadingcache.Entry[uint8, string]{Key: key, Value: "value"}
+			}
+			return entries, nil
+		},
+	}
+
+	var single, multi []loader.LoadStats
+	l := &loader.MetricsLoader[uint8, string]{
+		Loader: base,
+		OnLoadAndStore: func(stats loader.LoadStats) {
+			single = append(single, stats)
+		},
+		OnLoadAndStoreMulti: func(stats loader.LoadStats) {
+			multi = append(multi, stats)
+		},
+	}
+
+	entry, err := l.LoadAndStore(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "value"}, entry); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	if _, err := l.LoadAndStore(t.Context(), 0); !errors.Is(err, loadErr) {
+		t.Errorf("expected %v, got %v", loadErr, err)
+	}
+
+	entries, err := l.LoadAndStoreMulti(t.Context(), []uint8{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(entries))
+	}
+	if _, err := l.LoadAndStoreMulti(t.Context(), []uint8{1, 0}); !errors.Is(err, loadErr) {
+		t.Errorf("expected %v, got %v", loadErr, err)
+	}
+
+	ignoreDuration := cmpopts.IgnoreFields(loader.LoadStats{}, "Duration")
+	if diff := cmp.Diff([]loader.LoadStats{{Keys: 1}, {Keys: 1, Err: loadErr}}, single, ignoreDuration, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("unexpected LoadAndStore stats (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]loader.LoadStats{{Keys: 3}, {Keys: 2, Err: loadErr}}, multi, ignoreDuration, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("unexpected LoadAndStoreMulti stats (-want +got):\n%s", diff)
+	}
+	for _, stats := range append(single, multi...) {
+		if stats.Duration < time.Millisecond {
+			t.Errorf("expected the duration to include the load, got %v", stats.Duration)
+		}
+	}
+}
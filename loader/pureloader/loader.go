@@ -14,6 +14,7 @@ type PureLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] st
 }
 
 var _ loadingcache.SourceLoader[uint8, struct{}] = (*PureLoader[uint8, struct{}])(nil)
+var _ loadingcache.CacheEntrySourceLoader[uint8, struct{}] = (*PureLoader[uint8, struct{}])(nil)
 
 // NewPureLoader creates a new PureLoader with the given storage and source.
 func NewPureLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](storage loadingcache.CacheStorage[K, V], source loadingcache.LoadingSource[K, V]) *PureLoader[K, V] {
@@ -48,15 +49,11 @@ func (p *PureLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcac
 // stores them in the cache, and returns the loaded entries. If an error occurs during
 // the loading or storing process, it returns the error.
 func (p *PureLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
-	cacheEntries, err := p.source.GetMulti(ctx, keys)
+	cacheEntries, err := p.LoadAndStoreMultiCacheEntries(ctx, keys)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.storage.SetMulti(ctx, cacheEntries); err != nil {
-		return nil, err
-	}
-
 	entries := make([]*loadingcache.Entry[K, V], len(cacheEntries))
 	for i, e := range cacheEntries {
 		if e != nil && !e.NegativeCache {
@@ -65,3 +62,18 @@ func (p *PureLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*
 	}
 	return entries, nil
 }
+
+// LoadAndStoreMultiCacheEntries loads multiple entries from the source using the provided keys,
+// stores them in the cache, and returns the loaded entries with their expiration times.
+// If an error occurs during the loading or storing process, it returns the error.
+func (p *PureLoader[K, V]) LoadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	cacheEntries, err := p.source.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.storage.SetMulti(ctx, cacheEntries); err != nil {
+		return nil, err
+	}
+	return cacheEntries, nil
+}
@@ -9,4 +9,15 @@
 // The SingleFlightLoader can be configured with options:
 //   - WithCloner: Allows setting a custom value cloner to use when copying values to multiple requesters
 //   - WithBackgroundContextProvider: Sets a custom context provider for background operations
+//   - WithLoadTimeout: Bounds the duration of each background load regardless of the callers' deadlines
+//   - WithShareResults: Hands the same entry to all requesters without cloning for immutable values
+//   - WithEntryCopy: Clones the values (and optionally the keys) for all the requesters including the first one
+//   - WithBatchWindow: Coalesces the distinct single-key loads into batched GetMulti calls within a time window
+//   - WithDropExpiredEntries: Treats the entries already expired when loaded as not found
+//   - WithNegativeCacheTTL: Overrides the expiration times of the negative caches by their reasons
+//   - WithCancellationPolicy: Controls whether the cancellation of the first caller cancels the load for all the waiters
+//   - WithWorkerPool: Bounds the number of the goroutines running the background loads
+//   - WithSynchronousLoad: Runs the single-key loads on the caller's goroutine instead of a background one
+//   - WithInFlightGauge: Reports the current number of the in-flight background loads
+//   - WithMaxWaitingKeys: Bounds the number of the keys waiting for the loads with ErrLoaderOverloaded
 package singleflightloader
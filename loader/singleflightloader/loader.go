@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/internal/panicutil"
@@ -12,6 +15,10 @@ import (
 
 var errGoexit = errors.New("runtime.Goexit is called")
 
+// ErrLoaderOverloaded is returned by the loads when the number of the keys waiting for the loads
+// reaches the limit of WithMaxWaitingKeys.
+var ErrLoaderOverloaded = errors.New("singleflightloader: too many keys are waiting for the loads")
+
 // SingleFlightLoader is a SourceLoader implementation that uses a single flight mechanism to load values.
 // It uses a source to load the values, a storage to cache the values, and a cloner to clone the values.
 type SingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
@@ -20,11 +27,30 @@ type SingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstr
 	cloner  loadingcache.ValueCloner[V]
 	context func() context.Context
 
+	loadTimeout     time.Duration
+	shareResults    bool
+	copyEntries     bool
+	keyCloner       func(K) K
+	batchWindow     time.Duration
+	maxBatchSize    int
+	expiryClock     loadingcache.Clock
+	negativeClock   loadingcache.Clock
+	negativeTTLs    [2]time.Duration
+	cancelPolicy    CancellationPolicy
+	synchronousLoad bool
+	workers         *workerPool
+	gauge           func(inFlight int)
+	inFlight        atomic.Int64
+	maxWaitingKeys  int
+
 	mu        sync.RWMutex
-	waitlists map[K][]chan either[error, *loadingcache.Entry[K, V]]
+	waitlists map[K][]chan either[error, *loadingcache.CacheEntry[K, V]]
+	pending   []K
+	flusher   *time.Timer
 }
 
 var _ loadingcache.SourceLoader[uint8, struct{}] = (*SingleFlightLoader[uint8, struct{}])(nil)
+var _ loadingcache.CacheEntrySourceLoader[uint8, struct{}] = (*SingleFlightLoader[uint8, struct{}])(nil)
 
 // NewSingleFlightLoader creates a new SingleFlightLoader instance.
 func NewSingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](storage loadingcache.CacheStorage[K, V], source loadingcache.LoadingSource[K, V], opts ...Option[K, V]) *SingleFlightLoader[K, V] {
@@ -33,7 +59,7 @@ func NewSingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueCon
 		source:    source,
 		cloner:    nil,
 		context:   context.Background,
-		waitlists: map[K][]chan either[error, *loadingcache.Entry[K, V]]{},
+		waitlists: map[K][]chan either[error, *loadingcache.CacheEntry[K, V]]{},
 	}
 	for _, o := range opts {
 		o.apply(loader)
@@ -41,6 +67,9 @@ func NewSingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueCon
 	if loader.cloner == nil {
 		loader.cloner = loadingcache.DefaultValueCloner[V]()
 	}
+	if _, ok := loader.cloner.(loadingcache.ImmutableValueCloner[V]); ok {
+		loader.shareResults = true
+	}
 	return loader
 }
 
@@ -52,17 +81,25 @@ type either[L any, R any] struct {
 // LoadAndStore retrieves a value associated with the given key from the source,
 // stores it in the storage with an expiration time, and returns the cloned value.
 // If an error occurs during retrieval or storage, it returns the zero value of V and the error.
+//
+// The concurrent calls for the same key wait for a single load started by the first caller, and all of them
+// receive the same result or error. The cancellation of a caller's context only stops the waiting of that caller.
+// Whether the cancellation of the first caller's context also cancels the load for all the waiters is
+// controlled by WithCancellationPolicy.
 func (l *SingleFlightLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
-	ch := l.registerKey(ctx, key)
+	ch, load, err := l.registerKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if load != nil {
+		// the result has been sent to the channel when the synchronous load returns
+		load()
+		return receive(<-ch)
+	}
+
 	select {
 	case e := <-ch:
-		if e.L != nil {
-			if e.L == errGoexit {
-				runtime.Goexit()
-			}
-			return nil, e.L
-		}
-		return e.R, nil
+		return receive(e)
 	case <-ctx.Done():
 		go func() {
 			<-ch
@@ -71,21 +108,171 @@ func (l *SingleFlightLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*lo
 	}
 }
 
+// receive returns the entry or the error received from the channel.
+// It calls runtime.Goexit if the load called it.
+func receive[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](e either[error, *loadingcache.CacheEntry[K, V]]) (*loadingcache.Entry[K, V], error) {
+	if e.L != nil {
+		if e.L == errGoexit {
+			runtime.Goexit()
+		}
+		return nil, e.L
+	}
+	return toEntry(e.R), nil
+}
+
+// toEntry returns the entry of the cache entry, or nil if it is missing or a negative cache.
+func toEntry[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cacheEntry *loadingcache.CacheEntry[K, V]) *loadingcache.Entry[K, V] {
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return nil
+	}
+	return &cacheEntry.Entry
+}
+
+// WaitingKeys returns the number of the distinct keys waiting for the loads, including the pending keys of WithBatchWindow.
+// The keys are removed when their loads are completed.
+func (l *SingleFlightLoader[K, V]) WaitingKeys() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.waitlists)
+}
+
+// overloaded reports whether the new keys cannot be registered in addition to the waiting keys.
+// The caller must hold the lock.
+func (l *SingleFlightLoader[K, V]) overloaded(newKeys int) bool {
+	return l.maxWaitingKeys > 0 && len(l.waitlists)+newKeys > l.maxWaitingKeys
+}
+
 // registerKey registers a key and returns a channel to receive the result.
-func (l *SingleFlightLoader[K, V]) registerKey(ctx context.Context, key K) chan either[error, *loadingcache.Entry[K, V]] {
+// It also returns the load that the caller must run synchronously if WithSynchronousLoad is specified
+// and the caller is the first waiter of the key, or nil otherwise.
+// It returns ErrLoaderOverloaded if the key is not waiting yet and the limit of WithMaxWaitingKeys is reached.
+func (l *SingleFlightLoader[K, V]) registerKey(ctx context.Context, key K) (chan either[error, *loadingcache.CacheEntry[K, V]], func(), error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	ch := make(chan either[error, *loadingcache.Entry[K, V]], 1)
+	if _, ok := l.waitlists[key]; !ok && l.overloaded(1) {
+		return nil, nil, ErrLoaderOverloaded
+	}
+
+	ch := make(chan either[error, *loadingcache.CacheEntry[K, V]], 1)
 	l.waitlists[key] = append(l.waitlists[key], ch)
 	if len(l.waitlists[key]) == 1 {
-		go l.loadKeyAndStore(l.context(), key)
+		load := func() {
+			l.loadKeyAndStore(ctx, key)
+		}
+		switch {
+		case l.batchWindow > 0:
+			l.enqueueKey(key)
+		case l.synchronousLoad && l.workers == nil:
+			return ch, load, nil
+		default:
+			l.dispatch(load)
+		}
+	}
+	return ch, nil, nil
+}
+
+// enqueueKey adds the key to the pending batch.
+// The pending batch is loaded when the batch window elapses or the batch becomes full.
+// The caller must hold the lock.
+func (l *SingleFlightLoader[K, V]) enqueueKey(key K) {
+	l.pending = append(l.pending, key)
+	if l.maxBatchSize > 0 && len(l.pending) >= l.maxBatchSize {
+		if l.flusher != nil {
+			l.flusher.Stop()
+			l.flusher = nil
+		}
+		keys := l.takePending()
+		l.dispatch(func() {
+			l.loadKeysAndStore(nil, keys)
+		})
+		return
+	}
+	if l.flusher == nil {
+		l.flusher = time.AfterFunc(l.batchWindow, l.flushPending)
+	}
+}
+
+// takePending returns the pending batch and resets it.
+// The caller must hold the lock.
+func (l *SingleFlightLoader[K, V]) takePending() []K {
+	keys := l.pending
+	l.pending = nil
+	return keys
+}
+
+// flushPending loads the pending batch.
+func (l *SingleFlightLoader[K, V]) flushPending() {
+	l.mu.Lock()
+	l.flusher = nil
+	keys := l.takePending()
+	l.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+	if l.workers != nil {
+		l.workers.submit(func() {
+			l.loadKeysAndStore(nil, keys)
+		})
+		return
+	}
+	l.loadKeysAndStore(nil, keys)
+}
+
+// dispatch runs the load in the background, on the worker pool if WithWorkerPool is specified
+// or on a new goroutine otherwise.
+func (l *SingleFlightLoader[K, V]) dispatch(load func()) {
+	if l.workers != nil {
+		l.workers.submit(load)
+		return
+	}
+	go load()
+}
+
+// trackInFlight increments the number of the in-flight loads, and returns the function to decrement it.
+// Both report the updated number to the gauge of WithInFlightGauge.
+func (l *SingleFlightLoader[K, V]) trackInFlight() func() {
+	if l.gauge == nil {
+		return func() {}
+	}
+
+	l.gauge(int(l.inFlight.Add(1)))
+	return func() {
+		l.gauge(int(l.inFlight.Add(-1)))
+	}
+}
+
+// loadContext returns the context for a background load started by the caller with the given context.
+// The caller's context is nil for the batched loads of WithBatchWindow, which have no single caller.
+// The returned cancel function must be called when the load is completed.
+func (l *SingleFlightLoader[K, V]) loadContext(caller context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := l.context(), context.CancelFunc(func() {})
+	if l.loadTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, l.loadTimeout)
+	}
+	if l.cancelPolicy != CancellationPolicyFirstCaller || caller == nil {
+		return ctx, cancel
+	}
+
+	ctx, cancelCause := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(caller, func() {
+		cancelCause(context.Cause(caller))
+	})
+	return ctx, func() {
+		stop()
+		cancelCause(nil)
+		cancel()
 	}
-	return ch
 }
 
 // loadKeyAndStore loads a value from the source and stores it in the storage.
-func (l *SingleFlightLoader[K, V]) loadKeyAndStore(ctx context.Context, key K) {
+// The caller is the context of the first caller of the load.
+func (l *SingleFlightLoader[K, V]) loadKeyAndStore(caller context.Context, key K) {
+	defer l.trackInFlight()()
+	ctx, cancel := l.loadContext(caller)
+	defer cancel()
+
 	dds := panicutil.DoubleDeferSandwich{
 		OnGoexit: func() {
 			l.throwError(key, errGoexit)
@@ -101,6 +288,10 @@ func (l *SingleFlightLoader[K, V]) loadKeyAndStore(ctx context.Context, key K) {
 		return
 	}
 
+	cacheEntry = l.dropExpired(cacheEntry, l.now())
+	if l.negativeClock != nil {
+		cacheEntry = l.overrideNegativeTTL(cacheEntry, l.negativeClock.Now())
+	}
 	if cacheEntry != nil {
 		if err := l.storage.Set(ctx, cacheEntry); err != nil {
 			l.throwError(key, err)
@@ -110,25 +301,76 @@ func (l *SingleFlightLoader[K, V]) loadKeyAndStore(ctx context.Context, key K) {
 	l.sendEntry(key, cacheEntry)
 }
 
-// throwError sends an error to the waiting channels.
+// now returns the current time to check the expiration times of the loaded entries.
+// It returns the zero time if WithDropExpiredEntries is not specified.
+func (l *SingleFlightLoader[K, V]) now() time.Time {
+	if l.expiryClock == nil {
+		return time.Time{}
+	}
+	return l.expiryClock.Now()
+}
+
+// dropExpired returns nil if the entry is already expired at now, and the entry as it is otherwise.
+// It never drops the entries if now is zero.
+func (l *SingleFlightLoader[K, V]) dropExpired(cacheEntry *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	if cacheEntry == nil || now.IsZero() || cacheEntry.ExpiresAt.After(now) {
+		return cacheEntry
+	}
+	return nil
+}
+
+// overrideNegativeTTL returns a copy of the negative cache expiring after the TTL of its reason from now.
+// It returns the entry as it is if it is not a negative cache or the TTL of its reason is not set.
+func (l *SingleFlightLoader[K, V]) overrideNegativeTTL(cacheEntry *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	if !loadingcache.IsNegative(cacheEntry) || int(cacheEntry.NegativeReason) >= len(l.negativeTTLs) {
+		return cacheEntry
+	}
+	ttl := l.negativeTTLs[cacheEntry.NegativeReason]
+	if ttl <= 0 {
+		return cacheEntry
+	}
+
+	overridden := *cacheEntry
+	overridden.ExpiresAt = now.Add(ttl)
+	return &overridden
+}
+
+// receiverEntry returns the entry for the i-th receiver of the loaded entry.
+// The negative caches are sent as they are, since they have no value to clone.
+func (l *SingleFlightLoader[K, V]) receiverEntry(cacheEntry *loadingcache.CacheEntry[K, V], i int) *loadingcache.CacheEntry[K, V] {
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return cacheEntry
+	}
+	if l.copyEntries {
+		entry := *cacheEntry
+		if l.keyCloner != nil {
+			entry.Key = l.keyCloner(entry.Key)
+		}
+		entry.Value = l.cloner.CloneValue(entry.Value)
+		return &entry
+	}
+	if l.shareResults {
+		return cacheEntry
+	}
+
+	entry := *cacheEntry
+	if i != 0 {
+		// note: we clone the value only if it is not the first receiver
+		// to avoid unnecessary cloning when there are multiple receivers.
+		entry.Value = l.cloner.CloneValue(entry.Value)
+	}
+	return &entry
+}
+
+// sendEntry sends the entry to the waiting channels.
 func (l *SingleFlightLoader[K, V]) sendEntry(key K, cacheEntry *loadingcache.CacheEntry[K, V]) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	for i, wl := range l.waitlists[key] {
-		if cacheEntry == nil || cacheEntry.NegativeCache {
-			wl <- either[error, *loadingcache.Entry[K, V]]{R: nil}
-		} else {
-			entry := cacheEntry.Entry
-			if i != 0 {
-				// note: we clone the value only if it is not the first receiver
-				// to avoid unnecessary cloning when there are multiple receivers.
-				entry.Value = l.cloner.CloneValue(entry.Value)
-			}
-			wl <- either[error, *loadingcache.Entry[K, V]]{R: &entry}
-		}
+		wl <- either[error, *loadingcache.CacheEntry[K, V]]{R: l.receiverEntry(cacheEntry, i)}
 		close(wl)
 	}
-	l.waitlists[key] = l.waitlists[key][:0]
+	delete(l.waitlists, key)
 }
 
 // throwError sends an error to the waiting channels.
@@ -136,23 +378,41 @@ func (l *SingleFlightLoader[K, V]) throwError(k K, err error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	for _, wl := range l.waitlists[k] {
-		wl <- either[error, *loadingcache.Entry[K, V]]{L: err}
+		wl <- either[error, *loadingcache.CacheEntry[K, V]]{L: err}
 		close(wl)
 	}
-	l.waitlists[k] = l.waitlists[k][:0]
+	delete(l.waitlists, k)
 }
 
 // LoadAndStoreMulti loads multiple entries from the source using the provided keys,
 // stores them in the cache, and returns the loaded entries. If an error occurs during
 // the loading or storing process, it returns the error.
 func (l *SingleFlightLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
-	channels := l.registerKeys(ctx, keys)
+	cacheEntries, err := l.LoadAndStoreMultiCacheEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*loadingcache.Entry[K, V], len(cacheEntries))
+	for i, cacheEntry := range cacheEntries {
+		entries[i] = toEntry(cacheEntry)
+	}
+	return entries, nil
+}
+
+// LoadAndStoreMultiCacheEntries loads multiple entries as LoadAndStoreMulti does, and returns them with their
+// expiration times and the negative cache flags. The values are cloned for each receiver as LoadAndStoreMulti does.
+func (l *SingleFlightLoader[K, V]) LoadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	channels, err := l.registerKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
 	return l.awaitChannels(ctx, channels)
 }
 
 // awaitChannels waits for the channels to receive the results and returns the entries.
-func (l *SingleFlightLoader[K, V]) awaitChannels(ctx context.Context, channels []chan either[error, *loadingcache.Entry[K, V]]) ([]*loadingcache.Entry[K, V], error) {
-	entries := make([]*loadingcache.Entry[K, V], len(channels))
+func (l *SingleFlightLoader[K, V]) awaitChannels(ctx context.Context, channels []chan either[error, *loadingcache.CacheEntry[K, V]]) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries := make([]*loadingcache.CacheEntry[K, V], len(channels))
 
 	var lastErr error
 	for i, ch := range channels {
@@ -183,14 +443,27 @@ func (l *SingleFlightLoader[K, V]) awaitChannels(ctx context.Context, channels [
 }
 
 // registerKeys registers keys and returns channels to receive the results.
-func (l *SingleFlightLoader[K, V]) registerKeys(ctx context.Context, keys []K) []chan either[error, *loadingcache.Entry[K, V]] {
+// It returns ErrLoaderOverloaded without registering any key if the keys not waiting yet exceed the limit of WithMaxWaitingKeys.
+func (l *SingleFlightLoader[K, V]) registerKeys(ctx context.Context, keys []K) ([]chan either[error, *loadingcache.CacheEntry[K, V]], error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.maxWaitingKeys > 0 {
+		newKeys := make(map[K]struct{}, len(keys))
+		for _, key := range keys {
+			if _, ok := l.waitlists[key]; !ok {
+				newKeys[key] = struct{}{}
+			}
+		}
+		if l.overloaded(len(newKeys)) {
+			return nil, ErrLoaderOverloaded
+		}
+	}
+
 	targetKeys := make([]K, 0, len(keys))
-	channels := make([]chan either[error, *loadingcache.Entry[K, V]], len(keys))
+	channels := make([]chan either[error, *loadingcache.CacheEntry[K, V]], len(keys))
 	for i, key := range keys {
-		ch := make(chan either[error, *loadingcache.Entry[K, V]], 1)
+		ch := make(chan either[error, *loadingcache.CacheEntry[K, V]], 1)
 		l.waitlists[key] = append(l.waitlists[key], ch)
 		if len(l.waitlists[key]) == 1 {
 			targetKeys = append(targetKeys, key)
@@ -198,13 +471,20 @@ func (l *SingleFlightLoader[K, V]) registerKeys(ctx context.Context, keys []K) [
 		channels[i] = ch
 	}
 	if len(targetKeys) != 0 {
-		go l.loadKeysAndStore(l.context(), targetKeys)
+		l.dispatch(func() {
+			l.loadKeysAndStore(ctx, targetKeys)
+		})
 	}
-	return channels
+	return channels, nil
 }
 
 // loadKeysAndStore loads values from the source and stores them in the storage.
-func (l *SingleFlightLoader[K, V]) loadKeysAndStore(ctx context.Context, keys []K) {
+// The caller is the context of the first caller of the load, or nil if the load has no single caller.
+func (l *SingleFlightLoader[K, V]) loadKeysAndStore(caller context.Context, keys []K) {
+	defer l.trackInFlight()()
+	ctx, cancel := l.loadContext(caller)
+	defer cancel()
+
 	dds := panicutil.DoubleDeferSandwich{
 		OnGoexit: func() {
 			l.throwErrors(keys, errGoexit)
@@ -220,6 +500,20 @@ func (l *SingleFlightLoader[K, V]) loadKeysAndStore(ctx context.Context, keys []
 		return
 	}
 
+	if l.expiryClock != nil {
+		now := l.now()
+		entries = slices.Clone(entries)
+		for i, entry := range entries {
+			entries[i] = l.dropExpired(entry, now)
+		}
+	}
+	if l.negativeClock != nil {
+		now := l.negativeClock.Now()
+		entries = slices.Clone(entries)
+		for i, entry := range entries {
+			entries[i] = l.overrideNegativeTTL(entry, now)
+		}
+	}
 	if err := l.storage.SetMulti(ctx, entries); err != nil {
 		l.throwErrors(keys, err)
 		return
@@ -235,20 +529,10 @@ func (l *SingleFlightLoader[K, V]) sendEntries(keys []K, cacheEntries []*loading
 	for i, k := range keys {
 		cacheEntry := cacheEntries[i]
 		for j, wl := range l.waitlists[k] {
-			if cacheEntry == nil || cacheEntry.NegativeCache {
-				wl <- either[error, *loadingcache.Entry[K, V]]{R: nil}
-			} else {
-				entry := cacheEntry.Entry
-				if j != 0 {
-					// note: we clone the value only if it is not the first receiver
-					// to avoid unnecessary cloning when there are multiple receivers.
-					entry.Value = l.cloner.CloneValue(entry.Value)
-				}
-				wl <- either[error, *loadingcache.Entry[K, V]]{R: &entry}
-			}
+			wl <- either[error, *loadingcache.CacheEntry[K, V]]{R: l.receiverEntry(cacheEntry, j)}
 			close(wl)
 		}
-		l.waitlists[k] = l.waitlists[k][:0]
+		delete(l.waitlists, k)
 	}
 }
 
@@ -258,9 +542,9 @@ func (l *SingleFlightLoader[K, V]) throwErrors(keys []K, err error) {
 	defer l.mu.Unlock()
 	for _, k := range keys {
 		for _, wl := range l.waitlists[k] {
-			wl <- either[error, *loadingcache.Entry[K, V]]{L: err}
+			wl <- either[error, *loadingcache.CacheEntry[K, V]]{L: err}
 			close(wl)
 		}
-		l.waitlists[k] = l.waitlists[k][:0]
+		delete(l.waitlists, k)
 	}
 }
@@ -425,3 +425,229 @@ func TestLoadAndStoreMulti_ContextCancel(t *testing.T) {
 		t.Errorf("unexpected error: %v (expected: context deadline exceeded)", err)
 	}
 }
+
+func TestLoadAndStoreMultiCacheEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	entries := map[int]*loadingcache.CacheEntry[int, string]{
+		1: {Entry: loadingcache.Entry[int, string]{Key: 1, Value: "found"}, ExpiresAt: now.Add(time.Minute)},
+		2: {Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+	}
+	src := &source.FunctionsSource[int, string]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			result := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				result[i] = entries[key]
+			}
+			return result, nil
+		},
+	}
+	store := &storage.FunctionsStorage[int, string]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	clock := loadingcache.ClockFunc(func() time.Time { return now })
+	loader := singleflightloader.NewSingleFlightLoader(store, src, singleflightloader.WithNegativeCacheTTL[int, string](clock, 10*time.Second, 0))
+	got, err := loader.LoadAndStoreMultiCacheEntries(t.Context(), []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*loadingcache.CacheEntry[int, string]{
+		{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "found"}, ExpiresAt: now.Add(time.Minute)},
+		{Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: now.Add(10 * time.Second), NegativeCache: true},
+		nil,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadAndStoreMultiCacheEntries() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDropExpiredEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	entries := map[int]*loadingcache.CacheEntry[int, string]{
+		1: {Entry: loadingcache.Entry[int, string]{Key: 1, Value: "fresh"}, ExpiresAt: now.Add(time.Minute)},
+		2: {Entry: loadingcache.Entry[int, string]{Key: 2, Value: "expired"}, ExpiresAt: now.Add(-time.Minute)},
+		3: {Entry: loadingcache.Entry[int, string]{Key: 3, Value: "expiring"}, ExpiresAt: now},
+	}
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			return entries[key], nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			result := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				result[i] = entries[key]
+			}
+			return result, nil
+		},
+	}
+
+	newLoader := func(stored *[]int) *singleflightloader.SingleFlightLoader[int, string] {
+		s := &storage.FunctionsStorage[int, string]{
+			SetFunc: func(_ context.Context, entry *loadingcache.CacheEntry[int, string]) error {
+				*stored = append(*stored, entry.Key)
+				return nil
+			},
+			SetMultiFunc: func(_ context.Context, entries []*loadingcache.CacheEntry[int, string]) error {
+				for _, entry := range entries {
+					if entry != nil {
+						*stored = append(*stored, entry.Key)
+					}
+				}
+				return nil
+			},
+		}
+		clock := loadingcache.ClockFunc(func() time.Time { return now })
+		return singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithDropExpiredEntries[int, string](clock))
+	}
+
+	t.Run("LoadAndStore", func(t *testing.T) {
+		t.Parallel()
+
+		var stored []int
+		loader := newLoader(&stored)
+		for _, key := range []int{1, 2, 3} {
+			entry, err := loader.LoadAndStore(t.Context(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key == 1 && entry == nil {
+				t.Errorf("the fresh entry must be returned")
+			} else if key != 1 && entry != nil {
+				t.Errorf("the expired entry must not be returned: %+v", entry)
+			}
+		}
+		if diff := cmp.Diff([]int{1}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("LoadAndStoreMulti", func(t *testing.T) {
+		t.Parallel()
+
+		var stored []int
+		loader := newLoader(&stored)
+		got, err := loader.LoadAndStoreMulti(t.Context(), []int{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.Entry[int, string]{{Key: 1, Value: "fresh"}, nil, nil}, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]int{1}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestNegativeCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	entries := map[int]*loadingcache.CacheEntry[int, string]{
+		1: {Entry: loadingcache.Entry[int, string]{Key: 1, Value: "found"}, ExpiresAt: now.Add(time.Minute)},
+		2: {Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+		3: {Entry: loadingcache.Entry[int, string]{Key: 3}, ExpiresAt: now.Add(time.Minute), NegativeCache: true, NegativeReason: loadingcache.NegativeCachePermanent},
+	}
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			return entries[key], nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			result := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				result[i] = entries[key]
+			}
+			return result, nil
+		},
+	}
+
+	newLoader := func(stored map[int]time.Time, transientTTL, permanentTTL time.Duration) *singleflightloader.SingleFlightLoader[int, string] {
+		s := &storage.FunctionsStorage[int, string]{
+			SetFunc: func(_ context.Context, entry *loadingcache.CacheEntry[int, string]) error {
+				stored[entry.Key] = entry.ExpiresAt
+				return nil
+			},
+			SetMultiFunc: func(_ context.Context, entries []*loadingcache.CacheEntry[int, string]) error {
+				for _, entry := range entries {
+					stored[entry.Key] = entry.ExpiresAt
+				}
+				return nil
+			},
+		}
+		clock := loadingcache.ClockFunc(func() time.Time { return now })
+		return singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithNegativeCacheTTL[int, string](clock, transientTTL, permanentTTL))
+	}
+
+	for _, tt := range []struct {
+		name         string
+		transientTTL time.Duration
+		permanentTTL time.Duration
+		want         map[int]time.Time
+	}{
+		{
+			name:         "Both",
+			transientTTL: 10 * time.Second,
+			permanentTTL: 24 * time.Hour,
+			want: map[int]time.Time{
+				1: now.Add(time.Minute),
+				2: now.Add(10 * time.Second),
+				3: now.Add(24 * time.Hour),
+			},
+		},
+		{
+			name:         "PermanentOnly",
+			permanentTTL: 24 * time.Hour,
+			want: map[int]time.Time{
+				1: now.Add(time.Minute),
+				2: now.Add(time.Minute),
+				3: now.Add(24 * time.Hour),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("LoadAndStore", func(t *testing.T) {
+				t.Parallel()
+
+				stored := map[int]time.Time{}
+				loader := newLoader(stored, tt.transientTTL, tt.permanentTTL)
+				for key := range entries {
+					if _, err := loader.LoadAndStore(t.Context(), key); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if diff := cmp.Diff(tt.want, stored); diff != "" {
+					t.Errorf("stored expiration times mismatch (-want +got):\n%s", diff)
+				}
+			})
+
+			t.Run("LoadAndStoreMulti", func(t *testing.T) {
+				t.Parallel()
+
+				stored := map[int]time.Time{}
+				loader := newLoader(stored, tt.transientTTL, tt.permanentTTL)
+				if _, err := loader.LoadAndStoreMulti(t.Context(), []int{1, 2, 3}); err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tt.want, stored); diff != "" {
+					t.Errorf("stored expiration times mismatch (-want +got):\n%s", diff)
+				}
+			})
+		})
+	}
+
+	// the source's entries are never modified
+	t.Cleanup(func() {
+		if diff := cmp.Diff(now.Add(time.Minute), entries[3].ExpiresAt); diff != "" {
+			t.Errorf("source entry mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
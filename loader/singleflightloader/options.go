@@ -2,6 +2,7 @@ package singleflightloader
 
 import (
 	"context"
+	"time"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 )
@@ -18,6 +19,7 @@ func (f optionFunc[K, V]) apply(l *SingleFlightLoader[K, V]) {
 }
 
 // WithCloner sets the value cloner to the loader.
+// If it is a loadingcache.ImmutableValueCloner[V] (e.g. returned by loadingcache.ImmutableValues), WithShareResults is enabled as well.
 // The default value cloner is loadingcache.NopValueCloner.
 func WithCloner[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cloner loadingcache.ValueCloner[V]) Option[K, V] {
 	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
@@ -33,3 +35,146 @@ func WithBackgroundContextProvider[K loadingcache.KeyConstraint, V loadingcache.
 		l.context = provider
 	})
 }
+
+// WithLoadTimeout sets the timeout for each background load.
+// The background context of each load is bounded by the timeout regardless of the callers' deadlines,
+// so all waiters of the load receive context.DeadlineExceeded when the source does not complete in time.
+// The source must respect the context cancellation.
+// The default is no timeout.
+func WithLoadTimeout[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](d time.Duration) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.loadTimeout = d
+	})
+}
+
+// WithShareResults makes the loader hand the same entry to all the receivers of a load without cloning.
+// It saves the cloning of the value and the copy of the entry for each receiver,
+// but the receivers share the entry with each other, so the values must be immutable and the entries must not be modified.
+func WithShareResults[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.shareResults = true
+	})
+}
+
+// WithEntryCopy makes the loader hand a full copy of the entry to every receiver of a load, including the first one.
+// By default, the first receiver gets the value as returned by the source, and only the values for the other receivers are cloned.
+// With this option, the values for all the receivers are cloned by the cloner, and the keys are cloned by keyCloner if it is not nil.
+//
+// It matters when the receivers may modify the returned entries while the source keeps referring to the loaded values,
+// or when the keys refer to mutable data (e.g. a struct key with a pointer field), which is shared by copying the key.
+// It takes precedence over WithShareResults.
+func WithEntryCopy[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](keyCloner func(K) K) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.copyEntries = true
+		l.keyCloner = keyCloner
+	})
+}
+
+// WithBatchWindow makes the loader coalesce the distinct single-key loads of LoadAndStore into batched GetMulti calls of the source.
+// The keys requested within the window since the first pending key are loaded together by a GetMulti call,
+// or immediately once the number of the pending keys reaches maxBatchSize.
+// It reduces the round-trips to the source, but adds up to the window to the latency of each LoadAndStore.
+// The zero or negative maxBatchSize means no limit. LoadAndStoreMulti is not affected.
+func WithBatchWindow[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](window time.Duration, maxBatchSize int) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.batchWindow = window
+		l.maxBatchSize = maxBatchSize
+	})
+}
+
+// WithDropExpiredEntries makes the loader treat the entries already expired at the time of the clock as not found.
+// Such entries are returned by the sources with clock skews or bugs, and they would be missed by the next read anyway.
+// The dropped entries are neither stored nor returned, including the negative caches.
+func WithDropExpiredEntries[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](clock loadingcache.Clock) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.expiryClock = clock
+	})
+}
+
+// WithNegativeCacheTTL makes the loader override the expiration times of the negative caches loaded from the source
+// by their reasons before storing them: the transient ones expire after transientTTL, and the permanent ones expire
+// after permanentTTL from the time of the clock. A zero or negative TTL keeps the expiration times given by the source.
+// It lets the authoritative permanent misses be cached longer than the transient ones.
+func WithNegativeCacheTTL[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](clock loadingcache.Clock, transientTTL, permanentTTL time.Duration) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.negativeClock = clock
+		l.negativeTTLs = [...]time.Duration{
+			loadingcache.NegativeCacheTransient: transientTTL,
+			loadingcache.NegativeCachePermanent: permanentTTL,
+		}
+	})
+}
+
+// CancellationPolicy is the policy for the cancellation of the first caller of a load.
+type CancellationPolicy int
+
+const (
+	// CancellationPolicyDetached makes the loads run on the background context regardless of the callers' contexts.
+	// When the first caller cancels, the load proceeds and the remaining waiters receive its result.
+	CancellationPolicyDetached CancellationPolicy = iota
+
+	// CancellationPolicyFirstCaller makes the loads canceled when the context of the first caller is done.
+	// When the first caller cancels, all the waiters of the load receive the error returned by the source,
+	// which is typically the error of the canceled context. The source must respect the context cancellation.
+	// The batched loads of WithBatchWindow have no single caller, so they are always detached.
+	CancellationPolicyFirstCaller
+)
+
+// WithCancellationPolicy sets the policy for the cancellation of the first caller of a load.
+// The default is CancellationPolicyDetached.
+func WithCancellationPolicy[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](policy CancellationPolicy) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.cancelPolicy = policy
+	})
+}
+
+// WithWorkerPool makes the loader run the background loads on at most size goroutines instead of a goroutine per load.
+// The loads exceeding the size are queued and run in order as the running loads complete, so it bounds the number of
+// the goroutines and the concurrent calls of the source under a storm of distinct keys, at the cost of the queueing latency.
+// The workers are spawned on demand and exit when there are no queued loads.
+// The zero or negative size means no limit.
+func WithWorkerPool[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](size int) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		if size <= 0 {
+			l.workers = nil
+			return
+		}
+		l.workers = &workerPool{size: size}
+	})
+}
+
+// WithSynchronousLoad makes LoadAndStore run the load on the caller's goroutine instead of spawning a goroutine,
+// if the caller is the first waiter of the key. It saves the scheduling overhead for the uncontended keys.
+// The other callers of the same key arriving during the load still wait for it and receive its result.
+//
+// The caller blocks until the source returns even if its context is done, because the load runs on its goroutine.
+// With CancellationPolicyDetached, the load runs on the background context and ignores the caller's cancellation,
+// so the caller returns the result of the load rather than the error of its context.
+// Use CancellationPolicyFirstCaller to make the load respect the caller's cancellation.
+// It has no effect on LoadAndStoreMulti, or with WithBatchWindow or WithWorkerPool.
+func WithSynchronousLoad[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.synchronousLoad = true
+	})
+}
+
+// WithInFlightGauge sets the function that is called with the current number of the in-flight background loads
+// each time a load starts or completes. The queued loads of WithWorkerPool are not counted until they start.
+// It is called concurrently from the loads, so the reported numbers may arrive out of order.
+func WithInFlightGauge[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](gauge func(inFlight int)) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.gauge = gauge
+	})
+}
+
+// WithMaxWaitingKeys bounds the number of the distinct keys waiting for the loads, which are retained by the loader
+// until their loads are completed. The loads of the keys not waiting yet fail with ErrLoaderOverloaded once the limit
+// is reached, while the callers of the keys already waiting still join their loads.
+// LoadAndStoreMulti fails as a whole without loading any key if its keys not waiting yet exceed the limit.
+// It protects the loader from the unbounded growth under a storm of distinct keys with a slow source.
+// The zero or negative limit means no limit.
+func WithMaxWaitingKeys[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](limit int) Option[K, V] {
+	return optionFunc[K, V](func(l *SingleFlightLoader[K, V]) {
+		l.maxWaitingKeys = limit
+	})
+}
@@ -2,12 +2,15 @@ package singleflightloader_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/loader/singleflightloader"
 	"github.com/karupanerura/loading-cache/source"
@@ -325,3 +328,690 @@ func TestLoadAndStoreMulti_Parallel_EachKeys(t *testing.T) {
 		t.Errorf("expected source to be called only 3 times, but it was called %d times", callCount)
 	}
 }
+
+func TestLoadAndStore_Parallel_LoadTimeout(t *testing.T) {
+	t.Parallel()
+
+	slowGet := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
+			return nil
+		}
+	}
+	source := &source.FunctionsSource[int, string]{
+		GetFunc: func(ctx context.Context, i int) (*loadingcache.CacheEntry[int, string], error) {
+			return nil, slowGet(ctx)
+		},
+		GetMultiFunc: func(ctx context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			return nil, slowGet(ctx)
+		},
+	}
+	storage := &storage.FunctionsStorage[int, string]{}
+
+	const timeout = 100 * time.Millisecond
+	loader := singleflightloader.NewSingleFlightLoader(storage, source,
+		singleflightloader.WithLoadTimeout[int, string](timeout),
+	)
+
+	const numGoroutines = 3
+	var wg sync.WaitGroup
+	errs := make([]error, numGoroutines*2)
+	elapsed := make([]time.Duration, numGoroutines*2)
+	wg.Add(numGoroutines * 2)
+	for i := 0; i < numGoroutines; i++ {
+		go func(index int) {
+			defer wg.Done()
+			start := time.Now()
+			_, errs[index] = loader.LoadAndStore(t.Context(), 1)
+			elapsed[index] = time.Since(start)
+		}(i)
+		go func(index int) {
+			defer wg.Done()
+			start := time.Now()
+			_, errs[index] = loader.LoadAndStoreMulti(t.Context(), []int{2, 3})
+			elapsed[index] = time.Since(start)
+		}(numGoroutines + i)
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if !errors.Is(errs[i], context.DeadlineExceeded) {
+			t.Errorf("unexpected error: %v (expected: context deadline exceeded)", errs[i])
+		}
+		if elapsed[i] > timeout*5 {
+			t.Errorf("expected to be timed out around %v, but took %v", timeout, elapsed[i])
+		}
+	}
+}
+
+var cloneInts loadingcache.ValueCloner[[]int] = loadingcache.ValueClonerFunc[[]int](slices.Clone[[]int])
+
+func TestLoadAndStoreMulti_Parallel_ShareResults(t *testing.T) {
+	t.Parallel()
+
+	src := &source.FunctionsSource[int, []int]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, []int], error) {
+			entries := make([]*loadingcache.CacheEntry[int, []int], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, []int]{
+					Entry:     loadingcache.Entry[int, []int]{Key: key, Value: []int{key, key * 2}},
+					ExpiresAt: time.Date(2025, time.January, 1, 1, 30, 30, 0, time.UTC),
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, []int]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, []int]) error {
+			return nil
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		opts   []singleflightloader.Option[int, []int]
+		shared bool
+	}{
+		{name: "Clone", opts: []singleflightloader.Option[int, []int]{singleflightloader.WithCloner[int](cloneInts)}, shared: false},
+		{name: "Share", opts: []singleflightloader.Option[int, []int]{singleflightloader.WithCloner[int](cloneInts), singleflightloader.WithShareResults[int, []int]()}, shared: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			loader := singleflightloader.NewSingleFlightLoader(s, src, tt.opts...)
+
+			// the duplicated keys are the multiple receivers of a single load
+			const numGoroutines = 3
+			results := make([][]*loadingcache.Entry[int, []int], numGoroutines)
+			var wg sync.WaitGroup
+			for i := range numGoroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					var err error
+					results[i], err = loader.LoadAndStoreMulti(t.Context(), []int{1, 1, 1})
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			for _, entries := range results {
+				for i, entry := range entries {
+					if entry.Key != 1 || entry.Value[0] != 1 || entry.Value[1] != 2 {
+						t.Errorf("unexpected entry: %+v", entry)
+					}
+					if shared := entry == entries[0]; i != 0 && shared != tt.shared {
+						t.Errorf("expected shared=%v for the receiver %d, got %v", tt.shared, i, shared)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLoadAndStoreMulti_ShareResults(b *testing.B) {
+	src := &source.FunctionsSource[int, []int]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, []int], error) {
+			entries := make([]*loadingcache.CacheEntry[int, []int], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, []int]{
+					Entry: loadingcache.Entry[int, []int]{Key: key, Value: []int{key}},
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, []int]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, []int]) error {
+			return nil
+		},
+	}
+
+	keys := make([]int, 16)
+	for _, bc := range []struct {
+		name string
+		opts []singleflightloader.Option[int, []int]
+	}{
+		{name: "Clone", opts: []singleflightloader.Option[int, []int]{singleflightloader.WithCloner[int](cloneInts)}},
+		{name: "Share", opts: []singleflightloader.Option[int, []int]{singleflightloader.WithCloner[int](cloneInts), singleflightloader.WithShareResults[int, []int]()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			loader := singleflightloader.NewSingleFlightLoader(s, src, bc.opts...)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := loader.LoadAndStoreMulti(b.Context(), keys); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAndStore_Parallel_BatchWindow(t *testing.T) {
+	t.Parallel()
+
+	var getCalls, getMultiCalls atomic.Int32
+	var mu sync.Mutex
+	var batches [][]int
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			getCalls.Add(1)
+			return nil, errors.New("should not be called")
+		},
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			getMultiCalls.Add(1)
+			mu.Lock()
+			batches = append(batches, keys)
+			mu.Unlock()
+
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+					ExpiresAt: time.Date(2025, time.January, 1, 1, 30, 30, 0, time.UTC),
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	const numKeys = 20
+	const maxBatchSize = 8
+	loader := singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithBatchWindow[int, string](100*time.Millisecond, maxBatchSize))
+
+	var wg sync.WaitGroup
+	for key := range numKeys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			entry, err := loader.LoadAndStore(t.Context(), key)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if entry == nil || entry.Key != key || entry.Value != fmt.Sprintf("value%d", key) {
+				t.Errorf("unexpected entry for key %d: %+v", key, entry)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := getCalls.Load(); got != 0 {
+		t.Errorf("expected no Get calls, got %d", got)
+	}
+	// the keys are coalesced into the batches, though how they are split depends on the scheduling of the goroutines
+	if got := getMultiCalls.Load(); got >= numKeys {
+		t.Errorf("expected the keys to be batched into fewer than %d GetMulti calls, got %d (batches: %v)", numKeys, got, batches)
+	}
+	loaded := map[int]int{}
+	for _, batch := range batches {
+		if len(batch) > maxBatchSize {
+			t.Errorf("the batch exceeds the max size: %v", batch)
+		}
+		for _, key := range batch {
+			loaded[key]++
+		}
+	}
+	for key := range numKeys {
+		if loaded[key] != 1 {
+			t.Errorf("expected key %d to be loaded once, got %d (batches: %v)", key, loaded[key], batches)
+		}
+	}
+}
+
+func TestLoadAndStore_Parallel_CancellationPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		policy    singleflightloader.CancellationPolicy
+		wantValue bool
+	}{
+		{name: "Detached", policy: singleflightloader.CancellationPolicyDetached, wantValue: true},
+		{name: "FirstCaller", policy: singleflightloader.CancellationPolicyFirstCaller, wantValue: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			src := &source.FunctionsSource[int, string]{
+				GetFunc: func(ctx context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+					close(started)
+					select {
+					case <-release:
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+					return &loadingcache.CacheEntry[int, string]{
+						Entry:     loadingcache.Entry[int, string]{Key: key, Value: "value"},
+						ExpiresAt: time.Date(2025, time.January, 1, 1, 30, 30, 0, time.UTC),
+					}, nil
+				},
+			}
+			s := &storage.FunctionsStorage[int, string]{
+				SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+					return nil
+				},
+			}
+			loader := singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithCancellationPolicy[int, string](tt.policy))
+
+			firstCtx, cancelFirst := context.WithCancel(t.Context())
+			firstErr := make(chan error, 1)
+			go func() {
+				_, err := loader.LoadAndStore(firstCtx, 1)
+				firstErr <- err
+			}()
+			<-started
+
+			const numWaiters = 3
+			var wg sync.WaitGroup
+			entries := make([]*loadingcache.Entry[int, string], numWaiters)
+			errs := make([]error, numWaiters)
+			for i := range numWaiters {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					entries[i], errs[i] = loader.LoadAndStore(t.Context(), 1)
+				}()
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			// the first caller cancels mid-flight
+			cancelFirst()
+			if err := <-firstErr; !errors.Is(err, context.Canceled) {
+				t.Errorf("the first caller must receive its context error, got %v", err)
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			for i := range numWaiters {
+				if tt.wantValue {
+					if errs[i] != nil || entries[i] == nil || entries[i].Value != "value" {
+						t.Errorf("the waiter must receive the value: %+v, %v", entries[i], errs[i])
+					}
+				} else if !errors.Is(errs[i], context.Canceled) {
+					t.Errorf("the waiter must receive the cancellation: %+v, %v", entries[i], errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAndStore_Parallel_WorkerPool(t *testing.T) {
+	t.Parallel()
+
+	const numKeys = 100
+	const poolSize = 4
+
+	var running, maxRunning atomic.Int32
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				current := maxRunning.Load()
+				if n <= current || maxRunning.CompareAndSwap(current, n) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			return &loadingcache.CacheEntry[int, string]{
+				Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+				ExpiresAt: time.Date(2025, time.January, 1, 1, 30, 30, 0, time.UTC),
+			}, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	var maxGauge atomic.Int32
+	loader := singleflightloader.NewSingleFlightLoader(s, src,
+		singleflightloader.WithWorkerPool[int, string](poolSize),
+		singleflightloader.WithInFlightGauge[int, string](func(inFlight int) {
+			if inFlight < 0 || inFlight > poolSize {
+				t.Errorf("unexpected in-flight loads: %d", inFlight)
+			}
+			for {
+				current := maxGauge.Load()
+				if int32(inFlight) <= current || maxGauge.CompareAndSwap(current, int32(inFlight)) {
+					break
+				}
+			}
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for key := range numKeys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			entry, err := loader.LoadAndStore(t.Context(), key)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if entry == nil || entry.Key != key || entry.Value != fmt.Sprintf("value%d", key) {
+				t.Errorf("unexpected entry for key %d: %+v", key, entry)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxRunning.Load(); got > poolSize {
+		t.Errorf("expected at most %d concurrent loads, got %d", poolSize, got)
+	} else if got == 0 {
+		t.Error("expected the source to be called")
+	}
+	if got := maxGauge.Load(); got == 0 || got > poolSize {
+		t.Errorf("expected the gauge to report between 1 and %d in-flight loads, got %d", poolSize, got)
+	}
+}
+
+// refKey is a comparable key referring to mutable data.
+type refKey struct {
+	id  int
+	tag *string
+}
+
+func TestLoadAndStoreMulti_EntryCopy(t *testing.T) {
+	t.Parallel()
+
+	tag := "tag"
+	key := refKey{id: 1, tag: &tag}
+	var loaded []int
+	src := &source.FunctionsSource[refKey, []int]{
+		GetMultiFunc: func(_ context.Context, keys []refKey) ([]*loadingcache.CacheEntry[refKey, []int], error) {
+			// the source keeps referring to the loaded value
+			loaded = []int{1, 2}
+			entries := make([]*loadingcache.CacheEntry[refKey, []int], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[refKey, []int]{
+					Entry:     loadingcache.Entry[refKey, []int]{Key: key, Value: loaded},
+					ExpiresAt: time.Date(2025, time.January, 1, 1, 30, 30, 0, time.UTC),
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[refKey, []int]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[refKey, []int]) error {
+			return nil
+		},
+	}
+	loader := singleflightloader.NewSingleFlightLoader(s, src,
+		singleflightloader.WithCloner[refKey](cloneInts),
+		singleflightloader.WithEntryCopy[refKey, []int](func(k refKey) refKey {
+			tag := *k.tag
+			return refKey{id: k.id, tag: &tag}
+		}),
+	)
+
+	// the duplicated keys are the multiple receivers of a single load
+	entries, err := loader.LoadAndStoreMulti(t.Context(), []refKey{key, key, key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mutate the entry of the first receiver
+	*entries[0].Key.tag = "mutated"
+	entries[0].Value[0] = 100
+
+	for i, entry := range entries[1:] {
+		if *entry.Key.tag != "tag" || entry.Value[0] != 1 {
+			t.Errorf("the receiver %d is affected by the mutation: key=%+v value=%v", i+1, *entry.Key.tag, entry.Value)
+		}
+	}
+	if tag != "tag" || loaded[0] != 1 {
+		t.Errorf("the loaded data is affected by the mutation: key=%s value=%v", tag, loaded)
+	}
+}
+
+func TestLoadAndStore_Parallel_SynchronousLoad(t *testing.T) {
+	t.Parallel()
+
+	var callCount atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			// only the first load of the contended key blocks until released
+			if key == 1 && callCount.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			return &loadingcache.CacheEntry[int, string]{
+				Entry:     loadingcache.Entry[int, string]{Key: key, Value: "testValue"},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+	loader := singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithSynchronousLoad[int, string]())
+
+	// the uncontended load runs on the caller's goroutine
+	if got, err := loader.LoadAndStore(t.Context(), 2); err != nil {
+		t.Fatal(err)
+	} else if got.Value != "testValue" {
+		t.Errorf("unexpected value: %v", got)
+	}
+
+	// the straggler arriving during the synchronous load waits for it
+	var wg sync.WaitGroup
+	results := make([]*loadingcache.Entry[int, string], 2)
+	errs := make([]error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], errs[0] = loader.LoadAndStore(t.Context(), 1)
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], errs[1] = loader.LoadAndStore(t.Context(), 1)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	want := &loadingcache.Entry[int, string]{Key: 1, Value: "testValue"}
+	for i := range results {
+		if errs[i] != nil {
+			t.Errorf("unexpected error: %v", errs[i])
+		}
+		if diff := cmp.Diff(want, results[i]); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+	}
+	if n := callCount.Load(); n != 1 {
+		t.Errorf("expected source to be called once, but it was called %d times", n)
+	}
+}
+
+func TestLoadAndStore_Parallel_WaitingKeysReleased(t *testing.T) {
+	t.Parallel()
+
+	const numKeys = 1000
+
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			if key%10 == 0 {
+				return nil, errors.New("source error")
+			}
+			return &loadingcache.CacheEntry[int, string]{
+				Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+			entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, string]{
+					Entry:     loadingcache.Entry[int, string]{Key: key, Value: fmt.Sprintf("value%d", key)},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []singleflightloader.Option[int, string]
+	}{
+		{name: "Default"},
+		{name: "BatchWindow", opts: []singleflightloader.Option[int, string]{singleflightloader.WithBatchWindow[int, string](time.Millisecond, 16)}},
+		{name: "WorkerPool", opts: []singleflightloader.Option[int, string]{singleflightloader.WithWorkerPool[int, string](4)}},
+		{name: "SynchronousLoad", opts: []singleflightloader.Option[int, string]{singleflightloader.WithSynchronousLoad[int, string]()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			loader := singleflightloader.NewSingleFlightLoader(s, src, tc.opts...)
+
+			var wg sync.WaitGroup
+			for key := range numKeys {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					_, _ = loader.LoadAndStore(t.Context(), key)
+				}()
+				go func() {
+					defer wg.Done()
+					_, _ = loader.LoadAndStoreMulti(t.Context(), []int{numKeys + key, numKeys + key + 1})
+				}()
+			}
+			wg.Wait()
+
+			if n := loader.WaitingKeys(); n != 0 {
+				t.Errorf("expected the waiting keys to be released, but %d keys remain", n)
+			}
+		})
+	}
+}
+
+func TestLoadAndStore_Parallel_MaxWaitingKeys(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			<-release
+			return &loadingcache.CacheEntry[int, string]{
+				Entry:     loadingcache.Entry[int, string]{Key: key, Value: "testValue"},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+	loader := singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithMaxWaitingKeys[int, string](2))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, key := range []int{1, 2, 1} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = loader.LoadAndStore(t.Context(), key)
+		}()
+	}
+	for loader.WaitingKeys() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := loader.LoadAndStore(t.Context(), 3); !errors.Is(err, singleflightloader.ErrLoaderOverloaded) {
+		t.Errorf("expected ErrLoaderOverloaded, got %v", err)
+	}
+	if _, err := loader.LoadAndStoreMulti(t.Context(), []int{1, 3}); !errors.Is(err, singleflightloader.ErrLoaderOverloaded) {
+		t.Errorf("expected ErrLoaderOverloaded, got %v", err)
+	}
+	if n := loader.WaitingKeys(); n != 2 {
+		t.Errorf("expected the overloaded calls not to register the keys, but %d keys are waiting", n)
+	}
+
+	close(release)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error of the call %d: %v", i, err)
+		}
+	}
+
+	// the keys are accepted again once the loads are completed
+	if _, err := loader.LoadAndStoreMulti(t.Context(), []int{3, 4}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := loader.WaitingKeys(); n != 0 {
+		t.Errorf("expected the waiting keys to be released, but %d keys remain", n)
+	}
+}
+
+func BenchmarkLoadAndStore_SynchronousLoad(b *testing.B) {
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			return &loadingcache.CacheEntry[int, string]{
+				Entry: loadingcache.Entry[int, string]{Key: key, Value: "testValue"},
+			}, nil
+		},
+	}
+	s := &storage.FunctionsStorage[int, string]{
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+			return nil
+		},
+	}
+
+	for _, bc := range []struct {
+		name string
+		opts []singleflightloader.Option[int, string]
+	}{
+		{name: "Goroutine"},
+		{name: "Synchronous", opts: []singleflightloader.Option[int, string]{singleflightloader.WithSynchronousLoad[int, string]()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			loader := singleflightloader.NewSingleFlightLoader(s, src, bc.opts...)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := loader.LoadAndStore(b.Context(), 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package singleflightloader
+
+import "sync"
+
+// workerPool runs the submitted tasks on a bounded number of goroutines.
+// The workers are spawned on demand and exit when the queue becomes empty, so an idle pool has no goroutines.
+type workerPool struct {
+	size int
+
+	mu      sync.Mutex
+	queue   []func()
+	workers int
+}
+
+// submit queues the task, and spawns a worker if the number of the workers is less than the size.
+func (p *workerPool) submit(task func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queue = append(p.queue, task)
+	if p.workers < p.size {
+		p.workers++
+		go p.work()
+	}
+}
+
+// work runs the queued tasks until the queue becomes empty.
+func (p *workerPool) work() {
+	goexit := true
+	defer func() {
+		if goexit {
+			// the task called runtime.Goexit, so spawn another worker to take over the queue
+			p.respawn()
+		}
+	}()
+
+	for {
+		task, ok := p.next()
+		if !ok {
+			goexit = false
+			return
+		}
+		task()
+	}
+}
+
+// next dequeues the next task, or decrements the number of the workers and returns false if the queue is empty.
+func (p *workerPool) next() (func(), bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) == 0 {
+		p.workers--
+		return nil, false
+	}
+	task := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	return task, true
+}
+
+// respawn replaces the exited worker with a new one if there are queued tasks.
+func (p *workerPool) respawn() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) == 0 {
+		p.workers--
+		return
+	}
+	go p.work()
+}
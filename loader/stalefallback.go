@@ -0,0 +1,80 @@
+package loader
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// StaleFallbackLoader is a decorator for a loadingcache.SourceLoader that serves the last-known entries
+// in the storage when the underlying loader fails.
+// The storage must keep the expired entries to serve them, e.g. memstorage.WithStaleReads.
+type StaleFallbackLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Loader is the underlying loader that this decorator wraps.
+	Loader loadingcache.SourceLoader[K, V]
+
+	// Storage is the storage to read the stale entries from.
+	// It should be the same storage that the underlying loader stores the loaded entries into.
+	Storage loadingcache.StaleCacheStorage[K, V]
+
+	// OnStaleFallback is an optional function that is called with the keys and the error of the underlying loader
+	// when the stale entries are served instead of the error.
+	OnStaleFallback func(keys []K, err error)
+}
+
+var _ loadingcache.SourceLoader[uint8, struct{}] = (*StaleFallbackLoader[uint8, struct{}])(nil)
+
+// LoadAndStore calls the LoadAndStore of the underlying loader.
+// If it fails and the storage holds an entry for the key even if expired, it returns the stored entry instead of the error.
+// The stale negative cache is served as not found.
+func (l *StaleFallbackLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
+	entry, err := l.Loader.LoadAndStore(ctx, key)
+	if err == nil {
+		return entry, nil
+	}
+
+	stale, ok := l.getStale(ctx, key)
+	if !ok {
+		return nil, err
+	}
+	if l.OnStaleFallback != nil {
+		l.OnStaleFallback([]K{key}, err)
+	}
+	return stale, nil
+}
+
+// LoadAndStoreMulti calls the LoadAndStoreMulti of the underlying loader.
+// If it fails and the storage holds the entries for all the keys even if expired, it returns the stored entries instead of the error.
+// Otherwise, it returns the error of the underlying loader.
+func (l *StaleFallbackLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
+	entries, err := l.Loader.LoadAndStoreMulti(ctx, keys)
+	if err == nil {
+		return entries, nil
+	}
+
+	entries = make([]*loadingcache.Entry[K, V], len(keys))
+	for i, key := range keys {
+		stale, ok := l.getStale(ctx, key)
+		if !ok {
+			return nil, err
+		}
+		entries[i] = stale
+	}
+	if l.OnStaleFallback != nil {
+		l.OnStaleFallback(keys, err)
+	}
+	return entries, nil
+}
+
+// getStale returns the stale entry for the key, and whether the storage holds it.
+// The returned entry is nil for the negative cache.
+func (l *StaleFallbackLoader[K, V]) getStale(ctx context.Context, key K) (*loadingcache.Entry[K, V], bool) {
+	stale, err := l.Storage.GetStale(ctx, key)
+	if err != nil || stale == nil {
+		return nil, false
+	}
+	if stale.NegativeCache {
+		return nil, true
+	}
+	return &stale.Entry, true
+}
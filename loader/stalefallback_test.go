@@ -0,0 +1,100 @@
+package loader_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func TestStaleFallbackLoader(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	s := memstorage.NewInMemoryStorage(
+		memstorage.WithClock[uint8, string](clock),
+		memstorage.WithStaleReads[uint8, string](),
+	)
+	err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, string]{
+		{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "stale1"}, ExpiresAt: now.Add(time.Minute)},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "stale2"}, ExpiresAt: now.Add(time.Minute)},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 3}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Time = now.Add(time.Hour)
+
+	// the expired entries are missing for Get, but kept for GetStale
+	if entry, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	} else if entry != nil {
+		t.Fatalf("expected nil for the expired entry, got %+v", entry)
+	}
+
+	loadErr := errors.New("load error")
+	var fallbacks [][]uint8
+	l := &loader.StaleFallbackLoader[uint8, string]{
+		Loader: &functionsLoader[uint8, string]{
+			loadAndStore: func(context.Context, uint8) (*loadingcache.Entry[uint8, string], error) {
+				return nil, loadErr
+			},
+			loadAndStoreMulti: func(context.Context, []uint8) ([]*loadingcache.Entry[uint8, string], error) {
+				return nil, loadErr
+			},
+		},
+		Storage: s.(loadingcache.StaleCacheStorage[uint8, string]),
+		OnStaleFallback: func(keys []uint8, err error) {
+			if !errors.Is(err, loadErr) {
+				t.Errorf("expected %v, got %v", loadErr, err)
+			}
+			fallbacks = append(fallbacks, slices.Clone(keys))
+		},
+	}
+
+	entry, err := l.LoadAndStore(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "stale1"}, entry); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+
+	// the stale negative cache is served as not found
+	entry, err = l.LoadAndStore(t.Context(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Errorf("expected nil for the negative cache, got %+v", entry)
+	}
+
+	// the error is returned if there is no stale entry
+	if _, err := l.LoadAndStore(t.Context(), 4); !errors.Is(err, loadErr) {
+		t.Errorf("expected %v, got %v", loadErr, err)
+	}
+
+	entries, err := l.LoadAndStoreMulti(t.Context(), []uint8{2, 1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*loadingcache.Entry[uint8, string]{{Key: 2, Value: "stale2"}, {Key: 1, Value: "stale1"}, nil}
+	if diff := cmp.Diff(want, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if _, err := l.LoadAndStoreMulti(t.Context(), []uint8{1, 4}); !errors.Is(err, loadErr) {
+		t.Errorf("expected %v, got %v", loadErr, err)
+	}
+
+	if diff := cmp.Diff([][]uint8{{1}, {3}, {2, 1, 3}}, fallbacks); diff != "" {
+		t.Errorf("unexpected fallbacks (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,17 @@
+// Package xsingleflightloader provides a cache loader implementation backed by golang.org/x/sync/singleflight.
+//
+// It is an alternative to the singleflightloader package for the codebases that already depend on
+// golang.org/x/sync/singleflight and want the consistent semantics across them.
+// Both loaders coalesce the concurrent loads of the same key into a single source call, but they differ in:
+//   - LoadAndStoreMulti: the singleflightloader loads the missing keys by a single GetMulti call of the source,
+//     while this loader splits them into the coalesced single-key loads by Get, so GetMulti of the source is never called.
+//   - Forget: this loader can forget the in-flight load of a key, so that the later calls start a new load
+//     without waiting for it. The forgotten load continues and its callers still receive its result.
+//   - Panics: a panic in the source crashes the process as golang.org/x/sync/singleflight.Group.DoChan does,
+//     while the singleflightloader returns it as an error to the waiters.
+//
+// The XSingleFlightLoader can be configured with options:
+//   - WithCloner: Allows setting a custom value cloner to use when copying values to multiple requesters
+//   - WithBackgroundContextProvider: Sets a custom context provider for background operations
+//   - WithKeyString: Sets the function to convert the keys into the keys of the singleflight.Group
+package xsingleflightloader
@@ -0,0 +1,160 @@
+package xsingleflightloader
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// XSingleFlightLoader is a SourceLoader implementation that uses golang.org/x/sync/singleflight to load values.
+// It uses a source to load the values, a storage to cache the values, and a cloner to clone the values.
+type XSingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	storage   loadingcache.CacheStorage[K, V]
+	source    loadingcache.LoadingSource[K, V]
+	cloner    loadingcache.ValueCloner[V]
+	context   func() context.Context
+	keyString func(K) string
+
+	group singleflight.Group
+}
+
+var _ loadingcache.SourceLoader[uint8, struct{}] = (*XSingleFlightLoader[uint8, struct{}])(nil)
+var _ loadingcache.CacheEntrySourceLoader[uint8, struct{}] = (*XSingleFlightLoader[uint8, struct{}])(nil)
+
+// NewXSingleFlightLoader creates a new XSingleFlightLoader instance.
+func NewXSingleFlightLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](storage loadingcache.CacheStorage[K, V], source loadingcache.LoadingSource[K, V], opts ...Option[K, V]) *XSingleFlightLoader[K, V] {
+	loader := &XSingleFlightLoader[K, V]{
+		storage: storage,
+		source:  source,
+		cloner:  nil,
+		context: context.Background,
+		keyString: func(key K) string {
+			return fmt.Sprint(key)
+		},
+	}
+	for _, o := range opts {
+		o.apply(loader)
+	}
+	if loader.cloner == nil {
+		loader.cloner = loadingcache.DefaultValueCloner[V]()
+	}
+	return loader
+}
+
+// LoadAndStore retrieves a value associated with the given key from the source,
+// stores it in the storage with an expiration time, and returns the value.
+// The concurrent calls for the same key share a single load.
+// The load runs in the background with the context of the provider, so the cancellation of the caller's context
+// only stops the caller's waiting.
+func (l *XSingleFlightLoader[K, V]) LoadAndStore(ctx context.Context, key K) (*loadingcache.Entry[K, V], error) {
+	cacheEntry, err := l.loadAndStoreCacheEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return toEntry(cacheEntry), nil
+}
+
+// loadAndStoreCacheEntry loads the entry by the coalesced single-key load, and returns it with its metadata.
+func (l *XSingleFlightLoader[K, V]) loadAndStoreCacheEntry(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	ch := l.group.DoChan(l.keyString(key), func() (any, error) {
+		return l.loadKeyAndStore(key)
+	})
+
+	select {
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return l.receiverEntry(r.Val.(*loadingcache.CacheEntry[K, V]), r.Shared), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadKeyAndStore loads a value from the source and stores it in the storage.
+func (l *XSingleFlightLoader[K, V]) loadKeyAndStore(key K) (*loadingcache.CacheEntry[K, V], error) {
+	ctx := l.context()
+	cacheEntry, err := l.source.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if cacheEntry != nil {
+		if err := l.storage.Set(ctx, cacheEntry); err != nil {
+			return nil, err
+		}
+	}
+	return cacheEntry, nil
+}
+
+// receiverEntry returns the entry for a receiver of the loaded entry.
+// The value is cloned if the entry is shared with the other receivers.
+// The entry itself is shared with all the receivers if the cloner is a loadingcache.ImmutableValueCloner[V].
+// The negative caches are returned as they are, since they have no value to clone.
+func (l *XSingleFlightLoader[K, V]) receiverEntry(cacheEntry *loadingcache.CacheEntry[K, V], shared bool) *loadingcache.CacheEntry[K, V] {
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return cacheEntry
+	}
+
+	if _, ok := l.cloner.(loadingcache.ImmutableValueCloner[V]); ok {
+		return cacheEntry
+	}
+
+	entry := *cacheEntry
+	if shared {
+		entry.Value = l.cloner.CloneValue(entry.Value)
+	}
+	return &entry
+}
+
+// LoadAndStoreMulti loads multiple entries from the source using the provided keys,
+// stores them in the cache, and returns the loaded entries. If an error occurs during
+// the loading or storing process, it returns the error.
+// Unlike the singleflightloader, each key is loaded by the coalesced single-key load as LoadAndStore does.
+func (l *XSingleFlightLoader[K, V]) LoadAndStoreMulti(ctx context.Context, keys []K) ([]*loadingcache.Entry[K, V], error) {
+	cacheEntries, err := l.LoadAndStoreMultiCacheEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*loadingcache.Entry[K, V], len(cacheEntries))
+	for i, cacheEntry := range cacheEntries {
+		entries[i] = toEntry(cacheEntry)
+	}
+	return entries, nil
+}
+
+// LoadAndStoreMultiCacheEntries loads multiple entries as LoadAndStoreMulti does, and returns them with their
+// expiration times and the negative cache flags.
+func (l *XSingleFlightLoader[K, V]) LoadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	cacheEntries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i, key := range keys {
+		eg.Go(func() (err error) {
+			cacheEntries[i], err = l.loadAndStoreCacheEntry(egCtx, key)
+			return
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return cacheEntries, nil
+}
+
+// toEntry returns the entry of the cache entry, or nil if it is missing or a negative cache.
+func toEntry[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cacheEntry *loadingcache.CacheEntry[K, V]) *loadingcache.Entry[K, V] {
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return nil
+	}
+	return &cacheEntry.Entry
+}
+
+// Forget forgets the in-flight load of the key, so that the later calls for the key start a new load
+// instead of waiting for it. The forgotten load continues and its callers still receive its result.
+func (l *XSingleFlightLoader[K, V]) Forget(key K) {
+	l.group.Forget(l.keyString(key))
+}
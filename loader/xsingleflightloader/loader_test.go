@@ -0,0 +1,224 @@
+package xsingleflightloader_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader/singleflightloader"
+	"github.com/karupanerura/loading-cache/loader/xsingleflightloader"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+type blockingSource struct {
+	source.FunctionsSource[int, string]
+
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func newBlockingSource() *blockingSource {
+	s := &blockingSource{release: make(chan struct{})}
+	s.GetFunc = func(ctx context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+		s.calls.Add(1)
+		<-s.release
+		if key < 0 {
+			return nil, errors.New("negative key")
+		}
+		if key == 0 {
+			return nil, nil
+		}
+		return &loadingcache.CacheEntry[int, string]{
+			Entry:     loadingcache.Entry[int, string]{Key: key, Value: "value"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil
+	}
+	s.GetMultiFunc = func(ctx context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+		entries := make([]*loadingcache.CacheEntry[int, string], len(keys))
+		for i, key := range keys {
+			entry, err := s.GetFunc(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = entry
+		}
+		return entries, nil
+	}
+	return s
+}
+
+func TestXSingleFlightLoader_CoalescingParity(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		newLoader func(loadingcache.CacheStorage[int, string], loadingcache.LoadingSource[int, string]) loadingcache.SourceLoader[int, string]
+	}{
+		{
+			name: "SingleFlightLoader",
+			newLoader: func(s loadingcache.CacheStorage[int, string], src loadingcache.LoadingSource[int, string]) loadingcache.SourceLoader[int, string] {
+				return singleflightloader.NewSingleFlightLoader(s, src)
+			},
+		},
+		{
+			name: "XSingleFlightLoader",
+			newLoader: func(s loadingcache.CacheStorage[int, string], src loadingcache.LoadingSource[int, string]) loadingcache.SourceLoader[int, string] {
+				return xsingleflightloader.NewXSingleFlightLoader(s, src)
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, key := range []int{1, 0, -1} {
+				src := newBlockingSource()
+				s := memstorage.NewInMemoryStorage[int, string]()
+				loader := tt.newLoader(s, src)
+
+				const numGoroutines = 10
+				var wg sync.WaitGroup
+				entries := make([]*loadingcache.Entry[int, string], numGoroutines)
+				errs := make([]error, numGoroutines)
+				for i := range numGoroutines {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						entries[i], errs[i] = loader.LoadAndStore(t.Context(), key)
+					}()
+				}
+				time.Sleep(50 * time.Millisecond)
+				close(src.release)
+				wg.Wait()
+
+				if got := src.calls.Load(); got != 1 {
+					t.Errorf("key %d: expected the source to be called once, got %d", key, got)
+				}
+				for i := range numGoroutines {
+					switch {
+					case key < 0:
+						if errs[i] == nil {
+							t.Errorf("key %d: expected an error", key)
+						}
+					case key == 0:
+						if errs[i] != nil || entries[i] != nil {
+							t.Errorf("key %d: unexpected result: %+v, %v", key, entries[i], errs[i])
+						}
+					default:
+						if errs[i] != nil {
+							t.Errorf("key %d: unexpected error: %v", key, errs[i])
+						} else if diff := cmp.Diff(&loadingcache.Entry[int, string]{Key: key, Value: "value"}, entries[i]); diff != "" {
+							t.Errorf("key %d: unexpected entry (-want +got):\n%s", key, diff)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestXSingleFlightLoader_LoadAndStoreMulti(t *testing.T) {
+	t.Parallel()
+
+	src := newBlockingSource()
+	close(src.release)
+	s := memstorage.NewInMemoryStorage[int, string]()
+	loader := xsingleflightloader.NewXSingleFlightLoader[int, string](s, src)
+
+	entries, err := loader.LoadAndStoreMulti(t.Context(), []int{1, 0, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.Entry[int, string]{{Key: 1, Value: "value"}, nil, {Key: 2, Value: "value"}}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	stored, err := s.GetMulti(t.Context(), []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0] == nil || stored[1] == nil {
+		t.Errorf("the loaded entries must be stored: %+v", stored)
+	}
+
+	if _, err := loader.LoadAndStoreMulti(t.Context(), []int{1, -1}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestXSingleFlightLoader_LoadAndStoreMultiCacheEntries(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	src := &source.FunctionsSource[int, string]{
+		GetFunc: func(_ context.Context, key int) (*loadingcache.CacheEntry[int, string], error) {
+			switch key {
+			case 1:
+				return &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value"}, ExpiresAt: expiresAt}, nil
+			case 2:
+				return &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: expiresAt, NegativeCache: true}, nil
+			default:
+				return nil, nil
+			}
+		},
+	}
+	s := memstorage.NewInMemoryStorage[int, string]()
+	loader := xsingleflightloader.NewXSingleFlightLoader[int, string](s, src)
+
+	entries, err := loader.LoadAndStoreMultiCacheEntries(t.Context(), []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*loadingcache.CacheEntry[int, string]{
+		{Entry: loadingcache.Entry[int, string]{Key: 1, Value: "value"}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[int, string]{Key: 2}, ExpiresAt: expiresAt, NegativeCache: true},
+		nil,
+	}
+	if diff := cmp.Diff(want, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestXSingleFlightLoader_Forget(t *testing.T) {
+	t.Parallel()
+
+	src := newBlockingSource()
+	loader := xsingleflightloader.NewXSingleFlightLoader[int, string](memstorage.NewInMemoryStorage[int, string](), src)
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loader.LoadAndStore(t.Context(), 1); err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(50 * time.Millisecond)
+		loader.Forget(1)
+	}
+	close(src.release)
+	wg.Wait()
+
+	if got := src.calls.Load(); got != 2 {
+		t.Errorf("expected the forgotten key to be loaded again, got %d calls", got)
+	}
+}
+
+func TestXSingleFlightLoader_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	src := newBlockingSource()
+	defer close(src.release)
+	loader := xsingleflightloader.NewXSingleFlightLoader[int, string](memstorage.NewInMemoryStorage[int, string](), src)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := loader.LoadAndStore(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package xsingleflightloader
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// Option is the interface for the options of the XSingleFlightLoader.
+type Option[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	apply(*XSingleFlightLoader[K, V])
+}
+
+type optionFunc[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] func(*XSingleFlightLoader[K, V])
+
+func (f optionFunc[K, V]) apply(l *XSingleFlightLoader[K, V]) {
+	f(l)
+}
+
+// WithCloner sets the value cloner to the loader.
+// The default value cloner is loadingcache.DefaultValueCloner.
+// If it is a loadingcache.ImmutableValueCloner[V] (e.g. returned by loadingcache.ImmutableValues), the loaded entry is shared with all the receivers without copying.
+func WithCloner[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cloner loadingcache.ValueCloner[V]) Option[K, V] {
+	return optionFunc[K, V](func(l *XSingleFlightLoader[K, V]) {
+		l.cloner = cloner
+	})
+}
+
+// WithBackgroundContextProvider sets the context provider to the loader.
+// The provider must return a new context for each call.
+// The default context provider is context.Background.
+func WithBackgroundContextProvider[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](provider func() context.Context) Option[K, V] {
+	return optionFunc[K, V](func(l *XSingleFlightLoader[K, V]) {
+		l.context = provider
+	})
+}
+
+// WithKeyString sets the function to convert the keys into the keys of the singleflight.Group.
+// The function must return distinct strings for distinct keys.
+// The default function formats the keys by fmt.Sprint.
+func WithKeyString[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](f func(K) string) Option[K, V] {
+	return optionFunc[K, V](func(l *XSingleFlightLoader[K, V]) {
+		l.keyString = f
+	})
+}
@@ -2,19 +2,49 @@ package loadingcache
 
 import (
 	"context"
+	"slices"
+
+	"github.com/karupanerura/loading-cache/internal/panicutil"
 )
 
 // LoadingCache is a cache that loads values from an external source.
 type LoadingCache[K KeyConstraint, V ValueConstraint] struct {
 	Loader  SourceLoader[K, V]
 	Storage CacheStorage[K, V]
+
+	// OnSplit is an optional function that is called by GetOrLoadMulti after retrieving the entries from the storage
+	// and before loading the missing entries.
+	// The hits are the keys found in the storage including the negative caches, and the misses are the keys to be loaded.
+	// Both are in the order of the input keys.
+	OnSplit func(hits, misses []K)
+
+	// PeekNegativeCacheAsHit makes Peek report the negative caches as hits.
+	// If false, Peek reports them as misses.
+	PeekNegativeCacheAsHit bool
+
+	// OnRefreshError is an optional function that is called when the background reload of LoadWithRefresh fails.
+	OnRefreshError func(key K, err error)
+
+	// SortedKeepsMissing makes GetOrLoadMultiSorted keep nil for each missing entry at the end of the result.
+	// If false, the missing entries are excluded from the result.
+	SortedKeepsMissing bool
+
+	// IgnoreStorageGetErrors makes GetOrLoad, GetOrLoadMulti and GetOrLoadMultiCacheEntries treat the errors of the storage reads
+	// as cache misses and load the entries from the Loader instead of returning the errors.
+	// It keeps the reads available while the storage is flaky, but it also masks the storage failures,
+	// and all the reads hit the source while the storage is down. Use OnStorageGetError to observe them.
+	IgnoreStorageGetErrors bool
+
+	// OnStorageGetError is an optional function that is called with the keys and the error when a storage read error
+	// is ignored by IgnoreStorageGetErrors.
+	OnStorageGetError func(keys []K, err error)
 }
 
 // GetOrLoad retrieves the value associated with the given key from the cache.
 // If the value is not found in the cache, it loads the value from the external source.
 // If an error occurs during the loading process, the method returns the zero value of V and the error.
 func (c *LoadingCache[K, V]) GetOrLoad(ctx context.Context, key K) (*Entry[K, V], error) {
-	if cacheEntry, err := c.Storage.Get(ctx, key); err != nil {
+	if cacheEntry, err := c.storageGet(ctx, key); err != nil {
 		return nil, err
 	} else if cacheEntry != nil {
 		if cacheEntry.NegativeCache {
@@ -27,11 +57,75 @@ func (c *LoadingCache[K, V]) GetOrLoad(ctx context.Context, key K) (*Entry[K, V]
 	return entry, err
 }
 
+// Peek retrieves the value associated with the given key from the storage only, and never loads it.
+// It returns the entry and true if the fresh entry is found.
+// It returns nil and false if the entry is not found, or nil and PeekNegativeCacheAsHit if it is a negative cache.
+func (c *LoadingCache[K, V]) Peek(ctx context.Context, key K) (*Entry[K, V], bool, error) {
+	cacheEntry, err := c.Storage.Get(ctx, key)
+	if err != nil {
+		return nil, false, err
+	} else if cacheEntry == nil {
+		return nil, false, nil
+	} else if cacheEntry.NegativeCache {
+		return nil, c.PeekNegativeCacheAsHit, nil
+	}
+	return &cacheEntry.Entry, true, nil
+}
+
+// LoadWithRefresh retrieves the value associated with the given key from the storage, and returns it immediately.
+// It returns nil if the value is not found in the storage or is a negative cache.
+// If the Storage is a StaleCacheStorage, the expired value is also returned, since it is refreshed anyway.
+// It falls back to Get of the Storage if GetStale fails, e.g. the stale reads are disabled.
+//
+// Then it reloads the value from the external source in the background regardless of the cached value,
+// and calls onFresh once with the reloaded value, or nil if the key is not found in the source.
+// If onFresh is nil, the value is reloaded without the notification.
+// The background reload is not canceled by the cancellation of the given context.
+// If the reload fails, onFresh is not called and OnRefreshError is called instead if set.
+// The panics of the reload and onFresh are recovered and passed to OnRefreshError as errors.
+func (c *LoadingCache[K, V]) LoadWithRefresh(ctx context.Context, key K, onFresh func(*Entry[K, V])) (*Entry[K, V], error) {
+	cacheEntry, err := c.storageGetStale(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := panicutil.DDS(func() error {
+			entry, err := c.Loader.LoadAndStore(context.WithoutCancel(ctx), key)
+			if err != nil {
+				return err
+			}
+			if onFresh != nil {
+				onFresh(entry)
+			}
+			return nil
+		})
+		if err != nil && c.OnRefreshError != nil {
+			c.OnRefreshError(key, err)
+		}
+	}()
+
+	if cacheEntry == nil || cacheEntry.NegativeCache {
+		return nil, nil
+	}
+	return &cacheEntry.Entry, nil
+}
+
+// storageGetStale retrieves the entry from the storage by GetStale if it is a StaleCacheStorage, or by Get otherwise.
+func (c *LoadingCache[K, V]) storageGetStale(ctx context.Context, key K) (*CacheEntry[K, V], error) {
+	if s, ok := c.Storage.(StaleCacheStorage[K, V]); ok {
+		if cacheEntry, err := s.GetStale(ctx, key); err == nil {
+			return cacheEntry, nil
+		}
+	}
+	return c.Storage.Get(ctx, key)
+}
+
 // GetOrLoadMulti retrieves multiple values from the cache.
 // If a value is not found in the cache, it loads the value from the external source.
 // If an error occurs during the loading process, the method returns the zero value of V and the error.
 func (cl *LoadingCache[K, V]) GetOrLoadMulti(ctx context.Context, keys []K) ([]*Entry[K, V], error) {
-	cacheEntries, err := cl.Storage.GetMulti(ctx, keys)
+	cacheEntries, err := cl.storageGetMulti(ctx, keys)
 	if err != nil {
 		return nil, err
 	}
@@ -45,14 +139,18 @@ func (cl *LoadingCache[K, V]) GetOrLoadMulti(ctx context.Context, keys []K) ([]*
 			entries[i] = &entry.Entry
 		}
 	}
-	if len(indexes) == 0 {
-		return entries, nil
-	}
 
 	missing := make([]K, len(indexes))
 	for i, j := range indexes {
 		missing[i] = keys[j]
 	}
+	if cl.OnSplit != nil {
+		cl.OnSplit(splitHits(keys, cacheEntries), missing)
+	}
+	if len(indexes) == 0 {
+		return entries, nil
+	}
+
 	loaded, err := cl.Loader.LoadAndStoreMulti(ctx, missing)
 	if err != nil {
 		return nil, err
@@ -63,3 +161,156 @@ func (cl *LoadingCache[K, V]) GetOrLoadMulti(ctx context.Context, keys []K) ([]*
 	}
 	return entries, nil
 }
+
+// GetOrLoadMultiSorted retrieves multiple values in the same way as GetOrLoadMulti, and returns them sorted by less.
+// The sort is stable, so the entries considered equal by less keep the order of the input keys.
+// The missing entries are excluded from the result unless SortedKeepsMissing is true.
+func (cl *LoadingCache[K, V]) GetOrLoadMultiSorted(ctx context.Context, keys []K, less func(a, b *Entry[K, V]) bool) ([]*Entry[K, V], error) {
+	entries, err := cl.GetOrLoadMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*Entry[K, V], 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			sorted = append(sorted, entry)
+		}
+	}
+	slices.SortStableFunc(sorted, func(a, b *Entry[K, V]) int {
+		if less(a, b) {
+			return -1
+		} else if less(b, a) {
+			return 1
+		}
+		return 0
+	})
+	if cl.SortedKeepsMissing {
+		sorted = sorted[:len(entries)]
+	}
+	return sorted, nil
+}
+
+// GetOrLoadMultiCacheEntries retrieves multiple entries with their expiration times from the cache.
+// If an entry is not found in the cache, it loads the entry from the external source.
+// Unlike GetOrLoadMulti, the negative caches are returned as the entries with NegativeCache set to true.
+//
+// If the Loader implements CacheEntrySourceLoader, the loaded entries are returned as they are.
+// Otherwise, the loaded entries are built from the results of LoadAndStoreMulti without reading the storage again,
+// so their expiration times are zero, and the negative-cached keys are returned as nil like the keys not found.
+func (cl *LoadingCache[K, V]) GetOrLoadMultiCacheEntries(ctx context.Context, keys []K) ([]*CacheEntry[K, V], error) {
+	cacheEntries, err := cl.storageGetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0, len(keys))
+	for i, entry := range cacheEntries {
+		if entry == nil {
+			indexes = append(indexes, i)
+		}
+	}
+
+	missing := make([]K, len(indexes))
+	for i, j := range indexes {
+		missing[i] = keys[j]
+	}
+	if cl.OnSplit != nil {
+		cl.OnSplit(splitHits(keys, cacheEntries), missing)
+	}
+	if len(indexes) == 0 {
+		return cacheEntries, nil
+	}
+
+	loaded, err := cl.loadAndStoreMultiCacheEntries(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := range indexes {
+		cacheEntries[j] = loaded[i]
+	}
+	return cacheEntries, nil
+}
+
+// GetOrLoadMultiDetailed retrieves multiple values in the same way as GetOrLoadMulti,
+// and also returns the keys confirmed to be absent in the source by the negative caches, in the order of the input keys.
+// The found entries are in the order of the input keys, and they are nil for both the negative-cached keys
+// and the keys merely not found, so the keys not found in either result are neither found nor confirmed absent.
+// It is built on GetOrLoadMultiCacheEntries to distinguish the negative caches from the missing entries.
+func (cl *LoadingCache[K, V]) GetOrLoadMultiDetailed(ctx context.Context, keys []K) (found []*Entry[K, V], negative []K, err error) {
+	cacheEntries, err := cl.GetOrLoadMultiCacheEntries(ctx, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found = make([]*Entry[K, V], len(keys))
+	for i, entry := range cacheEntries {
+		switch {
+		case entry == nil:
+		case entry.NegativeCache:
+			negative = append(negative, keys[i])
+		default:
+			found[i] = &entry.Entry
+		}
+	}
+	return found, negative, nil
+}
+
+// storageGet retrieves the entry from the storage.
+// If IgnoreStorageGetErrors is true, the error is reported to OnStorageGetError and treated as a cache miss.
+func (c *LoadingCache[K, V]) storageGet(ctx context.Context, key K) (*CacheEntry[K, V], error) {
+	cacheEntry, err := c.Storage.Get(ctx, key)
+	if err != nil && c.IgnoreStorageGetErrors {
+		if c.OnStorageGetError != nil {
+			c.OnStorageGetError([]K{key}, err)
+		}
+		return nil, nil
+	}
+	return cacheEntry, err
+}
+
+// storageGetMulti retrieves the entries from the storage.
+// If IgnoreStorageGetErrors is true, the error is reported to OnStorageGetError and treated as cache misses for all the keys.
+func (c *LoadingCache[K, V]) storageGetMulti(ctx context.Context, keys []K) ([]*CacheEntry[K, V], error) {
+	cacheEntries, err := c.Storage.GetMulti(ctx, keys)
+	if err != nil && c.IgnoreStorageGetErrors {
+		if c.OnStorageGetError != nil {
+			c.OnStorageGetError(keys, err)
+		}
+		return make([]*CacheEntry[K, V], len(keys)), nil
+	}
+	return cacheEntries, err
+}
+
+// loadAndStoreMultiCacheEntries loads the entries by the loader, and returns them with their metadata.
+// If the loader is not a CacheEntrySourceLoader, the entries are built from the loaded values without their metadata.
+// The storage is never read again, since the loaded entries may be already dropped, evicted or expired in it.
+func (c *LoadingCache[K, V]) loadAndStoreMultiCacheEntries(ctx context.Context, keys []K) ([]*CacheEntry[K, V], error) {
+	if loader, ok := c.Loader.(CacheEntrySourceLoader[K, V]); ok {
+		return loader.LoadAndStoreMultiCacheEntries(ctx, keys)
+	}
+
+	loaded, err := c.Loader.LoadAndStoreMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	cacheEntries := make([]*CacheEntry[K, V], len(loaded))
+	for i, entry := range loaded {
+		if entry != nil {
+			cacheEntries[i] = &CacheEntry[K, V]{Entry: *entry}
+		}
+	}
+	return cacheEntries, nil
+}
+
+// splitHits returns the keys found in the storage.
+func splitHits[K KeyConstraint, V ValueConstraint](keys []K, cacheEntries []*CacheEntry[K, V]) []K {
+	hits := make([]K, 0, len(keys))
+	for i, entry := range cacheEntries {
+		if entry != nil {
+			hits = append(hits, keys[i])
+		}
+	}
+	return hits
+}
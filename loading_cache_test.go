@@ -3,6 +3,7 @@ package loadingcache_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/karupanerura/loading-cache/loader/pureloader"
 	"github.com/karupanerura/loading-cache/source"
 	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
 )
 
 func TestSingleFlightCacheLoader_GetOrLoad(t *testing.T) {
@@ -480,3 +482,590 @@ func TestSingleFlightCacheLoader_GetOrLoadMulti(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadingCache_GetOrLoadMulti_OnSplit(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	s := memstorage.NewInMemoryStorage[uint8, string]()
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, string]{
+		{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "value1"}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 3}, ExpiresAt: expiresAt, NegativeCache: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded []uint8
+	src := &source.FunctionsSource[uint8, string]{
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			loaded = append(loaded, keys...)
+			return make([]*loadingcache.CacheEntry[uint8, string], len(keys)), nil
+		},
+	}
+
+	var hits, misses []uint8
+	var calls int
+	cache := &loadingcache.LoadingCache[uint8, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+		OnSplit: func(h, m []uint8) {
+			calls++
+			if len(loaded) != 0 {
+				t.Error("OnSplit must be called before loading")
+			}
+			hits, misses = h, m
+		},
+	}
+
+	if _, err := cache.GetOrLoadMulti(t.Context(), []uint8{4, 3, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected OnSplit to be called once, got %d", calls)
+	}
+	if diff := cmp.Diff([]uint8{3, 1}, hits); diff != "" {
+		t.Errorf("unexpected hits (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint8{4, 2}, misses); diff != "" {
+		t.Errorf("unexpected misses (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(misses, loaded); diff != "" {
+		t.Errorf("the misses must be loaded (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadingCache_GetOrLoadMultiDetailed(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	s := memstorage.NewInMemoryStorage[uint8, string]()
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, string]{
+		{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "value1"}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 3}, ExpiresAt: expiresAt, NegativeCache: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sourceEntries := map[uint8]*loadingcache.CacheEntry[uint8, string]{
+		2: {Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "value2"}, ExpiresAt: expiresAt},
+		5: {Entry: loadingcache.Entry[uint8, string]{Key: 5}, ExpiresAt: expiresAt, NegativeCache: true},
+	}
+	src := &source.FunctionsSource[uint8, string]{
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			entries := make([]*loadingcache.CacheEntry[uint8, string], len(keys))
+			for i, key := range keys {
+				entries[i] = sourceEntries[key]
+			}
+			return entries, nil
+		},
+	}
+	cache := &loadingcache.LoadingCache[uint8, string]{
+		Loader:  pureloader.NewPureLoader(s, src),
+		Storage: s,
+	}
+
+	// 1: found in the storage, 2: found in the source, 3: negative-cached in the storage,
+	// 4: not found anywhere, 5: negative-cached by the source
+	found, negative, err := cache.GetOrLoadMultiDetailed(t.Context(), []uint8{5, 4, 3, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.Entry[uint8, string]{
+		nil,
+		nil,
+		nil,
+		{Key: 2, Value: "value2"},
+		{Key: 1, Value: "value1"},
+	}, found); diff != "" {
+		t.Errorf("unexpected found entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint8{5, 3}, negative); diff != "" {
+		t.Errorf("unexpected negative keys (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadingCache_Peek(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	s := memstorage.NewInMemoryStorage[uint8, string]()
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, string]{
+		{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "value1"}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "value2"}, ExpiresAt: time.Now().Add(-time.Hour)},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 3}, ExpiresAt: expiresAt, NegativeCache: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src := &source.FunctionsSource[uint8, string]{
+		GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			t.Error("Peek must not load")
+			return nil, nil
+		},
+		GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			t.Error("Peek must not load")
+			return nil, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		negativeAsHit bool
+		key           uint8
+		wantEntry     *loadingcache.Entry[uint8, string]
+		wantOK        bool
+	}{
+		{name: "Hit", key: 1, wantEntry: &loadingcache.Entry[uint8, string]{Key: 1, Value: "value1"}, wantOK: true},
+		{name: "Expired", key: 2},
+		{name: "Miss", key: 4},
+		{name: "NegativeCache", key: 3},
+		{name: "NegativeCacheAsHit", negativeAsHit: true, key: 3, wantOK: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := &loadingcache.LoadingCache[uint8, string]{
+				Loader:                 pureloader.NewPureLoader(s, src),
+				Storage:                s,
+				PeekNegativeCacheAsHit: tt.negativeAsHit,
+			}
+			entry, ok, err := cache.Peek(t.Context(), tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("unexpected ok: %v", ok)
+			}
+			if diff := cmp.Diff(tt.wantEntry, entry); diff != "" {
+				t.Errorf("unexpected entry (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("storage error")
+		cache := &loadingcache.LoadingCache[uint8, string]{
+			Loader: pureloader.NewPureLoader(s, src),
+			Storage: &storage.FunctionsStorage[uint8, string]{
+				GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					return nil, wantErr
+				},
+			},
+		}
+		if _, ok, err := cache.Peek(t.Context(), 1); !errors.Is(err, wantErr) || ok {
+			t.Errorf("unexpected result: ok=%v, err=%v", ok, err)
+		}
+	})
+}
+
+func TestLoadingCache_LoadWithRefresh(t *testing.T) {
+	t.Parallel()
+
+	newCache := func(t *testing.T, getFunc func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error)) *loadingcache.LoadingCache[uint8, string] {
+		s := memstorage.NewInMemoryStorage[uint8, string]()
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, string]{
+			Entry:     loadingcache.Entry[uint8, string]{Key: 1, Value: "cached"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return &loadingcache.LoadingCache[uint8, string]{
+			Loader:  pureloader.NewPureLoader(s, &source.FunctionsSource[uint8, string]{GetFunc: getFunc}),
+			Storage: s,
+		}
+	}
+
+	t.Run("Refreshed", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		cache := newCache(t, func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			<-release
+			return &loadingcache.CacheEntry[uint8, string]{
+				Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: "fresh"},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		})
+
+		fresh := make(chan *loadingcache.Entry[uint8, string], 2)
+		entry, err := cache.LoadWithRefresh(t.Context(), 1, func(e *loadingcache.Entry[uint8, string]) {
+			fresh <- e
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the cached value is returned before the reload completes
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "cached"}, entry); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+		close(release)
+
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "fresh"}, <-fresh); diff != "" {
+			t.Errorf("unexpected fresh entry (-want +got):\n%s", diff)
+		}
+		select {
+		case e := <-fresh:
+			t.Errorf("onFresh must be called once, but called again with %+v", e)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		stored, err := cache.Storage.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Value != "fresh" {
+			t.Errorf("the reloaded value must be stored, got %q", stored.Value)
+		}
+	})
+
+	t.Run("Miss", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCache(t, func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			return nil, nil
+		})
+
+		fresh := make(chan *loadingcache.Entry[uint8, string], 1)
+		entry, err := cache.LoadWithRefresh(t.Context(), 2, func(e *loadingcache.Entry[uint8, string]) {
+			fresh <- e
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry != nil {
+			t.Errorf("expected nil, got %+v", entry)
+		}
+		if e := <-fresh; e != nil {
+			t.Errorf("expected nil, got %+v", e)
+		}
+	})
+
+	t.Run("RefreshError", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("source error")
+		cache := newCache(t, func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			return nil, wantErr
+		})
+		errs := make(chan error, 1)
+		cache.OnRefreshError = func(key uint8, err error) {
+			errs <- err
+		}
+
+		entry, err := cache.LoadWithRefresh(t.Context(), 1, func(e *loadingcache.Entry[uint8, string]) {
+			t.Errorf("onFresh must not be called on error, got %+v", e)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil || entry.Value != "cached" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+		if err := <-errs; !errors.Is(err, wantErr) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(memstorage.WithStaleReads[uint8, string]())
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, string]{
+			Entry:     loadingcache.Entry[uint8, string]{Key: 1, Value: "stale"},
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		release := make(chan struct{})
+		cache := &loadingcache.LoadingCache[uint8, string]{
+			Loader: pureloader.NewPureLoader(s, &source.FunctionsSource[uint8, string]{
+				GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					<-release
+					return &loadingcache.CacheEntry[uint8, string]{
+						Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: "fresh"},
+						ExpiresAt: time.Now().Add(time.Hour),
+					}, nil
+				},
+			}),
+			Storage: s,
+		}
+
+		fresh := make(chan *loadingcache.Entry[uint8, string], 1)
+		entry, err := cache.LoadWithRefresh(t.Context(), 1, func(e *loadingcache.Entry[uint8, string]) {
+			fresh <- e
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the expired value is returned by GetStale while it is refreshed
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "stale"}, entry); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+		close(release)
+
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "fresh"}, <-fresh); diff != "" {
+			t.Errorf("unexpected fresh entry (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NilOnFresh", func(t *testing.T) {
+		t.Parallel()
+
+		loaded := make(chan struct{})
+		cache := newCache(t, func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			defer close(loaded)
+			return &loadingcache.CacheEntry[uint8, string]{
+				Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: "fresh"},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		})
+		errs := make(chan error, 1)
+		cache.OnRefreshError = func(key uint8, err error) {
+			errs <- err
+		}
+
+		entry, err := cache.LoadWithRefresh(t.Context(), 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil || entry.Value != "cached" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+		<-loaded
+		select {
+		case err := <-errs:
+			t.Errorf("unexpected refresh error: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCache(t, func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			panic("source panic")
+		})
+		errs := make(chan error, 1)
+		cache.OnRefreshError = func(key uint8, err error) {
+			errs <- err
+		}
+
+		if _, err := cache.LoadWithRefresh(t.Context(), 1, func(e *loadingcache.Entry[uint8, string]) {
+			t.Errorf("onFresh must not be called on panic, got %+v", e)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "source panic") {
+			t.Errorf("expected the recovered panic, got %v", err)
+		}
+	})
+}
+
+func TestLoadingCache_GetOrLoadMultiSorted(t *testing.T) {
+	t.Parallel()
+
+	values := map[uint8]string{1: "banana", 2: "apple", 4: "cherry", 5: "apple"}
+	src := &source.FunctionsSource[uint8, string]{
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			entries := make([]*loadingcache.CacheEntry[uint8, string], len(keys))
+			for i, key := range keys {
+				if v, ok := values[key]; ok {
+					entries[i] = &loadingcache.CacheEntry[uint8, string]{
+						Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: v},
+						ExpiresAt: time.Now().Add(time.Hour),
+					}
+				}
+			}
+			return entries, nil
+		},
+	}
+	byValue := func(a, b *loadingcache.Entry[uint8, string]) bool {
+		return a.Value < b.Value
+	}
+
+	for _, tt := range []struct {
+		name         string
+		keepsMissing bool
+		want         []*loadingcache.Entry[uint8, string]
+	}{
+		{
+			name: "ExcludeMissing",
+			want: []*loadingcache.Entry[uint8, string]{
+				{Key: 5, Value: "apple"},
+				{Key: 2, Value: "apple"},
+				{Key: 1, Value: "banana"},
+				{Key: 4, Value: "cherry"},
+			},
+		},
+		{
+			name:         "KeepMissing",
+			keepsMissing: true,
+			want: []*loadingcache.Entry[uint8, string]{
+				{Key: 5, Value: "apple"},
+				{Key: 2, Value: "apple"},
+				{Key: 1, Value: "banana"},
+				{Key: 4, Value: "cherry"},
+				nil,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage[uint8, string]()
+			cache := &loadingcache.LoadingCache[uint8, string]{
+				Loader:             pureloader.NewPureLoader(s, src),
+				Storage:            s,
+				SortedKeepsMissing: tt.keepsMissing,
+			}
+			entries, err := cache.GetOrLoadMultiSorted(t.Context(), []uint8{4, 5, 3, 1, 2}, byValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, entries); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadingCache_IgnoreStorageGetErrors(t *testing.T) {
+	t.Parallel()
+
+	storageErr := errors.New("storage error")
+	expiresAt := time.Now().Add(time.Hour)
+	newStorage := func() *storage.FunctionsStorage[uint8, string] {
+		return &storage.FunctionsStorage[uint8, string]{
+			GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+				return nil, storageErr
+			},
+			GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+				return nil, storageErr
+			},
+			SetFunc: func(context.Context, *loadingcache.CacheEntry[uint8, string]) error {
+				return nil
+			},
+			SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[uint8, string]) error {
+				return nil
+			},
+		}
+	}
+	src := &source.FunctionsSource[uint8, string]{
+		GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			return &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key, Value: "loaded"}, ExpiresAt: expiresAt}, nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			entries := make([]*loadingcache.CacheEntry[uint8, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key, Value: "loaded"}, ExpiresAt: expiresAt}
+			}
+			return entries, nil
+		},
+	}
+	newCache := func(ignore bool, reported *[][]uint8) *loadingcache.LoadingCache[uint8, string] {
+		s := newStorage()
+		return &loadingcache.LoadingCache[uint8, string]{
+			Loader:                 pureloader.NewPureLoader(s, src),
+			Storage:                s,
+			IgnoreStorageGetErrors: ignore,
+			OnStorageGetError: func(keys []uint8, err error) {
+				if !errors.Is(err, storageErr) {
+					t.Errorf("expected %v, got %v", storageErr, err)
+				}
+				*reported = append(*reported, keys)
+			},
+		}
+	}
+
+	t.Run("Ignored", func(t *testing.T) {
+		t.Parallel()
+
+		var reported [][]uint8
+		cache := newCache(true, &reported)
+
+		entry, err := cache.GetOrLoad(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "loaded"}, entry); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+
+		entries, err := cache.GetOrLoadMulti(t.Context(), []uint8{2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.Entry[uint8, string]{{Key: 2, Value: "loaded"}, {Key: 3, Value: "loaded"}}
+		if diff := cmp.Diff(want, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+
+		cacheEntries, err := cache.GetOrLoadMultiCacheEntries(t.Context(), []uint8{4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantCacheEntries := []*loadingcache.CacheEntry[uint8, string]{{Entry: loadingcache.Entry[uint8, string]{Key: 4, Value: "loaded"}, ExpiresAt: expiresAt}}
+		if diff := cmp.Diff(wantCacheEntries, cacheEntries); diff != "" {
+			t.Errorf("unexpected cache entries (-want +got):\n%s", diff)
+		}
+
+		if diff := cmp.Diff([][]uint8{{1}, {2, 3}, {4}}, reported); diff != "" {
+			t.Errorf("unexpected reported errors (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("IgnoredWithoutCacheEntrySourceLoader", func(t *testing.T) {
+		t.Parallel()
+
+		// the entries loaded by the loader without the CacheEntrySourceLoader are built from the loaded values,
+		// so they are returned without their expiration times even if the storage fails
+		var reported [][]uint8
+		s := newStorage()
+		cache := &loadingcache.LoadingCache[uint8, string]{
+			Loader: struct {
+				loadingcache.SourceLoader[uint8, string]
+			}{pureloader.NewPureLoader(s, src)},
+			Storage:                s,
+			IgnoreStorageGetErrors: true,
+			OnStorageGetError: func(keys []uint8, err error) {
+				if !errors.Is(err, storageErr) {
+					t.Errorf("expected %v, got %v", storageErr, err)
+				}
+				reported = append(reported, keys)
+			},
+		}
+
+		cacheEntries, err := cache.GetOrLoadMultiCacheEntries(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, string]{
+			{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "loaded"}},
+			{Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "loaded"}},
+		}
+		if diff := cmp.Diff(want, cacheEntries); diff != "" {
+			t.Errorf("unexpected cache entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([][]uint8{{1, 2}}, reported); diff != "" {
+			t.Errorf("unexpected reported errors (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NotIgnored", func(t *testing.T) {
+		t.Parallel()
+
+		var reported [][]uint8
+		cache := newCache(false, &reported)
+
+		if _, err := cache.GetOrLoad(t.Context(), 1); !errors.Is(err, storageErr) {
+			t.Errorf("expected %v, got %v", storageErr, err)
+		}
+		if _, err := cache.GetOrLoadMulti(t.Context(), []uint8{2, 3}); !errors.Is(err, storageErr) {
+			t.Errorf("expected %v, got %v", storageErr, err)
+		}
+		if len(reported) != 0 {
+			t.Errorf("expected no reported errors, got %v", reported)
+		}
+	})
+}
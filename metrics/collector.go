@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader"
+)
+
+// DefaultNamespace is the default prefix of the metric names.
+const DefaultNamespace = "loadingcache"
+
+// DefaultLoadDurationBuckets is the default upper bounds in seconds of the buckets of the load duration histogram.
+var DefaultLoadDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector aggregates the metrics of a loading cache.
+// The zero value is ready to use with DefaultNamespace and DefaultLoadDurationBuckets.
+// It is safe for concurrent use, but the fields must not be modified after the first use.
+type Collector struct {
+	// Namespace is the prefix of the metric names. If empty, DefaultNamespace is used.
+	Namespace string
+
+	// LoadDurationBuckets is the upper bounds in seconds of the buckets of the load duration histogram in ascending order.
+	// If nil, DefaultLoadDurationBuckets is used.
+	LoadDurationBuckets []float64
+
+	mu       sync.Mutex
+	snapshot Snapshot
+}
+
+// Snapshot is the values of the metrics aggregated by a Collector at a point in time.
+type Snapshot struct {
+	// Hits is the number of the keys found in the storage, including the negative caches.
+	Hits uint64
+
+	// Misses is the number of the keys not found in the storage.
+	Misses uint64
+
+	// Loads is the number of the calls of the loader.
+	Loads uint64
+
+	// LoadErrors is the number of the calls of the loader that failed.
+	LoadErrors uint64
+
+	// LoadedKeys is the number of the keys requested to the loader.
+	LoadedKeys uint64
+
+	// Evictions is the number of the entries evicted from the storage.
+	Evictions uint64
+
+	// LoadDuration is the histogram of the durations of the calls of the loader.
+	LoadDuration Histogram
+}
+
+// Histogram is the values of a histogram.
+type Histogram struct {
+	// Buckets is the upper bounds of the buckets in ascending order.
+	Buckets []float64
+
+	// Counts is the cumulative counts of the observations less than or equal to the upper bound of each bucket.
+	Counts []uint64
+
+	// Count is the total number of the observations.
+	Count uint64
+
+	// Sum is the sum of the observed values.
+	Sum float64
+}
+
+// RecordHits adds n to the number of the hits.
+func (c *Collector) RecordHits(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot.Hits += uint64(n)
+}
+
+// RecordMisses adds n to the number of the misses.
+func (c *Collector) RecordMisses(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot.Misses += uint64(n)
+}
+
+// RecordEvictions adds n to the number of the evictions.
+// It can be set to memstorage.WithEvictionObserver to count the entries evicted by memstorage.WithMaxEntries.
+func (c *Collector) RecordEvictions(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot.Evictions += uint64(n)
+}
+
+// ObserveLoad records the statistics of a call of the loader.
+// It can be used as the OnLoadAndStore and OnLoadAndStoreMulti hooks of loader.MetricsLoader.
+func (c *Collector) ObserveLoad(stats loader.LoadStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.snapshot.Loads++
+	c.snapshot.LoadedKeys += uint64(stats.Keys)
+	if stats.Err != nil {
+		c.snapshot.LoadErrors++
+	}
+
+	h := &c.snapshot.LoadDuration
+	if h.Buckets == nil {
+		*h = c.emptyHistogram()
+	}
+	seconds := stats.Duration.Seconds()
+	for i, upper := range h.Buckets {
+		if seconds <= upper {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
+}
+
+// emptyHistogram returns the load duration histogram with no observations.
+func (c *Collector) emptyHistogram() Histogram {
+	buckets := c.LoadDurationBuckets
+	if buckets == nil {
+		buckets = DefaultLoadDurationBuckets
+	}
+	return Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+// Snapshot returns the current values of the metrics.
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := c.snapshot
+	snapshot.LoadDuration.Counts = slices.Clone(snapshot.LoadDuration.Counts)
+	return snapshot
+}
+
+// OnSplit returns the function to record the hits and misses to the collector,
+// to be set to loadingcache.LoadingCache.OnSplit.
+func OnSplit[K loadingcache.KeyConstraint](c *Collector) func(hits, misses []K) {
+	return func(hits, misses []K) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.snapshot.Hits += uint64(len(hits))
+		c.snapshot.Misses += uint64(len(misses))
+	}
+}
+
+// WrapLoader returns the loader.MetricsLoader that records the calls of the given loader to the collector.
+func WrapLoader[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](c *Collector, l loadingcache.SourceLoader[K, V]) *loader.MetricsLoader[K, V] {
+	return &loader.MetricsLoader[K, V]{
+		Loader:              l,
+		OnLoadAndStore:      c.ObserveLoad,
+		OnLoadAndStoreMulti: c.ObserveLoad,
+	}
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WriteText(w io.Writer) error {
+	snapshot := c.Snapshot()
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	bw := bufio.NewWriter(w)
+	writeCounter := func(name, help string, value uint64) {
+		name = namespace + "_" + name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+	writeCounter("hits_total", "The number of the keys found in the storage.", snapshot.Hits)
+	writeCounter("misses_total", "The number of the keys not found in the storage.", snapshot.Misses)
+	writeCounter("loads_total", "The number of the calls of the loader.", snapshot.Loads)
+	writeCounter("load_errors_total", "The number of the failed calls of the loader.", snapshot.LoadErrors)
+	writeCounter("loaded_keys_total", "The number of the keys requested to the loader.", snapshot.LoadedKeys)
+	writeCounter("evictions_total", "The number of the entries evicted from the storage.", snapshot.Evictions)
+
+	h := snapshot.LoadDuration
+	if h.Buckets == nil {
+		h = c.emptyHistogram()
+	}
+	name := namespace + "_load_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s The durations of the calls of the loader.\n# TYPE %s histogram\n", name, name)
+	for i, upper := range h.Buckets {
+		fmt.Fprintf(bw, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(upper, 'g', -1, 64), h.Counts[i])
+	}
+	fmt.Fprintf(bw, "%s_bucket{le=\"+Inf\"} %d\n", name, h.Count)
+	fmt.Fprintf(bw, "%s_sum %s\n", name, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+	fmt.Fprintf(bw, "%s_count %d\n", name, h.Count)
+	return bw.Flush()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format as the response.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.WriteText(w)
+}
+
+var _ http.Handler = (*Collector)(nil)
@@ -0,0 +1,146 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader"
+	"github.com/karupanerura/loading-cache/loader/pureloader"
+	"github.com/karupanerura/loading-cache/metrics"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	c := &metrics.Collector{Namespace: "test", LoadDurationBuckets: []float64{0.01, 0.1}}
+	loadErr := errors.New("load error")
+	c.ObserveLoad(loader.LoadStats{Keys: 1, Duration: 5 * time.Millisecond})
+	c.ObserveLoad(loader.LoadStats{Keys: 3, Duration: 50 * time.Millisecond})
+	c.ObserveLoad(loader.LoadStats{Keys: 2, Duration: time.Second, Err: loadErr})
+	c.RecordHits(4)
+	c.RecordMisses(2)
+	c.RecordEvictions(1)
+	metrics.OnSplit[uint8](c)([]uint8{1}, []uint8{2, 3})
+
+	want := metrics.Snapshot{
+		Hits:       5,
+		Misses:     4,
+		Loads:      3,
+		LoadErrors: 1,
+		LoadedKeys: 6,
+		Evictions:  1,
+		LoadDuration: metrics.Histogram{
+			Buckets: []float64{0.01, 0.1},
+			Counts:  []uint64{1, 2},
+			Count:   3,
+			Sum:     1.055,
+		},
+	}
+	if diff := cmp.Diff(want, c.Snapshot()); diff != "" {
+		t.Errorf("unexpected snapshot (-want +got):\n%s", diff)
+	}
+
+	var sb strings.Builder
+	if err := c.WriteText(&sb); err != nil {
+		t.Fatal(err)
+	}
+	wantText := `# HELP test_hits_total The number of the keys found in the storage.
+# TYPE test_hits_total counter
+test_hits_total 5
+# HELP test_misses_total The number of the keys not found in the storage.
+# TYPE test_misses_total counter
+test_misses_total 4
+# HELP test_loads_total The number of the calls of the loader.
+# TYPE test_loads_total counter
+test_loads_total 3
+# HELP test_load_errors_total The number of the failed calls of the loader.
+# TYPE test_load_errors_total counter
+test_load_errors_total 1
+# HELP test_loaded_keys_total The number of the keys requested to the loader.
+# TYPE test_loaded_keys_total counter
+test_loaded_keys_total 6
+# HELP test_evictions_total The number of the entries evicted from the storage.
+# TYPE test_evictions_total counter
+test_evictions_total 1
+# HELP test_load_duration_seconds The durations of the calls of the loader.
+# TYPE test_load_duration_seconds histogram
+test_load_duration_seconds_bucket{le="0.01"} 1
+test_load_duration_seconds_bucket{le="0.1"} 2
+test_load_duration_seconds_bucket{le="+Inf"} 3
+test_load_duration_seconds_sum 1.055
+test_load_duration_seconds_count 3
+`
+	if diff := cmp.Diff(wantText, sb.String()); diff != "" {
+		t.Errorf("unexpected text (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollector_LoadingCache(t *testing.T) {
+	t.Parallel()
+
+	s := memstorage.NewInMemoryStorage[uint8, string]()
+	src := &source.FunctionsSource[uint8, string]{
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			entries := make([]*loadingcache.CacheEntry[uint8, string], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key, Value: "value"}, ExpiresAt: time.Now().Add(time.Hour)}
+			}
+			return entries, nil
+		},
+	}
+
+	var c metrics.Collector
+	cache := &loadingcache.LoadingCache[uint8, string]{
+		Loader:  metrics.WrapLoader(&c, pureloader.NewPureLoader(s, src)),
+		Storage: s,
+		OnSplit: metrics.OnSplit[uint8](&c),
+	}
+	for range 2 {
+		if _, err := cache.GetOrLoadMulti(t.Context(), []uint8{1, 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := c.Snapshot()
+	if snapshot.Hits != 2 || snapshot.Misses != 2 || snapshot.Loads != 1 || snapshot.LoadedKeys != 2 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if diff := cmp.Diff(metrics.DefaultLoadDurationBuckets, snapshot.LoadDuration.Buckets); diff != "" {
+		t.Errorf("unexpected buckets (-want +got):\n%s", diff)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Body.String(); !strings.Contains(got, "loadingcache_hits_total 2\n") || !strings.Contains(got, "loadingcache_load_duration_seconds_count 1\n") {
+		t.Errorf("unexpected response:\n%s", got)
+	}
+}
+
+func TestCollector_Evictions(t *testing.T) {
+	t.Parallel()
+
+	var c metrics.Collector
+	s := memstorage.NewInMemoryStorage(
+		memstorage.WithBucketsSize[uint8, string](1),
+		memstorage.WithMaxEntries[uint8, string](1),
+		memstorage.WithEvictionObserver[uint8, string](c.RecordEvictions),
+	)
+	for key := range uint8(3) {
+		entry := &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key, Value: "value"}, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := s.Set(t.Context(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := c.Snapshot().Evictions; got != 2 {
+		t.Errorf("unexpected evictions: %d", got)
+	}
+}
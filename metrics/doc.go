@@ -0,0 +1,9 @@
+// Package metrics provides a Collector that aggregates the cache metrics from the observer hooks of the loading cache,
+// such as loadingcache.LoadingCache.OnSplit and loader.MetricsLoader, and exposes them in the Prometheus text format.
+//
+// The Collector writes the text exposition format by itself, and it can be served as an http.Handler for scraping.
+// With the prometheus build tag, the Collector also implements prometheus.Collector of the Prometheus client library,
+// so it can be registered to a prometheus.Registerer instead.
+//
+// The evictions are reported by setting Collector.RecordEvictions to memstorage.WithEvictionObserver.
+package metrics
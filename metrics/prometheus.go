@@ -0,0 +1,67 @@
+//go:build prometheus
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// prometheusDescs is the descriptions of the metrics collected by a Collector.
+type prometheusDescs struct {
+	hits, misses, loads, loadErrors, loadedKeys, evictions, loadDuration *prometheus.Desc
+}
+
+// prometheusDescs returns the descriptions of the metrics with the namespace of the collector.
+// The names and the help texts are the same as the ones written by WriteText.
+func (c *Collector) prometheusDescs() prometheusDescs {
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+	}
+	return prometheusDescs{
+		hits:         desc("hits_total", "The number of the keys found in the storage."),
+		misses:       desc("misses_total", "The number of the keys not found in the storage."),
+		loads:        desc("loads_total", "The number of the calls of the loader."),
+		loadErrors:   desc("load_errors_total", "The number of the failed calls of the loader."),
+		loadedKeys:   desc("loaded_keys_total", "The number of the keys requested to the loader."),
+		evictions:    desc("evictions_total", "The number of the entries evicted from the storage."),
+		loadDuration: desc("load_duration_seconds", "The durations of the calls of the loader."),
+	}
+}
+
+// Describe sends the descriptions of the metrics to the channel.
+// It implements prometheus.Collector, so the collector can be registered to a prometheus.Registerer.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	descs := c.prometheusDescs()
+	for _, desc := range []*prometheus.Desc{descs.hits, descs.misses, descs.loads, descs.loadErrors, descs.loadedKeys, descs.evictions, descs.loadDuration} {
+		ch <- desc
+	}
+}
+
+// Collect sends the current values of the metrics to the channel.
+// It implements prometheus.Collector, so the collector can be registered to a prometheus.Registerer.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.Snapshot()
+	descs := c.prometheusDescs()
+	ch <- prometheus.MustNewConstMetric(descs.hits, prometheus.CounterValue, float64(snapshot.Hits))
+	ch <- prometheus.MustNewConstMetric(descs.misses, prometheus.CounterValue, float64(snapshot.Misses))
+	ch <- prometheus.MustNewConstMetric(descs.loads, prometheus.CounterValue, float64(snapshot.Loads))
+	ch <- prometheus.MustNewConstMetric(descs.loadErrors, prometheus.CounterValue, float64(snapshot.LoadErrors))
+	ch <- prometheus.MustNewConstMetric(descs.loadedKeys, prometheus.CounterValue, float64(snapshot.LoadedKeys))
+	ch <- prometheus.MustNewConstMetric(descs.evictions, prometheus.CounterValue, float64(snapshot.Evictions))
+
+	h := snapshot.LoadDuration
+	if h.Buckets == nil {
+		h = c.emptyHistogram()
+	}
+	buckets := make(map[float64]uint64, len(h.Buckets))
+	for i, upper := range h.Buckets {
+		buckets[upper] = h.Counts[i]
+	}
+	ch <- prometheus.MustNewConstHistogram(descs.loadDuration, h.Count, h.Sum, buckets)
+}
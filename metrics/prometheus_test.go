@@ -0,0 +1,38 @@
+//go:build prometheus
+
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karupanerura/loading-cache/loader"
+	"github.com/karupanerura/loading-cache/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_Prometheus(t *testing.T) {
+	t.Parallel()
+
+	c := &metrics.Collector{Namespace: "test", LoadDurationBuckets: []float64{0.01, 0.1}}
+	c.ObserveLoad(loader.LoadStats{Keys: 1, Duration: 5 * time.Millisecond})
+	c.ObserveLoad(loader.LoadStats{Keys: 3, Duration: 50 * time.Millisecond})
+	c.RecordHits(4)
+	c.RecordMisses(2)
+	c.RecordEvictions(1)
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	var want strings.Builder
+	if err := c.WriteText(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want.String())); err != nil {
+		t.Error(err)
+	}
+}
@@ -74,6 +74,7 @@ type FunctionsSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstrain
 	// It returns a slice of CacheEntry and an error if any.
 	// Must return results for all keys in the same order as the input keys.
 	// If a key is not found, it should return nil as *CacheEntry.
+	// If it is nil, GetMulti calls GetFunc for each key sequentially.
 	GetMultiFunc func(context.Context, []K) ([]*loadingcache.CacheEntry[K, V], error)
 }
 
@@ -85,7 +86,11 @@ func (s *FunctionsSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.C
 }
 
 // GetMulti calls the GetMultiFunc function to load multiple entries from the source.
+// If GetMultiFunc is nil, it calls the GetFunc function for each key sequentially.
 func (s *FunctionsSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	if s.GetMultiFunc == nil {
+		return getMultiByGet(ctx, s.GetFunc, keys, 1)
+	}
 	return s.GetMultiFunc(ctx, keys)
 }
 
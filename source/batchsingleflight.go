@@ -0,0 +1,153 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"slices"
+	"sync"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/internal/keyhash"
+)
+
+var errBatchAborted = errors.New("the batch call is aborted")
+
+var batchSeed = maphash.MakeSeed()
+
+// BatchSingleFlightSource is a loading source that coalesces the identical in-flight GetMulti batches.
+// When GetMulti is called with the same set of keys as an in-flight call, regardless of the order of the keys,
+// it waits for the in-flight call and shares its results instead of calling the source again.
+// Get is passed through to the source.
+//
+// Each in-flight batch holds a set of its keys to identify the identical batches,
+// so the memory cost is proportional to the total number of the keys in the in-flight batches.
+//
+// The in-flight call runs with the context of the first caller, so the cancellation of the first caller
+// fails the other callers of the same batch as well.
+type BatchSingleFlightSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// Cloner is an optional value cloner to clone the shared values for the callers other than the first one.
+	// If it is nil, the values are shared among the callers of the same batch.
+	Cloner loadingcache.ValueCloner[V]
+
+	mu    sync.Mutex
+	calls map[uint64][]*batchCall[K, V]
+}
+
+type batchCall[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	keys    []K
+	counts  map[K]int
+	done    chan struct{}
+	entries []*loadingcache.CacheEntry[K, V]
+	err     error
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*BatchSingleFlightSource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source.
+func (s *BatchSingleFlightSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.Source.Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries from the source.
+// If an identical batch is in flight, it waits for the batch and returns its results in the order of the given keys.
+func (s *BatchSingleFlightSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	h, counts := batchKey(keys)
+
+	s.mu.Lock()
+	for _, call := range s.calls[h] {
+		if !sameBatch(call.counts, counts) {
+			continue
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		return s.shareEntries(call, keys), nil
+	}
+
+	call := &batchCall[K, V]{keys: keys, counts: counts, done: make(chan struct{}), err: errBatchAborted}
+	if s.calls == nil {
+		s.calls = map[uint64][]*batchCall[K, V]{}
+	}
+	s.calls[h] = append(s.calls[h], call)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.calls[h] = slices.DeleteFunc(s.calls[h], func(c *batchCall[K, V]) bool {
+			return c == call
+		})
+		if len(s.calls[h]) == 0 {
+			delete(s.calls, h)
+		}
+		s.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.entries, call.err = s.Source.GetMulti(ctx, keys)
+	return call.entries, call.err
+}
+
+// shareEntries returns the results of the call in the order of the given keys.
+func (s *BatchSingleFlightSource[K, V]) shareEntries(call *batchCall[K, V], keys []K) []*loadingcache.CacheEntry[K, V] {
+	m := make(map[K]*loadingcache.CacheEntry[K, V], len(call.keys))
+	for i, key := range call.keys {
+		m[key] = call.entries[i]
+	}
+
+	entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	for i, key := range keys {
+		entry := m[key]
+		if entry == nil {
+			continue
+		}
+
+		shared := *entry
+		if s.Cloner != nil && !entry.NegativeCache {
+			shared.Value = s.Cloner.CloneValue(shared.Value)
+		}
+		entries[i] = &shared
+	}
+	return entries
+}
+
+// batchKey returns the order-insensitive hash of the keys and the set of the keys with their counts.
+func batchKey[K loadingcache.KeyConstraint](keys []K) (uint64, map[K]int) {
+	hashKey := keyhash.GetOrCreateKeyHash[K]()
+	hashes := make([]int, len(keys))
+	counts := make(map[K]int, len(keys))
+	for i, key := range keys {
+		hashes[i] = hashKey(key)
+		counts[key]++
+	}
+	slices.Sort(hashes)
+
+	var h maphash.Hash
+	h.SetSeed(batchSeed)
+	for _, v := range hashes {
+		maphash.WriteComparable(&h, v)
+	}
+	return h.Sum64(), counts
+}
+
+// sameBatch reports whether the two sets of the keys are identical.
+func sameBatch[K loadingcache.KeyConstraint](a, b map[K]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, count := range a {
+		if b[key] != count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func TestBatchSingleFlightSource_Parallel(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	s := &source.BatchSingleFlightSource[uint8, string]{
+		Source: &source.FunctionsSource[uint8, string]{
+			GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+				calls.Add(1)
+				<-release
+
+				entries := make([]*loadingcache.CacheEntry[uint8, string], len(keys))
+				for i, key := range keys {
+					if key != 3 {
+						entries[i] = &loadingcache.CacheEntry[uint8, string]{
+							Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: string(rune('a' + key))},
+							ExpiresAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+						}
+					}
+				}
+				return entries, nil
+			},
+		},
+	}
+
+	const numGoroutines = 10
+	results := make([][]*loadingcache.CacheEntry[uint8, string], numGoroutines)
+	keys := make([][]uint8, numGoroutines)
+	var wg sync.WaitGroup
+	for i := range numGoroutines {
+		keys[i] = []uint8{1, 2, 3}
+		if i%2 != 0 {
+			// the order of the keys does not matter
+			slices.Reverse(keys[i])
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var err error
+			results[i], err = (&source.LintSource[uint8, string]{Source: s}).GetMulti(t.Context(), keys[i])
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected the source to be called once, but called %d times", got)
+	}
+	for i, entries := range results {
+		expected := make([]*loadingcache.CacheEntry[uint8, string], len(keys[i]))
+		for j, key := range keys[i] {
+			if key != 3 {
+				expected[j] = &loadingcache.CacheEntry[uint8, string]{
+					Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: string(rune('a' + key))},
+					ExpiresAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				}
+			}
+		}
+		if diff := cmp.Diff(expected, entries); diff != "" {
+			t.Errorf("results[%d]: unexpected entries (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestBatchSingleFlightSource_DifferentBatches(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	sourceErr := errors.New("source error")
+	s := &source.BatchSingleFlightSource[uint8, string]{
+		Source: &source.FunctionsSource[uint8, string]{
+			GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return nil, sourceErr
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for _, keys := range [][]uint8{{1, 2}, {1, 2, 2}, {1, 3}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.GetMulti(t.Context(), keys); !errors.Is(err, sourceErr) {
+				t.Errorf("expected %v, got %v", sourceErr, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected the different batches not to be coalesced, but the source is called %d times", got)
+	}
+}
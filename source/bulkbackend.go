@@ -0,0 +1,128 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// BulkBackendOption is the interface for the options of BulkBackendSource.
+type BulkBackendOption interface {
+	apply(*bulkBackendOptions)
+}
+
+type bulkBackendOptionFunc func(*bulkBackendOptions)
+
+func (f bulkBackendOptionFunc) apply(o *bulkBackendOptions) {
+	f(o)
+}
+
+// WithBatchSize sets the maximum number of the keys passed to the fetch function at once.
+// The keys of GetMulti are split into the batches of the size, and the batches are fetched sequentially.
+// The size must be a natural number. The default is no limit.
+func WithBatchSize(size int) BulkBackendOption {
+	if size <= 0 {
+		panic("size must be natural number")
+	}
+	return bulkBackendOptionFunc(func(o *bulkBackendOptions) {
+		o.batchSize = size
+	})
+}
+
+type bulkBackendOptions struct {
+	batchSize int
+}
+
+// BulkBackendSource creates a FunctionsSource from a function that fetches the values of the keys in bulk,
+// such as a database query that omits the missing rows.
+// The fetch function returns the values of the found keys, and the keys missing from the result are considered not found.
+//
+// The found values expire after ttl, and the missing keys are turned into the negative caches expiring after negativeTTL.
+// If negativeTTL is zero or negative, the missing keys are returned as nil instead.
+// The results of GetMulti are in the same order as the input keys, and the duplicated keys are fetched only once.
+// If clock is nil, loadingcache.SystemClock is used.
+func BulkBackendSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](fetch func(context.Context, []K) (map[K]V, error), clock loadingcache.Clock, ttl, negativeTTL time.Duration, opts ...BulkBackendOption) *FunctionsSource[K, V] {
+	var options bulkBackendOptions
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if clock == nil {
+		clock = loadingcache.SystemClock
+	}
+
+	getMulti := func(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+		values, err := fetchInBatches(ctx, fetch, uniqueKeys(keys), options.batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		now := clock.Now()
+		entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+		for i, key := range keys {
+			if value, ok := values[key]; ok {
+				entries[i] = &loadingcache.CacheEntry[K, V]{
+					Entry:     loadingcache.Entry[K, V]{Key: key, Value: value},
+					ExpiresAt: now.Add(ttl),
+				}
+			} else if negativeTTL > 0 {
+				entries[i] = &loadingcache.CacheEntry[K, V]{
+					Entry:         loadingcache.Entry[K, V]{Key: key},
+					ExpiresAt:     now.Add(negativeTTL),
+					NegativeCache: true,
+				}
+			}
+		}
+		return entries, nil
+	}
+	return &FunctionsSource[K, V]{
+		GetFunc: func(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+			entries, err := getMulti(ctx, []K{key})
+			if err != nil {
+				return nil, err
+			}
+			return entries[0], nil
+		},
+		GetMultiFunc: getMulti,
+	}
+}
+
+// uniqueKeys returns the keys without duplicates in the order of their first appearance.
+// It returns the keys as they are if there are no duplicates.
+func uniqueKeys[K loadingcache.KeyConstraint](keys []K) []K {
+	seen := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		seen[key] = struct{}{}
+	}
+	if len(seen) == len(keys) {
+		return keys
+	}
+
+	unique := make([]K, 0, len(seen))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			delete(seen, key)
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// fetchInBatches calls the fetch function for each batch of the keys, and merges the results.
+func fetchInBatches[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ctx context.Context, fetch func(context.Context, []K) (map[K]V, error), keys []K, batchSize int) (map[K]V, error) {
+	if batchSize <= 0 || len(keys) <= batchSize {
+		return fetch(ctx, keys)
+	}
+
+	values := make(map[K]V, len(keys))
+	for start := 0; start < len(keys); start += batchSize {
+		batch, err := fetch(ctx, keys[start:min(start+batchSize, len(keys))])
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range batch {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,120 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func TestBulkBackendSource(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := loadingcache.ClockFunc(func() time.Time { return now })
+	fetchErr := errors.New("fetch error")
+	newFetch := func(calls *[][]uint8) func(context.Context, []uint8) (map[uint8]string, error) {
+		return func(_ context.Context, keys []uint8) (map[uint8]string, error) {
+			*calls = append(*calls, slices.Clone(keys))
+			values := map[uint8]string{}
+			for _, key := range keys {
+				switch {
+				case key == 0:
+					return nil, fetchErr
+				case key%2 == 1:
+					// the odd keys are found, and the even keys are omitted from the result
+					values[key] = string(rune('a' + key))
+				}
+			}
+			return values, nil
+		}
+	}
+	found := func(key uint8) *loadingcache.CacheEntry[uint8, string] {
+		return &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key, Value: string(rune('a' + key))}, ExpiresAt: now.Add(time.Hour)}
+	}
+	missing := func(key uint8) *loadingcache.CacheEntry[uint8, string] {
+		return &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: key}, ExpiresAt: now.Add(time.Minute), NegativeCache: true}
+	}
+
+	t.Run("GetMulti", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := source.BulkBackendSource(newFetch(&calls), clock, time.Hour, time.Minute)
+		entries, err := s.GetMulti(t.Context(), []uint8{3, 2, 1, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, string]{found(3), missing(2), found(1), found(3)}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([][]uint8{{3, 2, 1}}, calls); diff != "" {
+			t.Errorf("unexpected fetches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := source.BulkBackendSource(newFetch(&calls), clock, time.Hour, time.Minute)
+		for key, want := range map[uint8]*loadingcache.CacheEntry[uint8, string]{1: found(1), 2: missing(2)} {
+			entry, err := s.Get(t.Context(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, entry); diff != "" {
+				t.Errorf("unexpected entry (-want +got):\n%s", diff)
+			}
+		}
+	})
+
+	t.Run("WithoutNegativeCaching", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := source.BulkBackendSource(newFetch(&calls), clock, time.Hour, 0)
+		entries, err := s.GetMulti(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, string]{found(1), nil}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("WithBatchSize", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := source.BulkBackendSource(newFetch(&calls), clock, time.Hour, time.Minute, source.WithBatchSize(2))
+		entries, err := s.GetMulti(t.Context(), []uint8{1, 2, 3, 4, 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, string]{found(1), missing(2), found(3), missing(4), found(5)}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([][]uint8{{1, 2}, {3, 4}, {5}}, calls); diff != "" {
+			t.Errorf("unexpected fetches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := source.BulkBackendSource(newFetch(&calls), clock, time.Hour, time.Minute)
+		if _, err := s.GetMulti(t.Context(), []uint8{1, 0}); !errors.Is(err, fetchErr) {
+			t.Errorf("expected %v, got %v", fetchErr, err)
+		}
+		if _, err := s.Get(t.Context(), 0); !errors.Is(err, fetchErr) {
+			t.Errorf("expected %v, got %v", fetchErr, err)
+		}
+	})
+}
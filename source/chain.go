@@ -0,0 +1,56 @@
+package source
+
+import (
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// Middleware is a function that wraps a loading source with another one.
+type Middleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] func(loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V]
+
+// Chain composes the base source with the middlewares.
+// The first middleware is the outermost one, so Chain(base, a, b) is equivalent to a(b(base)).
+// In other words, the calls pass through the middlewares in the given order before reaching the base source.
+func Chain[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](base loadingcache.LoadingSource[K, V], middlewares ...Middleware[K, V]) loadingcache.LoadingSource[K, V] {
+	source := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		source = middlewares[i](source)
+	}
+	return source
+}
+
+// LintMiddleware returns a middleware that wraps the source with LintSource.
+func LintMiddleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Middleware[K, V] {
+	return func(source loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V] {
+		return &LintSource[K, V]{Source: source}
+	}
+}
+
+// CompactMiddleware returns a middleware that wraps the source with CompactSource.
+func CompactMiddleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Middleware[K, V] {
+	return func(source loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V] {
+		return &CompactSource[K, V]{Source: source}
+	}
+}
+
+// RepairingMiddleware returns a middleware that wraps the source with RepairingSource.
+func RepairingMiddleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](defaultTTL time.Duration, clock loadingcache.Clock) Middleware[K, V] {
+	return func(source loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V] {
+		return &RepairingSource[K, V]{Source: source, DefaultTTL: defaultTTL, Clock: clock}
+	}
+}
+
+// DynamicTTLMiddleware returns a middleware that wraps the source with DynamicTTLSource.
+func DynamicTTLMiddleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ttlFor func(V) time.Duration, negativeTTL time.Duration, clock loadingcache.Clock) Middleware[K, V] {
+	return func(source loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V] {
+		return &DynamicTTLSource[K, V]{Source: source, TTLFor: ttlFor, NegativeTTL: negativeTTL, Clock: clock}
+	}
+}
+
+// NegativeCachingMiddleware returns a middleware that wraps the source with NegativeCachingSource.
+func NegativeCachingMiddleware[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ttl, jitter time.Duration, clock loadingcache.Clock) Middleware[K, V] {
+	return func(source loadingcache.LoadingSource[K, V]) loadingcache.LoadingSource[K, V] {
+		return &NegativeCachingSource[K, V]{Source: source, TTL: ttl, Jitter: jitter, Clock: clock}
+	}
+}
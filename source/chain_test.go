@@ -0,0 +1,99 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type recordingSource struct {
+	name    string
+	records *[]string
+	source  loadingcache.LoadingSource[uint8, string]
+}
+
+func (s *recordingSource) Get(ctx context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+	*s.records = append(*s.records, s.name)
+	return s.source.Get(ctx, key)
+}
+
+func (s *recordingSource) GetMulti(ctx context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+	*s.records = append(*s.records, s.name)
+	return s.source.GetMulti(ctx, keys)
+}
+
+func recordingMiddleware(name string, records *[]string) source.Middleware[uint8, string] {
+	return func(s loadingcache.LoadingSource[uint8, string]) loadingcache.LoadingSource[uint8, string] {
+		return &recordingSource{name: name, records: records, source: s}
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Order", func(t *testing.T) {
+		t.Parallel()
+
+		var records []string
+		base := source.GetMultiFunctionSource[uint8, string](func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			records = append(records, "base")
+			return make([]*loadingcache.CacheEntry[uint8, string], len(keys)), nil
+		})
+
+		s := source.Chain(base,
+			recordingMiddleware("outer", &records),
+			recordingMiddleware("inner", &records),
+		)
+		if _, err := s.GetMulti(t.Context(), []uint8{1}); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"outer", "inner", "base"}, records); diff != "" {
+			t.Errorf("unexpected order (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NoMiddlewares", func(t *testing.T) {
+		t.Parallel()
+
+		base := source.GetMultiFunctionSource[uint8, string](func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			return make([]*loadingcache.CacheEntry[uint8, string], len(keys)), nil
+		})
+		if s := source.Chain[uint8, string](base); s == nil {
+			t.Error("expected the base source")
+		}
+	})
+
+	t.Run("BuiltinMiddlewares", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+		base := source.GetMultiFunctionSource[uint8, string](func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+			// compacted and without expiration time
+			return []*loadingcache.CacheEntry[uint8, string]{
+				{Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "two"}},
+			}, nil
+		})
+
+		// the lint middleware checks the results repaired by the inner middleware
+		s := source.Chain(base,
+			source.LintMiddleware[uint8, string](),
+			source.RepairingMiddleware[uint8, string](time.Minute, &storagetest.FixedClock{Time: now}),
+		)
+		got, err := s.GetMulti(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, string]{
+			nil,
+			{Entry: loadingcache.Entry[uint8, string]{Key: 2, Value: "two"}, ExpiresAt: now.Add(time.Minute)},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+	})
+}
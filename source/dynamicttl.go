@@ -0,0 +1,77 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// DynamicTTLSource is a loading source that derives the expiration time of each entry from its value.
+// It overrides the expiration times of the entries returned by the source with now+TTLFor(value).
+type DynamicTTLSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// TTLFor returns the time-to-live for the value.
+	TTLFor func(V) time.Duration
+
+	// NegativeTTL is the time-to-live for the negative caches.
+	// If it is zero, the expiration times of the negative caches are left as they are.
+	NegativeTTL time.Duration
+
+	// Clock is the clock to calculate the expiration times.
+	// If not set, loadingcache.SystemClock is used.
+	Clock loadingcache.Clock
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*DynamicTTLSource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source and overrides its expiration time.
+func (s *DynamicTTLSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Source.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	return s.overrideExpiration(entry, s.now()), nil
+}
+
+// GetMulti retrieves multiple entries from the source and overrides their expiration times.
+func (s *DynamicTTLSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.Source.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for i, entry := range entries {
+		if entry != nil {
+			entries[i] = s.overrideExpiration(entry, now)
+		}
+	}
+	return entries, nil
+}
+
+// overrideExpiration returns the copy of the entry with the expiration time derived from its value.
+func (s *DynamicTTLSource[K, V]) overrideExpiration(entry *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	if entry.NegativeCache && s.NegativeTTL == 0 {
+		return entry
+	}
+
+	overridden := *entry
+	if entry.NegativeCache {
+		overridden.ExpiresAt = now.Add(s.NegativeTTL)
+	} else {
+		overridden.ExpiresAt = now.Add(s.TTLFor(entry.Value))
+	}
+	return &overridden
+}
+
+func (s *DynamicTTLSource[K, V]) now() time.Time {
+	if s.Clock == nil {
+		return loadingcache.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
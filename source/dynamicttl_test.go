@@ -0,0 +1,98 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type member struct {
+	Premium bool
+}
+
+func TestDynamicTTLSource(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sourceExpiresAt := now.Add(time.Second)
+	base := &source.FunctionsSource[uint8, member]{
+		GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, member], error) {
+			switch key {
+			case 1:
+				return &loadingcache.CacheEntry[uint8, member]{Entry: loadingcache.Entry[uint8, member]{Key: key, Value: member{Premium: true}}, ExpiresAt: sourceExpiresAt}, nil
+			case 2:
+				return &loadingcache.CacheEntry[uint8, member]{Entry: loadingcache.Entry[uint8, member]{Key: key, Value: member{Premium: false}}, ExpiresAt: sourceExpiresAt}, nil
+			case 3:
+				return &loadingcache.CacheEntry[uint8, member]{Entry: loadingcache.Entry[uint8, member]{Key: key}, ExpiresAt: sourceExpiresAt, NegativeCache: true}, nil
+			default:
+				return nil, nil
+			}
+		},
+	}
+	ttlFor := func(m member) time.Duration {
+		if m.Premium {
+			return time.Hour
+		}
+		return time.Minute
+	}
+
+	for _, tt := range []struct {
+		name        string
+		negativeTTL time.Duration
+		expected    []*loadingcache.CacheEntry[uint8, member]
+	}{
+		{
+			name:        "WithNegativeTTL",
+			negativeTTL: 5 * time.Minute,
+			expected: []*loadingcache.CacheEntry[uint8, member]{
+				{Entry: loadingcache.Entry[uint8, member]{Key: 1, Value: member{Premium: true}}, ExpiresAt: now.Add(time.Hour)},
+				{Entry: loadingcache.Entry[uint8, member]{Key: 2, Value: member{Premium: false}}, ExpiresAt: now.Add(time.Minute)},
+				{Entry: loadingcache.Entry[uint8, member]{Key: 3}, ExpiresAt: now.Add(5 * time.Minute), NegativeCache: true},
+				nil,
+			},
+		},
+		{
+			name: "WithoutNegativeTTL",
+			expected: []*loadingcache.CacheEntry[uint8, member]{
+				{Entry: loadingcache.Entry[uint8, member]{Key: 1, Value: member{Premium: true}}, ExpiresAt: now.Add(time.Hour)},
+				{Entry: loadingcache.Entry[uint8, member]{Key: 2, Value: member{Premium: false}}, ExpiresAt: now.Add(time.Minute)},
+				{Entry: loadingcache.Entry[uint8, member]{Key: 3}, ExpiresAt: sourceExpiresAt, NegativeCache: true},
+				nil,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &source.DynamicTTLSource[uint8, member]{
+				Source:      base,
+				TTLFor:      ttlFor,
+				NegativeTTL: tt.negativeTTL,
+				Clock:       &storagetest.FixedClock{Time: now},
+			}
+
+			entries, err := s.GetMulti(t.Context(), []uint8{1, 2, 3, 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, entries); diff != "" {
+				t.Errorf("GetMulti: unexpected entries (-want +got):\n%s", diff)
+			}
+
+			for i, key := range []uint8{1, 2, 3, 4} {
+				entry, err := s.Get(t.Context(), key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tt.expected[i], entry); diff != "" {
+					t.Errorf("Get(%d): unexpected entry (-want +got):\n%s", key, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package source
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"golang.org/x/sync/errgroup"
+)
+
+// FromGetOption is the interface for the options of FromGet.
+type FromGetOption interface {
+	apply(*fromGetOptions)
+}
+
+type fromGetOptionFunc func(*fromGetOptions)
+
+func (f fromGetOptionFunc) apply(o *fromGetOptions) {
+	f(o)
+}
+
+// WithConcurrency sets the maximum number of concurrent calls of the get function in GetMulti.
+// The concurrency must be a natural number. The default is 1, which means the keys are loaded sequentially.
+func WithConcurrency(concurrency int) FromGetOption {
+	if concurrency <= 0 {
+		panic("concurrency must be natural number")
+	}
+	return fromGetOptionFunc(func(o *fromGetOptions) {
+		o.concurrency = concurrency
+	})
+}
+
+type fromGetOptions struct {
+	concurrency int
+}
+
+// FromGet creates a FunctionsSource from a function that loads a value by key.
+// The GetMulti of the created source calls the function for each key, concurrently up to WithConcurrency,
+// and returns the results in the same order as the input keys.
+// If any call fails, GetMulti returns the first error.
+func FromGet[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](get func(context.Context, K) (*loadingcache.CacheEntry[K, V], error), opts ...FromGetOption) *FunctionsSource[K, V] {
+	options := fromGetOptions{concurrency: 1}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	return &FunctionsSource[K, V]{
+		GetFunc: get,
+		GetMultiFunc: func(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+			return getMultiByGet(ctx, get, keys, options.concurrency)
+		},
+	}
+}
+
+// getMultiByGet loads multiple entries by calling the get function for each key.
+func getMultiByGet[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ctx context.Context, get func(context.Context, K) (*loadingcache.CacheEntry[K, V], error), keys []K, concurrency int) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	if concurrency == 1 || len(keys) <= 1 {
+		for i, key := range keys {
+			entry, err := get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = entry
+		}
+		return entries, nil
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(concurrency)
+	for i, key := range keys {
+		eg.Go(func() (err error) {
+			entries[i], err = get(ctx, key)
+			return
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,83 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func getEvenKeys(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+	if key%2 != 0 {
+		return nil, nil
+	}
+	return &loadingcache.CacheEntry[uint8, string]{
+		Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: string(rune('a' + key))},
+		ExpiresAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestFromGet(t *testing.T) {
+	t.Parallel()
+
+	keys := []uint8{0, 1, 2, 3, 4}
+	expected, err := (&source.FunctionsSource[uint8, string]{GetFunc: getEvenKeys}).GetMulti(t.Context(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		if (expected[i] != nil) != (key%2 == 0) {
+			t.Fatalf("unexpected fallback result at %d: %+v", i, expected[i])
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []source.FromGetOption
+	}{
+		{name: "Sequential"},
+		{name: "Concurrent", opts: []source.FromGetOption{source.WithConcurrency(3)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			s := source.FromGet(func(ctx context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+				calls.Add(1)
+				return getEvenKeys(ctx, key)
+			}, tt.opts...)
+			entries, err := (&source.LintSource[uint8, string]{Source: s}).GetMulti(t.Context(), keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, entries); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+			if got := calls.Load(); got != int32(len(keys)) {
+				t.Errorf("expected %d calls, got %d", len(keys), got)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		getErr := errors.New("get error")
+		for _, concurrency := range []int{1, 2} {
+			s := source.FromGet(func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+				if key == 3 {
+					return nil, getErr
+				}
+				return nil, nil
+			}, source.WithConcurrency(concurrency))
+			if _, err := s.GetMulti(t.Context(), keys); !errors.Is(err, getErr) {
+				t.Errorf("concurrency=%d: expected %v, got %v", concurrency, getErr, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package source
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// NegativeCachingSource is a loading source that turns the missing keys into the negative caches.
+// It prevents the repeated lookups of the keys that do not exist in the source.
+//
+// The expiration time of each negative cache is jittered by a random duration in [0, Jitter),
+// so that the negative caches of different keys expire at spread times instead of causing simultaneous re-lookups.
+type NegativeCachingSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// TTL is the time-to-live for the negative caches.
+	TTL time.Duration
+
+	// Jitter is the maximum random duration added to TTL for each negative cache.
+	// If it is zero, all the negative caches created at the same time expire at the same time.
+	Jitter time.Duration
+
+	// Random is the random number generator to decide the jitter.
+	// If not set, the default system random generator is used.
+	// This can be set to a seeded random generator for deterministic behavior in tests.
+	Random *rand.Rand
+
+	// Clock is the clock to calculate the expiration times.
+	// If not set, loadingcache.SystemClock is used.
+	Clock loadingcache.Clock
+
+	mu sync.Mutex
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*NegativeCachingSource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source.
+// If the key is not found, it returns a negative cache for the key.
+func (s *NegativeCachingSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Source.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return s.negativeCache(key, s.now()), nil
+	}
+	return entry, nil
+}
+
+// GetMulti retrieves multiple entries from the source.
+// The missing keys are returned as the negative caches.
+func (s *NegativeCachingSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.Source.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for i, entry := range entries {
+		if entry == nil {
+			entries[i] = s.negativeCache(keys[i], now)
+		}
+	}
+	return entries, nil
+}
+
+// negativeCache returns a negative cache for the key expiring at now+TTL+jitter.
+func (s *NegativeCachingSource[K, V]) negativeCache(key K, now time.Time) *loadingcache.CacheEntry[K, V] {
+	return &loadingcache.CacheEntry[K, V]{
+		Entry:         loadingcache.Entry[K, V]{Key: key},
+		ExpiresAt:     now.Add(s.TTL + s.jitter()),
+		NegativeCache: true,
+	}
+}
+
+// jitter returns a random duration in [0, Jitter).
+func (s *NegativeCachingSource[K, V]) jitter() time.Duration {
+	if s.Jitter <= 0 {
+		return 0
+	}
+	if s.Random == nil {
+		return rand.N(s.Jitter)
+	}
+
+	// rand.Rand is not safe for concurrent use
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.Random.Int64N(int64(s.Jitter)))
+}
+
+func (s *NegativeCachingSource[K, V]) now() time.Time {
+	if s.Clock == nil {
+		return loadingcache.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
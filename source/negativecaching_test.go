@@ -0,0 +1,89 @@
+package source_test
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func TestNegativeCachingSource(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := loadingcache.ClockFunc(func() time.Time { return now })
+	found := &loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "value1"}, ExpiresAt: now.Add(time.Hour)}
+	base := &source.FunctionsSource[uint8, string]{
+		GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			if key == 1 {
+				return found, nil
+			}
+			return nil, nil
+		},
+	}
+
+	t.Run("WithoutJitter", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.NegativeCachingSource[uint8, string]{Source: base, TTL: time.Minute, Clock: clock}
+		entries, err := s.GetMulti(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, string]{
+			found,
+			{Entry: loadingcache.Entry[uint8, string]{Key: 2}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+		}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+
+		entry, err := s.Get(t.Context(), 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&loadingcache.CacheEntry[uint8, string]{Entry: loadingcache.Entry[uint8, string]{Key: 3}, ExpiresAt: now.Add(time.Minute), NegativeCache: true}, entry); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("WithJitter", func(t *testing.T) {
+		t.Parallel()
+
+		const jitter = 10 * time.Second
+		s := &source.NegativeCachingSource[uint8, string]{
+			Source: base,
+			TTL:    time.Minute,
+			Jitter: jitter,
+			Random: rand.New(rand.NewPCG(1, 2)),
+			Clock:  clock,
+		}
+
+		keys := make([]uint8, 100)
+		for i := range keys {
+			keys[i] = uint8(i + 2)
+		}
+		entries, err := s.GetMulti(t.Context(), keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		distinct := map[time.Time]struct{}{}
+		for _, entry := range entries {
+			if !entry.NegativeCache {
+				t.Errorf("expected a negative cache for key %d", entry.Key)
+			}
+			lower, upper := now.Add(time.Minute), now.Add(time.Minute+jitter)
+			if entry.ExpiresAt.Before(lower) || !entry.ExpiresAt.Before(upper) {
+				t.Errorf("ExpiresAt of key %d is out of the jitter window: %v", entry.Key, entry.ExpiresAt)
+			}
+			distinct[entry.ExpiresAt] = struct{}{}
+		}
+		if len(distinct) < len(entries)/2 {
+			t.Errorf("the expiration times must be spread, got %d distinct values for %d entries", len(distinct), len(entries))
+		}
+	})
+}
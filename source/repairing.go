@@ -0,0 +1,103 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// RepairingSource is a loading source that repairs the results of a source violating the LoadingSource contract.
+// It is a forgiving production counterpart to LintSource: instead of panicking, it fixes the results.
+//
+// It reconstructs the positional results of GetMulti when the source omits missing keys or returns entries
+// in a different order, and fills a missing expiration time with the default TTL.
+type RepairingSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// DefaultTTL is the time-to-live for entries returned without an expiration time.
+	DefaultTTL time.Duration
+
+	// Clock is the clock to calculate the expiration time from DefaultTTL.
+	// If not set, loadingcache.SystemClock is used.
+	Clock loadingcache.Clock
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*RepairingSource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source.
+// If the source returns an entry for another key, it is treated as not found.
+// If the entry does not have an expiration time, it is filled with the default TTL.
+func (s *RepairingSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Source.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || entry.Key != key {
+		return nil, nil
+	}
+	return s.repairExpiration(entry, s.now()), nil
+}
+
+// GetMulti retrieves multiple entries from the source.
+// The results are reordered to match the order of the input keys, and nil entries are filled for missing keys.
+// Entries for keys that were not requested are dropped.
+// If an entry does not have an expiration time, it is filled with the default TTL.
+func (s *RepairingSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.Source.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if !isPositional(keys, entries) {
+		m := make(map[K]*loadingcache.CacheEntry[K, V], len(entries))
+		for _, entry := range entries {
+			if entry != nil {
+				m[entry.Key] = entry
+			}
+		}
+
+		entries = make([]*loadingcache.CacheEntry[K, V], len(keys))
+		for i, key := range keys {
+			entries[i] = m[key]
+		}
+	}
+
+	now := s.now()
+	for i, entry := range entries {
+		if entry != nil {
+			entries[i] = s.repairExpiration(entry, now)
+		}
+	}
+	return entries, nil
+}
+
+// isPositional reports whether the entries follow the order of the keys.
+func isPositional[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](keys []K, entries []*loadingcache.CacheEntry[K, V]) bool {
+	if len(entries) != len(keys) {
+		return false
+	}
+	for i, entry := range entries {
+		if entry != nil && entry.Key != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// repairExpiration returns the entry with the default TTL if it does not have an expiration time.
+func (s *RepairingSource[K, V]) repairExpiration(entry *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	if !entry.ExpiresAt.IsZero() {
+		return entry
+	}
+
+	repaired := *entry
+	repaired.ExpiresAt = now.Add(s.DefaultTTL)
+	return &repaired
+}
+
+func (s *RepairingSource[K, V]) now() time.Time {
+	if s.Clock == nil {
+		return loadingcache.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
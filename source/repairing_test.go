@@ -0,0 +1,129 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func TestRepairingSource(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	defaultExpiresAt := now.Add(10 * time.Minute)
+	newEntry := func(key uint8, expiresAt time.Time) *loadingcache.CacheEntry[uint8, string] {
+		return &loadingcache.CacheEntry[uint8, string]{
+			Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: string(rune('a' + key))},
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		result []*loadingcache.CacheEntry[uint8, string]
+		keys   []uint8
+		want   []*loadingcache.CacheEntry[uint8, string]
+	}{
+		{
+			name:   "compliant",
+			result: []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), nil, newEntry(3, expiresAt)},
+			keys:   []uint8{1, 2, 3},
+			want:   []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), nil, newEntry(3, expiresAt)},
+		},
+		{
+			name:   "compacted",
+			result: []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), newEntry(3, expiresAt)},
+			keys:   []uint8{1, 2, 3},
+			want:   []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), nil, newEntry(3, expiresAt)},
+		},
+		{
+			name:   "misordered",
+			result: []*loadingcache.CacheEntry[uint8, string]{newEntry(3, expiresAt), newEntry(2, expiresAt), newEntry(1, expiresAt)},
+			keys:   []uint8{1, 2, 3},
+			want:   []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), newEntry(2, expiresAt), newEntry(3, expiresAt)},
+		},
+		{
+			name:   "compacted and misordered with unknown key",
+			result: []*loadingcache.CacheEntry[uint8, string]{newEntry(3, expiresAt), newEntry(9, expiresAt)},
+			keys:   []uint8{1, 2, 3},
+			want:   []*loadingcache.CacheEntry[uint8, string]{nil, nil, newEntry(3, expiresAt)},
+		},
+		{
+			name:   "zero expiry",
+			result: []*loadingcache.CacheEntry[uint8, string]{newEntry(2, time.Time{}), newEntry(1, expiresAt)},
+			keys:   []uint8{1, 2},
+			want:   []*loadingcache.CacheEntry[uint8, string]{newEntry(1, expiresAt), newEntry(2, defaultExpiresAt)},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// LintSource panics if the repaired results violate the contract
+			s := &source.LintSource[uint8, string]{
+				Source: &source.RepairingSource[uint8, string]{
+					Source: source.GetMultiFunctionSource[uint8, string](func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+						return tt.result, nil
+					}),
+					DefaultTTL: 10 * time.Minute,
+					Clock:      &storagetest.FixedClock{Time: now},
+				},
+			}
+
+			got, err := s.GetMulti(t.Context(), tt.keys)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.LintSource[uint8, string]{
+			Source: &source.RepairingSource[uint8, string]{
+				Source: &source.FunctionsSource[uint8, string]{
+					GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+						switch key {
+						case 1:
+							return newEntry(1, time.Time{}), nil
+						case 2:
+							return newEntry(3, expiresAt), nil
+						default:
+							return nil, nil
+						}
+					},
+				},
+				DefaultTTL: 10 * time.Minute,
+				Clock:      &storagetest.FixedClock{Time: now},
+			},
+		}
+
+		got, err := s.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(newEntry(1, defaultExpiresAt), got); diff != "" {
+			t.Errorf("unexpected result (-want +got):\n%s", diff)
+		}
+
+		got, err = s.Get(t.Context(), 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != nil {
+			t.Errorf("mismatched key must be treated as not found, got %+v", got)
+		}
+	})
+}
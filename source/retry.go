@@ -0,0 +1,107 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// RetrySource is a loading source that retries the calls of a flaky source on the transient errors.
+// Both Get and GetMulti retry the whole call, waiting for the backoff between the attempts.
+// The wait is aborted by the cancellation of the context, and then the error of the context is returned.
+type RetrySource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// MaxAttempts is the maximum number of the attempts of a call, including the first one.
+	// The zero or negative value means a single attempt without retries.
+	MaxAttempts int
+
+	// Backoff returns the duration to wait after the given attempt failed, counted from 1.
+	// If not set, the calls are retried immediately.
+	Backoff func(attempt int) time.Duration
+
+	// Retryable reports whether the error is transient and the call should be retried.
+	// If not set, all the errors are retried except the errors of the context.
+	Retryable func(error) bool
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*RetrySource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source, retrying on the retryable errors.
+// It returns the error of the last attempt if all the attempts fail.
+func (s *RetrySource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return retry(ctx, s, func() (*loadingcache.CacheEntry[K, V], error) {
+		return s.Source.Get(ctx, key)
+	})
+}
+
+// GetMulti retrieves multiple entries from the source, retrying the whole call on the retryable errors.
+// It returns the error of the last attempt if all the attempts fail.
+func (s *RetrySource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	return retry(ctx, s, func() ([]*loadingcache.CacheEntry[K, V], error) {
+		return s.Source.GetMulti(ctx, keys)
+	})
+}
+
+// retry calls f until it succeeds, it fails with a non-retryable error, or the attempts are exhausted.
+func retry[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint, T any](ctx context.Context, s *RetrySource[K, V], f func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := f()
+		if err == nil || attempt >= s.MaxAttempts || !s.retryable(err) {
+			return result, err
+		}
+		if err := s.wait(ctx, attempt); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// retryable reports whether the error should be retried.
+func (s *RetrySource[K, V]) retryable(err error) bool {
+	if s.Retryable != nil {
+		return s.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// wait waits for the backoff after the failed attempt, and returns the error of the context if it is done.
+func (s *RetrySource[K, V]) wait(ctx context.Context, attempt int) error {
+	var d time.Duration
+	if s.Backoff != nil {
+		d = s.Backoff(attempt)
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ExponentialBackoff returns the backoff function for RetrySource that doubles the duration from base for each attempt,
+// capped at maxDelay. The zero or negative maxDelay means no cap.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for range attempt - 1 {
+			if (maxDelay > 0 && d >= maxDelay) || d > math.MaxInt64/2 {
+				break
+			}
+			d *= 2
+		}
+		if maxDelay > 0 && d > maxDelay {
+			return maxDelay
+		}
+		return d
+	}
+}
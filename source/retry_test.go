@@ -0,0 +1,143 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func TestRetrySource(t *testing.T) {
+	t.Parallel()
+
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	entry := &loadingcache.CacheEntry[uint8, string]{
+		Entry:     loadingcache.Entry[uint8, string]{Key: 1, Value: "a"},
+		ExpiresAt: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+		wantBackoffs []int
+	}{
+		{name: "success", wantAttempts: 1},
+		{name: "success after retries", errs: []error{errTransient, errTransient}, wantAttempts: 3, wantBackoffs: []int{1, 2}},
+		{name: "exhausted", errs: []error{errTransient, errTransient, errTransient, errTransient}, wantErr: errTransient, wantAttempts: 3, wantBackoffs: []int{1, 2}},
+		{name: "non-retryable", errs: []error{errPermanent}, wantErr: errPermanent, wantAttempts: 1},
+		{name: "context error", errs: []error{context.Canceled}, wantErr: context.Canceled, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var getAttempts, getMultiAttempts int
+			var backoffs []int
+			s := &source.RetrySource[uint8, string]{
+				Source: &source.FunctionsSource[uint8, string]{
+					GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+						getAttempts++
+						if getAttempts <= len(tt.errs) {
+							return nil, tt.errs[getAttempts-1]
+						}
+						return entry, nil
+					},
+					GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+						getMultiAttempts++
+						if getMultiAttempts <= len(tt.errs) {
+							return nil, tt.errs[getMultiAttempts-1]
+						}
+						return []*loadingcache.CacheEntry[uint8, string]{entry}, nil
+					},
+				},
+				MaxAttempts: 3,
+				Backoff: func(attempt int) time.Duration {
+					backoffs = append(backoffs, attempt)
+					return time.Millisecond
+				},
+				Retryable: func(err error) bool {
+					return err == errTransient
+				},
+			}
+
+			got, err := s.Get(t.Context(), 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error of Get: %v", err)
+			}
+			if err == nil && got != entry {
+				t.Errorf("unexpected entry: %+v", got)
+			}
+			if getAttempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts of Get, got %d", tt.wantAttempts, getAttempts)
+			}
+
+			entries, err := s.GetMulti(t.Context(), []uint8{1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error of GetMulti: %v", err)
+			}
+			if err == nil && (len(entries) != 1 || entries[0] != entry) {
+				t.Errorf("unexpected entries: %+v", entries)
+			}
+			if getMultiAttempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts of GetMulti, got %d", tt.wantAttempts, getMultiAttempts)
+			}
+
+			if diff := cmp.Diff(append(tt.wantBackoffs, tt.wantBackoffs...), backoffs); diff != "" {
+				t.Errorf("unexpected backoffs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRetrySource_ContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	s := &source.RetrySource[uint8, string]{
+		Source: &source.FunctionsSource[uint8, string]{
+			GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+				attempts++
+				return nil, errors.New("transient")
+			},
+		},
+		MaxAttempts: 10,
+		Backoff: func(int) time.Duration {
+			return time.Hour
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Get(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected the backoff to be aborted after the first attempt, got %d attempts", attempts)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := source.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, backoff(attempt))
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected backoffs (-want +got):\n%s", diff)
+	}
+
+	if d := source.ExponentialBackoff(time.Second, 0)(100); d <= 0 {
+		t.Errorf("the uncapped backoff must not overflow: %v", d)
+	}
+}
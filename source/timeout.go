@@ -0,0 +1,78 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/internal/panicutil"
+)
+
+// TimeoutSource is a loading source that bounds the duration of each call of a source by a timeout.
+// Each call runs with a child context of the caller's context with the timeout, so the earlier deadline of
+// the caller's context is respected as well. It bounds the background loads whose contexts may never be canceled.
+//
+// The call returns the error of the context as soon as the context is done, even if the source ignores the context.
+// In that case, the source keeps running on its own goroutine until it returns, and its result is discarded.
+// The panics of the source are returned as errors, since they occur on that goroutine.
+type TimeoutSource[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Source loadingcache.LoadingSource[K, V]
+
+	// Timeout is the maximum duration of each call.
+	// The zero or negative value means no timeout, and the calls are delegated to the source as they are.
+	Timeout time.Duration
+}
+
+var _ loadingcache.LoadingSource[uint8, struct{}] = (*TimeoutSource[uint8, struct{}])(nil)
+
+// Get retrieves the value associated with the given key from the source within the timeout.
+// It returns context.DeadlineExceeded if the source does not return in time.
+func (s *TimeoutSource[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if s.Timeout <= 0 {
+		return s.Source.Get(ctx, key)
+	}
+	return callWithTimeout(ctx, s.Timeout, func(ctx context.Context) (*loadingcache.CacheEntry[K, V], error) {
+		return s.Source.Get(ctx, key)
+	})
+}
+
+// GetMulti retrieves multiple entries from the source within the timeout.
+// It returns context.DeadlineExceeded if the source does not return in time.
+func (s *TimeoutSource[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	if s.Timeout <= 0 {
+		return s.Source.GetMulti(ctx, keys)
+	}
+	return callWithTimeout(ctx, s.Timeout, func(ctx context.Context) ([]*loadingcache.CacheEntry[K, V], error) {
+		return s.Source.GetMulti(ctx, keys)
+	})
+}
+
+// callWithTimeout calls f on a new goroutine with the context bounded by the timeout,
+// and returns its result, or the error of the context if it is done first.
+func callWithTimeout[T any](ctx context.Context, timeout time.Duration, f func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	// note: the channel is buffered so that the goroutine never blocks after the caller gives up
+	ch := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = panicutil.DDS(func() (err error) {
+			r.value, err = f(ctx)
+			return
+		})
+		ch <- r
+	}()
+
+	select {
+	case r := <-ch:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
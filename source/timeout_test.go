@@ -0,0 +1,134 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/source"
+)
+
+func TestTimeoutSource(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 20 * time.Millisecond
+	entry := &loadingcache.CacheEntry[uint8, string]{
+		Entry:     loadingcache.Entry[uint8, string]{Key: 1, Value: "a"},
+		ExpiresAt: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("IgnoringContext", func(t *testing.T) {
+		t.Parallel()
+
+		// the source ignores the context, and blocks until the test ends
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+		s := &source.TimeoutSource[uint8, string]{
+			Source: &source.FunctionsSource[uint8, string]{
+				GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					<-release
+					return entry, nil
+				},
+				GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+					<-release
+					return []*loadingcache.CacheEntry[uint8, string]{entry}, nil
+				},
+			},
+			Timeout: timeout,
+		}
+
+		start := time.Now()
+		if _, err := s.Get(t.Context(), 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if _, err := s.GetMulti(t.Context(), []uint8{1}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 2*timeout || elapsed > 2*timeout+time.Second {
+			t.Errorf("expected the calls to return near the timeout, took %v", elapsed)
+		}
+	})
+
+	t.Run("RespectingContext", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.TimeoutSource[uint8, string]{
+			Source: &source.FunctionsSource[uint8, string]{
+				GetFunc: func(ctx context.Context, _ uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					if _, ok := ctx.Deadline(); !ok {
+						t.Error("the context must have the deadline")
+					}
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			},
+			Timeout: timeout,
+		}
+		if _, err := s.Get(t.Context(), 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("InTime", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.TimeoutSource[uint8, string]{
+			Source: &source.FunctionsSource[uint8, string]{
+				GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					return entry, nil
+				},
+				GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, string], error) {
+					return []*loadingcache.CacheEntry[uint8, string]{entry}, nil
+				},
+			},
+			Timeout: time.Minute,
+		}
+		if got, err := s.Get(t.Context(), 1); err != nil || got != entry {
+			t.Errorf("unexpected result: %+v, %v", got, err)
+		}
+		if got, err := s.GetMulti(t.Context(), []uint8{1}); err != nil || len(got) != 1 || got[0] != entry {
+			t.Errorf("unexpected result: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("CallerDeadline", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.TimeoutSource[uint8, string]{
+			Source: &source.FunctionsSource[uint8, string]{
+				GetFunc: func(ctx context.Context, _ uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			},
+			Timeout: time.Minute,
+		}
+		ctx, cancel := context.WithTimeout(t.Context(), timeout)
+		defer cancel()
+		start := time.Now()
+		if _, err := s.Get(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the earlier deadline of the caller to be respected, took %v", elapsed)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		t.Parallel()
+
+		s := &source.TimeoutSource[uint8, string]{
+			Source: &source.FunctionsSource[uint8, string]{
+				GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+					panic("boom")
+				},
+			},
+			Timeout: time.Minute,
+		}
+		if _, err := s.Get(t.Context(), 1); err == nil {
+			t.Error("expected the panic to be returned as an error")
+		}
+	})
+}
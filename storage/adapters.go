@@ -2,10 +2,49 @@ package storage
 
 import (
 	"context"
+	"fmt"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 )
 
+// Operation is a kind of the storage operation.
+type Operation int
+
+const (
+	// OperationGet is the Get operation.
+	OperationGet Operation = iota + 1
+	// OperationGetMulti is the GetMulti operation.
+	OperationGetMulti
+	// OperationSet is the Set operation.
+	OperationSet
+	// OperationSetMulti is the SetMulti operation.
+	OperationSetMulti
+	// OperationDelete is the Delete operation.
+	OperationDelete
+	// OperationDeleteMulti is the DeleteMulti operation.
+	OperationDeleteMulti
+)
+
+// String returns the name of the operation.
+func (op Operation) String() string {
+	switch op {
+	case OperationGet:
+		return "Get"
+	case OperationGetMulti:
+		return "GetMulti"
+	case OperationSet:
+		return "Set"
+	case OperationSetMulti:
+		return "SetMulti"
+	case OperationDelete:
+		return "Delete"
+	case OperationDeleteMulti:
+		return "DeleteMulti"
+	default:
+		return fmt.Sprintf("Operation(%d)", int(op))
+	}
+}
+
 var _ loadingcache.CacheStorage[uint8, struct{}] = (*SilentErrorStorage[uint8, struct{}])(nil)
 
 // SilentErrorStorage is a decorator for a loadingcache.CacheStorage that silently handles
@@ -17,6 +56,11 @@ type SilentErrorStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstr
 	// OnError is a function that is called when an error occurs during an operation.
 	// The error is passed to the function as an argument.
 	OnError func(error)
+
+	// OnOperationError is a function that is called when an error occurs during an operation.
+	// The operation, the keys of the operation and the error are passed to the function as arguments.
+	// It is called in addition to OnError if both are set.
+	OnOperationError func(op Operation, keys []K, err error)
 }
 
 // Get retrieves the value associated with the given key from the underlying storage.
@@ -25,9 +69,7 @@ type SilentErrorStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstr
 func (s *SilentErrorStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
 	value, err := s.Storage.Get(ctx, key)
 	if err != nil {
-		if s.OnError != nil {
-			s.OnError(err)
-		}
+		s.handleError(OperationGet, []K{key}, err)
 		return nil, nil
 	}
 	return value, nil
@@ -39,9 +81,7 @@ func (s *SilentErrorStorage[K, V]) Get(ctx context.Context, key K) (*loadingcach
 func (s *SilentErrorStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
 	entries, err := s.Storage.GetMulti(ctx, keys)
 	if err != nil {
-		if s.OnError != nil {
-			s.OnError(err)
-		}
+		s.handleError(OperationGetMulti, keys, err)
 		return make([]*loadingcache.CacheEntry[K, V], len(keys)), nil
 	}
 	return entries, nil
@@ -51,8 +91,8 @@ func (s *SilentErrorStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*l
 // If an error occurs during the storage operation and an OnError handler is set, the error
 // will be passed to the OnError handler. The method itself always returns nil.
 func (s *SilentErrorStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
-	if err := s.Storage.Set(ctx, entry); err != nil && s.OnError != nil {
-		s.OnError(err)
+	if err := s.Storage.Set(ctx, entry); err != nil {
+		s.handleError(OperationSet, []K{entry.Key}, err)
 	}
 	return nil
 }
@@ -61,12 +101,48 @@ func (s *SilentErrorStorage[K, V]) Set(ctx context.Context, entry *loadingcache.
 // If an error occurs during the storage operation and an error handler is defined,
 // the error handler will be invoked with the error. The method itself always returns nil.
 func (s *SilentErrorStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
-	if err := s.Storage.SetMulti(ctx, entries); err != nil && s.OnError != nil {
-		s.OnError(err)
+	if err := s.Storage.SetMulti(ctx, entries); err != nil {
+		keys := make([]K, 0, len(entries))
+		for _, entry := range entries {
+			if entry != nil {
+				keys = append(keys, entry.Key)
+			}
+		}
+		s.handleError(OperationSetMulti, keys, err)
+	}
+	return nil
+}
+
+// Delete removes the entry by its key from the underlying storage.
+// If an error occurs during the deletion and an error handler is defined,
+// the error handler will be invoked with the error. The method itself always returns nil.
+func (s *SilentErrorStorage[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.Storage.Delete(ctx, key); err != nil {
+		s.handleError(OperationDelete, []K{key}, err)
 	}
 	return nil
 }
 
+// DeleteMulti removes the entries by their keys from the underlying storage.
+// If an error occurs during the deletion and an error handler is defined,
+// the error handler will be invoked with the error. The method itself always returns nil.
+func (s *SilentErrorStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if err := s.Storage.DeleteMulti(ctx, keys); err != nil {
+		s.handleError(OperationDeleteMulti, keys, err)
+	}
+	return nil
+}
+
+// handleError calls the error handlers with the failed operation.
+func (s *SilentErrorStorage[K, V]) handleError(op Operation, keys []K, err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+	if s.OnOperationError != nil {
+		s.OnOperationError(op, keys, err)
+	}
+}
+
 var _ loadingcache.CacheStorage[uint8, struct{}] = (*FunctionsStorage[uint8, struct{}])(nil)
 
 // FunctionsStorage is a loadingcache.CacheStorage implementation that uses functions to perform the storage operations.
@@ -89,6 +165,14 @@ type FunctionsStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstrai
 	// If a key is not found or expired, it returns nil for that key.
 	// If a key is cached as a negative cache, it should return a CacheEntry with NegativeCache set to true.
 	GetMultiFunc func(context.Context, []K) ([]*loadingcache.CacheEntry[K, V], error)
+
+	// DeleteFunc removes the entry by its key.
+	// Deleting a missing key should be a no-op and return nil.
+	DeleteFunc func(context.Context, K) error
+
+	// DeleteMultiFunc removes the entries by their keys.
+	// Deleting missing keys should be a no-op and return nil.
+	DeleteMultiFunc func(context.Context, []K) error
 }
 
 // Set calls the SetFunc function to store the given key-value pair.
@@ -110,3 +194,13 @@ func (s *FunctionsStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.
 func (s *FunctionsStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
 	return s.GetMultiFunc(ctx, keys)
 }
+
+// Delete calls the DeleteFunc function to remove the entry associated with the given key.
+func (s *FunctionsStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.DeleteFunc(ctx, key)
+}
+
+// DeleteMulti calls the DeleteMultiFunc function to remove multiple entries.
+func (s *FunctionsStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	return s.DeleteMultiFunc(ctx, keys)
+}
@@ -6,8 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
 )
 
 func TestSilentErrorStorage_Get(t *testing.T) {
@@ -250,3 +252,182 @@ func TestSilentErrorStorage_SetMulti(t *testing.T) {
 		t.Fatalf("expected captured error 'set multi error', got %v", capturedError)
 	}
 }
+
+func TestSilentErrorStorage_OnOperationError(t *testing.T) {
+	t.Parallel()
+
+	expectedError := errors.New("storage error")
+	mockStorage := &storage.FunctionsStorage[uint8, struct{}]{
+		GetFunc: func(context.Context, uint8) (*loadingcache.CacheEntry[uint8, struct{}], error) {
+			return nil, expectedError
+		},
+		GetMultiFunc: func(context.Context, []uint8) ([]*loadingcache.CacheEntry[uint8, struct{}], error) {
+			return nil, expectedError
+		},
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[uint8, struct{}]) error {
+			return expectedError
+		},
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[uint8, struct{}]) error {
+			return expectedError
+		},
+		DeleteFunc: func(context.Context, uint8) error {
+			return expectedError
+		},
+		DeleteMultiFunc: func(context.Context, []uint8) error {
+			return expectedError
+		},
+	}
+
+	type report struct {
+		Op   storage.Operation
+		Keys []uint8
+	}
+	entries := []*loadingcache.CacheEntry[uint8, struct{}]{
+		{Entry: loadingcache.Entry[uint8, struct{}]{Key: 4}},
+		{Entry: loadingcache.Entry[uint8, struct{}]{Key: 5}},
+	}
+	tests := []struct {
+		name   string
+		call   func(context.Context, loadingcache.CacheStorage[uint8, struct{}]) error
+		expect report
+	}{
+		{
+			name: "Get",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				_, err := s.Get(ctx, 1)
+				return err
+			},
+			expect: report{Op: storage.OperationGet, Keys: []uint8{1}},
+		},
+		{
+			name: "GetMulti",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				_, err := s.GetMulti(ctx, []uint8{2, 3})
+				return err
+			},
+			expect: report{Op: storage.OperationGetMulti, Keys: []uint8{2, 3}},
+		},
+		{
+			name: "Set",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				return s.Set(ctx, entries[0])
+			},
+			expect: report{Op: storage.OperationSet, Keys: []uint8{4}},
+		},
+		{
+			name: "SetMulti",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				// the nil entries are not reported as the keys
+				return s.SetMulti(ctx, []*loadingcache.CacheEntry[uint8, struct{}]{entries[0], nil, entries[1]})
+			},
+			expect: report{Op: storage.OperationSetMulti, Keys: []uint8{4, 5}},
+		},
+		{
+			name: "Delete",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				return s.Delete(ctx, 6)
+			},
+			expect: report{Op: storage.OperationDelete, Keys: []uint8{6}},
+		},
+		{
+			name: "DeleteMulti",
+			call: func(ctx context.Context, s loadingcache.CacheStorage[uint8, struct{}]) error {
+				return s.DeleteMulti(ctx, []uint8{7, 8})
+			},
+			expect: report{Op: storage.OperationDeleteMulti, Keys: []uint8{7, 8}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reports []report
+			var capturedError error
+			silentStorage := &storage.SilentErrorStorage[uint8, struct{}]{
+				Storage: mockStorage,
+				OnError: func(err error) {
+					capturedError = err
+				},
+				OnOperationError: func(op storage.Operation, keys []uint8, err error) {
+					if !errors.Is(err, expectedError) {
+						t.Errorf("unexpected error: %v", err)
+					}
+					reports = append(reports, report{Op: op, Keys: keys})
+				},
+			}
+
+			if err := tt.call(t.Context(), silentStorage); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !errors.Is(capturedError, expectedError) {
+				t.Errorf("expected OnError to be called with %v, got %v", expectedError, capturedError)
+			}
+			if diff := cmp.Diff([]report{tt.expect}, reports); diff != "" {
+				t.Errorf("unexpected reports (-want +got):\n%s", diff)
+			}
+			if got := reports[0].Op.String(); got != tt.name {
+				t.Errorf("expected operation name %q, got %q", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestDecorators_NegativeCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		decorate func(loadingcache.CacheStorage[uint8, int8]) loadingcache.CacheStorage[uint8, int8]
+	}{
+		{
+			name: "SilentErrorStorage",
+			decorate: func(s loadingcache.CacheStorage[uint8, int8]) loadingcache.CacheStorage[uint8, int8] {
+				return &storage.SilentErrorStorage[uint8, int8]{Storage: s}
+			},
+		},
+		{
+			name: "FrequencyStorage",
+			decorate: func(s loadingcache.CacheStorage[uint8, int8]) loadingcache.CacheStorage[uint8, int8] {
+				return &storage.FrequencyStorage[uint8, int8]{Storage: s}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := tt.decorate(memstorage.NewInMemoryStorage[uint8, int8]())
+			expiresAt := time.Now().Add(time.Hour)
+			entries := []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: expiresAt},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2}, ExpiresAt: expiresAt, NegativeCache: true},
+			}
+			if err := s.Set(t.Context(), entries[1]); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetMulti(t.Context(), entries[:1]); err != nil {
+				t.Fatal(err)
+			}
+
+			entry, err := s.Get(t.Context(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !loadingcache.IsNegative(entry) {
+				t.Errorf("expected a negative cache entry from Get, got %+v", entry)
+			}
+
+			got, err := s.GetMulti(t.Context(), []uint8{1, 2, 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(append(entries, nil), got); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+			for i, expect := range []bool{false, true, false} {
+				if loadingcache.IsNegative(got[i]) != expect {
+					t.Errorf("expected IsNegative(got[%d]) to be %v", i, expect)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*BatchLimitStorage[uint8, struct{}])(nil)
+var _ loadingcache.PartialSetCacheStorage[uint8, struct{}] = (*BatchLimitStorage[uint8, struct{}])(nil)
+
+// BatchLimitStorage is a decorator for a loadingcache.CacheStorage that limits the batch size of GetMulti and SetMulti.
+// The larger batches are split into the sub-batches of at most MaxBatchSize, and they are sent to the underlying storage sequentially.
+type BatchLimitStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// MaxBatchSize is the maximum number of the keys or the entries in a batch.
+	// If it is zero or negative, the batches are not split.
+	MaxBatchSize int
+}
+
+// Get retrieves the value associated with the given key from the underlying storage.
+func (s *BatchLimitStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.Storage.Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries from the underlying storage by the sub-batches.
+// The results are reassembled in the order of the input keys. If any sub-batch fails, it returns the error immediately.
+func (s *BatchLimitStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	if s.MaxBatchSize <= 0 || len(keys) <= s.MaxBatchSize {
+		return s.Storage.GetMulti(ctx, keys)
+	}
+
+	entries := make([]*loadingcache.CacheEntry[K, V], 0, len(keys))
+	for start := 0; start < len(keys); start += s.MaxBatchSize {
+		batch, err := s.Storage.GetMulti(ctx, keys[start:min(start+s.MaxBatchSize, len(keys))])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, batch...)
+	}
+	return entries, nil
+}
+
+// Set stores the given entry in the underlying storage.
+func (s *BatchLimitStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.Storage.Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries in the underlying storage by the sub-batches.
+// All the sub-batches are attempted even if some of them fail, and the errors are joined by errors.Join.
+func (s *BatchLimitStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	if s.MaxBatchSize <= 0 || len(entries) <= s.MaxBatchSize {
+		return s.Storage.SetMulti(ctx, entries)
+	}
+
+	var errs []error
+	for start := 0; start < len(entries); start += s.MaxBatchSize {
+		if err := s.Storage.SetMulti(ctx, entries[start:min(start+s.MaxBatchSize, len(entries))]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetMultiPartial stores multiple entries in the underlying storage by the sub-batches, and returns the keys of the stored entries.
+// All the sub-batches are attempted even if some of them fail, and the errors are joined by errors.Join.
+// The keys of the failed sub-batches are reported as SetMultiPartial of the package reports them for the underlying storage.
+func (s *BatchLimitStorage[K, V]) SetMultiPartial(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) ([]K, error) {
+	if s.MaxBatchSize <= 0 || len(entries) <= s.MaxBatchSize {
+		return SetMultiPartial(ctx, s.Storage, entries)
+	}
+
+	stored := make([]K, 0, len(entries))
+	var errs []error
+	for start := 0; start < len(entries); start += s.MaxBatchSize {
+		keys, err := SetMultiPartial(ctx, s.Storage, entries[start:min(start+s.MaxBatchSize, len(entries))])
+		stored = append(stored, keys...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stored, errors.Join(errs...)
+}
+
+// Delete removes the entry by its key from the underlying storage.
+func (s *BatchLimitStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.Storage.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the underlying storage by the sub-batches.
+// All the sub-batches are attempted even if some of them fail, and the errors are joined by errors.Join.
+func (s *BatchLimitStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if s.MaxBatchSize <= 0 || len(keys) <= s.MaxBatchSize {
+		return s.Storage.DeleteMulti(ctx, keys)
+	}
+
+	var errs []error
+	for start := 0; start < len(keys); start += s.MaxBatchSize {
+		if err := s.Storage.DeleteMulti(ctx, keys[start:min(start+s.MaxBatchSize, len(keys))]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
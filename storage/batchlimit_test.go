@@ -0,0 +1,75 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+)
+
+func TestBatchLimitStorage(t *testing.T) {
+	t.Parallel()
+
+	var getBatches [][]uint8
+	var setBatches [][]uint8
+	setErr := errors.New("set error")
+	s := &storage.BatchLimitStorage[uint8, int8]{
+		Storage: &storage.FunctionsStorage[uint8, int8]{
+			GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, int8], error) {
+				getBatches = append(getBatches, keys)
+				entries := make([]*loadingcache.CacheEntry[uint8, int8], len(keys))
+				for i, key := range keys {
+					if key%2 == 0 {
+						entries[i] = &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}}
+					}
+				}
+				return entries, nil
+			},
+			SetMultiFunc: func(_ context.Context, entries []*loadingcache.CacheEntry[uint8, int8]) error {
+				keys := make([]uint8, len(entries))
+				for i, entry := range entries {
+					keys[i] = entry.Key
+				}
+				setBatches = append(setBatches, keys)
+				if keys[0] == 3 {
+					return setErr
+				}
+				return nil
+			},
+		},
+		MaxBatchSize: 3,
+	}
+
+	keys := []uint8{0, 1, 2, 3, 4, 5, 6}
+	entries, err := s.GetMulti(t.Context(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]uint8{{0, 1, 2}, {3, 4, 5}, {6}}, getBatches); diff != "" {
+		t.Errorf("unexpected GetMulti batches (-want +got):\n%s", diff)
+	}
+	if len(entries) != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), len(entries))
+	}
+	for i, key := range keys {
+		if got := entries[i] != nil; got != (key%2 == 0) {
+			t.Errorf("unexpected entry at %d: %+v", i, entries[i])
+		} else if got && entries[i].Key != key {
+			t.Errorf("expected key %d at %d, got %d", key, i, entries[i].Key)
+		}
+	}
+
+	toSet := make([]*loadingcache.CacheEntry[uint8, int8], len(keys))
+	for i, key := range keys {
+		toSet[i] = &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key}}
+	}
+	if err := s.SetMulti(t.Context(), toSet); !errors.Is(err, setErr) {
+		t.Errorf("expected %v, got %v", setErr, err)
+	}
+	if diff := cmp.Diff([][]uint8{{0, 1, 2}, {3, 4, 5}, {6}}, setBatches); diff != "" {
+		t.Errorf("all the SetMulti batches must be attempted (-want +got):\n%s", diff)
+	}
+}
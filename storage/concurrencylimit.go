@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*ConcurrencyLimitStorage[uint8, struct{}])(nil)
+
+// ConcurrencyLimitStorage is a decorator for a loadingcache.CacheStorage that limits the number of the concurrent operations
+// on the underlying storage, to protect a remote backend from overload.
+// Each operation occupies a slot of the semaphore until it returns, and the operations exceeding the limit wait for a slot.
+// The waiting operations return the context error as soon as the context is done.
+// It must not be copied after first use.
+type ConcurrencyLimitStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// MaxConcurrency is the maximum number of the concurrent operations on the underlying storage.
+	// If it is zero or negative, the operations are not limited. It must not be changed after first use.
+	MaxConcurrency int
+
+	once sync.Once
+	sem  chan struct{}
+}
+
+// Get retrieves the value associated with the given key from the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Storage.Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries from the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Storage.GetMulti(ctx, keys)
+}
+
+// Set stores the given entry in the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return s.Storage.Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries in the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return s.Storage.SetMulti(ctx, entries)
+}
+
+// Delete removes the entry by its key from the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) Delete(ctx context.Context, key K) error {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return s.Storage.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the underlying storage, waiting for a slot.
+func (s *ConcurrencyLimitStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return s.Storage.DeleteMulti(ctx, keys)
+}
+
+// acquire waits for a slot of the semaphore, and returns the function to release it.
+// It returns the context error if the context is done before a slot is acquired.
+func (s *ConcurrencyLimitStorage[K, V]) acquire(ctx context.Context) (func(), error) {
+	s.once.Do(func() {
+		if s.MaxConcurrency > 0 {
+			s.sem = make(chan struct{}, s.MaxConcurrency)
+		}
+	})
+	if s.sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		return func() { <-s.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+)
+
+func TestConcurrencyLimitStorage(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+	var current, peak atomic.Int32
+	enter := func() {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		current.Add(-1)
+	}
+	s := &storage.ConcurrencyLimitStorage[int, string]{
+		Storage: &storage.FunctionsStorage[int, string]{
+			GetFunc: func(context.Context, int) (*loadingcache.CacheEntry[int, string], error) {
+				enter()
+				return nil, nil
+			},
+			GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, string], error) {
+				enter()
+				return make([]*loadingcache.CacheEntry[int, string], len(keys)), nil
+			},
+			SetFunc: func(context.Context, *loadingcache.CacheEntry[int, string]) error {
+				enter()
+				return nil
+			},
+			SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[int, string]) error {
+				enter()
+				return nil
+			},
+		},
+		MaxConcurrency: limit,
+	}
+
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			switch i % 4 {
+			case 0:
+				_, err = s.Get(t.Context(), i)
+			case 1:
+				_, err = s.GetMulti(t.Context(), []int{i})
+			case 2:
+				err = s.Set(t.Context(), &loadingcache.CacheEntry[int, string]{Entry: loadingcache.Entry[int, string]{Key: i}})
+			case 3:
+				err = s.SetMulti(t.Context(), []*loadingcache.CacheEntry[int, string]{{Entry: loadingcache.Entry[int, string]{Key: i}}})
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := peak.Load(); n > limit {
+		t.Errorf("the concurrent operations must not exceed %d, got %d", limit, n)
+	} else if n < limit {
+		t.Errorf("expected the concurrent operations to reach %d, got %d", limit, n)
+	}
+}
+
+func TestConcurrencyLimitStorage_CancelWhileWaiting(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s := &storage.ConcurrencyLimitStorage[int, string]{
+		Storage: &storage.FunctionsStorage[int, string]{
+			GetFunc: func(context.Context, int) (*loadingcache.CacheEntry[int, string], error) {
+				close(started)
+				<-release
+				return nil, nil
+			},
+		},
+		MaxConcurrency: 1,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := s.Get(t.Context(), 1); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-started
+
+	// the operation waiting for the occupied slot returns as soon as its context is done
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if _, err := s.Get(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("expected to return promptly on cancellation, took %v", elapsed)
+	}
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,16 @@
+// Package debughttp provides an http.Handler to inspect and evict the entries of a cache storage for operations.
+//
+// The Handler serves the following endpoints relative to its mount point, so mount it with http.StripPrefix:
+//
+//	GET    /stats         the statistics of the storage, such as the number of the live entries
+//	GET    /entries       the live entries of the storage, up to the limit query parameter
+//	GET    /entries/{key} the entry of the key
+//	DELETE /entries/{key} deletes the entry of the key, if the request is authorized
+//
+// The optional endpoints depend on the interfaces implemented by the storage, such as memstorage.Sizer
+// and memstorage.Iterable, and they respond with 501 Not Implemented otherwise.
+// The lookups of an entry use memstorage.Peeker if implemented, so they never affect the storage.
+// The responses are JSON, so the keys and the values must be serializable by encoding/json.
+//
+// The handler exposes the cached values as they are, so it must not be exposed publicly.
+package debughttp
@@ -0,0 +1,220 @@
+package debughttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+// DefaultListLimit is the default maximum number of the entries listed by GET /entries.
+const DefaultListLimit = 100
+
+// Handler serves the debug endpoints of a cache storage. See the package documentation for the endpoints.
+// The fields must not be modified after the first request.
+type Handler[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the storage to inspect.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// ParseKey parses the key in the path of the endpoints of an entry.
+	// The request fails with 400 Bad Request if it returns an error.
+	// If nil, the endpoints of an entry respond with 501 Not Implemented.
+	ParseKey func(string) (K, error)
+
+	// Authorize reports whether the request is allowed to delete the entries.
+	// If nil, the deletes are always forbidden, so the handler is read-only.
+	Authorize func(*http.Request) bool
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+var _ http.Handler = (*Handler[uint8, struct{}])(nil)
+
+// BearerToken returns the function for Handler.Authorize that accepts the requests with the given bearer token
+// in the Authorization header. The token is compared in constant time. It panics if the token is empty.
+func BearerToken(token string) func(*http.Request) bool {
+	if token == "" {
+		panic("token must not be empty")
+	}
+	expected := []byte("Bearer " + token)
+	return func(r *http.Request) bool {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1
+	}
+}
+
+// ServeHTTP dispatches the request to the endpoint.
+func (h *Handler[K, V]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.mux = http.NewServeMux()
+		h.mux.HandleFunc("GET /stats", h.serveStats)
+		h.mux.HandleFunc("GET /entries", h.serveList)
+		if h.ParseKey == nil {
+			h.mux.HandleFunc("/entries/{key}", serveNoParseKey)
+			return
+		}
+		h.mux.HandleFunc("GET /entries/{key}", h.serveLookup)
+		h.mux.HandleFunc("DELETE /entries/{key}", h.serveDelete)
+	})
+	h.mux.ServeHTTP(w, r)
+}
+
+// Stats is the response of GET /stats.
+type Stats struct {
+	// Len is the number of the live entries, or nil if the storage does not implement memstorage.Sizer.
+	Len *int `json:"len,omitempty"`
+}
+
+// Entry is the response of the endpoints of the entries.
+type Entry[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	Key           K         `json:"key"`
+	Value         *V        `json:"value,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	NegativeCache bool      `json:"negativeCache,omitempty"`
+
+	// LastAccess and LastWrite are set if the storage implements memstorage.EntryMetaInspector and tracks the accesses.
+	LastAccess *time.Time `json:"lastAccess,omitempty"`
+	LastWrite  *time.Time `json:"lastWrite,omitempty"`
+}
+
+// errorResponse is the response of the failed requests.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler[K, V]) serveStats(w http.ResponseWriter, r *http.Request) {
+	var stats Stats
+	if sizer, ok := h.Storage.(memstorage.Sizer); ok {
+		n, err := sizer.Len(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		stats.Len = &n
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler[K, V]) serveList(w http.ResponseWriter, r *http.Request) {
+	iterable, ok := h.Storage.(memstorage.Iterable[K, V])
+	if !ok {
+		writeError(w, http.StatusNotImplemented, errors.New("the storage cannot list the entries"))
+		return
+	}
+
+	limit := DefaultListLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("the limit must be a positive integer"))
+			return
+		}
+		limit = n
+	}
+
+	entries := make([]Entry[K, V], 0, min(limit, DefaultListLimit))
+	if err := iterable.ForEach(r.Context(), func(entry *loadingcache.CacheEntry[K, V]) bool {
+		entries = append(entries, h.entry(entry))
+		return len(entries) < limit
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (h *Handler[K, V]) serveLookup(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.parseKey(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.lookup(r, key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entry == nil {
+		writeError(w, http.StatusNotFound, errors.New("the entry is not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.entry(entry))
+}
+
+// lookup reads the entry of the key by memstorage.Peeker if the storage implements it, so that the lookups for debugging
+// never affect the storage, such as the access order, the sliding expiration and the last-access times.
+// Otherwise, it falls back to Get of the storage, which may have such side effects.
+func (h *Handler[K, V]) lookup(r *http.Request, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if peeker, ok := h.Storage.(memstorage.Peeker[K, V]); ok {
+		return peeker.Peek(r.Context(), key)
+	}
+	return h.Storage.Get(r.Context(), key)
+}
+
+func (h *Handler[K, V]) serveDelete(w http.ResponseWriter, r *http.Request) {
+	if h.Authorize == nil || !h.Authorize(r) {
+		writeError(w, http.StatusForbidden, errors.New("the request is not authorized to delete the entries"))
+		return
+	}
+	key, ok := h.parseKey(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Storage.Delete(r.Context(), key); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serveNoParseKey(w http.ResponseWriter, _ *http.Request) {
+	writeError(w, http.StatusNotImplemented, errors.New("the handler cannot parse the keys"))
+}
+
+// parseKey parses the key in the path, and writes the error response if it fails.
+func (h *Handler[K, V]) parseKey(w http.ResponseWriter, r *http.Request) (K, bool) {
+	key, err := h.ParseKey(r.PathValue("key"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return key, false
+	}
+	return key, true
+}
+
+// entry returns the response of the entry, with its metadata if available.
+func (h *Handler[K, V]) entry(entry *loadingcache.CacheEntry[K, V]) Entry[K, V] {
+	e := Entry[K, V]{
+		Key:           entry.Key,
+		ExpiresAt:     entry.ExpiresAt,
+		NegativeCache: entry.NegativeCache,
+	}
+	if !entry.NegativeCache {
+		e.Value = &entry.Value
+	}
+	if inspector, ok := h.Storage.(memstorage.EntryMetaInspector[K]); ok {
+		if lastAccess, lastWrite, ok := inspector.EntryMeta(entry.Key); ok {
+			if !lastAccess.IsZero() {
+				e.LastAccess = &lastAccess
+			}
+			e.LastWrite = &lastWrite
+		}
+	}
+	return e
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
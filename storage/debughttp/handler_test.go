@@ -0,0 +1,230 @@
+package debughttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage/debughttp"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func parseKey(s string) (uint8, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	return uint8(n), err
+}
+
+func newHandler(t *testing.T) (*debughttp.Handler[uint8, string], loadingcache.CacheStorage[uint8, string], time.Time) {
+	t.Helper()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := memstorage.NewInMemoryStorage(
+		memstorage.WithClock[uint8, string](&storagetest.FixedClock{Time: now}),
+	)
+	if err := storage.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, string]{
+		{Entry: loadingcache.Entry[uint8, string]{Key: 1, Value: "one"}, ExpiresAt: now.Add(time.Minute)},
+		{Entry: loadingcache.Entry[uint8, string]{Key: 2}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return &debughttp.Handler[uint8, string]{
+		Storage:   storage,
+		ParseKey:  parseKey,
+		Authorize: debughttp.BearerToken("secret"),
+	}, storage, now
+}
+
+func serve(t *testing.T, h http.Handler, method, target string, header http.Header, v any) int {
+	t.Helper()
+
+	req := httptest.NewRequestWithContext(t.Context(), method, target, nil)
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("unexpected response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandler_Stats(t *testing.T) {
+	t.Parallel()
+
+	h, _, _ := newHandler(t)
+	var got debughttp.Stats
+	if code := serve(t, h, http.MethodGet, "/stats", nil, &got); code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", code)
+	}
+	n := 2
+	if diff := cmp.Diff(debughttp.Stats{Len: &n}, got); diff != "" {
+		t.Errorf("unexpected stats (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandler_Entries(t *testing.T) {
+	t.Parallel()
+
+	h, _, now := newHandler(t)
+
+	t.Run("Present", func(t *testing.T) {
+		t.Parallel()
+
+		var got debughttp.Entry[uint8, string]
+		if code := serve(t, h, http.MethodGet, "/entries/1", nil, &got); code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		value := "one"
+		want := debughttp.Entry[uint8, string]{Key: 1, Value: &value, ExpiresAt: now.Add(time.Minute)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NegativeCache", func(t *testing.T) {
+		t.Parallel()
+
+		var got debughttp.Entry[uint8, string]
+		if code := serve(t, h, http.MethodGet, "/entries/2", nil, &got); code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		want := debughttp.Entry[uint8, string]{Key: 2, ExpiresAt: now.Add(time.Minute), NegativeCache: true}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Absent", func(t *testing.T) {
+		t.Parallel()
+
+		if code := serve(t, h, http.MethodGet, "/entries/3", nil, nil); code != http.StatusNotFound {
+			t.Errorf("unexpected status: %d", code)
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		t.Parallel()
+
+		if code := serve(t, h, http.MethodGet, "/entries/foo", nil, nil); code != http.StatusBadRequest {
+			t.Errorf("unexpected status: %d", code)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		var got []debughttp.Entry[uint8, string]
+		if code := serve(t, h, http.MethodGet, "/entries?limit=1", nil, &got); code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		if len(got) != 1 {
+			t.Errorf("unexpected entries: %+v", got)
+		}
+	})
+
+	t.Run("NilParseKey", func(t *testing.T) {
+		t.Parallel()
+
+		h := &debughttp.Handler[uint8, string]{Storage: memstorage.NewInMemoryStorage[uint8, string]()}
+		if code := serve(t, h, http.MethodGet, "/entries/1", nil, nil); code != http.StatusNotImplemented {
+			t.Errorf("unexpected status: %d", code)
+		}
+		if code := serve(t, h, http.MethodGet, "/entries", nil, nil); code != http.StatusOK {
+			t.Errorf("unexpected status: %d", code)
+		}
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		t.Parallel()
+
+		if code := serve(t, h, http.MethodPost, "/entries/1", nil, nil); code != http.StatusMethodNotAllowed {
+			t.Errorf("unexpected status: %d", code)
+		}
+	})
+}
+
+func TestHandler_Delete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Authorized", func(t *testing.T) {
+		t.Parallel()
+
+		h, storage, _ := newHandler(t)
+		header := http.Header{"Authorization": {"Bearer secret"}}
+		if code := serve(t, h, http.MethodDelete, "/entries/1", header, nil); code != http.StatusNoContent {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		if entry, err := storage.Get(t.Context(), 1); err != nil {
+			t.Fatal(err)
+		} else if entry != nil {
+			t.Errorf("entry should be deleted: %+v", entry)
+		}
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		t.Parallel()
+
+		h, storage, _ := newHandler(t)
+		header := http.Header{"Authorization": {"Bearer wrong"}}
+		if code := serve(t, h, http.MethodDelete, "/entries/1", header, nil); code != http.StatusForbidden {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		if entry, err := storage.Get(t.Context(), 1); err != nil {
+			t.Fatal(err)
+		} else if entry == nil {
+			t.Error("entry should not be deleted")
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, _ := newHandler(t)
+		h.Authorize = nil
+		header := http.Header{"Authorization": {"Bearer secret"}}
+		if code := serve(t, h, http.MethodDelete, "/entries/1", header, nil); code != http.StatusForbidden {
+			t.Errorf("unexpected status: %d", code)
+		}
+	})
+}
+
+func TestHandler_LookupWithoutSideEffects(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	storage := memstorage.NewInMemoryStorage(
+		memstorage.WithClock[uint8, string](clock),
+		memstorage.WithAccessTracking[uint8, string](),
+	)
+	if err := storage.Set(t.Context(), &loadingcache.CacheEntry[uint8, string]{
+		Entry:     loadingcache.Entry[uint8, string]{Key: 1, Value: "one"},
+		ExpiresAt: now.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := &debughttp.Handler[uint8, string]{Storage: storage, ParseKey: parseKey}
+
+	// the lookups are not recorded as the accesses
+	clock.Time = now.Add(time.Minute)
+	for range 2 {
+		var got debughttp.Entry[uint8, string]
+		if code := serve(t, h, http.MethodGet, "/entries/1", nil, &got); code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", code)
+		}
+		value := "one"
+		want := debughttp.Entry[uint8, string]{Key: 1, Value: &value, ExpiresAt: now.Add(time.Hour), LastWrite: &now}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package diskstorage
+
+import (
+	"encoding/json"
+)
+
+// Codec is an interface for serializing the keys or the values of the entries.
+// The encoded keys must be equal if and only if the keys are equal.
+type Codec[T any] interface {
+	// Encode serializes the value.
+	Encode(T) ([]byte, error)
+
+	// Decode deserializes the value.
+	// The given data is valid only during the call, so the implementations must copy it to retain.
+	Decode([]byte) (T, error)
+}
+
+// JSONCodec is a Codec that serializes the values by encoding/json.
+type JSONCodec[T any] struct{}
+
+var _ Codec[struct{}] = JSONCodec[struct{}]{}
+
+// Encode serializes the value by json.Marshal.
+func (JSONCodec[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode deserializes the value by json.Unmarshal.
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// StringCodec is a Codec for the string-based types that uses the bytes of the strings as they are.
+type StringCodec[T ~string] struct{}
+
+var _ Codec[string] = StringCodec[string]{}
+
+// Encode returns the bytes of the string.
+func (StringCodec[T]) Encode(v T) ([]byte, error) {
+	return []byte(v), nil
+}
+
+// Decode returns the string of the bytes.
+func (StringCodec[T]) Decode(data []byte) (T, error) {
+	return T(data), nil
+}
@@ -0,0 +1,6 @@
+// Package diskstorage provides a persistent implementation of the loadingcache.CacheStorage interface
+// backed by an embedded bbolt database.
+//
+// The entries survive the restarts of the process, so the cache can serve them without a warm-up.
+// The keys and the values are serialized by the Codecs, and the expired entries are deleted lazily when they are read.
+package diskstorage
@@ -0,0 +1,19 @@
+module github.com/karupanerura/loading-cache/storage/diskstorage
+
+go 1.24.1
+
+require (
+	github.com/google/go-cmp v0.7.0
+	github.com/karupanerura/loading-cache v0.1.0
+	go.etcd.io/bbolt v1.4.3
+)
+
+require (
+	github.com/sourcegraph/conc v0.3.0 // indirect
+	golang.org/x/sync v0.13.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
+
+// note: the replace directive only applies to the development in this repository, and it is ignored by the dependents,
+// which resolve the root module by the required version above. Bump it when the storage depends on a newer release.
+replace github.com/karupanerura/loading-cache => ../..
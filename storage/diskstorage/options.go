@@ -0,0 +1,53 @@
+package diskstorage
+
+import (
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// DefaultBucketName is the default name of the bbolt bucket to store the entries.
+const DefaultBucketName = "loadingcache"
+
+// Option is the interface for the options of the DiskStorage.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+type options struct {
+	bucketName   []byte
+	clock        loadingcache.Clock
+	cleanupError func(error)
+}
+
+// WithBucketName sets the name of the bbolt bucket to store the entries.
+// It allows multiple storages to share a database file. The default is DefaultBucketName.
+func WithBucketName(name string) Option {
+	if name == "" {
+		panic("bucket name must not be empty")
+	}
+	return optionFunc(func(o *options) {
+		o.bucketName = []byte(name)
+	})
+}
+
+// WithClock sets the clock to check the expiration of the entries.
+// The default is loadingcache.SystemClock.
+func WithClock(clock loadingcache.Clock) Option {
+	return optionFunc(func(o *options) {
+		o.clock = clock
+	})
+}
+
+// WithCleanupErrorHandler sets the function called with the error of the lazy deletion of the expired entries.
+// The lazy deletion runs in a separate write transaction after the reads, so its error never fails the reads.
+// The errors are ignored by default.
+func WithCleanupErrorHandler(handler func(error)) Option {
+	return optionFunc(func(o *options) {
+		o.cleanupError = handler
+	})
+}
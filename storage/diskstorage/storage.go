@@ -0,0 +1,286 @@
+package diskstorage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrCorruptedEntry is returned when a stored entry cannot be decoded.
+var ErrCorruptedEntry = errors.New("the stored entry is corrupted")
+
+const (
+	// headerSize is the size of the header of an encoded entry: the flags (1 byte) and the expiration time (8 bytes).
+	headerSize = 9
+
+	// flagNegativeCache is the flag of the negative caches.
+	flagNegativeCache = 1 << 0
+
+	// flagPermanent is the flag of the negative caches of the permanently absent keys.
+	flagPermanent = 1 << 1
+)
+
+// DiskStorage is a persistent CacheStorage backed by a bbolt database.
+// The keys and the values are serialized by the codecs, so the stored and the returned values never share memory.
+type DiskStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	db           *bolt.DB
+	keyCodec     Codec[K]
+	valueCodec   Codec[V]
+	bucketName   []byte
+	clock        loadingcache.Clock
+	cleanupError func(error)
+}
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*DiskStorage[uint8, struct{}])(nil)
+
+// NewDiskStorage creates a new DiskStorage on the database, creating its bucket if it does not exist.
+// The database is owned by the caller, and it must be kept open while the storage is used.
+// If the bucket already exists, the database is not written, so the storage can be created on a read-only database.
+func NewDiskStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](db *bolt.DB, keyCodec Codec[K], valueCodec Codec[V], opts ...Option) (*DiskStorage[K, V], error) {
+	o := options{
+		bucketName: []byte(DefaultBucketName),
+		clock:      loadingcache.SystemClock,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+
+	var exists bool
+	if err := db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(o.bucketName) != nil
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(o.bucketName)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return &DiskStorage[K, V]{
+		db:           db,
+		keyCodec:     keyCodec,
+		valueCodec:   valueCodec,
+		bucketName:   o.bucketName,
+		clock:        o.clock,
+		cleanupError: o.cleanupError,
+	}, nil
+}
+
+// Get retrieves a value by its key.
+// If the entry is expired, it returns nil and deletes the entry from the database.
+func (s *DiskStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.GetMulti(ctx, []K{key})
+	if err != nil {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// GetMulti retrieves multiple values by keys in a single read transaction.
+// The expired entries are returned as nil and deleted from the database in a separate write transaction.
+// The error of the deletion does not fail the read; it is passed to the handler of WithCleanupErrorHandler.
+func (s *DiskStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	encodedKeys, err := s.encodeKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	var expired [][]byte
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucketName)
+		for i, encodedKey := range encodedKeys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			data := bucket.Get(encodedKey)
+			if data == nil {
+				continue
+			}
+
+			entry, err := s.decodeEntry(keys[i], data)
+			if err != nil {
+				return err
+			}
+			if !entry.ExpiresAt.After(now) {
+				expired = append(expired, encodedKey)
+				continue
+			}
+			entries[i] = entry
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(expired) != 0 {
+		if err := s.deleteExpired(expired); err != nil && s.cleanupError != nil {
+			s.cleanupError(err)
+		}
+	}
+	return entries, nil
+}
+
+// Set stores the entry.
+// If the key already exists, it overwrites the existing entry.
+func (s *DiskStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.SetMulti(ctx, []*loadingcache.CacheEntry[K, V]{entry})
+}
+
+// SetMulti stores multiple entries in a single write transaction.
+// The nil entries are skipped.
+func (s *DiskStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	encodedKeys := make([][]byte, 0, len(entries))
+	encodedEntries := make([][]byte, 0, len(entries))
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		encodedKey, err := s.keyCodec.Encode(entry.Key)
+		if err != nil {
+			return err
+		}
+		encodedEntry, err := s.encodeEntry(entry)
+		if err != nil {
+			return err
+		}
+		encodedKeys = append(encodedKeys, encodedKey)
+		encodedEntries = append(encodedEntries, encodedEntry)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bucket := tx.Bucket(s.bucketName)
+		for i, encodedKey := range encodedKeys {
+			if err := bucket.Put(encodedKey, encodedEntries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the entry by its key.
+// Deleting a missing key is a no-op and returns nil.
+func (s *DiskStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.DeleteMulti(ctx, []K{key})
+}
+
+// DeleteMulti removes the entries by their keys in a single write transaction.
+// Deleting missing keys is a no-op and returns nil.
+func (s *DiskStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	encodedKeys, err := s.encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bucket := tx.Bucket(s.bucketName)
+		for _, encodedKey := range encodedKeys {
+			if err := bucket.Delete(encodedKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteExpired deletes the entries of the keys if they are still expired.
+// The entries may have been overwritten since they were read, so their expiration times are checked again.
+func (s *DiskStorage[K, V]) deleteExpired(encodedKeys [][]byte) error {
+	now := s.clock.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucketName)
+		for _, encodedKey := range encodedKeys {
+			data := bucket.Get(encodedKey)
+			if len(data) < headerSize || expiresAt(data).After(now) {
+				continue
+			}
+			if err := bucket.Delete(encodedKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeKeys serializes the keys by the key codec.
+func (s *DiskStorage[K, V]) encodeKeys(keys []K) ([][]byte, error) {
+	encodedKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		var err error
+		if encodedKeys[i], err = s.keyCodec.Encode(key); err != nil {
+			return nil, err
+		}
+	}
+	return encodedKeys, nil
+}
+
+// encodeEntry serializes the entry into the header and the value encoded by the value codec.
+// The value of the negative cache is not stored.
+func (s *DiskStorage[K, V]) encodeEntry(entry *loadingcache.CacheEntry[K, V]) ([]byte, error) {
+	header := make([]byte, headerSize)
+	binary.BigEndian.PutUint64(header[1:], uint64(entry.ExpiresAt.UnixNano()))
+	if entry.NegativeCache {
+		header[0] = flagNegativeCache
+		if entry.NegativeReason == loadingcache.NegativeCachePermanent {
+			header[0] |= flagPermanent
+		}
+		return header, nil
+	}
+
+	value, err := s.valueCodec.Encode(entry.Value)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, value...), nil
+}
+
+// decodeEntry deserializes the entry of the key.
+func (s *DiskStorage[K, V]) decodeEntry(key K, data []byte) (*loadingcache.CacheEntry[K, V], error) {
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%w: key=%v", ErrCorruptedEntry, key)
+	}
+
+	entry := &loadingcache.CacheEntry[K, V]{
+		Entry:         loadingcache.Entry[K, V]{Key: key},
+		ExpiresAt:     expiresAt(data),
+		NegativeCache: data[0]&flagNegativeCache != 0,
+	}
+	if entry.NegativeCache {
+		if data[0]&flagPermanent != 0 {
+			entry.NegativeReason = loadingcache.NegativeCachePermanent
+		}
+		return entry, nil
+	}
+
+	value, err := s.valueCodec.Decode(data[headerSize:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: key=%v: %w", ErrCorruptedEntry, key, err)
+	}
+	entry.Value = value
+	return entry, nil
+}
+
+// expiresAt returns the expiration time in the header of the encoded entry.
+func expiresAt(data []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data[1:headerSize])))
+}
@@ -0,0 +1,201 @@
+package diskstorage_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage/diskstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openDB(t *testing.T, path string) *bolt.DB {
+	t.Helper()
+
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+
+	storagetest.RunAll(t, storagetest.ClockProvider(func(clock loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func()) {
+		db := openDB(t, filepath.Join(t.TempDir(), "cache.db"))
+		s, err := diskstorage.NewDiskStorage(db, diskstorage.JSONCodec[uint8]{}, diskstorage.JSONCodec[int8]{}, diskstorage.WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, func() { db.Close() }
+	}))
+}
+
+func TestDiskStorage_Persistence(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	path := filepath.Join(t.TempDir(), "cache.db")
+	entries := []*loadingcache.CacheEntry[string, []string]{
+		{Entry: loadingcache.Entry[string, []string]{Key: "a", Value: []string{"x", "y"}}, ExpiresAt: now.Add(time.Hour)},
+		{Entry: loadingcache.Entry[string, []string]{Key: "b"}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+		{Entry: loadingcache.Entry[string, []string]{Key: "d"}, ExpiresAt: now.Add(time.Hour), NegativeCache: true, NegativeReason: loadingcache.NegativeCachePermanent},
+	}
+
+	db := openDB(t, path)
+	s, err := diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[[]string]{}, diskstorage.WithClock(clock), diskstorage.WithBucketName("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries survive reopening the database file
+	db = openDB(t, path)
+	defer db.Close()
+	s, err = diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[[]string]{}, diskstorage.WithClock(clock), diskstorage.WithBucketName("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetMulti(t.Context(), []string{"a", "b", "d", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(append(entries, nil), got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+
+	// the expired entry is deleted lazily by the read
+	clock.Time = now.Add(10 * time.Minute)
+	if entry, err := s.Get(t.Context(), "b"); err != nil {
+		t.Fatal(err)
+	} else if entry != nil {
+		t.Errorf("unexpected expired entry: %+v", entry)
+	}
+	if err := db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("test")).Get([]byte("b")) != nil {
+			t.Error("the expired entry must be deleted")
+		}
+		if tx.Bucket([]byte("test")).Get([]byte("a")) == nil {
+			t.Error("the live entry must be kept")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the deleted entries are missing
+	if err := s.Delete(t.Context(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := s.Get(t.Context(), "a"); err != nil {
+		t.Fatal(err)
+	} else if entry != nil {
+		t.Errorf("unexpected deleted entry: %+v", entry)
+	}
+}
+
+func TestDiskStorage_CleanupError(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	path := filepath.Join(t.TempDir(), "cache.db")
+	entries := []*loadingcache.CacheEntry[string, int]{
+		{Entry: loadingcache.Entry[string, int]{Key: "a", Value: 1}, ExpiresAt: now.Add(time.Hour)},
+		{Entry: loadingcache.Entry[string, int]{Key: "b", Value: 2}, ExpiresAt: now.Add(time.Minute)},
+	}
+
+	db := openDB(t, path)
+	s, err := diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[int]{}, diskstorage.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired entry cannot be deleted on the read-only database
+	db, err = bolt.Open(path, 0o600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var cleanupErrs []error
+	s, err = diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[int]{}, diskstorage.WithClock(clock), diskstorage.WithCleanupErrorHandler(func(err error) {
+		cleanupErrs = append(cleanupErrs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Time = now.Add(10 * time.Minute)
+	got, err := s.GetMulti(t.Context(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[string, int]{entries[0], nil}, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if len(cleanupErrs) != 1 || !errors.Is(cleanupErrs[0], bolt.ErrDatabaseReadOnly) {
+		t.Errorf("expected %v to be reported, got %v", bolt.ErrDatabaseReadOnly, cleanupErrs)
+	}
+}
+
+func TestDiskStorage_CorruptedEntry(t *testing.T) {
+	t.Parallel()
+
+	db := openDB(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer db.Close()
+	s, err := diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(diskstorage.DefaultBucketName)).Put([]byte("a"), []byte{0})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(t.Context(), "a"); !errors.Is(err, diskstorage.ErrCorruptedEntry) {
+		t.Errorf("expected %v, got %v", diskstorage.ErrCorruptedEntry, err)
+	}
+}
+
+func TestDiskStorage_SetMultiWithNilEntries(t *testing.T) {
+	t.Parallel()
+
+	db := openDB(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer db.Close()
+	s, err := diskstorage.NewDiskStorage(db, diskstorage.StringCodec[string]{}, diskstorage.JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	entry := &loadingcache.CacheEntry[string, int]{Entry: loadingcache.Entry[string, int]{Key: "a", Value: 1}, ExpiresAt: expiresAt}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[string, int]{nil, entry, nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetMulti(t.Context(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[string, int]{entry, nil}, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
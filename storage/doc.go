@@ -3,6 +3,7 @@
 // This package contains adapters such as SilentErrorStorage, which wraps any CacheStorage
 // implementation to silently handle errors, and FunctionsStorage, which allows building
 // custom storage implementations using function callbacks.
+// Composite storages such as TieredStorage and MigrationStorage combine multiple storages.
 //
 // This package also defines common error types for storage operations:
 // ErrGet, ErrSet, ErrGetMulti, and ErrSetMulti.
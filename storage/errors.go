@@ -7,4 +7,5 @@ var (
 	ErrSet      = errors.New("unable to store data in cache storage")
 	ErrGetMulti = errors.New("unable to retrieve multiple entries from cache storage")
 	ErrSetMulti = errors.New("unable to store multiple entries in cache storage")
+	ErrReadOnly = errors.New("cache storage is read-only")
 )
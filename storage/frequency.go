@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*FrequencyStorage[uint8, struct{}])(nil)
+
+// KeyCount is a key with its access count.
+type KeyCount[K loadingcache.KeyConstraint] struct {
+	// Key is the accessed key.
+	Key K
+
+	// Count is the number of hits for the key.
+	Count uint64
+}
+
+// FrequencyStorage is a decorator for a loadingcache.CacheStorage that records the access frequency per key.
+// It counts the hits of Get and GetMulti to identify hot keys. Negative cache hits are counted as well.
+//
+// The number of tracked keys is bounded by MaxKeys. When a new key is hit while MaxKeys keys are already tracked,
+// the least frequent key is replaced by the new key, and the new key inherits its count (the Space-Saving algorithm).
+// So the counts are approximate and may overestimate the infrequent keys, but the frequent keys are ranked correctly.
+// Replacing a key scans all the tracked keys, so MaxKeys should be kept small.
+type FrequencyStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// MaxKeys is the maximum number of tracked keys.
+	// If it is zero or negative, all keys are tracked.
+	MaxKeys int
+
+	mu     sync.RWMutex
+	counts map[K]*atomic.Uint64
+}
+
+// Get retrieves the value associated with the given key from the underlying storage and counts the hit.
+func (s *FrequencyStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		s.hit(key)
+	}
+	return entry, nil
+}
+
+// GetMulti retrieves multiple entries from the underlying storage and counts the hits.
+func (s *FrequencyStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.Storage.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if entry != nil {
+			s.hit(keys[i])
+		}
+	}
+	return entries, nil
+}
+
+// Set stores the given entry in the underlying storage.
+func (s *FrequencyStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.Storage.Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries in the underlying storage.
+func (s *FrequencyStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	return s.Storage.SetMulti(ctx, entries)
+}
+
+// Delete removes the entry by its key from the underlying storage.
+// The access count of the key is kept.
+func (s *FrequencyStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.Storage.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the underlying storage.
+// The access counts of the keys are kept.
+func (s *FrequencyStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	return s.Storage.DeleteMulti(ctx, keys)
+}
+
+// TopN returns a snapshot of the n most frequently hit keys in descending order of the count.
+// If n is greater than the number of tracked keys, all tracked keys are returned.
+func (s *FrequencyStorage[K, V]) TopN(n int) []KeyCount[K] {
+	s.mu.RLock()
+	counts := make([]KeyCount[K], 0, len(s.counts))
+	for key, count := range s.counts {
+		counts = append(counts, KeyCount[K]{Key: key, Count: count.Load()})
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(counts, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if n < len(counts) {
+		counts = counts[:max(n, 0)]
+	}
+	return counts
+}
+
+// hit increments the count of the key.
+func (s *FrequencyStorage[K, V]) hit(key K) {
+	s.mu.RLock()
+	count, ok := s.counts[key]
+	s.mu.RUnlock()
+	if ok {
+		count.Add(1)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if count, ok := s.counts[key]; ok {
+		count.Add(1)
+		return
+	}
+	if s.counts == nil {
+		s.counts = map[K]*atomic.Uint64{}
+	}
+	if s.MaxKeys <= 0 || len(s.counts) < s.MaxKeys {
+		count := &atomic.Uint64{}
+		count.Store(1)
+		s.counts[key] = count
+		return
+	}
+
+	// replace the least frequent key with the new key
+	var minKey K
+	var minCount *atomic.Uint64
+	for k, c := range s.counts {
+		if minCount == nil || c.Load() < minCount.Load() {
+			minKey, minCount = k, c
+		}
+	}
+	delete(s.counts, minKey)
+
+	// note: the new key takes a fresh counter instead of reusing the replaced one, since the concurrent hits
+	// of the replaced key may still increment it after releasing the read lock, and they must not be counted for the new key.
+	inherited := &atomic.Uint64{}
+	inherited.Store(minCount.Load() + 1)
+	s.counts[key] = inherited
+}
@@ -0,0 +1,136 @@
+package storage_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+)
+
+func newFrequencyTestStorage() *storage.FunctionsStorage[uint8, uint8] {
+	get := func(key uint8) *loadingcache.CacheEntry[uint8, uint8] {
+		// odd keys are missing
+		if key%2 == 1 {
+			return nil
+		}
+		return &loadingcache.CacheEntry[uint8, uint8]{
+			Entry:     loadingcache.Entry[uint8, uint8]{Key: key, Value: key},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+	return &storage.FunctionsStorage[uint8, uint8]{
+		GetFunc: func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, uint8], error) {
+			return get(key), nil
+		},
+		GetMultiFunc: func(_ context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, uint8], error) {
+			entries := make([]*loadingcache.CacheEntry[uint8, uint8], len(keys))
+			for i, key := range keys {
+				entries[i] = get(key)
+			}
+			return entries, nil
+		},
+	}
+}
+
+func TestFrequencyStorage_TopN(t *testing.T) {
+	t.Parallel()
+
+	s := &storage.FrequencyStorage[uint8, uint8]{Storage: newFrequencyTestStorage()}
+	for range 5 {
+		if _, err := s.Get(t.Context(), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 3 {
+		if _, err := s.GetMulti(t.Context(), []uint8{4, 1, 3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Get(t.Context(), 6); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []storage.KeyCount[uint8]{
+		{Key: 2, Count: 5},
+		{Key: 4, Count: 3},
+		{Key: 6, Count: 1},
+	}
+	if diff := cmp.Diff(want, s.TopN(10)); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want[:2], s.TopN(2)); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if got := s.TopN(0); len(got) != 0 {
+		t.Errorf("expected empty result, got %v", got)
+	}
+}
+
+func TestFrequencyStorage_MaxKeys(t *testing.T) {
+	t.Parallel()
+
+	s := &storage.FrequencyStorage[uint8, uint8]{Storage: newFrequencyTestStorage(), MaxKeys: 3}
+	for range 10 {
+		if _, err := s.Get(t.Context(), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 5 {
+		if _, err := s.Get(t.Context(), 4); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// infrequent keys compete for the remaining slot
+	for key := uint8(6); key < 14; key += 2 {
+		if _, err := s.Get(t.Context(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	top := s.TopN(10)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 tracked keys, got %v", top)
+	}
+	if diff := cmp.Diff([]storage.KeyCount[uint8]{{Key: 2, Count: 10}, {Key: 4, Count: 5}}, top[:2]); diff != "" {
+		t.Errorf("frequent keys must rank above infrequent ones (-want +got):\n%s", diff)
+	}
+}
+
+func TestFrequencyStorage_MaxKeys_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const goroutines, hits = 8, 1000
+	s := &storage.FrequencyStorage[uint8, uint8]{Storage: newFrequencyTestStorage(), MaxKeys: 2}
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range hits {
+				// the even keys are always found, and they keep replacing each other
+				if _, err := s.Get(t.Context(), uint8((i+j)%8*2)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total uint64
+	for _, kc := range s.TopN(10) {
+		total += kc.Count
+	}
+	if total > goroutines*hits {
+		t.Errorf("the counts must not exceed the number of hits %d, got %d", goroutines*hits, total)
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// ReadOnlyFuncStorage creates a FunctionsStorage that reads by the given functions and discards the writes.
+// Either get or getMulti may be nil, and it is derived from the other one: the derived GetMulti calls get for each key in order,
+// and the derived Get calls getMulti with the single key. Set, SetMulti, Delete and DeleteMulti are no-op.
+// It is useful for the read-only test doubles.
+func ReadOnlyFuncStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](
+	get func(context.Context, K) (*loadingcache.CacheEntry[K, V], error),
+	getMulti func(context.Context, []K) ([]*loadingcache.CacheEntry[K, V], error),
+) *FunctionsStorage[K, V] {
+	if get == nil && getMulti == nil {
+		panic("either get or getMulti must not be nil")
+	}
+	if get == nil {
+		get = getFromGetMulti(getMulti)
+	}
+	if getMulti == nil {
+		getMulti = getMultiFromGet(get)
+	}
+	return &FunctionsStorage[K, V]{
+		GetFunc:      get,
+		GetMultiFunc: getMulti,
+		SetFunc: func(context.Context, *loadingcache.CacheEntry[K, V]) error {
+			return nil
+		},
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[K, V]) error {
+			return nil
+		},
+		DeleteFunc: func(context.Context, K) error {
+			return nil
+		},
+		DeleteMultiFunc: func(context.Context, []K) error {
+			return nil
+		},
+	}
+}
+
+// WriteThroughFuncStorage creates a FunctionsStorage from the single-key functions.
+// GetMulti calls get for each key in order, SetMulti calls set for each non-nil entry in order,
+// and DeleteMulti calls del for each key in order. They stop at the first error and return it.
+func WriteThroughFuncStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](
+	get func(context.Context, K) (*loadingcache.CacheEntry[K, V], error),
+	set func(context.Context, *loadingcache.CacheEntry[K, V]) error,
+	del func(context.Context, K) error,
+) *FunctionsStorage[K, V] {
+	if get == nil || set == nil || del == nil {
+		panic("get, set and del must not be nil")
+	}
+	return &FunctionsStorage[K, V]{
+		GetFunc:      get,
+		GetMultiFunc: getMultiFromGet(get),
+		SetFunc:      set,
+		SetMultiFunc: func(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+			for _, entry := range entries {
+				if entry == nil {
+					continue
+				}
+				if err := set(ctx, entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		DeleteFunc: del,
+		DeleteMultiFunc: func(ctx context.Context, keys []K) error {
+			for _, key := range keys {
+				if err := del(ctx, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// getMultiFromGet derives GetMulti from Get.
+func getMultiFromGet[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](get func(context.Context, K) (*loadingcache.CacheEntry[K, V], error)) func(context.Context, []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	return func(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+		entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+		for i, key := range keys {
+			entry, err := get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = entry
+		}
+		return entries, nil
+	}
+}
+
+// getFromGetMulti derives Get from GetMulti.
+func getFromGetMulti[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](getMulti func(context.Context, []K) ([]*loadingcache.CacheEntry[K, V], error)) func(context.Context, K) (*loadingcache.CacheEntry[K, V], error) {
+	return func(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+		entries, err := getMulti(ctx, []K{key})
+		if err != nil {
+			return nil, err
+		}
+		return entries[0], nil
+	}
+}
@@ -0,0 +1,162 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+)
+
+func TestReadOnlyFuncStorage(t *testing.T) {
+	t.Parallel()
+
+	getErr := errors.New("get error")
+	get := func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, int8], error) {
+		switch {
+		case key == 0:
+			return nil, getErr
+		case key%2 == 0:
+			return nil, nil
+		default:
+			return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}}, nil
+		}
+	}
+	entry := func(key uint8) *loadingcache.CacheEntry[uint8, int8] {
+		return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}}
+	}
+
+	t.Run("GetMultiDerivedFromGet", func(t *testing.T) {
+		t.Parallel()
+
+		s := storage.ReadOnlyFuncStorage(get, nil)
+		entries, err := s.GetMulti(t.Context(), []uint8{3, 2, 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(3), nil, entry(1)}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+		if _, err := s.GetMulti(t.Context(), []uint8{1, 0}); !errors.Is(err, getErr) {
+			t.Errorf("expected %v, got %v", getErr, err)
+		}
+
+		// the writes are discarded
+		if err := s.Set(t.Context(), entry(2)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(4)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete(t.Context(), 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.DeleteMulti(t.Context(), []uint8{1, 3}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := s.Get(t.Context(), 2); err != nil || got != nil {
+			t.Errorf("expected nil, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("GetDerivedFromGetMulti", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]uint8
+		s := storage.ReadOnlyFuncStorage(nil, func(ctx context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, int8], error) {
+			calls = append(calls, keys)
+			entries := make([]*loadingcache.CacheEntry[uint8, int8], len(keys))
+			for i, key := range keys {
+				var err error
+				if entries[i], err = get(ctx, key); err != nil {
+					return nil, err
+				}
+			}
+			return entries, nil
+		})
+		got, err := s.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(entry(1), got); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+		if _, err := s.Get(t.Context(), 0); !errors.Is(err, getErr) {
+			t.Errorf("expected %v, got %v", getErr, err)
+		}
+		if diff := cmp.Diff([][]uint8{{1}, {0}}, calls); diff != "" {
+			t.Errorf("unexpected GetMulti calls (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NoFunctions", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic")
+			}
+		}()
+		storage.ReadOnlyFuncStorage[uint8, int8](nil, nil)
+	})
+}
+
+func TestWriteThroughFuncStorage(t *testing.T) {
+	t.Parallel()
+
+	setErr := errors.New("set error")
+	delErr := errors.New("delete error")
+	m := map[uint8]*loadingcache.CacheEntry[uint8, int8]{}
+	s := storage.WriteThroughFuncStorage(
+		func(_ context.Context, key uint8) (*loadingcache.CacheEntry[uint8, int8], error) {
+			return m[key], nil
+		},
+		func(_ context.Context, entry *loadingcache.CacheEntry[uint8, int8]) error {
+			if entry.Key == 0 {
+				return setErr
+			}
+			m[entry.Key] = entry
+			return nil
+		},
+		func(_ context.Context, key uint8) error {
+			if key == 0 {
+				return delErr
+			}
+			delete(m, key)
+			return nil
+		},
+	)
+
+	entry := func(key uint8) *loadingcache.CacheEntry[uint8, int8] {
+		return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}}
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(1), nil, entry(3)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(0), entry(4)}); !errors.Is(err, setErr) {
+		t.Errorf("expected %v, got %v", setErr, err)
+	}
+
+	entries, err := s.GetMulti(t.Context(), []uint8{3, 2, 1, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(3), nil, entry(1), nil}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+
+	if err := s.Delete(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMulti(t.Context(), []uint8{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMulti(t.Context(), []uint8{0, 4}); !errors.Is(err, delErr) {
+		t.Errorf("expected %v, got %v", delErr, err)
+	}
+	if len(m) != 0 {
+		t.Errorf("expected all the entries to be deleted, got %v", m)
+	}
+}
@@ -0,0 +1,171 @@
+package memstorage
+
+import (
+	"container/heap"
+	"container/list"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// evictionHeap is the priority queue of the entries of a bucket for WithEvictionPriority.
+// The entry with the lowest priority is at the top.
+//
+// The expired entries removed on reads under the read lock of the bucket cannot be removed from the heap,
+// so the heap may have the stale entries whose keys are no longer in the bucket. They are skipped on eviction,
+// and the heap is rebuilt from the bucket when the stale entries outnumber the live ones.
+type evictionHeap[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	less    func(a, b *loadingcache.CacheEntry[K, V]) bool
+	entries []*loadingcache.CacheEntry[K, V]
+	indexes map[K]int
+}
+
+var _ heap.Interface = (*evictionHeap[uint8, struct{}])(nil)
+
+func (h *evictionHeap[K, V]) Len() int {
+	return len(h.entries)
+}
+
+func (h *evictionHeap[K, V]) Less(i, j int) bool {
+	return h.less(h.entries[i], h.entries[j])
+}
+
+func (h *evictionHeap[K, V]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.indexes[h.entries[i].Key] = i
+	h.indexes[h.entries[j].Key] = j
+}
+
+func (h *evictionHeap[K, V]) Push(x any) {
+	entry := x.(*loadingcache.CacheEntry[K, V])
+	h.indexes[entry.Key] = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *evictionHeap[K, V]) Pop() any {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries[last] = nil
+	h.entries = h.entries[:last]
+	delete(h.indexes, entry.Key)
+	return entry
+}
+
+// update adds the stored entry to the heap, or replaces the entry of the same key.
+func (h *evictionHeap[K, V]) update(entry *loadingcache.CacheEntry[K, V]) {
+	if i, ok := h.indexes[entry.Key]; ok {
+		h.entries[i] = entry
+		heap.Fix(h, i)
+		return
+	}
+	heap.Push(h, entry)
+}
+
+// remove removes the entry of the key from the heap if it exists.
+func (h *evictionHeap[K, V]) remove(key K) {
+	if i, ok := h.indexes[key]; ok {
+		heap.Remove(h, i)
+	}
+}
+
+// reset removes all the entries from the heap.
+func (h *evictionHeap[K, V]) reset() {
+	clear(h.entries)
+	h.entries = h.entries[:0]
+	clear(h.indexes)
+}
+
+// rebuild replaces the entries of the heap with the entries stored in the bucket to drop the stale entries.
+func (h *evictionHeap[K, V]) rebuild(m map[K]*loadingcache.CacheEntry[K, V]) {
+	h.reset()
+	for key, entry := range m {
+		h.indexes[key] = len(h.entries)
+		h.entries = append(h.entries, entry)
+	}
+	heap.Init(h)
+}
+
+// lruList is the access order of the keys of a bucket for WithMaxEntries without WithEvictionPriority.
+// The most recently used key is at the front, and the least recently used key is at the back.
+// Unlike evictionHeap, it never has the stale keys, since all the updates are made under the write lock of the bucket.
+type lruList[K loadingcache.KeyConstraint] struct {
+	order    list.List
+	elements map[K]*list.Element
+}
+
+// touch moves the key to the front, or adds it to the front if it does not exist.
+func (l *lruList[K]) touch(key K) {
+	if e, ok := l.elements[key]; ok {
+		l.order.MoveToFront(e)
+		return
+	}
+	l.elements[key] = l.order.PushFront(key)
+}
+
+// remove removes the key if it exists.
+func (l *lruList[K]) remove(key K) {
+	if e, ok := l.elements[key]; ok {
+		l.order.Remove(e)
+		delete(l.elements, key)
+	}
+}
+
+// oldest returns the least recently used key, or false if the list is empty.
+func (l *lruList[K]) oldest() (K, bool) {
+	e := l.order.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	return e.Value.(K), true
+}
+
+// reset removes all the keys.
+func (l *lruList[K]) reset() {
+	l.order.Init()
+	clear(l.elements)
+}
+
+// evictOverflow evicts the least recently used entries or the entries with the lowest priority
+// until the number of the entries in the bucket fits in the max entries of WithMaxEntries,
+// and reports the number of the evicted entries to the observer of WithEvictionObserver.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) evictOverflow(o *options[K, V]) {
+	if evicted := b.evictOverflowEntries(o); evicted != 0 && o.evictionObserver != nil {
+		o.evictionObserver(evicted)
+	}
+}
+
+// evictOverflowEntries evicts the overflowed entries of the bucket, and returns the number of them.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) evictOverflowEntries(o *options[K, V]) int {
+	var evicted int
+	if b.recency != nil {
+		limit := o.bucketMaxEntries()
+		for len(b.m) > limit {
+			key, ok := b.recency.oldest()
+			if !ok {
+				break
+			}
+			b.delete(key)
+			evicted++
+		}
+		return evicted
+	}
+	if b.evictions == nil {
+		return 0
+	}
+
+	limit := o.bucketMaxEntries()
+	for len(b.m) > limit && b.evictions.Len() != 0 {
+		entry := heap.Pop(b.evictions).(*loadingcache.CacheEntry[K, V])
+		if b.m[entry.Key] == entry {
+			delete(b.m, entry.Key)
+			delete(b.metas, entry.Key)
+			evicted++
+		}
+	}
+	if b.evictions.Len() > 2*len(b.m) {
+		b.evictions.rebuild(b.m)
+	}
+	return evicted
+}
@@ -28,3 +28,10 @@ func ExampleNewInMemoryStorage_opts() {
 
 	_ = storage
 }
+
+func ExampleNewSizedInMemoryStorage() {
+	// Create a storage sized for about 100,000 entries
+	storage := memstorage.NewSizedInMemoryStorage[string, MyValue](100_000)
+
+	_ = storage
+}
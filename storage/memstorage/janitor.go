@@ -0,0 +1,68 @@
+package memstorage
+
+import (
+	"sync"
+	"time"
+)
+
+// janitor is the background goroutine of WithJanitor that sweeps the expired entries periodically.
+type janitor struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// launchJanitor starts calling sweep at every interval until the janitor is closed.
+func launchJanitor(interval time.Duration, sweep func()) *janitor {
+	j := &janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+	return j
+}
+
+// close stops the janitor and waits for the running sweep to finish.
+// It is safe to call it multiple times, or on a nil janitor.
+func (j *janitor) close() {
+	if j == nil {
+		return
+	}
+	j.once.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+}
+
+// sweepExpired removes the expired entries in the bucket under its write lock.
+// The expired entries are removed even if WithStaleReads is specified.
+// The stale metadata of the entries removed by evictExpired is removed as well.
+func (b *bucket[K, V]) sweepExpired(o *options[K, V]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := o.clock.Now()
+	for key, v := range b.m {
+		if o.isExpired(now, v) {
+			b.delete(key)
+		}
+	}
+	for key := range b.metas {
+		if _, ok := b.m[key]; !ok {
+			delete(b.metas, key)
+		}
+	}
+}
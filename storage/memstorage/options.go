@@ -1,6 +1,12 @@
 package memstorage
 
 import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/expiration"
 	"github.com/karupanerura/loading-cache/internal/keyhash"
@@ -9,6 +15,21 @@ import (
 // DefaultBucketsSize is the default number of buckets in the cache.
 var DefaultBucketsSize = 256
 
+// SizedEntriesPerBucket is the target number of entries per bucket for NewSizedInMemoryStorage.
+var SizedEntriesPerBucket = 64
+
+// MaxSizedBucketsSize is the maximum number of buckets chosen by NewSizedInMemoryStorage.
+var MaxSizedBucketsSize = 4096
+
+// ErrInvalidOptions is returned by NewInMemoryStorageE if the options are invalid.
+var ErrInvalidOptions = errors.New("memstorage: invalid options")
+
+// ErrStaleReadsDisabled is returned by GetStale of the storage if WithStaleReads is not specified.
+var ErrStaleReadsDisabled = errors.New("memstorage: stale reads are disabled")
+
+// ErrUnsafeRefAccessDisabled is returned by UnsafeRefAccessor.GetRef if WithUnsafeRefAccess is not specified.
+var ErrUnsafeRefAccessDisabled = errors.New("memstorage: unsafe ref access is disabled")
+
 // Option is the interface for the options of the in-memory cache storage.
 type Option[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
 	apply(*options[K, V])
@@ -23,6 +44,11 @@ func (f optionFunc[K, V]) apply(o *options[K, V]) {
 // WithKeyHash sets the key hash function to the storage.
 func WithKeyHash[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](f func(K) int) Option[K, V] {
 	return optionFunc[K, V](func(o *options[K, V]) {
+		o.defaultKeyHash = false
+		if f == nil {
+			o.hashKey = nil
+			return
+		}
 		o.hashKey = func(key any) int {
 			return f(key.(K))
 		}
@@ -40,6 +66,21 @@ func WithBucketsSize[K loadingcache.KeyConstraint, V loadingcache.ValueConstrain
 	})
 }
 
+// WithShardGroups makes the storage use a two-level bucket structure: the keys are distributed across the shard groups first,
+// and then across the buckets of each group. So the total number of buckets is the number of shard groups multiplied by
+// the number of buckets specified by WithBucketsSize.
+// The index of each level is computed from the key hash mixed independently, so the keys in a group are still spread
+// evenly across its buckets even if the key hash is poorly distributed in some bits.
+//
+// It helps for extremely large caches, where a single level would need too many buckets with a weak key hash,
+// or too large maps per bucket. For the most caches, WithBucketsSize alone is enough.
+// The number of shard groups must be a natural number, otherwise NewInMemoryStorageE returns an error.
+func WithShardGroups[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](shardGroups int) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.shardGroups = shardGroups
+	})
+}
+
 // WithClock sets the clock to the storage.
 func WithClock[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](clock loadingcache.Clock) Option[K, V] {
 	return optionFunc[K, V](func(o *options[K, V]) {
@@ -47,34 +88,494 @@ func WithClock[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](clo
 	})
 }
 
+// WithCachedClock makes the storage read the current time from a loadingcache.CachedClock
+// that caches the time of the configured clock and is refreshed at the given resolution.
+// It reduces the overhead of reading the clock for each Get in high-frequency paths.
+//
+// The cached time lags behind the configured clock by at most the resolution, so the expired entries may be
+// returned for up to the resolution after their expiration.
+// The background updater of the clock is stopped by canceling the given context; after that, the time is no longer refreshed.
+// The resolution must be positive, otherwise NewInMemoryStorageE returns an error.
+func WithCachedClock[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ctx context.Context, resolution time.Duration) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.cachedClock = true
+		o.cachedClockCtx = ctx
+		o.cachedClockResolution = resolution
+	})
+}
+
+// WithTTLBounds makes the storage clamp the expiration time of each stored entry between now+minTTL and now+maxTTL,
+// where now is the time of the clock at Set or SetMulti.
+// It protects the storage against the sources returning absurd expiration times.
+// The zero maxTTL means no upper bound.
+//
+// The entries with the zero ExpiresAt are stored as they are, so whether they expire immediately or never
+// depends on the expiration policy (e.g. expiration.NeverExpirationPolicy).
+// The bounds must not be negative, and minTTL must not be greater than the non-zero maxTTL,
+// otherwise NewInMemoryStorageE returns an error.
+func WithTTLBounds[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](minTTL, maxTTL time.Duration) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.minTTL = minTTL
+		o.maxTTL = maxTTL
+	})
+}
+
 // WithCloner sets the value cloner to the storage.
+// If it is a loadingcache.ImmutableValueCloner[V] (e.g. returned by loadingcache.ImmutableValues), WithCopyOnWrite is enabled as well.
+// It overrides WithClonerChain.
 func WithCloner[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cloner loadingcache.ValueCloner[V]) Option[K, V] {
 	return optionFunc[K, V](func(o *options[K, V]) {
 		o.cloner = cloner
+		o.clonerChain = nil
+		o.defaultCloner = false
+	})
+}
+
+// WithClonerChain sets the value cloner selected by the first applicable strategy in the given order,
+// e.g. loadingcache.CloneMethodStrategy, then loadingcache.FixedStrategy with a cloner registered for the value type.
+// The strategies are applicable or not by the value type, so the cloner is selected once when the storage is created.
+// If none of them is applicable, the storage is not created: NewInMemoryStorageE returns an error wrapping ErrInvalidOptions,
+// and NewInMemoryStorage panics. It overrides WithCloner.
+//
+// The default is the chain of loadingcache.DefaultValueCloner: the Immutable types, the Clone method, the DeepCopy method,
+// and the primitive types.
+func WithClonerChain[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](strategies ...loadingcache.ValueClonerStrategy[V]) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.cloner = nil
+		o.clonerChain = append([]loadingcache.ValueClonerStrategy[V]{}, strategies...)
+		o.defaultCloner = false
 	})
 }
 
 // WithExpirationPolicy sets the expiration policy to the storage.
+// If the policy implements expiration.PerEntryExpirationPolicy for the key and value types of the storage,
+// the storage checks the expiration of each entry by IsEntryExpired.
+// If the policy implements expiration.AccessObserver (e.g. expiration.SlidingExpirationPolicy), the reads of Get, GetMulti
+// and GetRef extend the expiration times of the returned entries, clamped by WithTTLBounds. Such reads take the write lock
+// of the bucket instead of the read lock, so they are serialized with the other accesses to the bucket.
 func WithExpirationPolicy[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](policy expiration.ExpirationPolicy) Option[K, V] {
 	return optionFunc[K, V](func(o *options[K, V]) {
 		o.expirationPolicy = policy
 	})
 }
 
+// WithCopyOnWrite makes the storage share the stored entries with readers instead of cloning them.
+//
+// The stored entries are never mutated in place: Set and SetMulti always clone the input entry and replace
+// the stored one with it. So a reader holding an entry returned by Get or GetMulti keeps seeing the value
+// at the time of the read, even if the key is overwritten later.
+// In exchange, the returned entries and their values are shared between the storage and all readers,
+// so the callers must treat them as read-only.
+//
+// It is enabled automatically if the value type implements Immutable.
+func WithCopyOnWrite[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.copyOnWrite = true
+	})
+}
+
+// WriteSkipMode is the behavior of WithWriteSkipIfEqual for the skipped writes.
+type WriteSkipMode int
+
+const (
+	// WriteSkipUpdateExpiration makes the skipped writes update only the expiration time of the stored entry.
+	// The value is neither cloned nor replaced.
+	WriteSkipUpdateExpiration WriteSkipMode = iota
+
+	// WriteSkipEntirely makes the skipped writes leave the stored entry as it is, including the expiration time.
+	WriteSkipEntirely
+)
+
+// WithWriteSkipIfEqual makes Set and SetMulti skip replacing the stored entry if it is live and its value equals the new one by equal.
+// The negative caches are considered equal to each other. The behavior of the skipped writes is specified by mode.
+// It reduces the cloning of the values and the churn of the stored entries for the sources returning the same values repeatedly,
+// and the skipped writes do not update the last-write times of WithAccessTracking.
+func WithWriteSkipIfEqual[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](equal func(a, b V) bool, mode WriteSkipMode) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.writeSkipEqual = equal
+		o.writeSkipMode = mode
+	})
+}
+
+// WithUnsafeRefAccess enables UnsafeRefAccessor.GetRef of the storage.
+// It is disabled by default because GetRef returns the stored entries without cloning,
+// and the callers are responsible for not mutating them.
+func WithUnsafeRefAccess[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.unsafeRefAccess = true
+	})
+}
+
+// WithAccessTracking makes the storage record the last-access and last-write times of each entry,
+// and enables EntryMetaInspector.EntryMeta of the storage.
+// The times are read from the clock of the storage, and they are kept internally instead of in the returned entries.
+// Get, GetMulti and GetRef update the last-access time of the found entries, and Set and SetMulti update the last-write time.
+func WithAccessTracking[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.accessTracking = true
+	})
+}
+
+// WithStaleReads makes the storage keep the expired entries, and enables GetStale of the storage
+// to implement loadingcache.StaleCacheStorage.
+// The expired entries are still treated as missing by Get and GetMulti, but they are kept until they are overwritten or cleared
+// instead of being removed when they are found expired.
+func WithStaleReads[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.staleReads = true
+	})
+}
+
+// WithMaxEntries bounds the number of the entries in the storage, evicting the entries on Set and SetMulti
+// when the bound is exceeded. The least recently used entries are evicted by default, or the entries to evict
+// are chosen by WithEvictionPriority if it is specified.
+// The bound is divided evenly among the buckets, so each bucket holds at most ceil(maxEntries / the number of buckets)
+// entries and the storage may evict the entries before it holds maxEntries in total.
+// The expired entries count toward the bound until they are removed.
+//
+// For the LRU eviction, Get, GetMulti and GetRef update the access order of the entries,
+// so they acquire the write lock of the bucket instead of the read lock. Use more buckets to reduce the contention.
+func WithMaxEntries[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](maxEntries int) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.maxEntries = maxEntries
+	})
+}
+
+// WithEvictionPriority sets the priority of the entries to evict when the storage exceeds the bound of WithMaxEntries.
+// less reports whether the entry a has a lower priority than the entry b, and the entry with the lowest priority
+// is evicted first. For example, comparing the expiration times evicts the soonest-expiring entries first.
+// The entries are kept in a heap per bucket, so Set and SetMulti take O(log n) additionally.
+// It replaces the default LRU eviction, and it has no effect unless WithMaxEntries is specified.
+func WithEvictionPriority[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](less func(a, b *loadingcache.CacheEntry[K, V]) bool) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.evictionLess = less
+	})
+}
+
+// WithEvictionObserver sets the function called with the number of the entries evicted by the bound of WithMaxEntries,
+// e.g. metrics.Collector.RecordEvictions. It is called under the write lock of the bucket, so it must be fast
+// and must not call the storage. The expired entries removed by the reads or the janitor are not counted.
+// It has no effect unless WithMaxEntries is specified.
+func WithEvictionObserver[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](observer func(n int)) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.evictionObserver = observer
+	})
+}
+
+// WithJanitor launches a background goroutine that removes the expired entries of all the buckets at every interval.
+// Without it, the expired entries are removed only when they are read, so the entries never read again are kept forever.
+// The buckets are swept one by one under the write lock of each, so the sweep of a bucket does not block the others.
+// The expired entries are removed even if WithStaleReads is specified, so they are readable by GetStale only until the next sweep.
+//
+// The storage implements io.Closer, and the goroutine runs until Close of the storage is called.
+// The interval must be positive, otherwise NewInMemoryStorageE returns an error.
+func WithJanitor[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](interval time.Duration) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.janitor = true
+		o.janitorInterval = interval
+	})
+}
+
+// BucketHinter is the interface for the key types that provide their own bucket hints for WithBucketHints.
+type BucketHinter interface {
+	// BucketHint returns the hint to choose the bucket of the key, which is used in place of the hash of the key.
+	// It must be stable: the same key must always return the same hint for the lifetime of the storage,
+	// otherwise the entries are stored in a bucket and looked up in another one.
+	// It should be well-distributed across the keys like a hash, since the buckets are chosen by the hint
+	// modulo the number of buckets, so the poorly distributed hints concentrate the entries and the lock contention
+	// on a few buckets. Negative hints are allowed.
+	BucketHint() int
+}
+
+// WithBucketHints makes the storage choose the buckets by BucketHint of the keys instead of hashing them.
+// It saves hashing the keys on every access, e.g. for the hot keys whose hints are precomputed on construction.
+// The key type K must implement BucketHinter, otherwise NewInMemoryStorageE returns an error.
+// It takes precedence over WithKeyHash. See BucketHinter for the requirements of the hints.
+func WithBucketHints[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.bucketHints = true
+	})
+}
+
+// withExpectedEntries sets the expected number of entries to preallocate the buckets.
+func withExpectedEntries[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](expectedEntries int) Option[K, V] {
+	return optionFunc[K, V](func(o *options[K, V]) {
+		o.expectedEntries = expectedEntries
+	})
+}
+
 type options[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
 	hashKey          func(any) int
+	defaultKeyHash   bool
 	bucketsSize      int
+	shardGroups      int
 	clock            loadingcache.Clock
 	cloner           loadingcache.ValueCloner[V]
+	clonerChain      []loadingcache.ValueClonerStrategy[V]
+	defaultCloner    bool
 	expirationPolicy expiration.ExpirationPolicy
+	entryPolicy      expiration.PerEntryExpirationPolicy[K, V]
+	accessObserver   expiration.AccessObserver
+	expectedEntries  int
+	copyOnWrite      bool
+	unsafeRefAccess  bool
+	accessTracking   bool
+	staleReads       bool
+	writeSkipEqual   func(a, b V) bool
+	writeSkipMode    WriteSkipMode
+	minTTL           time.Duration
+	maxTTL           time.Duration
+	maxEntries       int
+	evictionLess     func(a, b *loadingcache.CacheEntry[K, V]) bool
+	evictionObserver func(n int)
+	bucketHints      bool
+	janitor          bool
+	janitorInterval  time.Duration
+
+	cachedClock           bool
+	cachedClockCtx        context.Context
+	cachedClockResolution time.Duration
+}
+
+// validate checks the options and returns an error wrapping ErrInvalidOptions if they are invalid.
+func (o *options[K, V]) validate() error {
+	switch {
+	case o.bucketsSize < 1:
+		return fmt.Errorf("%w: the number of buckets must be a natural number, got %d", ErrInvalidOptions, o.bucketsSize)
+	case o.shardGroups < 1:
+		return fmt.Errorf("%w: the number of shard groups must be a natural number, got %d", ErrInvalidOptions, o.shardGroups)
+	case o.hashKey == nil && !o.defaultKeyHash && !o.bucketHints:
+		return fmt.Errorf("%w: the key hash function must not be nil", ErrInvalidOptions)
+	case o.clock == nil:
+		return fmt.Errorf("%w: the clock must not be nil", ErrInvalidOptions)
+	case o.cloner == nil && o.clonerChain == nil && !o.defaultCloner:
+		return fmt.Errorf("%w: the value cloner must not be nil", ErrInvalidOptions)
+	case o.clonerChain != nil && !o.clonerChainApplicable():
+		return fmt.Errorf("%w: none of the cloner strategies is applicable to the value type", ErrInvalidOptions)
+	case o.cloner == nil && o.clonerChain == nil && !defaultClonerApplicable[V]():
+		return fmt.Errorf("%w: the default value cloner is not applicable to the value type; specify WithCloner or WithClonerChain", ErrInvalidOptions)
+	case o.expirationPolicy == nil:
+		return fmt.Errorf("%w: the expiration policy must not be nil", ErrInvalidOptions)
+	case o.expectedEntries < 0:
+		return fmt.Errorf("%w: the expected number of entries must not be negative, got %d", ErrInvalidOptions, o.expectedEntries)
+	case o.maxEntries < 0:
+		return fmt.Errorf("%w: the max entries must not be negative, got %d", ErrInvalidOptions, o.maxEntries)
+	case o.bucketHints && !implementsBucketHinter[K]():
+		return fmt.Errorf("%w: the key type must implement BucketHinter for the bucket hints", ErrInvalidOptions)
+	case o.cachedClock && o.cachedClockResolution <= 0:
+		return fmt.Errorf("%w: the resolution of the cached clock must be positive, got %v", ErrInvalidOptions, o.cachedClockResolution)
+	case o.cachedClock && o.cachedClockCtx == nil:
+		return fmt.Errorf("%w: the context of the cached clock must not be nil", ErrInvalidOptions)
+	case o.minTTL < 0 || o.maxTTL < 0:
+		return fmt.Errorf("%w: the TTL bounds must not be negative, got [%v, %v]", ErrInvalidOptions, o.minTTL, o.maxTTL)
+	case o.maxTTL != 0 && o.minTTL > o.maxTTL:
+		return fmt.Errorf("%w: the min TTL must not be greater than the max TTL, got [%v, %v]", ErrInvalidOptions, o.minTTL, o.maxTTL)
+	case o.janitor && o.janitorInterval <= 0:
+		return fmt.Errorf("%w: the interval of the janitor must be positive, got %v", ErrInvalidOptions, o.janitorInterval)
+	}
+	return nil
+}
+
+// resolveClock wraps the clock with the loadingcache.CachedClock if WithCachedClock is specified.
+// It must be called after all the options are applied, to wrap the clock specified by WithClock regardless of the order.
+func (o *options[K, V]) resolveClock() {
+	if !o.cachedClock {
+		return
+	}
+
+	clock := loadingcache.NewCachedClock(o.clock)
+	clock.LaunchBackgroundUpdater(o.cachedClockCtx, o.cachedClockResolution)
+	o.clock = clock
+}
+
+// resolveKeyHash replaces the key hash function by BucketHint of the keys if WithBucketHints is specified,
+// or sets the default key hash function of the key type unless WithKeyHash is specified.
+// It must be called after all the options are applied, to take precedence over WithKeyHash regardless of the order.
+// The default key hash function is resolved lazily, since it panics for the key types without the default.
+func (o *options[K, V]) resolveKeyHash() {
+	switch {
+	case o.bucketHints:
+		o.hashKey = func(key any) int {
+			return key.(BucketHinter).BucketHint()
+		}
+	case o.defaultKeyHash:
+		o.hashKey = keyhash.GetOrCreateKeyHash[K]()
+	}
+}
+
+// implementsBucketHinter reports whether the key type implements BucketHinter.
+func implementsBucketHinter[K loadingcache.KeyConstraint]() bool {
+	var zero K
+	_, ok := any(zero).(BucketHinter)
+	return ok
+}
+
+// clonerChainApplicable reports whether any strategy of WithClonerChain is applicable to the value type.
+func (o *options[K, V]) clonerChainApplicable() bool {
+	_, ok := loadingcache.ResolveValueCloner(o.clonerChain...)
+	return ok
+}
+
+// defaultClonerApplicable reports whether loadingcache.DefaultValueCloner is applicable to the value type without panic.
+func defaultClonerApplicable[V loadingcache.ValueConstraint]() bool {
+	_, ok := loadingcache.ResolveValueCloner(loadingcache.ImmutableStrategy[V](), loadingcache.CloneMethodStrategy[V](), loadingcache.DeepCopyMethodStrategy[V](), loadingcache.PrimitiveStrategy[V]())
+	return ok
+}
+
+// resolveCloner selects the value cloner by WithClonerChain or the default one unless WithCloner is specified,
+// and enables WithCopyOnWrite if the value cloner is a loadingcache.ImmutableValueCloner[V].
+// It must be called after all the options are applied and validated.
+func (o *options[K, V]) resolveCloner() {
+	switch {
+	case o.clonerChain != nil:
+		o.cloner, _ = loadingcache.ResolveValueCloner(o.clonerChain...)
+	case o.defaultCloner:
+		o.cloner = loadingcache.DefaultValueCloner[V]()
+	}
+	if _, ok := o.cloner.(loadingcache.ImmutableValueCloner[V]); ok {
+		o.copyOnWrite = true
+	}
+}
+
+// resolveExpirationPolicy detects expiration.PerEntryExpirationPolicy and expiration.AccessObserver of the expiration policy.
+// It must be called after all the options are applied.
+func (o *options[K, V]) resolveExpirationPolicy() {
+	o.entryPolicy, _ = o.expirationPolicy.(expiration.PerEntryExpirationPolicy[K, V])
+	o.accessObserver, _ = o.expirationPolicy.(expiration.AccessObserver)
+}
+
+// isExpired reports whether the stored entry is expired at now by the expiration policy.
+func (o *options[K, V]) isExpired(now time.Time, v *loadingcache.CacheEntry[K, V]) bool {
+	if o.entryPolicy != nil {
+		return o.entryPolicy.IsEntryExpired(now, v)
+	}
+	return o.expirationPolicy.IsExpired(now, v.ExpiresAt)
+}
+
+// viewEntry returns the entry to be returned to readers.
+func (o *options[K, V]) viewEntry(v *loadingcache.CacheEntry[K, V]) *loadingcache.CacheEntry[K, V] {
+	if o.copyOnWrite {
+		return v
+	}
+	return cloneCacheEntry(o.cloner, v)
+}
+
+// storedEntryClock returns the current time to clamp the expiration times of the stored entries.
+// It returns the zero time if WithTTLBounds is not specified.
+func (o *options[K, V]) storedEntryClock() time.Time {
+	if o.minTTL == 0 && o.maxTTL == 0 {
+		return time.Time{}
+	}
+	return o.clock.Now()
+}
+
+// accessTrackingClock returns the current time to record the last-write times of the stored entries.
+// It returns the zero time if WithAccessTracking is not specified.
+func (o *options[K, V]) accessTrackingClock() time.Time {
+	if !o.accessTracking {
+		return time.Time{}
+	}
+	return o.clock.Now()
+}
+
+// newEntryMetas returns the map of the entry metadata for a bucket, or nil if WithAccessTracking is not specified.
+func (o *options[K, V]) newEntryMetas(capacity int) map[K]*entryMeta {
+	if !o.accessTracking {
+		return nil
+	}
+	return make(map[K]*entryMeta, capacity)
+}
+
+// newEvictionHeap returns the priority queue of the entries of a bucket,
+// or nil unless both WithMaxEntries and WithEvictionPriority are specified.
+func (o *options[K, V]) newEvictionHeap(capacity int) *evictionHeap[K, V] {
+	if o.maxEntries == 0 || o.evictionLess == nil {
+		return nil
+	}
+	return &evictionHeap[K, V]{
+		less:    o.evictionLess,
+		entries: make([]*loadingcache.CacheEntry[K, V], 0, capacity),
+		indexes: make(map[K]int, capacity),
+	}
 }
 
+// newLRUList returns the access order of the entries of a bucket,
+// or nil unless WithMaxEntries is specified without WithEvictionPriority.
+func (o *options[K, V]) newLRUList(capacity int) *lruList[K] {
+	if o.maxEntries == 0 || o.evictionLess != nil {
+		return nil
+	}
+	return &lruList[K]{elements: make(map[K]*list.Element, capacity)}
+}
+
+// bucketMaxEntries returns the max entries of each bucket.
+func (o *options[K, V]) bucketMaxEntries() int {
+	return (o.maxEntries + o.totalBuckets() - 1) / o.totalBuckets()
+}
+
+// storedEntry returns the entry to be stored.
+// It clones the given entry, and clamps its expiration time by the TTL bounds unless now is zero.
+func (o *options[K, V]) storedEntry(v *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	entry := cloneCacheEntry(o.cloner, v)
+	entry.ExpiresAt = o.clampExpiresAt(entry.ExpiresAt, now)
+	return entry
+}
+
+// clampExpiresAt clamps the expiration time by the TTL bounds unless now or the expiration time is zero.
+func (o *options[K, V]) clampExpiresAt(expiresAt, now time.Time) time.Time {
+	if now.IsZero() || expiresAt.IsZero() {
+		return expiresAt
+	}
+
+	if lower := now.Add(o.minTTL); expiresAt.Before(lower) {
+		expiresAt = lower
+	}
+	if upper := now.Add(o.maxTTL); o.maxTTL != 0 && expiresAt.After(upper) {
+		expiresAt = upper
+	}
+	return expiresAt
+}
+
+// skipsWrite reports whether the write of the entry over the existing live entry is skipped by WithWriteSkipIfEqual.
+func (o *options[K, V]) skipsWrite(existing, entry *loadingcache.CacheEntry[K, V]) bool {
+	if o.writeSkipEqual == nil || existing.NegativeCache != entry.NegativeCache {
+		return false
+	}
+	if !existing.NegativeCache && !o.writeSkipEqual(existing.Value, entry.Value) {
+		return false
+	}
+	return !o.isExpired(o.clock.Now(), existing)
+}
+
+// totalBuckets returns the total number of buckets across all the shard groups.
+func (o *options[K, V]) totalBuckets() int {
+	return o.bucketsSize * o.shardGroups
+}
+
+// bucketCapacity returns the initial capacity of each bucket.
+func (o *options[K, V]) bucketCapacity() int {
+	return (o.expectedEntries + o.totalBuckets() - 1) / o.totalBuckets()
+}
+
+// Immutable is a marker interface for immutable values. It is an alias of loadingcache.Immutable.
+// The storage shares the values implementing it with readers as WithCopyOnWrite does,
+// and uses loadingcache.ImmutableValueCloner as the default value cloner for them, as the loaders do.
+type Immutable = loadingcache.Immutable
+
 func defaultOptions[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint]() options[K, V] {
+	var zero V
+	_, immutable := any(zero).(Immutable)
+
+	// note: the default value cloner is resolved lazily by resolveCloner, since it panics for the value types
+	// without Clone or DeepCopy method even if WithCloner or WithClonerChain is specified.
+	// validate reports an error instead if it is required but not applicable.
 	return options[K, V]{
-		hashKey:          keyhash.GetOrCreateKeyHash[K](),
+		defaultKeyHash:   true,
 		bucketsSize:      DefaultBucketsSize,
+		shardGroups:      1,
 		clock:            loadingcache.SystemClock,
-		cloner:           loadingcache.DefaultValueCloner[V](),
+		defaultCloner:    true,
 		expirationPolicy: expiration.GeneralExpirationPolicy{},
+		copyOnWrite:      immutable,
 	}
 }
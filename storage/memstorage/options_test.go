@@ -1,11 +1,47 @@
 package memstorage_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	loadingcache "github.com/karupanerura/loading-cache"
 	"github.com/karupanerura/loading-cache/storage/memstorage"
 )
 
+func TestBucketsSizeFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expectedEntries int
+		want            int
+	}{
+		{0, 1},
+		{1, 1},
+		{memstorage.SizedEntriesPerBucket, 1},
+		{memstorage.SizedEntriesPerBucket + 1, 2},
+		{memstorage.SizedEntriesPerBucket * 100, 100},
+		{memstorage.SizedEntriesPerBucket * memstorage.MaxSizedBucketsSize, memstorage.MaxSizedBucketsSize},
+		{memstorage.SizedEntriesPerBucket*memstorage.MaxSizedBucketsSize + 1, memstorage.MaxSizedBucketsSize},
+	}
+	for _, tt := range tests {
+		if got := memstorage.BucketsSizeFor(tt.expectedEntries); got != tt.want {
+			t.Errorf("BucketsSizeFor(%d) = %d, want %d", tt.expectedEntries, got, tt.want)
+		}
+	}
+
+	// the number of buckets must scale with the expected entries
+	prev := 0
+	for expectedEntries := 0; expectedEntries <= memstorage.SizedEntriesPerBucket*memstorage.MaxSizedBucketsSize; expectedEntries += 1000 {
+		got := memstorage.BucketsSizeFor(expectedEntries)
+		if got < prev {
+			t.Errorf("BucketsSizeFor(%d) = %d must not be less than %d", expectedEntries, got, prev)
+		}
+		prev = got
+	}
+}
+
 func TestWithBucketsSize(t *testing.T) {
 	t.Parallel()
 
@@ -31,3 +67,133 @@ func TestWithBucketsSize(t *testing.T) {
 		memstorage.WithBucketsSize[uint8, uint8](0)
 	})
 }
+
+func TestNewInMemoryStorageE(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		opts    []memstorage.Option[uint8, int8]
+		message string
+	}{
+		{
+			name:    "ZeroShardGroups",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithShardGroups[uint8, int8](0)},
+			message: "the number of shard groups must be a natural number",
+		},
+		{
+			name:    "NilClock",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithClock[uint8, int8](nil)},
+			message: "the clock must not be nil",
+		},
+		{
+			name:    "NilCloner",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithCloner[uint8, int8](nil)},
+			message: "the value cloner must not be nil",
+		},
+		{
+			name:    "InapplicableClonerChain",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithClonerChain[uint8, int8](loadingcache.CloneMethodStrategy[int8]())},
+			message: "none of the cloner strategies is applicable",
+		},
+		{
+			name:    "NilExpirationPolicy",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithExpirationPolicy[uint8, int8](nil)},
+			message: "the expiration policy must not be nil",
+		},
+		{
+			name:    "NilKeyHash",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithKeyHash[uint8, int8](nil)},
+			message: "the key hash function must not be nil",
+		},
+		{
+			name:    "CachedClockWithoutContext",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithCachedClock[uint8, int8](nil, time.Second)},
+			message: "the context of the cached clock must not be nil",
+		},
+		{
+			name:    "NonPositiveCachedClockResolution",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithCachedClock[uint8, int8](t.Context(), 0)},
+			message: "the resolution of the cached clock must be positive",
+		},
+		{
+			name:    "NegativeTTLBounds",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithTTLBounds[uint8, int8](-time.Second, time.Hour)},
+			message: "the TTL bounds must not be negative",
+		},
+		{
+			name:    "InvertedTTLBounds",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithTTLBounds[uint8, int8](time.Hour, time.Minute)},
+			message: "the min TTL must not be greater than the max TTL",
+		},
+		{
+			name:    "NegativeMaxEntries",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithMaxEntries[uint8, int8](-1)},
+			message: "the max entries must not be negative",
+		},
+		{
+			name:    "NonPositiveJanitorInterval",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithJanitor[uint8, int8](0)},
+			message: "the interval of the janitor must be positive",
+		},
+		{
+			name:    "BucketHintsWithoutBucketHinter",
+			opts:    []memstorage.Option[uint8, int8]{memstorage.WithBucketHints[uint8, int8]()},
+			message: "the key type must implement BucketHinter",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := memstorage.NewInMemoryStorageE(tt.opts...)
+			if !errors.Is(err, memstorage.ErrInvalidOptions) {
+				t.Fatalf("expected ErrInvalidOptions, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected the error message to contain %q, got %q", tt.message, err.Error())
+			}
+			if s != nil {
+				t.Errorf("expected nil storage, got %v", s)
+			}
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("expected NewInMemoryStorage to panic, but did not panic")
+				} else if err, ok := r.(error); !ok || !errors.Is(err, memstorage.ErrInvalidOptions) {
+					t.Errorf("expected panic with ErrInvalidOptions, got %v", r)
+				}
+			}()
+			memstorage.NewInMemoryStorage(tt.opts...)
+		})
+	}
+
+	t.Run("InapplicableDefaultCloner", func(t *testing.T) {
+		t.Parallel()
+
+		type plain struct{ X []int }
+		s, err := memstorage.NewInMemoryStorageE[uint8, plain]()
+		if !errors.Is(err, memstorage.ErrInvalidOptions) {
+			t.Fatalf("expected ErrInvalidOptions, got %v", err)
+		}
+		if s != nil {
+			t.Errorf("expected nil storage, got %v", s)
+		}
+
+		// the default value cloner is not required if the value cloner is specified
+		if _, err := memstorage.NewInMemoryStorageE(memstorage.WithCloner[uint8, plain](loadingcache.NopValueCloner[plain]{})); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := memstorage.NewInMemoryStorageE[uint8, int8]()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s == nil {
+			t.Error("expected non-nil storage")
+		}
+	})
+}
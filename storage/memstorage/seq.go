@@ -0,0 +1,64 @@
+package memstorage
+
+import (
+	"context"
+	"iter"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// seqBatchSize is the maximum number of the entries that SetSeq stores at once.
+const seqBatchSize = 256
+
+// SeqSetter is the interface for the in-memory cache storages that can store the entries produced lazily by an iterator.
+// The storages created by this package implement it.
+type SeqSetter[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	// SetSeq stores the entries yielded by the iterator in the same way as SetMulti, without materializing all of them.
+	// The entries are consumed in batches, and each batch is stored by locking only the buckets of its entries,
+	// so the locks are never held while the iterator produces the entries.
+	// The nil entries are ignored. The batches stored before an error are kept.
+	// It returns the context error if the context is done before the iterator is exhausted.
+	SetSeq(ctx context.Context, seq iter.Seq[*loadingcache.CacheEntry[K, V]]) error
+}
+
+var _ SeqSetter[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ SeqSetter[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+
+func (s *distributedStorage[K, V]) SetSeq(ctx context.Context, seq iter.Seq[*loadingcache.CacheEntry[K, V]]) error {
+	return setSeqInBatches(ctx, seq, s.SetMulti)
+}
+
+func (s *storage[K, V]) SetSeq(ctx context.Context, seq iter.Seq[*loadingcache.CacheEntry[K, V]]) error {
+	return setSeqInBatches(ctx, seq, s.SetMulti)
+}
+
+// setSeqInBatches consumes the iterator and stores the entries by setMulti for each batch of seqBatchSize.
+// The batch buffer is reused, since setMulti clones the entries.
+func setSeqInBatches[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ctx context.Context, seq iter.Seq[*loadingcache.CacheEntry[K, V]], setMulti func(context.Context, []*loadingcache.CacheEntry[K, V]) error) error {
+	batch := make([]*loadingcache.CacheEntry[K, V], 0, seqBatchSize)
+	flush := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := setMulti(ctx, batch)
+		clear(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	for entry := range seq {
+		if entry == nil {
+			continue
+		}
+		batch = append(batch, entry)
+		if len(batch) == seqBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(batch) == 0 {
+		return ctx.Err()
+	}
+	return flush()
+}
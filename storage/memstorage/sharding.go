@@ -0,0 +1,26 @@
+package memstorage
+
+// The seeds to mix the key hash independently for each level of WithShardGroups.
+const (
+	shardGroupSeed  = 0x9e3779b97f4a7c15
+	shardBucketSeed = 0xc2b2ae3d27d4eb4f
+)
+
+// shardedBucketIndex returns the index of the bucket for the key hash in the two-level bucket structure.
+// The buckets of a shard group are laid out contiguously, so the index is group*bucketsSize+bucket.
+func shardedBucketIndex(hash, shardGroups, bucketsSize int) int {
+	group := mixHash(uint64(hash), shardGroupSeed) % uint64(shardGroups)
+	bucket := mixHash(uint64(hash), shardBucketSeed) % uint64(bucketsSize)
+	return int(group)*bucketsSize + int(bucket)
+}
+
+// mixHash mixes the bits of the hash with the seed by the finalizer of MurmurHash3.
+func mixHash(hash, seed uint64) uint64 {
+	hash ^= seed
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
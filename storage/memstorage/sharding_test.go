@@ -0,0 +1,37 @@
+package memstorage
+
+import (
+	"testing"
+
+	"github.com/karupanerura/loading-cache/internal/keyhash"
+)
+
+func TestShardedBucketIndex_Distribution(t *testing.T) {
+	t.Parallel()
+
+	const (
+		shardGroups     = 4
+		bucketsSize     = 16
+		entriesPerCell  = 1000
+		allowedVariance = 0.2
+	)
+
+	hashKey := keyhash.GetOrCreateKeyHash[uint32]()
+	counts := make([]int, shardGroups*bucketsSize)
+	for key := range uint32(len(counts) * entriesPerCell) {
+		index := shardedBucketIndex(hashKey(key), shardGroups, bucketsSize)
+		if index < 0 || index >= len(counts) {
+			t.Fatalf("index out of range for key %d: %d", key, index)
+		}
+		counts[index]++
+	}
+
+	for group := range shardGroups {
+		for bucket := range bucketsSize {
+			count := counts[group*bucketsSize+bucket]
+			if count < entriesPerCell*(1-allowedVariance) || count > entriesPerCell*(1+allowedVariance) {
+				t.Errorf("uneven distribution at group=%d bucket=%d: %d entries, want %d±%.0f%%", group, bucket, count, entriesPerCell, allowedVariance*100)
+			}
+		}
+	}
+}
@@ -2,9 +2,13 @@ package memstorage_test
 
 import (
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/expiration"
 	"github.com/karupanerura/loading-cache/storage/memstorage"
 	"github.com/karupanerura/loading-cache/storage/storagetest"
 )
@@ -44,6 +48,181 @@ func TestConsistency(t *testing.T) {
 	}
 }
 
+// event is a value type that needs a custom comparer, since time.Time must be compared by Equal.
+type event struct {
+	name string
+	at   time.Time
+}
+
+func (e event) Clone() event {
+	return e
+}
+
+func TestConsistencyWithOptions(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	patterns := make([]loadingcache.Entry[string, event], 10)
+	for i := range patterns {
+		name := "event" + strconv.Itoa(i)
+		patterns[i] = loadingcache.Entry[string, event]{Key: name, Value: event{name: name, at: base.Add(time.Duration(i) * time.Minute)}}
+	}
+	storagetest.TestConsistencyWithOptions(t, func() (loadingcache.CacheStorage[string, event], func()) {
+		return memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[string, event](4)), func() {}
+	}, patterns, cmp.Options{
+		cmp.Comparer(func(a, b event) bool {
+			return a.name == b.name && a.at.Equal(b.at)
+		}),
+	})
+}
+
+func TestRunAll(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var clocks []loadingcache.Clock
+	var cloners, deepCopyers int
+	t.Run("Suites", func(t *testing.T) {
+		storagetest.RunAll(t,
+			storagetest.ClockProvider(func(clock loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func()) {
+				mu.Lock()
+				defer mu.Unlock()
+				clocks = append(clocks, clock)
+				return memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock)), func() {}
+			}),
+			storagetest.ClonerProvider(func() (loadingcache.CacheStorage[uint8, *storagetest.TestClonerStruct], func()) {
+				mu.Lock()
+				defer mu.Unlock()
+				cloners++
+				return memstorage.NewInMemoryStorage[uint8, *storagetest.TestClonerStruct](), func() {}
+			}),
+			storagetest.DeepCopyerProvider(func() (loadingcache.CacheStorage[uint8, *storagetest.TestDeepCopyerStruct], func()) {
+				mu.Lock()
+				defer mu.Unlock()
+				deepCopyers++
+				return memstorage.NewInMemoryStorage[uint8, *storagetest.TestDeepCopyerStruct](), func() {}
+			}),
+		)
+	})
+
+	// the consistency suite uses the system clock, and the expiration suites use the fixed clocks
+	var systemClocks, fixedClocks int
+	for _, clock := range clocks {
+		switch clock.(type) {
+		case *storagetest.FixedClock:
+			fixedClocks++
+		default:
+			systemClocks++
+		}
+	}
+	if systemClocks == 0 || fixedClocks == 0 {
+		t.Errorf("the storage-level suites are not exercised: system=%d fixed=%d", systemClocks, fixedClocks)
+	}
+	if cloners == 0 || deepCopyers == 0 {
+		t.Errorf("the cloning suites are not exercised: cloners=%d deepCopyers=%d", cloners, deepCopyers)
+	}
+}
+
+func TestNeverExpiration(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			storagetest.TestNeverExpiration(t, func(clock loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func()) {
+				return memstorage.NewInMemoryStorage(
+					memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+					memstorage.WithClock[uint8, int8](clock),
+					memstorage.WithExpirationPolicy[uint8, int8](expiration.NeverExpirationPolicy{}),
+				), func() {}
+			})
+
+			t.Run("LenAndForEach", func(t *testing.T) {
+				t.Parallel()
+
+				base := time.Now()
+				clock := &storagetest.FixedClock{Time: base}
+				s := memstorage.NewInMemoryStorage(
+					memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+					memstorage.WithClock[uint8, int8](clock),
+					memstorage.WithExpirationPolicy[uint8, int8](expiration.NeverExpirationPolicy{}),
+				)
+				if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+					{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: base.Add(time.Second)},
+					{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: base.Add(time.Hour)},
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				clock.Time = base.Add(24 * time.Hour)
+				if n, err := s.(memstorage.Sizer).Len(t.Context()); err != nil {
+					t.Fatal(err)
+				} else if n != 2 {
+					t.Errorf("unexpected len: %d", n)
+				}
+				var visited int
+				if err := s.(memstorage.Iterable[uint8, int8]).ForEach(t.Context(), func(*loadingcache.CacheEntry[uint8, int8]) bool {
+					visited++
+					return true
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if visited != 2 {
+					t.Errorf("unexpected visited entries: %d", visited)
+				}
+			})
+		})
+	}
+}
+
+func TestShardGroupsConsistency(t *testing.T) {
+	t.Parallel()
+	for _, shardGroups := range []int{1, 3, 4} {
+		t.Run(strconv.Itoa(shardGroups), func(t *testing.T) {
+			t.Parallel()
+
+			storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+				return memstorage.NewInMemoryStorage(
+					memstorage.WithShardGroups[uint8, int8](shardGroups),
+					memstorage.WithBucketsSize[uint8, int8](4),
+				), func() {}
+			})
+		})
+	}
+}
+
+func TestSizedConsistency(t *testing.T) {
+	t.Parallel()
+	for _, expectedEntries := range []int{0, 1, 256, 100000} {
+		expectedEntries := expectedEntries
+		t.Run(strconv.Itoa(expectedEntries), func(t *testing.T) {
+			t.Parallel()
+
+			storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+				return memstorage.NewSizedInMemoryStorage[uint8, int8](expectedEntries), func() {}
+			})
+		})
+	}
+}
+
+func TestMaxEntriesConsistency(t *testing.T) {
+	t.Parallel()
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			// note: each bucket can hold all the uint8 keys, so the consistency is not affected by the LRU eviction.
+			storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+				return memstorage.NewInMemoryStorage(
+					memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+					memstorage.WithMaxEntries[uint8, int8](256*bucketsSize),
+				), func() {}
+			})
+		})
+	}
+}
+
 func TestKeyHash(t *testing.T) {
 	t.Parallel()
 	for i := range 7 {
@@ -143,6 +322,48 @@ func TestExpiration(t *testing.T) {
 	})
 }
 
+func TestPerEntryExpirationPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](&storagetest.FixedClock{Time: now}),
+				memstorage.WithExpirationPolicy[uint8, int8](&expiration.NegativeCacheSplitPolicy[uint8, int8]{
+					Positive: expiration.GeneralExpirationPolicy{},
+					Negative: &expiration.EarlyExpirationPolicy{Duration: 10 * time.Minute, Percentage: 1},
+				}),
+			)
+			err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(5 * time.Minute)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2}, ExpiresAt: now.Add(5 * time.Minute), NegativeCache: true},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 3}, ExpiresAt: now.Add(15 * time.Minute), NegativeCache: true},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := s.GetMulti(t.Context(), []uint8{1, 2, 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries[0] == nil || entries[0].Value != 1 {
+				t.Errorf("the positive entry must be alive, got %+v", entries[0])
+			}
+			if entries[1] != nil {
+				t.Errorf("the negative cache must be expired early, got %+v", entries[1])
+			}
+			if entries[2] == nil || !entries[2].NegativeCache {
+				t.Errorf("the negative cache before the early expiration must be alive, got %+v", entries[2])
+			}
+		})
+	}
+}
+
 func TestNegativeCache(t *testing.T) {
 	t.Parallel()
 	t.Run("SingleBucket", func(t *testing.T) {
@@ -2,8 +2,11 @@ package memstorage
 
 import (
 	"context"
-	"sort"
+	"io"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	loadingcache "github.com/karupanerura/loading-cache"
 )
@@ -11,104 +14,349 @@ import (
 type bucket[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
 	m  map[K]*loadingcache.CacheEntry[K, V]
 	mu sync.RWMutex
+
+	// metas is the metadata of the entries, or nil if WithAccessTracking is not specified.
+	metas map[K]*entryMeta
+
+	// evictions is the priority queue of the entries, or nil unless both WithMaxEntries and WithEvictionPriority are specified.
+	evictions *evictionHeap[K, V]
+
+	// recency is the access order of the entries, or nil unless WithMaxEntries is specified without WithEvictionPriority.
+	recency *lruList[K]
+
+	// slides is whether the reads extend the expiration times of the entries by the expiration.AccessObserver.
+	slides bool
+}
+
+// entryMeta is the metadata of an entry recorded by WithAccessTracking.
+type entryMeta struct {
+	// lastAccess is the last-access time in Unix nanoseconds, or zero if the entry has never been accessed.
+	// It is updated atomically because the readers hold only the read lock of the bucket.
+	lastAccess atomic.Int64
+
+	// lastWrite is the last-write time, updated under the write lock of the bucket.
+	lastWrite time.Time
 }
 
 type distributedStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
 	buckets []*bucket[K, V]
 	options options[K, V]
+	janitor *janitor
 }
 
 // NewInMemoryStorage creates a new in-memory cache storage.
 // The storage can be distributed across multiple buckets for improved performance and scalability.
 // The storage uses a hash function to distribute the keys across the buckets.
+// It panics if the options are invalid. Use NewInMemoryStorageE to handle the error instead.
 func NewInMemoryStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](opts ...Option[K, V]) loadingcache.CacheStorage[K, V] {
+	s, err := NewInMemoryStorageE(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewInMemoryStorageE is the same as NewInMemoryStorage, but it returns an error wrapping ErrInvalidOptions
+// instead of panicking if the options are invalid.
+func NewInMemoryStorageE[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](opts ...Option[K, V]) (loadingcache.CacheStorage[K, V], error) {
 	options := defaultOptions[K, V]()
 	for _, opt := range opts {
 		opt.apply(&options)
 	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	options.resolveClock()
+	options.resolveKeyHash()
+	options.resolveCloner()
+	options.resolveExpirationPolicy()
 
-	if options.bucketsSize == 1 {
-		return &storage[K, V]{
-			bucket:  bucket[K, V]{m: map[K]*loadingcache.CacheEntry[K, V]{}},
+	capacity := options.bucketCapacity()
+	if options.totalBuckets() == 1 {
+		s := &storage[K, V]{
+			bucket:  bucket[K, V]{m: make(map[K]*loadingcache.CacheEntry[K, V], capacity), metas: options.newEntryMetas(capacity), evictions: options.newEvictionHeap(capacity), recency: options.newLRUList(capacity), slides: options.accessObserver != nil},
 			options: options,
 		}
+		if options.janitorInterval > 0 {
+			s.janitor = launchJanitor(options.janitorInterval, func() {
+				s.sweepExpired(&s.options)
+			})
+		}
+		return s, nil
 	}
 
-	buckets := make([]*bucket[K, V], options.bucketsSize)
+	buckets := make([]*bucket[K, V], options.totalBuckets())
 	for i := range buckets {
-		buckets[i] = &bucket[K, V]{m: map[K]*loadingcache.CacheEntry[K, V]{}}
+		buckets[i] = &bucket[K, V]{m: make(map[K]*loadingcache.CacheEntry[K, V], capacity), metas: options.newEntryMetas(capacity), evictions: options.newEvictionHeap(capacity), recency: options.newLRUList(capacity), slides: options.accessObserver != nil}
 	}
 
-	return &distributedStorage[K, V]{
+	s := &distributedStorage[K, V]{
 		buckets: buckets,
 		options: options,
 	}
+	if options.janitorInterval > 0 {
+		s.janitor = launchJanitor(options.janitorInterval, func() {
+			for _, bucket := range s.buckets {
+				bucket.sweepExpired(&s.options)
+			}
+		})
+	}
+	return s, nil
+}
+
+// NewSizedInMemoryStorage creates a new in-memory cache storage sized for the expected number of entries.
+// The number of buckets is chosen by BucketsSizeFor, and each bucket is preallocated to hold its share of the expected entries.
+// The options are applied after the sizing, so WithBucketsSize overrides the chosen number of buckets.
+func NewSizedInMemoryStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](expectedEntries int, opts ...Option[K, V]) loadingcache.CacheStorage[K, V] {
+	if expectedEntries < 0 {
+		panic("expectedEntries must not be negative")
+	}
+
+	sized := make([]Option[K, V], 0, len(opts)+2)
+	sized = append(sized, WithBucketsSize[K, V](BucketsSizeFor(expectedEntries)), withExpectedEntries[K, V](expectedEntries))
+	sized = append(sized, opts...)
+	return NewInMemoryStorage(sized...)
+}
+
+// BucketsSizeFor returns the number of buckets for the expected number of entries.
+// It aims at SizedEntriesPerBucket entries per bucket, and the result is capped between 1 and MaxSizedBucketsSize.
+// Larger numbers of buckets reduce the lock contention, but each bucket has its own map and lock overhead.
+func BucketsSizeFor(expectedEntries int) int {
+	bucketsSize := (expectedEntries + SizedEntriesPerBucket - 1) / SizedEntriesPerBucket
+	return min(max(bucketsSize, 1), MaxSizedBucketsSize)
+}
+
+// Iterable is the interface for the in-memory cache storages that can iterate over their entries.
+// The storages created by this package implement it.
+type Iterable[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	// ForEach calls the visitor for each live entry until the visitor returns false.
+	// The entries are visited bucket by bucket: the live entries of a bucket are collected under its read lock,
+	// and the visitor is called after the lock is released, so the visitor may call the storage.
+	// The consistency is weak: the entries set or expired during the iteration may or may not be visited.
+	// It returns the context error if the context is done during the iteration.
+	ForEach(ctx context.Context, visitor func(*loadingcache.CacheEntry[K, V]) bool) error
+}
+
+// Clearable is the interface for the in-memory cache storages that can remove all their entries.
+// The storages created by this package implement it.
+type Clearable interface {
+	// Clear removes all the entries.
+	// It locks all the buckets at once, so the other operations are blocked until all the buckets are cleared.
+	Clear(ctx context.Context) error
+
+	// ClearIncremental removes all the entries bucket by bucket, releasing the lock of each bucket before clearing the next one.
+	// It does not stall the other operations on the whole storage, but the consistency is weak:
+	// during the operation, some buckets are cleared while others are not yet, and the entries set to the cleared buckets are kept.
+	// It returns the context error if the context is done before all the buckets are cleared.
+	ClearIncremental(ctx context.Context) error
+}
+
+// Taker is the interface for the in-memory cache storages that can get and delete the entries atomically.
+// The storages created by this package implement it.
+type Taker[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	// Take returns the live entry for the key and deletes it under the write lock of its bucket,
+	// or returns nil if it is not found or expired.
+	// Of the concurrent calls for the same key, only one of them returns the entry.
+	// It is suitable for the single-consumption entries such as the one-time tokens.
+	Take(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error)
+
+	// TakeMulti is the same as Take, but it takes multiple entries at once.
+	// The order of the returned entries matches the order of the input keys.
+	// If a key is duplicated, only its first occurrence returns the entry.
+	TakeMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error)
+}
+
+// UnsafeRefAccessor is the interface for the in-memory cache storages that can return the stored entries without cloning.
+// The storages created by this package implement it, but GetRef returns ErrUnsafeRefAccessDisabled unless WithUnsafeRefAccess is specified.
+type UnsafeRefAccessor[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	// GetRef returns the pointer to the stored entry for the key, or nil if it is not found or expired.
+	//
+	// It is unsafe: the returned entry and its value are shared with the storage and all other callers of GetRef,
+	// so the callers must never mutate them. The read lock is released before return, so the key may be overwritten
+	// or expired right after the call; the returned entry is never mutated by the storage, but it may be stale.
+	GetRef(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error)
+}
+
+// Peeker is the interface for the in-memory cache storages that can read the entries without the side effects of the reads.
+// The storages created by this package implement it.
+type Peeker[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] interface {
+	// Peek returns the live entry for the key, or nil if it is not found or expired.
+	// Unlike Get, it never updates the access order of WithMaxEntries, the expiration times of the sliding expiration
+	// and the last-access times of WithAccessTracking, and it never removes the expired entry.
+	// It is suitable for the inspection of the storages for debugging.
+	Peek(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error)
+}
+
+// Sizer is the interface for the in-memory cache storages that can count their live entries.
+// The storages created by this package implement it.
+type Sizer interface {
+	// Len returns the number of the live entries. The expired entries not removed yet are not counted.
+	// It acquires the read locks of all the buckets at once in the order of the bucket indexes, so the count is
+	// a consistent snapshot, but the writes are blocked until all the buckets are counted.
+	// It returns the context error if the context is done before counting.
+	Len(ctx context.Context) (int, error)
+}
+
+// EntryMetaInspector is the interface for the in-memory cache storages that can report the metadata of the entries for debugging.
+// The storages created by this package implement it, but EntryMeta always returns false unless WithAccessTracking is specified.
+type EntryMetaInspector[K loadingcache.KeyConstraint] interface {
+	// EntryMeta returns the last-access and last-write times of the live entry for the key.
+	// The last-access time is zero if the entry has never been accessed since the storage was created.
+	// It returns false if the entry is not found or expired.
+	EntryMeta(key K) (lastAccess, lastWrite time.Time, ok bool)
 }
 
 var _ loadingcache.CacheStorage[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ Iterable[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ Clearable = (*distributedStorage[uint8, struct{}])(nil)
+var _ Sizer = (*distributedStorage[uint8, struct{}])(nil)
+var _ loadingcache.PartialSetCacheStorage[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ io.Closer = (*distributedStorage[uint8, struct{}])(nil)
+var _ Taker[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ UnsafeRefAccessor[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ EntryMetaInspector[uint8] = (*distributedStorage[uint8, struct{}])(nil)
+var _ Peeker[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
+var _ loadingcache.StaleCacheStorage[uint8, struct{}] = (*distributedStorage[uint8, struct{}])(nil)
 
 // resolveBucket returns the bucket that corresponds to the given key.
 func (s *distributedStorage[K, V]) resolveBucket(key K) *bucket[K, V] {
+	return s.buckets[s.bucketIndex(key)]
+}
+
+// bucketIndex returns the index of the bucket that corresponds to the given key.
+func (s *distributedStorage[K, V]) bucketIndex(key K) int {
+	if s.options.shardGroups > 1 {
+		return shardedBucketIndex(s.options.hashKey(key), s.options.shardGroups, s.options.bucketsSize)
+	}
+
 	index := s.options.hashKey(key) % len(s.buckets)
 	if index < 0 {
 		index *= -1
 	}
-	return s.buckets[index]
+	return index
 }
 
-// resolveBuckets returns the indexes and buckets that correspond to the given keys.
-func (s *distributedStorage[K, V]) resolveBuckets(keys []K) (indexes map[K]int, buckets []int) {
-	indexes = make(map[K]int, len(keys))
-	seen := make(map[int]struct{}, len(keys))
-	for _, key := range keys {
-		index := s.options.hashKey(key) % len(s.buckets)
-		if index < 0 {
-			index *= -1
+// smallBatchSize is the maximum number of keys for which resolveBuckets deduplicates the buckets by a linear scan.
+// The linear scan is faster than sorting for the small number of keys typical in GetMulti.
+const smallBatchSize = 16
+
+// maxPooledBatchSize is the maximum capacity of the scratch buffers returned to the pool.
+// The larger buffers are discarded to avoid retaining memory for rare large batches.
+const maxPooledBatchSize = 1024
+
+// bucketIndexes is the scratch buffers of resolveBuckets.
+type bucketIndexes struct {
+	// indexes is the bucket indexes of the keys, in the order of the keys.
+	indexes []int
+
+	// buckets is the distinct bucket indexes in ascending order.
+	buckets []int
+}
+
+var bucketIndexesPool = sync.Pool{
+	New: func() any {
+		return &bucketIndexes{
+			indexes: make([]int, 0, smallBatchSize),
+			buckets: make([]int, 0, smallBatchSize),
 		}
-		indexes[key] = index
-		if _, ok := seen[index]; !ok {
-			buckets = append(buckets, index)
-			seen[index] = struct{}{}
+	},
+}
+
+// release returns the scratch buffers to the pool.
+func (b *bucketIndexes) release() {
+	if cap(b.indexes) > maxPooledBatchSize || cap(b.buckets) > maxPooledBatchSize {
+		return
+	}
+	b.indexes = b.indexes[:0]
+	b.buckets = b.buckets[:0]
+	bucketIndexesPool.Put(b)
+}
+
+// resolveBuckets returns the bucket indexes of the given keys and the distinct buckets to lock in ascending order.
+// The result must be released after use.
+func (s *distributedStorage[K, V]) resolveBuckets(keys []K) *bucketIndexes {
+	r := bucketIndexesPool.Get().(*bucketIndexes)
+	for _, key := range keys {
+		r.indexes = append(r.indexes, s.bucketIndex(key))
+	}
+
+	if len(keys) <= smallBatchSize {
+		// insertion sort with deduplication
+		for _, index := range r.indexes {
+			i, found := slices.BinarySearch(r.buckets, index)
+			if !found {
+				r.buckets = slices.Insert(r.buckets, i, index)
+			}
 		}
+		return r
 	}
-	return
+
+	r.buckets = append(r.buckets, r.indexes...)
+	slices.Sort(r.buckets)
+	r.buckets = slices.Compact(r.buckets)
+	return r
 }
 
 func (s *distributedStorage[K, V]) Get(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
 	bucket := s.resolveBucket(key)
-	bucket.mu.RLock()
-	defer bucket.mu.RUnlock()
+	bucket.lockForAccess()
+	defer bucket.unlockForAccess()
 
+	now := s.options.clock.Now()
 	if v, ok := bucket.m[key]; !ok {
 		return nil, nil
-	} else if s.options.expirationPolicy.IsExpired(s.options.clock.Now(), v.ExpiresAt) {
-		delete(bucket.m, key)
+	} else if s.options.isExpired(now, v) {
+		bucket.evictExpired(&s.options, key)
 		return nil, nil
 	} else {
-		return cloneCacheEntry(s.options.cloner, v), nil
+		bucket.recordAccess(key, now)
+		return s.options.viewEntry(bucket.slideExpiration(&s.options, v, now)), nil
 	}
 }
 
-func (s *distributedStorage[K, V]) GetMulti(_ context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
-	indexes, buckets := s.resolveBuckets(keys)
-	if len(buckets) != 0 {
-		sort.Ints(buckets)
+func (s *distributedStorage[K, V]) GetRef(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if !s.options.unsafeRefAccess {
+		return nil, ErrUnsafeRefAccessDisabled
 	}
-	for _, i := range buckets {
+	return s.resolveBucket(key).getRef(&s.options, key), nil
+}
+
+// GetStale returns the entry for the key regardless of its expiration.
+// It returns ErrStaleReadsDisabled unless WithStaleReads is specified.
+func (s *distributedStorage[K, V]) GetStale(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if !s.options.staleReads {
+		return nil, ErrStaleReadsDisabled
+	}
+	return s.resolveBucket(key).getStale(&s.options, key), nil
+}
+
+func (s *distributedStorage[K, V]) Peek(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.resolveBucket(key).peek(&s.options, key), nil
+}
+
+func (s *distributedStorage[K, V]) EntryMeta(key K) (lastAccess, lastWrite time.Time, ok bool) {
+	return s.resolveBucket(key).lookupMeta(&s.options, key)
+}
+
+func (s *distributedStorage[K, V]) GetMulti(_ context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	r := s.resolveBuckets(keys)
+	defer r.release()
+	for _, i := range r.buckets {
 		bucket := s.buckets[i]
-		bucket.mu.RLock()
-		defer bucket.mu.RUnlock()
+		bucket.lockForAccess()
+		defer bucket.unlockForAccess()
 	}
 
 	now := s.options.clock.Now()
 	result := make([]*loadingcache.CacheEntry[K, V], len(keys))
 	for i, key := range keys {
-		bucket := s.buckets[indexes[key]]
+		bucket := s.buckets[r.indexes[i]]
 		if v, ok := bucket.m[key]; ok {
-			if s.options.expirationPolicy.IsExpired(now, v.ExpiresAt) {
-				delete(bucket.m, key)
+			if s.options.isExpired(now, v) {
+				bucket.evictExpired(&s.options, key)
 			} else {
-				result[i] = cloneCacheEntry(s.options.cloner, v)
+				bucket.recordAccess(key, now)
+				result[i] = s.options.viewEntry(bucket.slideExpiration(&s.options, v, now))
 			}
 		}
 	}
@@ -120,7 +368,7 @@ func (s *distributedStorage[K, V]) Set(_ context.Context, entry *loadingcache.Ca
 	bucket.mu.Lock()
 	defer bucket.mu.Unlock()
 
-	bucket.m[entry.Key] = cloneCacheEntry(s.options.cloner, entry)
+	bucket.put(&s.options, entry, s.options.storedEntryClock(), s.options.accessTrackingClock())
 	return nil
 }
 
@@ -132,58 +380,261 @@ func (s *distributedStorage[K, V]) SetMulti(_ context.Context, entries []*loadin
 		}
 	}
 
-	indexes, buckets := s.resolveBuckets(keys)
-	if len(buckets) != 0 {
-		sort.Ints(buckets)
-	}
-	for _, index := range buckets {
+	r := s.resolveBuckets(keys)
+	defer r.release()
+	for _, index := range r.buckets {
 		bucket := s.buckets[index]
 		bucket.mu.Lock()
 		defer bucket.mu.Unlock()
 	}
 
+	now := s.options.storedEntryClock()
+	writtenAt := s.options.accessTrackingClock()
+	i := 0
 	for _, e := range entries {
 		if e != nil {
-			bucket := s.buckets[indexes[e.Key]]
-			bucket.m[e.Key] = cloneCacheEntry(s.options.cloner, e)
+			bucket := s.buckets[r.indexes[i]]
+			bucket.put(&s.options, e, now, writtenAt)
+			i++
+		}
+	}
+	return nil
+}
+
+// SetMultiPartial stores multiple entries bucket by bucket in the order of the bucket indexes,
+// and returns the keys of the stored entries. The entries of each bucket are stored atomically under its write lock,
+// and it returns the context error without storing the remaining buckets if the context is done between the buckets.
+// Unlike SetMulti, it does not lock all the buckets at once, so the readers may observe the entries partially stored.
+func (s *distributedStorage[K, V]) SetMultiPartial(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) ([]K, error) {
+	nonNil := make([]*loadingcache.CacheEntry[K, V], 0, len(entries))
+	keys := make([]K, 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			nonNil = append(nonNil, entry)
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	r := s.resolveBuckets(keys)
+	defer r.release()
+
+	// note: the positions of the entries are sorted by their bucket indexes to store them bucket by bucket.
+	positions := make([]int, len(nonNil))
+	for i := range positions {
+		positions[i] = i
+	}
+	slices.SortStableFunc(positions, func(a, b int) int {
+		return r.indexes[a] - r.indexes[b]
+	})
+
+	now := s.options.storedEntryClock()
+	writtenAt := s.options.accessTrackingClock()
+	stored := make([]K, 0, len(nonNil))
+	for start := 0; start < len(positions); {
+		index := r.indexes[positions[start]]
+		end := start + 1
+		for end < len(positions) && r.indexes[positions[end]] == index {
+			end++
+		}
+		if err := ctx.Err(); err != nil {
+			return stored, err
+		}
+
+		bucket := s.buckets[index]
+		bucket.mu.Lock()
+		for _, i := range positions[start:end] {
+			bucket.put(&s.options, nonNil[i], now, writtenAt)
+			stored = append(stored, nonNil[i].Key)
+		}
+		bucket.mu.Unlock()
+		start = end
+	}
+	return stored, nil
+}
+
+func (s *distributedStorage[K, V]) Delete(_ context.Context, key K) error {
+	bucket := s.resolveBucket(key)
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	bucket.delete(key)
+	return nil
+}
+
+func (s *distributedStorage[K, V]) DeleteMulti(_ context.Context, keys []K) error {
+	r := s.resolveBuckets(keys)
+	defer r.release()
+	for _, index := range r.buckets {
+		bucket := s.buckets[index]
+		bucket.mu.Lock()
+		defer bucket.mu.Unlock()
+	}
+
+	for i, key := range keys {
+		s.buckets[r.indexes[i]].delete(key)
+	}
+	return nil
+}
+
+func (s *distributedStorage[K, V]) Take(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	bucket := s.resolveBucket(key)
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	return bucket.take(&s.options, key, s.options.clock.Now()), nil
+}
+
+func (s *distributedStorage[K, V]) TakeMulti(_ context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	r := s.resolveBuckets(keys)
+	defer r.release()
+	for _, index := range r.buckets {
+		bucket := s.buckets[index]
+		bucket.mu.Lock()
+		defer bucket.mu.Unlock()
+	}
+
+	now := s.options.clock.Now()
+	result := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	for i, key := range keys {
+		result[i] = s.buckets[r.indexes[i]].take(&s.options, key, now)
+	}
+	return result, nil
+}
+
+func (s *distributedStorage[K, V]) ForEach(ctx context.Context, visitor func(*loadingcache.CacheEntry[K, V]) bool) error {
+	for _, bucket := range s.buckets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, entry := range bucket.liveEntries(&s.options) {
+			if !visitor(entry) {
+				return nil
+			}
 		}
 	}
 	return nil
 }
 
+func (s *distributedStorage[K, V]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, bucket := range s.buckets {
+		bucket.mu.Lock()
+		defer bucket.mu.Unlock()
+	}
+
+	for _, bucket := range s.buckets {
+		bucket.reset()
+	}
+	return nil
+}
+
+// Close stops the background goroutine of WithJanitor, waiting for the running sweep to finish.
+// It is a no-op without WithJanitor, and the storage remains usable after it is closed. It always returns nil.
+func (s *distributedStorage[K, V]) Close() error {
+	s.janitor.close()
+	return nil
+}
+
+func (s *distributedStorage[K, V]) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	for _, bucket := range s.buckets {
+		bucket.mu.RLock()
+		defer bucket.mu.RUnlock()
+	}
+
+	now := s.options.clock.Now()
+	n := 0
+	for _, bucket := range s.buckets {
+		n += bucket.countLive(&s.options, now)
+	}
+	return n, nil
+}
+
+func (s *distributedStorage[K, V]) ClearIncremental(ctx context.Context) error {
+	for _, bucket := range s.buckets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		bucket.clear()
+	}
+	return nil
+}
+
 type storage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
 	bucket[K, V]
 	options options[K, V]
+	janitor *janitor
 }
 
 var _ loadingcache.CacheStorage[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ Iterable[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ Clearable = (*storage[uint8, struct{}])(nil)
+var _ Sizer = (*storage[uint8, struct{}])(nil)
+var _ loadingcache.PartialSetCacheStorage[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ io.Closer = (*storage[uint8, struct{}])(nil)
+var _ Taker[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ UnsafeRefAccessor[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ EntryMetaInspector[uint8] = (*storage[uint8, struct{}])(nil)
+var _ Peeker[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
+var _ loadingcache.StaleCacheStorage[uint8, struct{}] = (*storage[uint8, struct{}])(nil)
 
 func (s *storage[K, V]) Get(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.lockForAccess()
+	defer s.unlockForAccess()
 
+	now := s.options.clock.Now()
 	if v, ok := s.m[key]; !ok {
 		return nil, nil
-	} else if s.options.expirationPolicy.IsExpired(s.options.clock.Now(), v.ExpiresAt) {
-		delete(s.m, key)
+	} else if s.options.isExpired(now, v) {
+		s.evictExpired(&s.options, key)
 		return nil, nil
 	} else {
-		return cloneCacheEntry(s.options.cloner, v), nil
+		s.recordAccess(key, now)
+		return s.options.viewEntry(s.slideExpiration(&s.options, v, now)), nil
+	}
+}
+
+func (s *storage[K, V]) GetRef(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if !s.options.unsafeRefAccess {
+		return nil, ErrUnsafeRefAccessDisabled
+	}
+	return s.bucket.getRef(&s.options, key), nil
+}
+
+// GetStale returns the entry for the key regardless of its expiration.
+// It returns ErrStaleReadsDisabled unless WithStaleReads is specified.
+func (s *storage[K, V]) GetStale(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if !s.options.staleReads {
+		return nil, ErrStaleReadsDisabled
 	}
+	return s.bucket.getStale(&s.options, key), nil
+}
+
+func (s *storage[K, V]) Peek(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.bucket.peek(&s.options, key), nil
+}
+
+func (s *storage[K, V]) EntryMeta(key K) (lastAccess, lastWrite time.Time, ok bool) {
+	return s.bucket.lookupMeta(&s.options, key)
 }
 
 func (s *storage[K, V]) GetMulti(_ context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.lockForAccess()
+	defer s.unlockForAccess()
 
 	now := s.options.clock.Now()
 	result := make([]*loadingcache.CacheEntry[K, V], len(keys))
 	for i, key := range keys {
 		if v, ok := s.m[key]; ok {
-			if s.options.expirationPolicy.IsExpired(now, v.ExpiresAt) {
-				delete(s.m, key)
+			if s.options.isExpired(now, v) {
+				s.evictExpired(&s.options, key)
 			} else {
-				result[i] = cloneCacheEntry(s.options.cloner, v)
+				s.recordAccess(key, now)
+				result[i] = s.options.viewEntry(s.slideExpiration(&s.options, v, now))
 			}
 		}
 	}
@@ -194,7 +645,7 @@ func (s *storage[K, V]) Set(_ context.Context, entry *loadingcache.CacheEntry[K,
 	s.bucket.mu.Lock()
 	defer s.bucket.mu.Unlock()
 
-	s.bucket.m[entry.Key] = cloneCacheEntry(s.options.cloner, entry)
+	s.bucket.put(&s.options, entry, s.options.storedEntryClock(), s.options.accessTrackingClock())
 	return nil
 }
 
@@ -202,20 +653,374 @@ func (s *storage[K, V]) SetMulti(_ context.Context, entries []*loadingcache.Cach
 	s.bucket.mu.Lock()
 	defer s.bucket.mu.Unlock()
 
+	now := s.options.storedEntryClock()
+	writtenAt := s.options.accessTrackingClock()
 	for _, e := range entries {
 		if e != nil {
-			s.bucket.m[e.Key] = cloneCacheEntry(s.options.cloner, e)
+			s.bucket.put(&s.options, e, now, writtenAt)
+		}
+	}
+	return nil
+}
+
+// SetMultiPartial stores multiple entries atomically, and returns the keys of the stored entries.
+// It returns the context error without storing any entries if the context is done,
+// since the storage has a single bucket.
+func (s *storage[K, V]) SetMultiPartial(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) ([]K, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.SetMulti(ctx, entries); err != nil {
+		return nil, err
+	}
+
+	stored := make([]K, 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			stored = append(stored, entry.Key)
+		}
+	}
+	return stored, nil
+}
+
+func (s *storage[K, V]) Delete(_ context.Context, key K) error {
+	s.bucket.mu.Lock()
+	defer s.bucket.mu.Unlock()
+
+	s.bucket.delete(key)
+	return nil
+}
+
+func (s *storage[K, V]) DeleteMulti(_ context.Context, keys []K) error {
+	s.bucket.mu.Lock()
+	defer s.bucket.mu.Unlock()
+
+	for _, key := range keys {
+		s.bucket.delete(key)
+	}
+	return nil
+}
+
+func (s *storage[K, V]) Take(_ context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	s.bucket.mu.Lock()
+	defer s.bucket.mu.Unlock()
+
+	return s.bucket.take(&s.options, key, s.options.clock.Now()), nil
+}
+
+func (s *storage[K, V]) TakeMulti(_ context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	s.bucket.mu.Lock()
+	defer s.bucket.mu.Unlock()
+
+	now := s.options.clock.Now()
+	result := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	for i, key := range keys {
+		result[i] = s.bucket.take(&s.options, key, now)
+	}
+	return result, nil
+}
+
+func (s *storage[K, V]) ForEach(ctx context.Context, visitor func(*loadingcache.CacheEntry[K, V]) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, entry := range s.bucket.liveEntries(&s.options) {
+		if !visitor(entry) {
+			return nil
 		}
 	}
 	return nil
 }
 
+func (s *storage[K, V]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.bucket.clear()
+	return nil
+}
+
+// Close stops the background goroutine of WithJanitor, waiting for the running sweep to finish.
+// It is a no-op without WithJanitor, and the storage remains usable after it is closed. It always returns nil.
+func (s *storage[K, V]) Close() error {
+	s.janitor.close()
+	return nil
+}
+
+func (s *storage[K, V]) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.countLive(&s.options, s.options.clock.Now()), nil
+}
+
+// ClearIncremental is the same as Clear because the storage has a single bucket.
+func (s *storage[K, V]) ClearIncremental(ctx context.Context) error {
+	return s.Clear(ctx)
+}
+
+// clear removes all the entries in the bucket.
+func (b *bucket[K, V]) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reset()
+}
+
+// reset removes all the entries and their metadata in the bucket.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) reset() {
+	clear(b.m)
+	clear(b.metas)
+	if b.evictions != nil {
+		b.evictions.reset()
+	}
+	if b.recency != nil {
+		b.recency.reset()
+	}
+}
+
+// countLive returns the number of the live entries in the bucket at now.
+// The caller must hold the read or write lock of the bucket.
+func (b *bucket[K, V]) countLive(o *options[K, V], now time.Time) int {
+	n := 0
+	for _, v := range b.m {
+		if !o.isExpired(now, v) {
+			n++
+		}
+	}
+	return n
+}
+
+// put stores the clone of the entry, clamping its expiration time at now, and records the write time.
+// If the write is skipped by WithWriteSkipIfEqual, the stored entry is kept, or replaced by its shallow copy
+// with the new expiration time so that the readers sharing it never see it mutated.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) put(o *options[K, V], entry *loadingcache.CacheEntry[K, V], now, writtenAt time.Time) {
+	if existing, ok := b.m[entry.Key]; ok && o.skipsWrite(existing, entry) {
+		expiresAt := o.clampExpiresAt(entry.ExpiresAt, now)
+		if o.writeSkipMode == WriteSkipUpdateExpiration && !existing.ExpiresAt.Equal(expiresAt) {
+			updated := *existing
+			updated.ExpiresAt = expiresAt
+			b.store(&updated)
+		} else if b.recency != nil {
+			// note: the skipped write is still a use of the entry for the LRU eviction.
+			b.recency.touch(entry.Key)
+		}
+		return
+	}
+
+	if _, ok := b.m[entry.Key]; !ok {
+		// note: drop the stale metadata left by evictExpired, so that the new entry starts over.
+		delete(b.metas, entry.Key)
+	}
+	b.store(o.storedEntry(entry, now))
+	b.recordWrite(entry.Key, writtenAt)
+	b.evictOverflow(o)
+}
+
+// store stores the entry as it is, and updates the priority queue or the access order of WithMaxEntries.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) store(entry *loadingcache.CacheEntry[K, V]) {
+	b.m[entry.Key] = entry
+	if b.evictions != nil {
+		b.evictions.update(entry)
+	}
+	if b.recency != nil {
+		b.recency.touch(entry.Key)
+	}
+}
+
+// delete removes the entry and its metadata for the key.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) delete(key K) {
+	delete(b.m, key)
+	delete(b.metas, key)
+	if b.evictions != nil {
+		b.evictions.remove(key)
+	}
+	if b.recency != nil {
+		b.recency.remove(key)
+	}
+}
+
+// take removes the live entry for the key and returns its view, or returns nil if it is not found or expired.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) take(o *options[K, V], key K, now time.Time) *loadingcache.CacheEntry[K, V] {
+	v, ok := b.m[key]
+	if !ok {
+		return nil
+	}
+	if o.isExpired(now, v) {
+		b.evictExpired(o, key)
+		return nil
+	}
+	b.delete(key)
+	return o.viewEntry(v)
+}
+
+// getRef returns the stored entry for the key without cloning, or nil if it is not found or expired.
+func (b *bucket[K, V]) getRef(o *options[K, V], key K) *loadingcache.CacheEntry[K, V] {
+	b.lockForAccess()
+	defer b.unlockForAccess()
+
+	now := o.clock.Now()
+	if v, ok := b.m[key]; ok && !o.isExpired(now, v) {
+		b.recordAccess(key, now)
+		return b.slideExpiration(o, v, now)
+	}
+	return nil
+}
+
+// getStale returns the view of the stored entry for the key regardless of its expiration, or nil if it is not found.
+func (b *bucket[K, V]) getStale(o *options[K, V], key K) *loadingcache.CacheEntry[K, V] {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if v, ok := b.m[key]; ok {
+		return o.viewEntry(v)
+	}
+	return nil
+}
+
+// peek returns the view of the live entry for the key without the side effects of the reads, or nil if it is not found or expired.
+func (b *bucket[K, V]) peek(o *options[K, V], key K) *loadingcache.CacheEntry[K, V] {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if v, ok := b.m[key]; ok && !o.isExpired(o.clock.Now(), v) {
+		return o.viewEntry(v)
+	}
+	return nil
+}
+
+// evictExpired removes the expired entry for the key unless WithStaleReads is specified.
+// The caller must hold the lock of the bucket by lockForAccess at least.
+//
+// The priority queue of WithEvictionPriority and the metadata of WithAccessTracking are left as they are,
+// since they cannot be updated under the read lock. The stale metadata is dropped by put and sweepExpired.
+func (b *bucket[K, V]) evictExpired(o *options[K, V], key K) {
+	if !o.staleReads {
+		delete(b.m, key)
+		if b.recency != nil {
+			b.recency.remove(key)
+		}
+	}
+}
+
+// recordAccess updates the last-access time of the entry for the key if WithAccessTracking is specified,
+// and moves the entry to the most recently used in the access order of WithMaxEntries.
+// The caller must hold the lock of the bucket by lockForAccess at least.
+func (b *bucket[K, V]) recordAccess(key K, now time.Time) {
+	if meta, ok := b.metas[key]; ok {
+		meta.lastAccess.Store(now.UnixNano())
+	}
+	if b.recency != nil {
+		b.recency.touch(key)
+	}
+}
+
+// slideExpiration extends the expiration time of the live entry accessed at now by the expiration.AccessObserver,
+// and returns the stored entry. The extended entry is stored as a shallow copy so that the readers sharing the old one
+// never see it mutated.
+// The caller must hold the lock of the bucket by lockForAccess.
+func (b *bucket[K, V]) slideExpiration(o *options[K, V], v *loadingcache.CacheEntry[K, V], now time.Time) *loadingcache.CacheEntry[K, V] {
+	if !b.slides {
+		return v
+	}
+	expiresAt, ok := o.accessObserver.OnAccess(now, v.ExpiresAt, v.NegativeCache)
+	if !ok {
+		return v
+	}
+	if o.minTTL != 0 || o.maxTTL != 0 {
+		expiresAt = o.clampExpiresAt(expiresAt, now)
+	}
+	if expiresAt.Equal(v.ExpiresAt) {
+		return v
+	}
+
+	updated := *v
+	updated.ExpiresAt = expiresAt
+	b.store(&updated)
+	return &updated
+}
+
+// lockForAccess acquires the lock of the bucket for the reads that record the accesses.
+// It is the write lock if the reads update the access order of WithMaxEntries or extend the expiration times,
+// and the read lock otherwise.
+func (b *bucket[K, V]) lockForAccess() {
+	if b.recency != nil || b.slides {
+		b.mu.Lock()
+		return
+	}
+	b.mu.RLock()
+}
+
+// unlockForAccess releases the lock acquired by lockForAccess.
+func (b *bucket[K, V]) unlockForAccess() {
+	if b.recency != nil || b.slides {
+		b.mu.Unlock()
+		return
+	}
+	b.mu.RUnlock()
+}
+
+// recordWrite updates the last-write time of the entry for the key if WithAccessTracking is specified.
+// The caller must hold the write lock of the bucket.
+func (b *bucket[K, V]) recordWrite(key K, now time.Time) {
+	if b.metas == nil {
+		return
+	}
+	if meta, ok := b.metas[key]; ok {
+		meta.lastWrite = now
+		return
+	}
+	b.metas[key] = &entryMeta{lastWrite: now}
+}
+
+// lookupMeta returns the last-access and last-write times of the live entry for the key.
+func (b *bucket[K, V]) lookupMeta(o *options[K, V], key K) (lastAccess, lastWrite time.Time, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	v, found := b.m[key]
+	if !found || o.isExpired(o.clock.Now(), v) {
+		return time.Time{}, time.Time{}, false
+	}
+	meta, found := b.metas[key]
+	if !found {
+		return time.Time{}, time.Time{}, false
+	}
+	if nsec := meta.lastAccess.Load(); nsec != 0 {
+		lastAccess = time.Unix(0, nsec)
+	}
+	return lastAccess, meta.lastWrite, true
+}
+
+// liveEntries returns the snapshot of the live entries in the bucket.
+func (b *bucket[K, V]) liveEntries(o *options[K, V]) []*loadingcache.CacheEntry[K, V] {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := o.clock.Now()
+	entries := make([]*loadingcache.CacheEntry[K, V], 0, len(b.m))
+	for _, v := range b.m {
+		if !o.isExpired(now, v) {
+			entries = append(entries, o.viewEntry(v))
+		}
+	}
+	return entries
+}
+
 func cloneCacheEntry[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](cloner loadingcache.ValueCloner[V], v *loadingcache.CacheEntry[K, V]) *loadingcache.CacheEntry[K, V] {
 	if v.NegativeCache {
 		return &loadingcache.CacheEntry[K, V]{
-			Entry:         loadingcache.Entry[K, V]{Key: v.Key},
-			ExpiresAt:     v.ExpiresAt,
-			NegativeCache: true,
+			Entry:          loadingcache.Entry[K, V]{Key: v.Key},
+			ExpiresAt:      v.ExpiresAt,
+			NegativeCache:  true,
+			NegativeReason: v.NegativeReason,
 		}
 	}
 	return &loadingcache.CacheEntry[K, V]{
@@ -0,0 +1,1818 @@
+package memstorage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/expiration"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type immutableValue struct {
+	Number int
+}
+
+func (*immutableValue) Immutable() {}
+
+func TestCopyOnWrite(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		storage func() loadingcache.CacheStorage[uint8, *immutableValue]
+	}{
+		{
+			name: "ImmutableMarker/SingleBucket",
+			storage: func() loadingcache.CacheStorage[uint8, *immutableValue] {
+				return memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, *immutableValue](1))
+			},
+		},
+		{
+			name: "Option/MultipleBucket",
+			storage: func() loadingcache.CacheStorage[uint8, *immutableValue] {
+				return memstorage.NewInMemoryStorage(
+					memstorage.WithBucketsSize[uint8, *immutableValue](8),
+					memstorage.WithCloner[uint8](loadingcache.NopValueCloner[*immutableValue]{}),
+					memstorage.WithCopyOnWrite[uint8, *immutableValue](),
+				)
+			},
+		},
+		{
+			name: "ImmutableValues/MultipleBucket",
+			storage: func() loadingcache.CacheStorage[uint8, *immutableValue] {
+				return memstorage.NewInMemoryStorage(
+					memstorage.WithBucketsSize[uint8, *immutableValue](8),
+					memstorage.WithCloner[uint8](loadingcache.ImmutableValues[*immutableValue]()),
+				)
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := tt.storage()
+			keys := []uint8{1, 2, 3, 4}
+			expiresAt := time.Now().Add(time.Hour)
+			set := func(version int) {
+				entries := make([]*loadingcache.CacheEntry[uint8, *immutableValue], len(keys))
+				for i, key := range keys {
+					entries[i] = &loadingcache.CacheEntry[uint8, *immutableValue]{
+						Entry:     loadingcache.Entry[uint8, *immutableValue]{Key: key, Value: &immutableValue{Number: version}},
+						ExpiresAt: expiresAt,
+					}
+				}
+				if err := storage.SetMulti(t.Context(), entries); err != nil {
+					t.Error(err)
+				}
+			}
+			set(0)
+
+			// the stored entries are shared with readers
+			first, err := storage.GetMulti(t.Context(), keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := storage.GetMulti(t.Context(), keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range keys {
+				if first[i] != second[i] {
+					t.Errorf("entry[%d] must be shared", i)
+				}
+			}
+
+			var wg sync.WaitGroup
+			for version := 1; version <= 100; version++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					set(version)
+				}()
+				go func() {
+					defer wg.Done()
+
+					entries, err := storage.GetMulti(t.Context(), keys)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					held := make([]int, len(entries))
+					for i, entry := range entries {
+						held[i] = entry.Value.Number
+					}
+
+					// a later Set must not affect the held references
+					time.Sleep(time.Millisecond)
+					for i, entry := range entries {
+						if entry.Value.Number != held[i] {
+							t.Errorf("entry[%d] is mutated: %d -> %d", i, held[i], entry.Value.Number)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			for i, entry := range first {
+				if entry.Value.Number != 0 {
+					t.Errorf("entry[%d] is mutated: 0 -> %d", i, entry.Value.Number)
+				}
+			}
+
+			set(101)
+			entry, err := storage.Get(t.Context(), keys[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Value.Number != 101 {
+				t.Errorf("unexpected value: %d", entry.Value.Number)
+			}
+		})
+	}
+}
+
+func TestCachedClock(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			var elapsed atomic.Int64
+			clock := loadingcache.ClockFunc(func() time.Time {
+				return base.Add(time.Duration(elapsed.Load()))
+			})
+
+			const resolution = 10 * time.Millisecond
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithCachedClock[uint8, int8](t.Context(), resolution),
+				memstorage.WithClock[uint8, int8](clock),
+			)
+			entry := &loadingcache.CacheEntry[uint8, int8]{
+				Entry:     loadingcache.Entry[uint8, int8]{Key: 1, Value: 1},
+				ExpiresAt: base.Add(time.Second),
+			}
+			if err := s.Set(t.Context(), entry); err != nil {
+				t.Fatal(err)
+			}
+
+			// the expiration must be observed within the resolution (plus the scheduling delay)
+			elapsed.Store(int64(2 * time.Second))
+			deadline := time.Now().Add(100 * resolution)
+			for {
+				got, err := s.Get(t.Context(), 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("the expired entry is still returned after the staleness bound")
+				}
+				time.Sleep(resolution / 10)
+			}
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []memstorage.Option[uint8, int8]
+	}{
+		{
+			name: "SystemClock",
+			opts: []memstorage.Option[uint8, int8]{memstorage.WithBucketsSize[uint8, int8](1)},
+		},
+		{
+			name: "CachedClock",
+			opts: []memstorage.Option[uint8, int8]{
+				memstorage.WithBucketsSize[uint8, int8](1),
+				memstorage.WithCachedClock[uint8, int8](b.Context(), time.Millisecond),
+			},
+		},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			s := memstorage.NewInMemoryStorage(bc.opts...)
+			entry := &loadingcache.CacheEntry[uint8, int8]{
+				Entry:     loadingcache.Entry[uint8, int8]{Key: 1, Value: 1},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if err := s.Set(b.Context(), entry); err != nil {
+				b.Fatal(err)
+			}
+
+			for b.Loop() {
+				if _, err := s.Get(b.Context(), 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type numbers []int
+
+func (n numbers) Clone() numbers {
+	return slices.Clone(n)
+}
+
+func BenchmarkGetRef(b *testing.B) {
+	s := memstorage.NewInMemoryStorage(
+		memstorage.WithBucketsSize[uint8, numbers](1),
+		memstorage.WithUnsafeRefAccess[uint8, numbers](),
+	)
+	entry := &loadingcache.CacheEntry[uint8, numbers]{
+		Entry:     loadingcache.Entry[uint8, numbers]{Key: 1, Value: make(numbers, 1024)},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := s.Set(b.Context(), entry); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Get", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.Get(b.Context(), 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("GetRef", func(b *testing.B) {
+		accessor := s.(memstorage.UnsafeRefAccessor[uint8, numbers])
+		for b.Loop() {
+			if _, err := accessor.GetRef(b.Context(), 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetRef(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, numbers](bucketsSize),
+				memstorage.WithClock[uint8, numbers](loadingcache.ClockFunc(func() time.Time { return now })),
+				memstorage.WithUnsafeRefAccess[uint8, numbers](),
+			)
+			accessor := s.(memstorage.UnsafeRefAccessor[uint8, numbers])
+			err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, numbers]{
+				{Entry: loadingcache.Entry[uint8, numbers]{Key: 1, Value: numbers{1, 2, 3}}, ExpiresAt: now.Add(time.Minute)},
+				{Entry: loadingcache.Entry[uint8, numbers]{Key: 2, Value: numbers{4}}, ExpiresAt: now},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// GetRef returns the same stored entry without cloning
+			first, err := accessor.GetRef(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := accessor.GetRef(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first != second || &first.Value[0] != &second.Value[0] {
+				t.Error("GetRef must return the stored entry without cloning")
+			}
+			if diff := cmp.Diff(numbers{1, 2, 3}, first.Value); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+
+			// Get still clones the entry
+			cloned, err := s.Get(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cloned == first || &cloned.Value[0] == &first.Value[0] {
+				t.Error("Get must clone the stored entry")
+			}
+
+			for _, key := range []uint8{2, 3} {
+				entry, err := accessor.GetRef(t.Context(), key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry != nil {
+					t.Errorf("expected nil for key %d, got %+v", key, entry)
+				}
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage[uint8, int]()
+		_, err := s.(memstorage.UnsafeRefAccessor[uint8, int]).GetRef(t.Context(), 1)
+		if !errors.Is(err, memstorage.ErrUnsafeRefAccessDisabled) {
+			t.Errorf("expected ErrUnsafeRefAccessDisabled, got %v", err)
+		}
+	})
+}
+
+func BenchmarkGetMulti(b *testing.B) {
+	for _, size := range []int{1, 4, 16, 64} {
+		b.Run("Keys="+strconv.Itoa(size), func(b *testing.B) {
+			s := memstorage.NewInMemoryStorage[int, int]()
+			keys := make([]int, size)
+			entries := make([]*loadingcache.CacheEntry[int, int], size)
+			for i := range keys {
+				keys[i] = i
+				entries[i] = &loadingcache.CacheEntry[int, int]{
+					Entry:     loadingcache.Entry[int, int]{Key: i, Value: i},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			if err := s.SetMulti(b.Context(), entries); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := s.GetMulti(b.Context(), keys); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetMulti_BatchSizes(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{2, 7, 256} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[int, int](bucketsSize))
+			expiresAt := time.Now().Add(time.Hour)
+			for _, size := range []int{0, 1, 15, 16, 17, 100, 2000} {
+				// store the even keys only, with the nil entries interleaved
+				entries := make([]*loadingcache.CacheEntry[int, int], size)
+				for i := range entries {
+					if i%2 == 0 {
+						entries[i] = &loadingcache.CacheEntry[int, int]{Entry: loadingcache.Entry[int, int]{Key: size*10000 + i, Value: i}, ExpiresAt: expiresAt}
+					}
+				}
+				if err := s.SetMulti(t.Context(), entries); err != nil {
+					t.Fatal(err)
+				}
+
+				// look up the keys in the reverse order with duplicates
+				keys := make([]int, 0, size*2)
+				want := make([]*loadingcache.CacheEntry[int, int], 0, size*2)
+				for i := size - 1; i >= 0; i-- {
+					keys = append(keys, size*10000+i, size*10000+i)
+					want = append(want, entries[i], entries[i])
+				}
+				got, err := s.GetMulti(t.Context(), keys)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("size %d: unexpected entries (-want +got):\n%s", size, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestTTLBounds(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](clock),
+				memstorage.WithExpirationPolicy[uint8, int8](expiration.NeverExpirationPolicy{}),
+				memstorage.WithTTLBounds[uint8, int8](time.Minute, time.Hour),
+			)
+
+			entries := []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Second)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: now.Add(10 * time.Minute)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 3, Value: 3}, ExpiresAt: now.Add(24 * time.Hour)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 4, Value: 4}},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 5}, ExpiresAt: now.Add(-time.Hour), NegativeCache: true},
+			}
+			if err := s.Set(t.Context(), entries[0]); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetMulti(t.Context(), entries[1:]); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.GetMulti(t.Context(), []uint8{1, 2, 3, 4, 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Minute)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: now.Add(10 * time.Minute)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 3, Value: 3}, ExpiresAt: now.Add(time.Hour)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 4, Value: 4}},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 5}, ExpiresAt: now.Add(time.Minute), NegativeCache: true},
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestForEach(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](&storagetest.FixedClock{Time: now}),
+			)
+			var expected []*loadingcache.CacheEntry[uint8, int8]
+			for i := range uint8(10) {
+				entry := &loadingcache.CacheEntry[uint8, int8]{
+					Entry:     loadingcache.Entry[uint8, int8]{Key: i, Value: int8(i)},
+					ExpiresAt: now.Add(time.Hour),
+				}
+				if i%3 == 0 {
+					entry.ExpiresAt = now.Add(-time.Hour)
+				} else {
+					expected = append(expected, entry)
+				}
+				if err := s.Set(t.Context(), entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			iterable, ok := s.(memstorage.Iterable[uint8, int8])
+			if !ok {
+				t.Fatalf("%T must implement memstorage.Iterable", s)
+			}
+
+			t.Run("All", func(t *testing.T) {
+				var visited []*loadingcache.CacheEntry[uint8, int8]
+				if err := iterable.ForEach(t.Context(), func(entry *loadingcache.CacheEntry[uint8, int8]) bool {
+					visited = append(visited, entry)
+					return true
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(expected, visited, cmpopts.SortSlices(func(a, b *loadingcache.CacheEntry[uint8, int8]) bool {
+					return a.Key < b.Key
+				})); diff != "" {
+					t.Errorf("unexpected entries (-want +got):\n%s", diff)
+				}
+			})
+
+			t.Run("Stop", func(t *testing.T) {
+				var visited int
+				if err := iterable.ForEach(t.Context(), func(*loadingcache.CacheEntry[uint8, int8]) bool {
+					visited++
+					return visited < 3
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if visited != 3 {
+					t.Errorf("expected 3 visits, got %d", visited)
+				}
+			})
+
+			t.Run("Canceled", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(t.Context())
+				cancel()
+				err := iterable.ForEach(ctx, func(entry *loadingcache.CacheEntry[uint8, int8]) bool {
+					t.Errorf("unexpected visit: %+v", entry)
+					return true
+				})
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("expected context.Canceled, got %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name  string
+		clear func(memstorage.Clearable, context.Context) error
+	}{
+		{name: "Clear", clear: memstorage.Clearable.Clear},
+		{name: "ClearIncremental", clear: memstorage.Clearable.ClearIncremental},
+	} {
+		for _, bucketsSize := range []int{1, 4} {
+			t.Run(tt.name+"/"+strconv.Itoa(bucketsSize), func(t *testing.T) {
+				t.Parallel()
+
+				s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int8](bucketsSize))
+				keys := make([]uint8, 16)
+				entries := make([]*loadingcache.CacheEntry[uint8, int8], len(keys))
+				for i := range keys {
+					keys[i] = uint8(i)
+					entries[i] = &loadingcache.CacheEntry[uint8, int8]{
+						Entry:     loadingcache.Entry[uint8, int8]{Key: uint8(i), Value: int8(i)},
+						ExpiresAt: time.Now().Add(time.Hour),
+					}
+				}
+				if err := s.SetMulti(t.Context(), entries); err != nil {
+					t.Fatal(err)
+				}
+
+				clearable, ok := s.(memstorage.Clearable)
+				if !ok {
+					t.Fatalf("%T must implement memstorage.Clearable", s)
+				}
+
+				ctx, cancel := context.WithCancel(t.Context())
+				cancel()
+				if err := tt.clear(clearable, ctx); !errors.Is(err, context.Canceled) {
+					t.Errorf("expected context.Canceled, got %v", err)
+				}
+
+				// the storage must remain readable during the operation
+				done := make(chan struct{})
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						if _, err := s.GetMulti(t.Context(), keys); err != nil {
+							t.Errorf("unexpected error: %v", err)
+							return
+						}
+					}
+				}()
+				err := tt.clear(clearable, t.Context())
+				close(done)
+				wg.Wait()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := s.GetMulti(t.Context(), keys)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(make([]*loadingcache.CacheEntry[uint8, int8], len(keys)), got); diff != "" {
+					t.Errorf("all the entries must be cleared (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: now}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](clock),
+			)
+			sizer, ok := s.(memstorage.Sizer)
+			if !ok {
+				t.Fatalf("%T must implement memstorage.Sizer", s)
+			}
+
+			// the even keys expire in a minute, and the odd keys expire in an hour
+			entries := make([]*loadingcache.CacheEntry[uint8, int8], 10)
+			for i := range entries {
+				ttl := time.Minute
+				if i%2 == 1 {
+					ttl = time.Hour
+				}
+				entries[i] = &loadingcache.CacheEntry[uint8, int8]{
+					Entry:     loadingcache.Entry[uint8, int8]{Key: uint8(i), Value: int8(i)},
+					ExpiresAt: now.Add(ttl),
+				}
+			}
+			if err := s.SetMulti(t.Context(), entries); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := sizer.Len(t.Context()); err != nil {
+				t.Fatal(err)
+			} else if n != 10 {
+				t.Errorf("expected 10 live entries, got %d", n)
+			}
+
+			// the expired entries are not counted even before they are removed
+			clock.Time = now.Add(time.Minute)
+			if n, err := sizer.Len(t.Context()); err != nil {
+				t.Fatal(err)
+			} else if n != 5 {
+				t.Errorf("expected 5 live entries, got %d", n)
+			}
+
+			if err := s.Delete(t.Context(), 1); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := sizer.Len(t.Context()); err != nil {
+				t.Fatal(err)
+			} else if n != 4 {
+				t.Errorf("expected 4 live entries after the deletion, got %d", n)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+			if _, err := sizer.Len(ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			// note: the clock is read by the janitor concurrently, so it is advanced by replacing the fixed clock atomically.
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			var clock atomic.Pointer[storagetest.FixedClock]
+			clock.Store(&storagetest.FixedClock{Time: now})
+			// note: the stale reads make the expired entries observable until they are swept.
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](loadingcache.ClockFunc(func() time.Time {
+					return clock.Load().Now()
+				})),
+				memstorage.WithStaleReads[uint8, int8](),
+				memstorage.WithJanitor[uint8, int8](time.Millisecond),
+			)
+			closer, ok := s.(io.Closer)
+			if !ok {
+				t.Fatalf("%T must implement io.Closer", s)
+			}
+			t.Cleanup(func() {
+				if err := closer.Close(); err != nil {
+					t.Error(err)
+				}
+			})
+			stale := s.(loadingcache.StaleCacheStorage[uint8, int8])
+
+			entries := []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Minute)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: now.Add(time.Hour)},
+			}
+			if err := s.SetMulti(t.Context(), entries); err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Store(&storagetest.FixedClock{Time: now.Add(time.Minute)})
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				entry, err := stale.GetStale(t.Context(), 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("the expired entry is not swept")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			// the live entries are kept
+			got, err := s.Get(t.Context(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(entries[1], got); diff != "" {
+				t.Errorf("unexpected entry (-want +got):\n%s", diff)
+			}
+
+			// the storage remains usable after it is closed, and closing it again is a no-op
+			if err := closer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get(t.Context(), 2); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("WithoutJanitor", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage[uint8, int8]()
+		if err := s.(io.Closer).Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestAccessTracking(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			storedAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: storedAt}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int](bucketsSize),
+				memstorage.WithClock[uint8, int](clock),
+				memstorage.WithAccessTracking[uint8, int](),
+			)
+			inspector := s.(memstorage.EntryMetaInspector[uint8])
+			assertMeta := func(t *testing.T, key uint8, wantAccess, wantWrite time.Time, wantOK bool) {
+				t.Helper()
+				lastAccess, lastWrite, ok := inspector.EntryMeta(key)
+				if ok != wantOK {
+					t.Fatalf("EntryMeta(%d): ok = %t, want %t", key, ok, wantOK)
+				}
+				if !lastAccess.Equal(wantAccess) {
+					t.Errorf("EntryMeta(%d): lastAccess = %v, want %v", key, lastAccess, wantAccess)
+				}
+				if !lastWrite.Equal(wantWrite) {
+					t.Errorf("EntryMeta(%d): lastWrite = %v, want %v", key, lastWrite, wantWrite)
+				}
+			}
+
+			err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int]{
+				{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: storedAt.Add(time.Hour)},
+				{Entry: loadingcache.Entry[uint8, int]{Key: 2, Value: 2}, ExpiresAt: storedAt.Add(time.Hour)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertMeta(t, 1, time.Time{}, storedAt, true)
+			assertMeta(t, 2, time.Time{}, storedAt, true)
+			assertMeta(t, 3, time.Time{}, time.Time{}, false)
+
+			// Get updates the last-access time
+			clock.Time = storedAt.Add(time.Minute)
+			if _, err := s.Get(t.Context(), 1); err != nil {
+				t.Fatal(err)
+			}
+			assertMeta(t, 1, storedAt.Add(time.Minute), storedAt, true)
+			assertMeta(t, 2, time.Time{}, storedAt, true)
+
+			// GetMulti updates the last-access time of the found entries
+			clock.Time = storedAt.Add(2 * time.Minute)
+			if _, err := s.GetMulti(t.Context(), []uint8{2, 3}); err != nil {
+				t.Fatal(err)
+			}
+			assertMeta(t, 1, storedAt.Add(time.Minute), storedAt, true)
+			assertMeta(t, 2, storedAt.Add(2*time.Minute), storedAt, true)
+			assertMeta(t, 3, time.Time{}, time.Time{}, false)
+
+			// Set updates the last-write time, and keeps the last-access time
+			clock.Time = storedAt.Add(3 * time.Minute)
+			err = s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 10}, ExpiresAt: storedAt.Add(time.Hour)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertMeta(t, 1, storedAt.Add(time.Minute), storedAt.Add(3*time.Minute), true)
+
+			// the expired entries have no metadata
+			clock.Time = storedAt.Add(time.Hour)
+			assertMeta(t, 1, time.Time{}, time.Time{}, false)
+
+			// the metadata is removed with the expired entry, so the entry stored again starts over
+			if _, err := s.Get(t.Context(), 1); err != nil {
+				t.Fatal(err)
+			}
+			clock.Time = storedAt.Add(2 * time.Hour)
+			err = s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 100}, ExpiresAt: storedAt.Add(3 * time.Hour)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertMeta(t, 1, time.Time{}, storedAt.Add(2*time.Hour), true)
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage[uint8, int]()
+		err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, ok := s.(memstorage.EntryMetaInspector[uint8]).EntryMeta(1); ok {
+			t.Error("EntryMeta must return false unless WithAccessTracking is specified")
+		}
+	})
+}
+
+func TestPeek(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			storedAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: storedAt}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int](bucketsSize),
+				memstorage.WithClock[uint8, int](clock),
+				memstorage.WithAccessTracking[uint8, int](),
+			)
+			entry := &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: storedAt.Add(time.Hour)}
+			if err := s.Set(t.Context(), entry); err != nil {
+				t.Fatal(err)
+			}
+
+			// Peek never updates the last-access time
+			clock.Time = storedAt.Add(time.Minute)
+			peeker := s.(memstorage.Peeker[uint8, int])
+			got, err := peeker.Peek(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(entry, got); diff != "" {
+				t.Errorf("unexpected entry (-want +got):\n%s", diff)
+			}
+			if lastAccess, _, ok := s.(memstorage.EntryMetaInspector[uint8]).EntryMeta(1); !ok || !lastAccess.IsZero() {
+				t.Errorf("EntryMeta(1): lastAccess = %v, ok = %t, want zero and true", lastAccess, ok)
+			}
+
+			// the missing and the expired entries are not found
+			if got, err := peeker.Peek(t.Context(), 2); err != nil || got != nil {
+				t.Errorf("Peek(2) = %+v, %v, want nil", got, err)
+			}
+			clock.Time = storedAt.Add(time.Hour)
+			if got, err := peeker.Peek(t.Context(), 1); err != nil || got != nil {
+				t.Errorf("Peek(1) = %+v, %v, want nil", got, err)
+			}
+		})
+	}
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int](1), memstorage.WithMaxEntries[uint8, int](2))
+		expiresAt := time.Now().Add(time.Hour)
+		for key := range uint8(2) {
+			if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: key}, ExpiresAt: expiresAt}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Peek never updates the access order, so the least recently stored entry is evicted
+		if _, err := s.(memstorage.Peeker[uint8, int]).Peek(t.Context(), 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 2}, ExpiresAt: expiresAt}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := s.Get(t.Context(), 0); err != nil || got != nil {
+			t.Errorf("Get(0) = %+v, %v, want nil", got, err)
+		}
+	})
+}
+
+func TestStaleReads(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: now}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int](bucketsSize),
+				memstorage.WithClock[uint8, int](clock),
+				memstorage.WithStaleReads[uint8, int](),
+			)
+			stale := s.(loadingcache.StaleCacheStorage[uint8, int])
+			want := &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Minute)}
+			if err := s.Set(t.Context(), want); err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Time = now.Add(time.Hour)
+			for range 2 {
+				if entries, err := s.GetMulti(t.Context(), []uint8{1}); err != nil {
+					t.Fatal(err)
+				} else if entries[0] != nil {
+					t.Fatalf("expected nil for the expired entry, got %+v", entries[0])
+				}
+				got, err := stale.GetStale(t.Context(), 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("unexpected stale entry (-want +got):\n%s", diff)
+				}
+			}
+
+			if got, err := stale.GetStale(t.Context(), 2); err != nil {
+				t.Fatal(err)
+			} else if got != nil {
+				t.Errorf("expected nil for the missing key, got %+v", got)
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage[uint8, int]()
+		_, err := s.(loadingcache.StaleCacheStorage[uint8, int]).GetStale(t.Context(), 1)
+		if !errors.Is(err, memstorage.ErrStaleReadsDisabled) {
+			t.Errorf("expected ErrStaleReadsDisabled, got %v", err)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int](bucketsSize))
+			entries := make([]*loadingcache.CacheEntry[uint8, int], 0, 32)
+			for key := range uint8(32) {
+				entries = append(entries, &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: key, Value: int(key)}, ExpiresAt: time.Now().Add(time.Hour)})
+			}
+			if err := s.SetMulti(t.Context(), entries); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Delete(t.Context(), 0); err != nil {
+				t.Fatal(err)
+			}
+			// the missing keys are ignored
+			if err := s.DeleteMulti(t.Context(), []uint8{1, 17, 100, 1}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(t.Context(), 100); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.GetMulti(t.Context(), []uint8{0, 1, 2, 17, 31})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []*loadingcache.CacheEntry[uint8, int]{nil, nil, entries[2], nil, entries[31]}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTake(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now()
+			s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int](bucketsSize))
+			taker := s.(memstorage.Taker[uint8, int])
+			entries := make([]*loadingcache.CacheEntry[uint8, int], 0, 32)
+			for key := range uint8(32) {
+				entries = append(entries, &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: key, Value: int(key)}, ExpiresAt: now.Add(time.Hour)})
+			}
+			if err := s.SetMulti(t.Context(), entries); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := taker.Take(t.Context(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(entries[0], got); diff != "" {
+				t.Errorf("unexpected taken entry (-want +got):\n%s", diff)
+			}
+
+			// the duplicated keys are taken only once, and the missing keys are nil
+			gotMulti, err := taker.TakeMulti(t.Context(), []uint8{1, 17, 100, 1, 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []*loadingcache.CacheEntry[uint8, int]{entries[1], entries[17], nil, nil, nil}
+			if diff := cmp.Diff(want, gotMulti); diff != "" {
+				t.Errorf("unexpected taken entries (-want +got):\n%s", diff)
+			}
+
+			// the taken keys are absent afterwards
+			gotMulti, err = s.GetMulti(t.Context(), []uint8{0, 1, 2, 17, 31})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = []*loadingcache.CacheEntry[uint8, int]{nil, nil, entries[2], nil, entries[31]}
+			if diff := cmp.Diff(want, gotMulti); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &storagetest.FixedClock{Time: time.Now()}
+		s := memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int](clock))
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: clock.Time.Add(time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.Time = clock.Time.Add(time.Hour)
+		if got, err := s.(memstorage.Taker[uint8, int]).Take(t.Context(), 1); err != nil {
+			t.Fatal(err)
+		} else if got != nil {
+			t.Errorf("expected nil for the expired key, got %+v", got)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int](8))
+		taker := s.(memstorage.Taker[uint8, int])
+		for range 100 {
+			if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+
+			var taken atomic.Int32
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					got, err := taker.Take(t.Context(), 1)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if got != nil {
+						taken.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := taken.Load(); n != 1 {
+				t.Fatalf("expected exactly one caller to take the entry, got %d", n)
+			}
+		}
+	})
+}
+
+// deepCopyOnlyValue has only DeepCopy method.
+type deepCopyOnlyValue struct {
+	Value int
+}
+
+func (v *deepCopyOnlyValue) DeepCopy() *deepCopyOnlyValue {
+	return &deepCopyOnlyValue{Value: v.Value}
+}
+
+// plainValue has neither Clone nor DeepCopy method.
+type plainValue struct {
+	Tags []string
+}
+
+func TestClonerChain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DeepCopyOnly", func(t *testing.T) {
+		t.Parallel()
+
+		var fixedCalls int
+		s := memstorage.NewInMemoryStorage(memstorage.WithClonerChain[uint8](
+			loadingcache.CloneMethodStrategy[*deepCopyOnlyValue](),
+			loadingcache.DeepCopyMethodStrategy[*deepCopyOnlyValue](),
+			loadingcache.FixedStrategy[*deepCopyOnlyValue](loadingcache.ValueClonerFunc[*deepCopyOnlyValue](func(v *deepCopyOnlyValue) *deepCopyOnlyValue {
+				fixedCalls++
+				return v
+			})),
+		))
+		value := &deepCopyOnlyValue{Value: 1}
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, *deepCopyOnlyValue]{
+			Entry:     loadingcache.Entry[uint8, *deepCopyOnlyValue]{Key: 1, Value: value},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Value == value || got.Value.Value != 1 {
+			t.Errorf("the value must be copied by DeepCopy: %+v", got.Value)
+		}
+		if fixedCalls != 0 {
+			t.Errorf("the fallback cloner must not be used: %d calls", fixedCalls)
+		}
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		t.Parallel()
+
+		// the default cloner is not applicable to plainValue, so the storage must be creatable only with the chain
+		s := memstorage.NewInMemoryStorage(memstorage.WithClonerChain[uint8](
+			loadingcache.CloneMethodStrategy[*plainValue](),
+			loadingcache.DeepCopyMethodStrategy[*plainValue](),
+			loadingcache.FixedStrategy[*plainValue](loadingcache.ValueClonerFunc[*plainValue](func(v *plainValue) *plainValue {
+				return &plainValue{Tags: slices.Clone(v.Tags)}
+			})),
+		))
+		value := &plainValue{Tags: []string{"a"}}
+		if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, *plainValue]{
+			Entry:     loadingcache.Entry[uint8, *plainValue]{Key: 1, Value: value},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		value.Tags[0] = "modified"
+		got, err := s.Get(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&plainValue{Tags: []string{"a"}}, got.Value); diff != "" {
+			t.Errorf("the value must be copied by the fallback cloner (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Inapplicable", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := memstorage.NewInMemoryStorageE(memstorage.WithClonerChain[uint8](
+			loadingcache.CloneMethodStrategy[*plainValue](),
+			loadingcache.DeepCopyMethodStrategy[*plainValue](),
+		))
+		if !errors.Is(err, memstorage.ErrInvalidOptions) {
+			t.Errorf("expected ErrInvalidOptions, got %v", err)
+		}
+	})
+}
+
+func TestSlidingExpiration(t *testing.T) {
+	t.Parallel()
+
+	const idle = time.Minute
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: base}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithClock[uint8, int8](clock),
+				memstorage.WithExpirationPolicy[uint8, int8](&expiration.SlidingExpirationPolicy{IdleTimeout: idle}),
+			)
+			if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: base.Add(idle)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: base.Add(idle)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 3}, ExpiresAt: base.Add(idle), NegativeCache: true},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			// the entries read just before the timeout survive another full idle window
+			clock.Time = base.Add(idle - time.Second)
+			if entry, err := s.Get(t.Context(), 1); err != nil {
+				t.Fatal(err)
+			} else if entry == nil || !entry.ExpiresAt.Equal(clock.Time.Add(idle)) {
+				t.Errorf("unexpected entry: %+v", entry)
+			}
+			if entries, err := s.GetMulti(t.Context(), []uint8{3}); err != nil {
+				t.Fatal(err)
+			} else if entries[0] == nil || !entries[0].ExpiresAt.Equal(base.Add(idle)) {
+				t.Errorf("the negative cache must not slide: %+v", entries[0])
+			}
+
+			clock.Time = base.Add(2*idle - 2*time.Second)
+			entries, err := s.GetMulti(t.Context(), []uint8{1, 2, 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries[0] == nil || !entries[0].ExpiresAt.Equal(clock.Time.Add(idle)) {
+				t.Errorf("the entry read before the timeout must survive: %+v", entries[0])
+			}
+			if entries[1] != nil {
+				t.Errorf("the idle entry must expire: %+v", entries[1])
+			}
+			if entries[2] != nil {
+				t.Errorf("the negative cache must expire: %+v", entries[2])
+			}
+		})
+	}
+}
+
+func TestEvictionPriority(t *testing.T) {
+	t.Parallel()
+
+	soonestExpiry := func(a, b *loadingcache.CacheEntry[uint8, int]) bool {
+		return a.ExpiresAt.Before(b.ExpiresAt)
+	}
+	now := time.Now()
+	entry := func(key uint8, ttl time.Duration) *loadingcache.CacheEntry[uint8, int] {
+		return &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: key, Value: int(key)}, ExpiresAt: now.Add(ttl)}
+	}
+
+	t.Run("SingleBucket", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[uint8, int](1),
+			memstorage.WithMaxEntries[uint8, int](3),
+			memstorage.WithEvictionPriority(soonestExpiry),
+		)
+		if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int]{
+			entry(1, 10*time.Minute),
+			entry(2, 5*time.Minute),
+			entry(3, 20*time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the soonest-expiring entry is evicted first
+		if err := s.Set(t.Context(), entry(4, 15*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		// the overwritten entry is prioritized by its new expiration time
+		if err := s.Set(t.Context(), entry(1, 30*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		// the new entry is evicted if it has the lowest priority
+		if err := s.Set(t.Context(), entry(5, time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(6, 25*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.GetMulti(t.Context(), []uint8{1, 2, 3, 4, 5, 6})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, int]{entry(1, 30*time.Minute), nil, entry(3, 20*time.Minute), nil, nil, entry(6, 25*time.Minute)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+
+		// the deleted entries free the room without eviction
+		if err := s.Delete(t.Context(), 3); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(7, time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		got, err = s.GetMulti(t.Context(), []uint8{1, 6, 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []*loadingcache.CacheEntry[uint8, int]{entry(1, 30*time.Minute), entry(6, 25*time.Minute), entry(7, time.Minute)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("MultipleBuckets", func(t *testing.T) {
+		t.Parallel()
+
+		// the even and odd keys are in the different buckets, and each bucket holds at most 2 entries
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[uint8, int](2),
+			memstorage.WithKeyHash[uint8, int](func(key uint8) int { return int(key % 2) }),
+			memstorage.WithMaxEntries[uint8, int](4),
+			memstorage.WithEvictionPriority(soonestExpiry),
+		)
+		entries := make([]*loadingcache.CacheEntry[uint8, int], 0, 8)
+		for key := range uint8(8) {
+			entries = append(entries, entry(key, time.Duration(key+1)*time.Minute))
+		}
+		if err := s.SetMulti(t.Context(), entries); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.GetMulti(t.Context(), []uint8{0, 1, 2, 3, 4, 5, 6, 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, int]{nil, nil, nil, nil, entries[4], entries[5], entries[6], entries[7]}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestEvictionObserver(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entries := make([]*loadingcache.CacheEntry[uint8, int], 5)
+	for i := range entries {
+		entries[i] = &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: uint8(i), Value: i}, ExpiresAt: expiresAt}
+	}
+
+	for name, opt := range map[string]memstorage.Option[uint8, int]{
+		"LRU": memstorage.WithBucketsSize[uint8, int](1),
+		"Priority": memstorage.WithEvictionPriority(func(a, b *loadingcache.CacheEntry[uint8, int]) bool {
+			return a.Key < b.Key
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var evicted []int
+			s := memstorage.NewInMemoryStorage(
+				opt,
+				memstorage.WithBucketsSize[uint8, int](1),
+				memstorage.WithMaxEntries[uint8, int](2),
+				memstorage.WithEvictionObserver[uint8, int](func(n int) {
+					evicted = append(evicted, n)
+				}),
+			)
+			if err := s.SetMulti(t.Context(), entries[:2]); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetMulti(t.Context(), entries[2:]); err != nil {
+				t.Fatal(err)
+			}
+			// the overwrites evict nothing
+			if err := s.Set(t.Context(), entries[4]); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []int{1, 1, 1}
+			if diff := cmp.Diff(want, evicted); diff != "" {
+				t.Errorf("unexpected evictions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entry := func(key uint8) *loadingcache.CacheEntry[uint8, int] {
+		return &loadingcache.CacheEntry[uint8, int]{Entry: loadingcache.Entry[uint8, int]{Key: key, Value: int(key)}, ExpiresAt: expiresAt}
+	}
+
+	t.Run("SingleBucket", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[uint8, int](1),
+			memstorage.WithMaxEntries[uint8, int](3),
+		)
+		for key := range uint8(3) {
+			if err := s.Set(t.Context(), entry(key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the read entry survives, and the oldest one is evicted instead
+		if _, err := s.Get(t.Context(), 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(3)); err != nil {
+			t.Fatal(err)
+		}
+		// the entries read by GetMulti and overwritten by Set are also the recently used ones
+		if _, err := s.GetMulti(t.Context(), []uint8{0}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(2)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(4)); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.GetMulti(t.Context(), []uint8{0, 1, 2, 3, 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, int]{entry(0), nil, entry(2), nil, entry(4)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+
+		// the deleted entries free the room without eviction
+		if err := s.Delete(t.Context(), 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(t.Context(), entry(5)); err != nil {
+			t.Fatal(err)
+		}
+		got, err = s.GetMulti(t.Context(), []uint8{0, 4, 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []*loadingcache.CacheEntry[uint8, int]{entry(0), entry(4), entry(5)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("MultipleBuckets", func(t *testing.T) {
+		t.Parallel()
+
+		// the even and odd keys are in the different buckets, and each bucket holds at most 2 entries
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[uint8, int](2),
+			memstorage.WithKeyHash[uint8, int](func(key uint8) int { return int(key % 2) }),
+			memstorage.WithMaxEntries[uint8, int](4),
+		)
+		if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int]{entry(0), entry(1), entry(2), entry(3)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetMulti(t.Context(), []uint8{0, 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int]{entry(4), entry(5)}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.GetMulti(t.Context(), []uint8{0, 1, 2, 3, 4, 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*loadingcache.CacheEntry[uint8, int]{entry(0), entry(1), nil, nil, entry(4), entry(5)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[int, int](4),
+			memstorage.WithMaxEntries[int, int](64),
+		)
+		var wg sync.WaitGroup
+		for g := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					key := g*1000 + i
+					_ = s.Set(t.Context(), &loadingcache.CacheEntry[int, int]{Entry: loadingcache.Entry[int, int]{Key: key, Value: key}, ExpiresAt: expiresAt})
+					_, _ = s.GetMulti(t.Context(), []int{key, key - 1})
+				}
+			}()
+		}
+		wg.Wait()
+
+		n := 0
+		if err := s.(memstorage.Iterable[int, int]).ForEach(t.Context(), func(*loadingcache.CacheEntry[int, int]) bool {
+			n++
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if n > 64 {
+			t.Errorf("the storage must hold at most 64 entries, got %d", n)
+		}
+	})
+}
+
+type hintedKey struct {
+	id   int
+	hint int
+}
+
+func (k hintedKey) BucketHint() int {
+	return k.hint
+}
+
+func TestBucketHints(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entry := func(key hintedKey) *loadingcache.CacheEntry[hintedKey, int] {
+		return &loadingcache.CacheEntry[hintedKey, int]{Entry: loadingcache.Entry[hintedKey, int]{Key: key, Value: key.id}, ExpiresAt: expiresAt}
+	}
+
+	// each of the 4 buckets holds at most 1 entry, so the keys routed to the same bucket evict each other
+	s := memstorage.NewInMemoryStorage(
+		memstorage.WithBucketsSize[hintedKey, int](4),
+		memstorage.WithMaxEntries[hintedKey, int](4),
+		memstorage.WithBucketHints[hintedKey, int](),
+		// note: the bucket hints take precedence over the key hash
+		memstorage.WithKeyHash[hintedKey, int](func(hintedKey) int { return 0 }),
+	)
+	keys := []hintedKey{{id: 1, hint: 0}, {id: 2, hint: 1}, {id: 3, hint: -2}, {id: 4, hint: 7}}
+	for _, key := range keys {
+		if err := s.Set(t.Context(), entry(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.GetMulti(t.Context(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*loadingcache.CacheEntry[hintedKey, int]{entry(keys[0]), entry(keys[1]), entry(keys[2]), entry(keys[3])}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(hintedKey{})); diff != "" {
+		t.Errorf("the keys with the distinct hints must be in the distinct buckets (-want +got):\n%s", diff)
+	}
+
+	// the key with the same hint modulo the number of buckets is routed to the same bucket consistently
+	collided := hintedKey{id: 5, hint: 4}
+	if err := s.Set(t.Context(), entry(collided)); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.GetMulti(t.Context(), []hintedKey{keys[0], collided, keys[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []*loadingcache.CacheEntry[hintedKey, int]{nil, entry(collided), entry(keys[1])}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(hintedKey{})); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
+
+// countdownContext is a context that is canceled after its Err is called the given times.
+type countdownContext struct {
+	context.Context
+	remaining atomic.Int32
+}
+
+func (c *countdownContext) Err() error {
+	if c.remaining.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestSetMultiPartial(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entries := make([]*loadingcache.CacheEntry[uint8, int8], 0, 9)
+	keys := make([]uint8, 0, 8)
+	for key := range uint8(8) {
+		entries = append(entries, &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}, ExpiresAt: expiresAt})
+		keys = append(keys, key)
+	}
+	entries = append(entries, nil)
+
+	persisted := func(t *testing.T, s loadingcache.CacheStorage[uint8, int8]) []uint8 {
+		t.Helper()
+
+		got, err := s.GetMulti(t.Context(), keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var persisted []uint8
+		for _, entry := range got {
+			if entry != nil {
+				persisted = append(persisted, entry.Key)
+			}
+		}
+		return persisted
+	}
+
+	for _, bucketsSize := range []int{1, 4} {
+		t.Run(strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithBucketsSize[uint8, int8](bucketsSize),
+				memstorage.WithKeyHash[uint8, int8](func(key uint8) int { return int(key) }),
+			)
+			stored, err := s.(loadingcache.PartialSetCacheStorage[uint8, int8]).SetMultiPartial(t.Context(), entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(stored)
+			if diff := cmp.Diff(keys, stored); diff != "" {
+				t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(keys, persisted(t, s)); diff != "" {
+				t.Errorf("unexpected persisted keys (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+
+		// the keys are stored in the buckets of key%4, and the context is canceled after the first 2 buckets
+		s := memstorage.NewInMemoryStorage(
+			memstorage.WithBucketsSize[uint8, int8](4),
+			memstorage.WithKeyHash[uint8, int8](func(key uint8) int { return int(key) }),
+		)
+		ctx := &countdownContext{Context: t.Context()}
+		ctx.remaining.Store(2)
+		stored, err := s.(loadingcache.PartialSetCacheStorage[uint8, int8]).SetMultiPartial(ctx, entries)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if diff := cmp.Diff([]uint8{0, 4, 1, 5}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]uint8{0, 1, 4, 5}, persisted(t, s)); diff != "" {
+			t.Errorf("the stored keys must be persisted (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Canceled/SingleBucket", func(t *testing.T) {
+		t.Parallel()
+
+		s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[uint8, int8](1))
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		stored, err := s.(loadingcache.PartialSetCacheStorage[uint8, int8]).SetMultiPartial(ctx, entries)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if len(stored) != 0 {
+			t.Errorf("expected no stored keys, got %v", stored)
+		}
+		if got := persisted(t, s); len(got) != 0 {
+			t.Errorf("expected no persisted keys, got %v", got)
+		}
+	})
+}
+
+func TestSetSeq(t *testing.T) {
+	t.Parallel()
+
+	for _, bucketsSize := range []int{1, 8} {
+		t.Run("BucketsSize="+strconv.Itoa(bucketsSize), func(t *testing.T) {
+			t.Parallel()
+
+			expiresAt := time.Now().Add(time.Hour)
+			s := memstorage.NewInMemoryStorage(memstorage.WithBucketsSize[int, int](bucketsSize))
+			const n = 1000
+			seq := func(yield func(*loadingcache.CacheEntry[int, int]) bool) {
+				for key := range n {
+					if key == 300 {
+						// the earlier batch is already stored, and the storage is not locked while producing the entries
+						if got, err := s.Get(t.Context(), 0); err != nil {
+							t.Error(err)
+						} else if got == nil {
+							t.Error("expected the first batch to be stored before the iterator is exhausted")
+						}
+					}
+					if key%100 == 0 && !yield(nil) {
+						return
+					}
+					if !yield(&loadingcache.CacheEntry[int, int]{Entry: loadingcache.Entry[int, int]{Key: key, Value: key * 2}, ExpiresAt: expiresAt}) {
+						return
+					}
+				}
+			}
+			if err := s.(memstorage.SeqSetter[int, int]).SetSeq(t.Context(), seq); err != nil {
+				t.Fatal(err)
+			}
+
+			keys := make([]int, n)
+			want := make([]*loadingcache.CacheEntry[int, int], n)
+			for key := range n {
+				keys[key] = key
+				want[key] = &loadingcache.CacheEntry[int, int]{Entry: loadingcache.Entry[int, int]{Key: key, Value: key * 2}, ExpiresAt: expiresAt}
+			}
+			got, err := s.GetMulti(t.Context(), keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		s := memstorage.NewInMemoryStorage[int, int]()
+		seq := func(yield func(*loadingcache.CacheEntry[int, int]) bool) {
+			yield(&loadingcache.CacheEntry[int, int]{Entry: loadingcache.Entry[int, int]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)})
+		}
+		if err := s.(memstorage.SeqSetter[int, int]).SetSeq(ctx, seq); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestWriteSkipIfEqual(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name          string
+		mode          memstorage.WriteSkipMode
+		wantExpiresAt func(stored, written time.Time) time.Time
+	}{
+		{name: "UpdateExpiration", mode: memstorage.WriteSkipUpdateExpiration, wantExpiresAt: func(_, written time.Time) time.Time { return written }},
+		{name: "Entirely", mode: memstorage.WriteSkipEntirely, wantExpiresAt: func(stored, _ time.Time) time.Time { return stored }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+			clock := &storagetest.FixedClock{Time: now}
+			s := memstorage.NewInMemoryStorage(
+				memstorage.WithClock[uint8, numbers](clock),
+				memstorage.WithUnsafeRefAccess[uint8, numbers](),
+				memstorage.WithAccessTracking[uint8, numbers](),
+				memstorage.WithWriteSkipIfEqual[uint8, numbers](slices.Equal, tt.mode),
+			)
+			accessor := s.(memstorage.UnsafeRefAccessor[uint8, numbers])
+			inspector := s.(memstorage.EntryMetaInspector[uint8])
+
+			storedAt := now.Add(time.Minute)
+			if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, numbers]{Entry: loadingcache.Entry[uint8, numbers]{Key: 1, Value: numbers{1, 2}}, ExpiresAt: storedAt}); err != nil {
+				t.Fatal(err)
+			}
+			before, err := accessor.GetRef(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the equal value is not replaced, and the write is not recorded
+			clock.Time = now.Add(time.Second)
+			writtenAt := now.Add(time.Hour)
+			if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, numbers]{{Entry: loadingcache.Entry[uint8, numbers]{Key: 1, Value: numbers{1, 2}}, ExpiresAt: writtenAt}}); err != nil {
+				t.Fatal(err)
+			}
+			after, err := accessor.GetRef(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if &before.Value[0] != &after.Value[0] {
+				t.Error("the equal value must not be replaced")
+			}
+			if !after.ExpiresAt.Equal(tt.wantExpiresAt(storedAt, writtenAt)) {
+				t.Errorf("unexpected expiration time: %v", after.ExpiresAt)
+			}
+			if !before.ExpiresAt.Equal(storedAt) {
+				t.Error("the entry held by the reader must not be mutated")
+			}
+			if _, lastWrite, ok := inspector.EntryMeta(1); !ok || !lastWrite.Equal(now) {
+				t.Errorf("the skipped write must not be recorded: %v, %v", lastWrite, ok)
+			}
+
+			// the different value is replaced
+			if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, numbers]{Entry: loadingcache.Entry[uint8, numbers]{Key: 1, Value: numbers{3}}, ExpiresAt: writtenAt}); err != nil {
+				t.Fatal(err)
+			}
+			replaced, err := s.Get(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(numbers{3}, replaced.Value); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if _, lastWrite, ok := inspector.EntryMeta(1); !ok || !lastWrite.Equal(clock.Time) {
+				t.Errorf("the write must be recorded: %v, %v", lastWrite, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*MigrationStorage[uint8, struct{}])(nil)
+
+// MigrationStorage is a composite loadingcache.CacheStorage for the gradual migration from an old backend to a new one.
+// The writes go only to the Primary (the new backend), and the reads check the Primary first and then the Secondary (the old backend).
+// The entries found only in the Secondary are promoted into the Primary, so the Primary is warmed up by the reads.
+//
+// Once the Primary is warm enough, stop reading the Secondary by SetSecondaryReads(false) before removing it.
+type MigrationStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Primary is the storage for the writes and the reads.
+	Primary loadingcache.CacheStorage[K, V]
+
+	// Secondary is the storage only for the reads of the keys missing in the Primary.
+	Secondary loadingcache.CacheStorage[K, V]
+
+	// OnPromoteError is an optional function that is called with the error of the promotion into the Primary.
+	// The failed promotion does not fail the read, and the entries found in the Secondary are returned anyway.
+	OnPromoteError func(error)
+
+	secondaryReadsDisabled atomic.Bool
+}
+
+// SetSecondaryReads enables or disables the reads of the Secondary. They are enabled by default.
+// It is safe to call concurrently with the other methods.
+func (s *MigrationStorage[K, V]) SetSecondaryReads(enabled bool) {
+	s.secondaryReadsDisabled.Store(!enabled)
+}
+
+// Get retrieves the value associated with the given key from the Primary, or from the Secondary if it is missing in the Primary.
+// The entry found in the Secondary is stored into the Primary before it is returned.
+// If the promotion fails, it returns the entry anyway and passes the error to OnPromoteError.
+func (s *MigrationStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	if s.secondaryReadsDisabled.Load() {
+		return s.Primary.Get(ctx, key)
+	}
+	return promoteGet(ctx, s.Primary, s.Secondary, key, nil, s.OnPromoteError)
+}
+
+// GetMulti retrieves multiple entries from the Primary, and the missing ones from the Secondary.
+// The entries found in the Secondary are stored into the Primary by a SetMulti call before they are returned.
+// If the promotion fails, it returns the entries anyway and passes the error to OnPromoteError.
+func (s *MigrationStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	if s.secondaryReadsDisabled.Load() {
+		return s.Primary.GetMulti(ctx, keys)
+	}
+	return promoteGetMulti(ctx, s.Primary, s.Secondary, keys, nil, s.OnPromoteError)
+}
+
+// Set stores the entry to the Primary only.
+func (s *MigrationStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.Primary.Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries to the Primary only.
+func (s *MigrationStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	return s.Primary.SetMulti(ctx, entries)
+}
+
+// Delete removes the entry by its key from the Primary, and from the Secondary unless its reads are disabled,
+// so that the deleted entry is not promoted from the Secondary again.
+func (s *MigrationStorage[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.Primary.Delete(ctx, key); err != nil {
+		return err
+	}
+	if s.secondaryReadsDisabled.Load() {
+		return nil
+	}
+	return s.Secondary.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the Primary, and from the Secondary unless its reads are disabled,
+// so that the deleted entries are not promoted from the Secondary again.
+func (s *MigrationStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if err := s.Primary.DeleteMulti(ctx, keys); err != nil {
+		return err
+	}
+	if s.secondaryReadsDisabled.Load() {
+		return nil
+	}
+	return s.Secondary.DeleteMulti(ctx, keys)
+}
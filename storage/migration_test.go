@@ -0,0 +1,149 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func TestMigrationStorage(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entry := func(key uint8, value int8) *loadingcache.CacheEntry[uint8, int8] {
+		return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: value}, ExpiresAt: expiresAt}
+	}
+
+	primary := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage[uint8, int8]()}
+	secondary := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage[uint8, int8]()}
+	if err := secondary.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(1, 1), entry(2, 2), entry(3, 3)}); err != nil {
+		t.Fatal(err)
+	}
+	secondary.setMultiCalls = nil
+	s := &storage.MigrationStorage[uint8, int8]{Primary: primary, Secondary: secondary}
+
+	// the writes go only to the primary
+	if err := s.Set(t.Context(), entry(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(4, 40)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(secondary.setMultiCalls) != 0 {
+		t.Errorf("the secondary must not be written: %v", secondary.setMultiCalls)
+	}
+
+	// the primary is preferred, and the entries only in the secondary are promoted
+	got, err := s.Get(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entry(1, 10), got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	got, err = s.Get(t.Context(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entry(2, 2), got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	if promoted, err := primary.Get(t.Context(), 2); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(entry(2, 2), promoted); diff != "" {
+		t.Errorf("the entry must be promoted to the primary (-want +got):\n%s", diff)
+	}
+
+	primary.getMultiCalls, primary.setMultiCalls = nil, nil
+	entries, err := s.GetMulti(t.Context(), []uint8{4, 3, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(4, 40), entry(3, 3), nil, entry(1, 10)}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{3, 5}}, secondary.getMultiCalls); diff != "" {
+		t.Errorf("unexpected GetMulti calls of the secondary (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{3}}, primary.setMultiCalls); diff != "" {
+		t.Errorf("unexpected promotions (-want +got):\n%s", diff)
+	}
+
+	// the secondary is no longer read once disabled
+	s.SetSecondaryReads(false)
+	if err := secondary.Set(t.Context(), entry(6, 6)); err != nil {
+		t.Fatal(err)
+	}
+	secondary.getMultiCalls = nil
+	if got, err := s.Get(t.Context(), 6); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Errorf("expected nil, got %+v", got)
+	}
+	if entries, err := s.GetMulti(t.Context(), []uint8{6, 4}); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{nil, entry(4, 40)}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if len(secondary.getMultiCalls) != 0 {
+		t.Errorf("the secondary must not be read: %v", secondary.getMultiCalls)
+	}
+
+	s.SetSecondaryReads(true)
+	if got, err := s.Get(t.Context(), 6); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(entry(6, 6), got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+}
+
+func TestMigrationStorage_Consistency(t *testing.T) {
+	t.Parallel()
+
+	storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		return &storage.MigrationStorage[uint8, int8]{
+			Primary:   memstorage.NewInMemoryStorage[uint8, int8](),
+			Secondary: memstorage.NewInMemoryStorage[uint8, int8](),
+		}, func() {}
+	})
+}
+
+func TestMigrationStorage_PromoteError(t *testing.T) {
+	t.Parallel()
+
+	stored := &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)}
+	secondary := memstorage.NewInMemoryStorage[uint8, int8]()
+	if err := secondary.Set(t.Context(), stored); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	s := &storage.MigrationStorage[uint8, int8]{
+		Primary:   &storage.ReadOnlyStorage[uint8, int8]{Storage: memstorage.NewInMemoryStorage[uint8, int8]()},
+		Secondary: secondary,
+		OnPromoteError: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	// the entries found in the Secondary are returned even if the promotion fails
+	if got, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(stored, got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	if got, err := s.GetMulti(t.Context(), []uint8{1, 2}); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{stored, nil}, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]error{storage.ErrReadOnly, storage.ErrReadOnly}, errs, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("unexpected promotion errors (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*NamespacedStorage[uint8, struct{}])(nil)
+
+// NamespacedStorage is a decorator for a loadingcache.CacheStorage that partitions the keys into a namespace.
+// It allows multiple logical caches to share one underlying storage without key collisions.
+// The keys are combined with the namespace on the way to the underlying storage, and stripped on the way back.
+type NamespacedStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// Namespace is the namespace of the keys.
+	Namespace string
+
+	// Join combines the namespace and the key into the key of the underlying storage.
+	// The combined keys must be unique across the namespaces.
+	Join func(ns string, key K) K
+
+	// Split strips the namespace from the key of the underlying storage. It is the inverse of Join.
+	Split func(ns string, key K) K
+}
+
+// NewStringNamespacedStorage creates a new NamespacedStorage for string keys.
+// The keys are prefixed with the namespace and a colon.
+func NewStringNamespacedStorage[V loadingcache.ValueConstraint](s loadingcache.CacheStorage[string, V], ns string) *NamespacedStorage[string, V] {
+	prefix := ns + ":"
+	return &NamespacedStorage[string, V]{
+		Storage:   s,
+		Namespace: ns,
+		Join: func(_ string, key string) string {
+			return prefix + key
+		},
+		Split: func(_ string, key string) string {
+			return key[len(prefix):]
+		},
+	}
+}
+
+// Get retrieves the value associated with the given key in the namespace from the underlying storage.
+func (s *NamespacedStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Storage.Get(ctx, s.Join(s.Namespace, key))
+	if err != nil {
+		return nil, err
+	}
+	return s.strip(entry), nil
+}
+
+// GetMulti retrieves multiple entries in the namespace from the underlying storage.
+func (s *NamespacedStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	joined := make([]K, len(keys))
+	for i, key := range keys {
+		joined[i] = s.Join(s.Namespace, key)
+	}
+
+	entries, err := s.Storage.GetMulti(ctx, joined)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*loadingcache.CacheEntry[K, V], len(entries))
+	for i, entry := range entries {
+		result[i] = s.strip(entry)
+	}
+	return result, nil
+}
+
+// Set stores the entry in the namespace to the underlying storage.
+func (s *NamespacedStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.Storage.Set(ctx, s.join(entry))
+}
+
+// SetMulti stores multiple entries in the namespace to the underlying storage.
+func (s *NamespacedStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	joined := make([]*loadingcache.CacheEntry[K, V], len(entries))
+	for i, entry := range entries {
+		joined[i] = s.join(entry)
+	}
+	return s.Storage.SetMulti(ctx, joined)
+}
+
+// Delete removes the entry by its key in the namespace from the underlying storage.
+func (s *NamespacedStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.Storage.Delete(ctx, s.Join(s.Namespace, key))
+}
+
+// DeleteMulti removes multiple entries in the namespace from the underlying storage.
+func (s *NamespacedStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	joined := make([]K, len(keys))
+	for i, key := range keys {
+		joined[i] = s.Join(s.Namespace, key)
+	}
+	return s.Storage.DeleteMulti(ctx, joined)
+}
+
+// join returns a shallow copy of the entry with the key combined with the namespace.
+func (s *NamespacedStorage[K, V]) join(entry *loadingcache.CacheEntry[K, V]) *loadingcache.CacheEntry[K, V] {
+	if entry == nil {
+		return nil
+	}
+	joined := *entry
+	joined.Key = s.Join(s.Namespace, entry.Key)
+	return &joined
+}
+
+// strip returns a shallow copy of the entry with the namespace stripped from the key.
+// It does not modify the given entry because it may be shared with the underlying storage.
+func (s *NamespacedStorage[K, V]) strip(entry *loadingcache.CacheEntry[K, V]) *loadingcache.CacheEntry[K, V] {
+	if entry == nil {
+		return nil
+	}
+	stripped := *entry
+	stripped.Key = s.Split(s.Namespace, entry.Key)
+	return &stripped
+}
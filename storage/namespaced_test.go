@@ -0,0 +1,81 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func TestNamespacedStorage(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	base := memstorage.NewInMemoryStorage[string, int]()
+	users := storage.NewStringNamespacedStorage(base, "users")
+	items := storage.NewStringNamespacedStorage(base, "items")
+
+	if err := users.Set(t.Context(), &loadingcache.CacheEntry[string, int]{Entry: loadingcache.Entry[string, int]{Key: "1", Value: 100}, ExpiresAt: expiresAt}); err != nil {
+		t.Fatal(err)
+	}
+	if err := items.SetMulti(t.Context(), []*loadingcache.CacheEntry[string, int]{
+		{Entry: loadingcache.Entry[string, int]{Key: "1", Value: 200}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[string, int]{Key: "2", Value: 300}, ExpiresAt: expiresAt},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same keys are isolated per namespace
+	userEntries, err := users.GetMulti(t.Context(), []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[string, int]{
+		{Entry: loadingcache.Entry[string, int]{Key: "1", Value: 100}, ExpiresAt: expiresAt},
+		nil,
+	}, userEntries); diff != "" {
+		t.Errorf("unexpected users (-want +got):\n%s", diff)
+	}
+	item, err := items.Get(t.Context(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&loadingcache.CacheEntry[string, int]{Entry: loadingcache.Entry[string, int]{Key: "1", Value: 200}, ExpiresAt: expiresAt}, item); diff != "" {
+		t.Errorf("unexpected item (-want +got):\n%s", diff)
+	}
+
+	// the underlying storage has the namespaced keys
+	raw, err := base.GetMulti(t.Context(), []string{"1", "users:1", "items:1", "items:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[string, int]{
+		nil,
+		{Entry: loadingcache.Entry[string, int]{Key: "users:1", Value: 100}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[string, int]{Key: "items:1", Value: 200}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[string, int]{Key: "items:2", Value: 300}, ExpiresAt: expiresAt},
+	}, raw); diff != "" {
+		t.Errorf("unexpected underlying entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestNamespacedStorage_Consistency(t *testing.T) {
+	t.Parallel()
+
+	storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		return &storage.NamespacedStorage[uint8, int8]{
+			Storage:   memstorage.NewInMemoryStorage[uint8, int8](),
+			Namespace: "ns",
+			Join: func(_ string, key uint8) uint8 {
+				return key ^ 0x80
+			},
+			Split: func(_ string, key uint8) uint8 {
+				return key ^ 0x80
+			},
+		}, func() {}
+	})
+}
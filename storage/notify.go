@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*NotifyStorage[uint8, struct{}])(nil)
+
+// ChangeKind is a kind of the change of the storage.
+type ChangeKind int
+
+const (
+	// ChangeSet is the change by Set or SetMulti.
+	ChangeSet ChangeKind = iota + 1
+
+	// ChangeDelete is the change by Delete or DeleteMulti.
+	ChangeDelete
+)
+
+// ChangeEvent is an event that describes a change of the storage.
+type ChangeEvent[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Key is the changed key.
+	Key K
+
+	// Kind is the kind of the change.
+	Kind ChangeKind
+
+	// Entry is the entry passed to the storage, or nil for ChangeDelete.
+	// It is shared with the caller of the write operation, so the consumers must treat it as read-only.
+	Entry *loadingcache.CacheEntry[K, V]
+}
+
+// NotifyPolicy is a policy for sending the change events to a full channel.
+type NotifyPolicy int
+
+const (
+	// NotifyDrop drops the change events if the channel is full.
+	NotifyDrop NotifyPolicy = iota
+	// NotifyBlock blocks the write operation until the channel accepts the change events or the context is done.
+	NotifyBlock
+)
+
+// NotifyStorage is a decorator for a loadingcache.CacheStorage that notifies the changes of the storage.
+// After a successful write to the underlying storage, it emits a change event per written key
+// to the Events channel and the OnChange function. The reads pass through unchanged.
+// The events are not emitted for failed writes.
+type NotifyStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// Events is the channel to which the change events are sent.
+	// If it is nil, no events are sent to the channel.
+	Events chan<- ChangeEvent[K, V]
+
+	// Policy is the policy for sending the change events when the Events channel is full.
+	// The default is NotifyDrop.
+	Policy NotifyPolicy
+
+	// OnDrop is an optional function that is called when a change event is dropped.
+	OnDrop func(ChangeEvent[K, V])
+
+	// OnChange is an optional function that is called synchronously for each change event.
+	OnChange func(ChangeEvent[K, V])
+}
+
+// Get retrieves the value associated with the given key from the underlying storage.
+func (s *NotifyStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.Storage.Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries from the underlying storage.
+func (s *NotifyStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	return s.Storage.GetMulti(ctx, keys)
+}
+
+// Set stores the given entry in the underlying storage and notifies the change.
+func (s *NotifyStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	if err := s.Storage.Set(ctx, entry); err != nil {
+		return err
+	}
+
+	s.notify(ctx, ChangeEvent[K, V]{Key: entry.Key, Kind: ChangeSet, Entry: entry})
+	return nil
+}
+
+// SetMulti stores multiple entries in the underlying storage and notifies the changes.
+// The nil entries are not notified.
+func (s *NotifyStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	if err := s.Storage.SetMulti(ctx, entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		s.notify(ctx, ChangeEvent[K, V]{Key: entry.Key, Kind: ChangeSet, Entry: entry})
+	}
+	return nil
+}
+
+// Delete removes the entry by its key from the underlying storage and notifies the change.
+// The change is notified even if the key is missing, since the storage does not report it.
+func (s *NotifyStorage[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.Storage.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.notify(ctx, ChangeEvent[K, V]{Key: key, Kind: ChangeDelete})
+	return nil
+}
+
+// DeleteMulti removes multiple entries from the underlying storage and notifies the changes.
+// The changes are notified even if the keys are missing, since the storage does not report them.
+func (s *NotifyStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if err := s.Storage.DeleteMulti(ctx, keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s.notify(ctx, ChangeEvent[K, V]{Key: key, Kind: ChangeDelete})
+	}
+	return nil
+}
+
+// notify emits the change event.
+func (s *NotifyStorage[K, V]) notify(ctx context.Context, event ChangeEvent[K, V]) {
+	if s.OnChange != nil {
+		s.OnChange(event)
+	}
+	if s.Events == nil {
+		return
+	}
+
+	if s.Policy == NotifyBlock {
+		select {
+		case s.Events <- event:
+			return
+		case <-ctx.Done():
+		}
+	} else {
+		select {
+		case s.Events <- event:
+			return
+		default:
+		}
+	}
+	if s.OnDrop != nil {
+		s.OnDrop(event)
+	}
+}
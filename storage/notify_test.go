@@ -0,0 +1,195 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+func TestNotifyStorage(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan storage.ChangeEvent[uint8, int8], 6)
+	var changed []uint8
+	s := &storage.NotifyStorage[uint8, int8]{
+		Storage: memstorage.NewInMemoryStorage[uint8, int8](),
+		Events:  events,
+		OnChange: func(event storage.ChangeEvent[uint8, int8]) {
+			changed = append(changed, event.Key)
+		},
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	entries := []*loadingcache.CacheEntry[uint8, int8]{
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 3, Value: 3}, ExpiresAt: expiresAt},
+	}
+	if err := s.Set(t.Context(), entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), entries[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetMulti(t.Context(), []uint8{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMulti(t.Context(), []uint8{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+
+	var got []storage.ChangeEvent[uint8, int8]
+	for event := range events {
+		got = append(got, event)
+	}
+	expected := []storage.ChangeEvent[uint8, int8]{
+		{Key: 1, Kind: storage.ChangeSet, Entry: entries[0]},
+		{Key: 2, Kind: storage.ChangeSet, Entry: entries[1]},
+		{Key: 3, Kind: storage.ChangeSet, Entry: entries[2]},
+		{Key: 1, Kind: storage.ChangeDelete},
+		{Key: 2, Kind: storage.ChangeDelete},
+		{Key: 3, Kind: storage.ChangeDelete},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint8{1, 2, 3, 1, 2, 3}, changed); diff != "" {
+		t.Errorf("unexpected changed keys (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotifyStorage_Error(t *testing.T) {
+	t.Parallel()
+
+	storageErr := errors.New("storage error")
+	s := &storage.NotifyStorage[uint8, int8]{
+		Storage: &storage.FunctionsStorage[uint8, int8]{
+			SetFunc: func(context.Context, *loadingcache.CacheEntry[uint8, int8]) error {
+				return storageErr
+			},
+		},
+		OnChange: func(event storage.ChangeEvent[uint8, int8]) {
+			t.Errorf("unexpected event: %+v", event)
+		},
+	}
+	if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int8]{}); !errors.Is(err, storageErr) {
+		t.Errorf("expected %v, got %v", storageErr, err)
+	}
+}
+
+func TestNotifyStorage_Policy(t *testing.T) {
+	t.Parallel()
+
+	entries := []*loadingcache.CacheEntry[uint8, int8]{
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)},
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	t.Run("Drop", func(t *testing.T) {
+		t.Parallel()
+
+		// nobody consumes the events, so the second event must be dropped without blocking
+		events := make(chan storage.ChangeEvent[uint8, int8], 1)
+		var dropped []uint8
+		s := &storage.NotifyStorage[uint8, int8]{
+			Storage: memstorage.NewInMemoryStorage[uint8, int8](),
+			Events:  events,
+			OnDrop: func(event storage.ChangeEvent[uint8, int8]) {
+				dropped = append(dropped, event.Key)
+			},
+		}
+		if err := s.SetMulti(t.Context(), entries); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]uint8{2}, dropped); diff != "" {
+			t.Errorf("unexpected dropped keys (-want +got):\n%s", diff)
+		}
+		if event := <-events; event.Key != 1 {
+			t.Errorf("expected the event of key 1, got %+v", event)
+		}
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		t.Parallel()
+
+		events := make(chan storage.ChangeEvent[uint8, int8])
+		s := &storage.NotifyStorage[uint8, int8]{
+			Storage: memstorage.NewInMemoryStorage[uint8, int8](),
+			Events:  events,
+			Policy:  storage.NotifyBlock,
+			OnDrop: func(event storage.ChangeEvent[uint8, int8]) {
+				t.Errorf("unexpected dropped event: %+v", event)
+			},
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- s.SetMulti(t.Context(), entries)
+		}()
+		for _, entry := range entries {
+			if event := <-events; event.Key != entry.Key {
+				t.Errorf("expected the event of key %d, got %+v", entry.Key, event)
+			}
+		}
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("BlockCanceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		var dropped []uint8
+		s := &storage.NotifyStorage[uint8, int8]{
+			Storage: memstorage.NewInMemoryStorage[uint8, int8](),
+			Events:  make(chan storage.ChangeEvent[uint8, int8]),
+			Policy:  storage.NotifyBlock,
+			OnDrop: func(event storage.ChangeEvent[uint8, int8]) {
+				dropped = append(dropped, event.Key)
+			},
+		}
+		if err := s.Set(ctx, entries[0]); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]uint8{1}, dropped); diff != "" {
+			t.Errorf("unexpected dropped keys (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestNotifyStorage_SetMultiWithNilEntries(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan storage.ChangeEvent[uint8, int8], 3)
+	s := &storage.NotifyStorage[uint8, int8]{
+		Storage: memstorage.NewInMemoryStorage[uint8, int8](),
+		Events:  events,
+	}
+
+	entry := &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{nil, entry, nil}); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+
+	var got []storage.ChangeEvent[uint8, int8]
+	for event := range events {
+		got = append(got, event)
+	}
+	if diff := cmp.Diff([]storage.ChangeEvent[uint8, int8]{{Key: 1, Kind: storage.ChangeSet, Entry: entry}}, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
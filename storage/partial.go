@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// SetMultiPartial stores multiple entries in the storage, and returns the keys of the stored entries.
+// It calls SetMultiPartial of the storage if it implements loadingcache.PartialSetCacheStorage.
+// Otherwise it calls SetMulti, and returns the keys of all the non-nil entries on success or no keys on failure,
+// since the storage does not report which entries are stored.
+func SetMultiPartial[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](ctx context.Context, s loadingcache.CacheStorage[K, V], entries []*loadingcache.CacheEntry[K, V]) ([]K, error) {
+	if s, ok := s.(loadingcache.PartialSetCacheStorage[K, V]); ok {
+		return s.SetMultiPartial(ctx, entries)
+	}
+
+	if err := s.SetMulti(ctx, entries); err != nil {
+		return nil, err
+	}
+	return entryKeys(entries), nil
+}
+
+// entryKeys returns the keys of the non-nil entries.
+func entryKeys[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](entries []*loadingcache.CacheEntry[K, V]) []K {
+	keys := make([]K, 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			keys = append(keys, entry.Key)
+		}
+	}
+	return keys
+}
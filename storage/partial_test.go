@@ -0,0 +1,164 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+// failingStorage is a storage that fails to store the entries of the failing keys,
+// storing the other entries of the same batch before them.
+func failingStorage(backend loadingcache.CacheStorage[uint8, int8], setErr error, failing ...uint8) *storage.FunctionsStorage[uint8, int8] {
+	return &storage.FunctionsStorage[uint8, int8]{
+		GetMultiFunc: backend.GetMulti,
+		SetMultiFunc: func(ctx context.Context, entries []*loadingcache.CacheEntry[uint8, int8]) error {
+			for _, entry := range entries {
+				if entry == nil {
+					continue
+				}
+				if slices.Contains(failing, entry.Key) {
+					return setErr
+				}
+				if err := backend.Set(ctx, entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// persistedKeys returns the keys stored in the storage among the given keys.
+func persistedKeys(t *testing.T, s loadingcache.CacheStorage[uint8, int8], keys []uint8) []uint8 {
+	t.Helper()
+
+	entries, err := s.GetMulti(t.Context(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var persisted []uint8
+	for _, entry := range entries {
+		if entry != nil {
+			persisted = append(persisted, entry.Key)
+		}
+	}
+	return persisted
+}
+
+func TestSetMultiPartial(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	entries := make([]*loadingcache.CacheEntry[uint8, int8], 0, 7)
+	keys := make([]uint8, 0, 6)
+	for key := range uint8(6) {
+		entries = append(entries, &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}, ExpiresAt: expiresAt})
+		keys = append(keys, key)
+	}
+	entries = append(entries, nil)
+	setErr := errors.New("set error")
+
+	t.Run("NotPartialSetCacheStorage", func(t *testing.T) {
+		t.Parallel()
+
+		backend := memstorage.NewInMemoryStorage[uint8, int8]()
+		stored, err := storage.SetMultiPartial(t.Context(), failingStorage(backend, setErr), entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(keys, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+
+		// no keys are reported on failure, even if some of them are stored
+		backend = memstorage.NewInMemoryStorage[uint8, int8]()
+		stored, err = storage.SetMultiPartial(t.Context(), failingStorage(backend, setErr, 3), entries)
+		if !errors.Is(err, setErr) {
+			t.Errorf("expected %v, got %v", setErr, err)
+		}
+		if len(stored) != 0 {
+			t.Errorf("expected no stored keys, got %v", stored)
+		}
+	})
+
+	t.Run("BatchLimitStorage", func(t *testing.T) {
+		t.Parallel()
+
+		// the batches are [0 1] [2 3] [4 5] [nil], and the batch [2 3] fails at 3 after storing 2
+		backend := memstorage.NewInMemoryStorage[uint8, int8]()
+		s := &storage.BatchLimitStorage[uint8, int8]{Storage: failingStorage(backend, setErr, 3), MaxBatchSize: 2}
+		stored, err := s.SetMultiPartial(t.Context(), entries)
+		if !errors.Is(err, setErr) {
+			t.Errorf("expected %v, got %v", setErr, err)
+		}
+		if diff := cmp.Diff([]uint8{0, 1, 4, 5}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]uint8{0, 1, 2, 4, 5}, persistedKeys(t, backend, keys)); diff != "" {
+			t.Errorf("the stored keys must be persisted (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("BatchLimitStorage/PartialSetCacheStorage", func(t *testing.T) {
+		t.Parallel()
+
+		// the underlying routing storage reports the even keys stored in each batch, even though the odd keys fail
+		healthy := memstorage.NewInMemoryStorage[uint8, int8]()
+		failingBackend := failingStorage(memstorage.NewInMemoryStorage[uint8, int8](), setErr, 1, 3, 5)
+		s := &storage.BatchLimitStorage[uint8, int8]{
+			Storage: &storage.RoutingStorage[uint8, int8]{
+				Route: func(key uint8) loadingcache.CacheStorage[uint8, int8] {
+					if key%2 == 0 {
+						return healthy
+					}
+					return failingBackend
+				},
+			},
+			MaxBatchSize: 2,
+		}
+		stored, err := s.SetMultiPartial(t.Context(), entries)
+		if !errors.Is(err, setErr) {
+			t.Errorf("expected %v, got %v", setErr, err)
+		}
+		if diff := cmp.Diff([]uint8{0, 2, 4}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]uint8{0, 2, 4}, persistedKeys(t, healthy, keys)); diff != "" {
+			t.Errorf("the stored keys must be persisted (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("RoutingStorage", func(t *testing.T) {
+		t.Parallel()
+
+		// the even keys are routed to the healthy backend, and the odd keys are routed to the failing one
+		healthy := memstorage.NewInMemoryStorage[uint8, int8]()
+		failing := memstorage.NewInMemoryStorage[uint8, int8]()
+		failingBackend := failingStorage(failing, setErr, 1)
+		s := &storage.RoutingStorage[uint8, int8]{
+			Route: func(key uint8) loadingcache.CacheStorage[uint8, int8] {
+				if key%2 == 0 {
+					return healthy
+				}
+				return failingBackend
+			},
+		}
+		stored, err := s.SetMultiPartial(t.Context(), entries)
+		if !errors.Is(err, setErr) {
+			t.Errorf("expected %v, got %v", setErr, err)
+		}
+		if diff := cmp.Diff([]uint8{0, 2, 4}, stored); diff != "" {
+			t.Errorf("unexpected stored keys (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]uint8{0, 2, 4}, persistedKeys(t, healthy, keys)); diff != "" {
+			t.Errorf("the stored keys must be persisted (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// promoteGet retrieves the entry from the primary, or from the fallback if it is missing in the primary.
+// The entry found in the fallback is stored into the primary before it is returned.
+// If accept is not nil, the entry found in the fallback is treated as missing unless accept reports true for it.
+// The failure of the promotion does not fail the read; its error is passed to onPromoteError if it is not nil.
+func promoteGet[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](
+	ctx context.Context,
+	primary, fallback loadingcache.CacheStorage[K, V],
+	key K,
+	accept func(*loadingcache.CacheEntry[K, V]) bool,
+	onPromoteError func(error),
+) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := primary.Get(ctx, key)
+	if err != nil || entry != nil {
+		return entry, err
+	}
+
+	entry, err = fallback.Get(ctx, key)
+	if err != nil || entry == nil || (accept != nil && !accept(entry)) {
+		return nil, err
+	}
+	if err := primary.Set(ctx, entry); err != nil && onPromoteError != nil {
+		onPromoteError(err)
+	}
+	return entry, nil
+}
+
+// promoteGetMulti retrieves multiple entries from the primary, and the missing ones from the fallback.
+// The entries found in the fallback are stored into the primary by a SetMulti call before they are returned.
+// If accept is not nil, the entries found in the fallback are treated as missing unless accept reports true for them.
+// The failure of the promotion does not fail the read; its error is passed to onPromoteError if it is not nil.
+func promoteGetMulti[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](
+	ctx context.Context,
+	primary, fallback loadingcache.CacheStorage[K, V],
+	keys []K,
+	accept func(*loadingcache.CacheEntry[K, V]) bool,
+	onPromoteError func(error),
+) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := primary.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0, len(keys))
+	for i, entry := range entries {
+		if entry == nil {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return entries, nil
+	}
+
+	missing := make([]K, len(indexes))
+	for i, j := range indexes {
+		missing[i] = keys[j]
+	}
+	found, err := fallback.GetMulti(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted := make([]*loadingcache.CacheEntry[K, V], 0, len(found))
+	for i, j := range indexes {
+		if found[i] != nil && (accept == nil || accept(found[i])) {
+			entries[j] = found[i]
+			promoted = append(promoted, found[i])
+		}
+	}
+	if len(promoted) != 0 {
+		if err := primary.SetMulti(ctx, promoted); err != nil && onPromoteError != nil {
+			onPromoteError(err)
+		}
+	}
+	return entries, nil
+}
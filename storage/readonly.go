@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*ReadOnlyStorage[uint8, struct{}])(nil)
+
+// ReadOnlyStorage is a decorator for a loadingcache.CacheStorage that rejects the write operations.
+// The read operations pass through to the underlying storage.
+type ReadOnlyStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// SilentWrite makes the write operations no-op instead of returning ErrReadOnly.
+	SilentWrite bool
+}
+
+// Get retrieves the value associated with the given key from the underlying storage.
+func (s *ReadOnlyStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.Storage.Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries from the underlying storage.
+func (s *ReadOnlyStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	return s.Storage.GetMulti(ctx, keys)
+}
+
+// Set returns ErrReadOnly, or nil if SilentWrite is true. The entry is never stored.
+func (s *ReadOnlyStorage[K, V]) Set(context.Context, *loadingcache.CacheEntry[K, V]) error {
+	return s.writeError()
+}
+
+// SetMulti returns ErrReadOnly, or nil if SilentWrite is true. The entries are never stored.
+func (s *ReadOnlyStorage[K, V]) SetMulti(context.Context, []*loadingcache.CacheEntry[K, V]) error {
+	return s.writeError()
+}
+
+// Delete returns ErrReadOnly, or nil if SilentWrite is true. The entry is never deleted.
+func (s *ReadOnlyStorage[K, V]) Delete(context.Context, K) error {
+	return s.writeError()
+}
+
+// DeleteMulti returns ErrReadOnly, or nil if SilentWrite is true. The entries are never deleted.
+func (s *ReadOnlyStorage[K, V]) DeleteMulti(context.Context, []K) error {
+	return s.writeError()
+}
+
+func (s *ReadOnlyStorage[K, V]) writeError() error {
+	if s.SilentWrite {
+		return nil
+	}
+	return ErrReadOnly
+}
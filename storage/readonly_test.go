@@ -0,0 +1,62 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+func TestReadOnlyStorage(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	stored := &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: expiresAt}
+	written := &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: expiresAt}
+
+	for _, tt := range []struct {
+		name        string
+		silentWrite bool
+		expectedErr error
+	}{
+		{name: "Reject", silentWrite: false, expectedErr: storage.ErrReadOnly},
+		{name: "Silent", silentWrite: true, expectedErr: nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			base := memstorage.NewInMemoryStorage[uint8, int8]()
+			if err := base.Set(t.Context(), stored); err != nil {
+				t.Fatal(err)
+			}
+			s := &storage.ReadOnlyStorage[uint8, int8]{Storage: base, SilentWrite: tt.silentWrite}
+
+			if err := s.Set(t.Context(), written); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Set: expected %v, got %v", tt.expectedErr, err)
+			}
+			if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{written}); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("SetMulti: expected %v, got %v", tt.expectedErr, err)
+			}
+
+			entry, err := s.Get(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(stored, entry); diff != "" {
+				t.Errorf("Get: unexpected entry (-want +got):\n%s", diff)
+			}
+
+			entries, err := s.GetMulti(t.Context(), []uint8{1, 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{stored, nil}, entries); diff != "" {
+				t.Errorf("GetMulti: the writes must not be stored (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
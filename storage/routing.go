@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*RoutingStorage[uint8, struct{}])(nil)
+var _ loadingcache.PartialSetCacheStorage[uint8, struct{}] = (*RoutingStorage[uint8, struct{}])(nil)
+
+// RoutingStorage is a composite loadingcache.CacheStorage that routes each key to one of the backends.
+// It is useful to store the keys in different backends by their characteristics (e.g. hot keys in memory and the others in a remote store).
+type RoutingStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Route returns the backend storage for the key.
+	// It must always return the same backend for the same key, and the backends must be comparable (e.g. pointers).
+	Route func(K) loadingcache.CacheStorage[K, V]
+}
+
+// routeGroup is a group of the keys routed to the same backend.
+type routeGroup[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	storage loadingcache.CacheStorage[K, V]
+
+	// indexes is the positions of the keys in the input.
+	indexes []int
+}
+
+// Get retrieves the value associated with the given key from the backend of the key.
+func (s *RoutingStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return s.Route(key).Get(ctx, key)
+}
+
+// GetMulti retrieves multiple entries by calling GetMulti of each backend once with the keys routed to it.
+// The results are reassembled in the order of the input keys. If any backend fails, it returns the error immediately.
+func (s *RoutingStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	groups := s.group(len(keys), func(i int) K { return keys[i] })
+	if len(groups) == 1 {
+		return groups[0].storage.GetMulti(ctx, keys)
+	}
+
+	entries := make([]*loadingcache.CacheEntry[K, V], len(keys))
+	for _, g := range groups {
+		groupKeys := make([]K, len(g.indexes))
+		for i, j := range g.indexes {
+			groupKeys[i] = keys[j]
+		}
+
+		groupEntries, err := g.storage.GetMulti(ctx, groupKeys)
+		if err != nil {
+			return nil, err
+		}
+		for i, j := range g.indexes {
+			entries[j] = groupEntries[i]
+		}
+	}
+	return entries, nil
+}
+
+// Set stores the entry to the backend of its key.
+func (s *RoutingStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	return s.Route(entry.Key).Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries by calling SetMulti of each backend once with the entries routed to it.
+// It attempts all the backends even if some of them fail, and returns the errors joined by errors.Join.
+// The nil entries are skipped.
+func (s *RoutingStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	nonNil := make([]*loadingcache.CacheEntry[K, V], 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			nonNil = append(nonNil, entry)
+		}
+	}
+
+	var errs []error
+	for _, g := range s.group(len(nonNil), func(i int) K { return nonNil[i].Key }) {
+		groupEntries := make([]*loadingcache.CacheEntry[K, V], len(g.indexes))
+		for i, j := range g.indexes {
+			groupEntries[i] = nonNil[j]
+		}
+		if err := g.storage.SetMulti(ctx, groupEntries); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetMultiPartial stores multiple entries by calling SetMultiPartial of the package for each backend once
+// with the entries routed to it, and returns the keys of the stored entries.
+// It attempts all the backends even if some of them fail, and returns the errors joined by errors.Join.
+func (s *RoutingStorage[K, V]) SetMultiPartial(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) ([]K, error) {
+	nonNil := make([]*loadingcache.CacheEntry[K, V], 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			nonNil = append(nonNil, entry)
+		}
+	}
+
+	stored := make([]K, 0, len(nonNil))
+	var errs []error
+	for _, g := range s.group(len(nonNil), func(i int) K { return nonNil[i].Key }) {
+		groupEntries := make([]*loadingcache.CacheEntry[K, V], len(g.indexes))
+		for i, j := range g.indexes {
+			groupEntries[i] = nonNil[j]
+		}
+		keys, err := SetMultiPartial(ctx, g.storage, groupEntries)
+		stored = append(stored, keys...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stored, errors.Join(errs...)
+}
+
+// Delete removes the entry by its key from the backend of the key.
+func (s *RoutingStorage[K, V]) Delete(ctx context.Context, key K) error {
+	return s.Route(key).Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries by calling DeleteMulti of each backend once with the keys routed to it.
+// It attempts all the backends even if some of them fail, and returns the errors joined by errors.Join.
+func (s *RoutingStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	var errs []error
+	for _, g := range s.group(len(keys), func(i int) K { return keys[i] }) {
+		groupKeys := make([]K, len(g.indexes))
+		for i, j := range g.indexes {
+			groupKeys[i] = keys[j]
+		}
+		if err := g.storage.DeleteMulti(ctx, groupKeys); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// group groups the positions of n keys by their backends in the order of the first appearance.
+func (s *RoutingStorage[K, V]) group(n int, keyAt func(int) K) []*routeGroup[K, V] {
+	var groups []*routeGroup[K, V]
+	for i := range n {
+		storage := s.Route(keyAt(i))
+
+		var g *routeGroup[K, V]
+		for _, candidate := range groups {
+			if candidate.storage == storage {
+				g = candidate
+				break
+			}
+		}
+		if g == nil {
+			g = &routeGroup[K, V]{storage: storage}
+			groups = append(groups, g)
+		}
+		g.indexes = append(g.indexes, i)
+	}
+	return groups
+}
@@ -0,0 +1,137 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type recordingStorage struct {
+	loadingcache.CacheStorage[uint8, int8]
+
+	getMultiCalls [][]uint8
+	setMultiCalls [][]uint8
+}
+
+func (s *recordingStorage) GetMulti(ctx context.Context, keys []uint8) ([]*loadingcache.CacheEntry[uint8, int8], error) {
+	s.getMultiCalls = append(s.getMultiCalls, keys)
+	return s.CacheStorage.GetMulti(ctx, keys)
+}
+
+func (s *recordingStorage) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[uint8, int8]) error {
+	keys := make([]uint8, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+	s.setMultiCalls = append(s.setMultiCalls, keys)
+	return s.CacheStorage.SetMulti(ctx, entries)
+}
+
+func TestRoutingStorage(t *testing.T) {
+	t.Parallel()
+
+	small := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage[uint8, int8]()}
+	large := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage[uint8, int8]()}
+	s := &storage.RoutingStorage[uint8, int8]{
+		Route: func(key uint8) loadingcache.CacheStorage[uint8, int8] {
+			if key < 10 {
+				return small
+			}
+			return large
+		},
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	entry := func(key uint8) *loadingcache.CacheEntry[uint8, int8] {
+		return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: int8(key)}, ExpiresAt: expiresAt}
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(1), entry(20), nil, entry(2), entry(30)}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]uint8{{1, 2}}, small.setMultiCalls); diff != "" {
+		t.Errorf("unexpected SetMulti calls of small (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{20, 30}}, large.setMultiCalls); diff != "" {
+		t.Errorf("unexpected SetMulti calls of large (-want +got):\n%s", diff)
+	}
+
+	entries, err := s.GetMulti(t.Context(), []uint8{30, 2, 40, 1, 20, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(30), entry(2), nil, entry(1), entry(20), nil}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{2, 1, 3}}, small.getMultiCalls); diff != "" {
+		t.Errorf("unexpected GetMulti calls of small (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{30, 40, 20}}, large.getMultiCalls); diff != "" {
+		t.Errorf("unexpected GetMulti calls of large (-want +got):\n%s", diff)
+	}
+
+	if err := s.Set(t.Context(), entry(50)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := large.Get(t.Context(), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entry(50), got); diff != "" {
+		t.Errorf("the entry must be routed to large (-want +got):\n%s", diff)
+	}
+}
+
+func TestRoutingStorage_SetMultiError(t *testing.T) {
+	t.Parallel()
+
+	errFailing := errors.New("failing")
+	failing := &storage.FunctionsStorage[uint8, int8]{
+		SetMultiFunc: func(context.Context, []*loadingcache.CacheEntry[uint8, int8]) error {
+			return errFailing
+		},
+	}
+	healthy := memstorage.NewInMemoryStorage[uint8, int8]()
+	s := &storage.RoutingStorage[uint8, int8]{
+		Route: func(key uint8) loadingcache.CacheStorage[uint8, int8] {
+			if key%2 == 0 {
+				return failing
+			}
+			return healthy
+		},
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: expiresAt},
+	})
+	if !errors.Is(err, errFailing) {
+		t.Errorf("expected the error of the failing backend, got %v", err)
+	}
+	if entry, err := healthy.Get(t.Context(), 1); err != nil || entry == nil {
+		t.Errorf("the healthy backend must be written: %+v, %v", entry, err)
+	}
+}
+
+func TestRoutingStorage_Consistency(t *testing.T) {
+	t.Parallel()
+
+	storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		backends := []loadingcache.CacheStorage[uint8, int8]{
+			memstorage.NewInMemoryStorage[uint8, int8](),
+			memstorage.NewInMemoryStorage[uint8, int8](),
+		}
+		return &storage.RoutingStorage[uint8, int8]{
+			Route: func(key uint8) loadingcache.CacheStorage[uint8, int8] {
+				return backends[key%2]
+			},
+		}, func() {}
+	})
+}
@@ -0,0 +1,48 @@
+package storagetest
+
+import (
+	"testing"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+// Provider is a provider of the cache storages under test for RunAll.
+// It is one of ClockProvider, ClonerProvider and DeepCopyerProvider.
+type Provider interface {
+	run(t *testing.T)
+}
+
+// ClockProvider provides the storages of int8 values that use the given clock to check the expiration.
+// RunAll runs TestConsistency, TestExpiration and TestNegativeCache with it.
+// TestConsistency is given loadingcache.SystemClock.
+type ClockProvider func(loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func())
+
+func (p ClockProvider) run(t *testing.T) {
+	TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		return p(loadingcache.SystemClock)
+	})
+	TestExpiration(t, p)
+	TestNegativeCache(t, p)
+}
+
+// ClonerProvider provides the storages of TestClonerStruct values. RunAll runs TestCloneStruct with it.
+type ClonerProvider func() (loadingcache.CacheStorage[uint8, *TestClonerStruct], func())
+
+func (p ClonerProvider) run(t *testing.T) {
+	TestCloneStruct(t, p)
+}
+
+// DeepCopyerProvider provides the storages of TestDeepCopyerStruct values. RunAll runs TestDeepCopyStruct with it.
+type DeepCopyerProvider func() (loadingcache.CacheStorage[uint8, *TestDeepCopyerStruct], func())
+
+func (p DeepCopyerProvider) run(t *testing.T) {
+	TestDeepCopyStruct(t, p)
+}
+
+// RunAll runs all the test suites of this package that accept the given providers.
+// A storage implementation can pass the providers of all the kinds to be tested by the suites added in the future as well.
+func RunAll(t *testing.T, providers ...Provider) {
+	for _, p := range providers {
+		p.run(t)
+	}
+}
@@ -4,6 +4,7 @@ package storagetest
 import (
 	"fmt"
 	"math/rand/v2"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -133,6 +134,31 @@ func TestDeepCopyStruct(t *testing.T, provider func() (loadingcache.CacheStorage
 }
 
 func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[uint8, int8], func())) {
+	TestConsistencyWithOptions(t, provider, []loadingcache.Entry[uint8, int8]{
+		{0, 1},
+		{1, 2},
+		{2, 3},
+		{3, 4},
+		{4, 5},
+		{5, 6},
+		{6, 7},
+		{7, 8},
+		{8, 9},
+		{9, 10},
+		{10, 11},
+		{251, 124},
+		{252, 125},
+		{253, 126},
+		{254, 127},
+		{255, -128},
+	}, nil)
+}
+
+// TestConsistencyWithOptions runs the same test cases as TestConsistency for the arbitrary key and value types.
+// The patterns are the entries to be stored, and their keys must be unique.
+// The opts are passed to cmp.Diff to compare the values, e.g. cmp.Comparer for the values containing time.Time
+// or cmp.AllowUnexported for the values with the unexported fields.
+func TestConsistencyWithOptions[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint](t *testing.T, provider func() (loadingcache.CacheStorage[K, V], func()), patterns []loadingcache.Entry[K, V], opts cmp.Options) {
 	t.Run("Consistency", func(t *testing.T) {
 		t.Parallel()
 
@@ -143,30 +169,18 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 			defer release()
 
 			expiresAt := time.Now().Add(time.Hour)
-			patterns := []loadingcache.Entry[uint8, int8]{
-				{0, 1},
-				{1, 2},
-				{2, 3},
-				{3, 4},
-				{4, 5},
-				{251, 124},
-				{252, 125},
-				{253, 126},
-				{254, 127},
-				{255, -128},
-			}
+			patterns := slices.Clone(patterns)
 			rand.Shuffle(len(patterns), func(i, j int) {
 				patterns[i], patterns[j] = patterns[j], patterns[i]
 			})
 			var eg errgroup.Group
 			for _, pattern := range patterns {
-				pattern := pattern
 				eg.Go(func() error {
 					entry, err := storage.Get(t.Context(), pattern.Key)
 					if err != nil {
 						return err
 					} else if entry != nil {
-						return fmt.Errorf("unexpected exists value for key %d", pattern.Key)
+						return fmt.Errorf("unexpected exists value for key %v", pattern.Key)
 					}
 					return nil
 				})
@@ -177,9 +191,8 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 
 			eg = errgroup.Group{}
 			for _, pattern := range patterns {
-				pattern := pattern
 				eg.Go(func() error {
-					return storage.Set(t.Context(), &loadingcache.CacheEntry[uint8, int8]{
+					return storage.Set(t.Context(), &loadingcache.CacheEntry[K, V]{
 						Entry:     pattern,
 						ExpiresAt: expiresAt,
 					})
@@ -190,10 +203,8 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 			}
 
 			eg = errgroup.Group{}
-			entries := make([]*loadingcache.CacheEntry[uint8, int8], len(patterns))
+			entries := make([]*loadingcache.CacheEntry[K, V], len(patterns))
 			for i, pattern := range patterns {
-				i := i
-				pattern := pattern
 				eg.Go(func() error {
 					entry, err := storage.Get(t.Context(), pattern.Key)
 					if err != nil {
@@ -208,8 +219,10 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 			}
 
 			for i, pattern := range patterns {
-				if df := cmp.Diff(pattern, entries[i].Entry); df != "" {
-					t.Errorf("pattern[%d] key=%d entry diff=%s", i, pattern.Key, df)
+				if entries[i] == nil {
+					t.Errorf("pattern[%d] key=%v is missing", i, pattern.Key)
+				} else if df := cmp.Diff(pattern, entries[i].Entry, opts); df != "" {
+					t.Errorf("pattern[%d] key=%v entry diff=%s", i, pattern.Key, df)
 				}
 			}
 		})
@@ -221,55 +234,24 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 		storage, release := provider()
 		defer release()
 
+		// split the patterns into the groups of 1, 2, 3, ... entries
 		expiresAt := time.Now().Add(time.Hour)
-		patterns := []struct {
-			pairs []*loadingcache.CacheEntry[uint8, int8]
-		}{
-			{
-				[]*loadingcache.CacheEntry[uint8, int8]{
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 0, Value: 1}, ExpiresAt: expiresAt},
-				},
-			},
-			{
-				[]*loadingcache.CacheEntry[uint8, int8]{
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 2}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 3}, ExpiresAt: expiresAt},
-				},
-			},
-			{
-				[]*loadingcache.CacheEntry[uint8, int8]{
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 4, Value: 5}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 5, Value: 6}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 6, Value: 7}, ExpiresAt: expiresAt},
-				},
-			},
-			{
-				[]*loadingcache.CacheEntry[uint8, int8]{
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 7, Value: 8}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 8, Value: 9}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 9, Value: 10}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 10, Value: 11}, ExpiresAt: expiresAt},
-				},
-			},
-			{
-				[]*loadingcache.CacheEntry[uint8, int8]{
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 251, Value: 124}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 252, Value: 125}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 253, Value: 126}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 254, Value: 127}, ExpiresAt: expiresAt},
-					{Entry: loadingcache.Entry[uint8, int8]{Key: 255, Value: -128}, ExpiresAt: expiresAt},
-				},
-			},
+		var groups [][]*loadingcache.CacheEntry[K, V]
+		for i, size := 0, 1; i < len(patterns); i, size = i+size, size+1 {
+			group := make([]*loadingcache.CacheEntry[K, V], 0, size)
+			for _, pattern := range patterns[i:min(i+size, len(patterns))] {
+				group = append(group, &loadingcache.CacheEntry[K, V]{Entry: pattern, ExpiresAt: expiresAt})
+			}
+			groups = append(groups, group)
 		}
-		rand.Shuffle(len(patterns), func(i, j int) {
-			patterns[i], patterns[j] = patterns[j], patterns[i]
+		rand.Shuffle(len(groups), func(i, j int) {
+			groups[i], groups[j] = groups[j], groups[i]
 		})
 
 		var eg errgroup.Group
-		for _, pattern := range patterns {
-			pattern := pattern
+		for _, group := range groups {
 			eg.Go(func() error {
-				return storage.SetMulti(t.Context(), pattern.pairs)
+				return storage.SetMulti(t.Context(), group)
 			})
 		}
 		if err := eg.Wait(); err != nil {
@@ -278,13 +260,10 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 
 		eg = errgroup.Group{}
 		mu := sync.Mutex{}
-		results := make([][]*loadingcache.CacheEntry[uint8, int8], len(patterns))
-		for i, pattern := range patterns {
-			i := i
-			results[i] = make([]*loadingcache.CacheEntry[uint8, int8], len(pattern.pairs))
-
-			keys := make([]uint8, len(pattern.pairs))
-			for j, pair := range pattern.pairs {
+		results := make([][]*loadingcache.CacheEntry[K, V], len(groups))
+		for i, group := range groups {
+			keys := make([]K, len(group))
+			for j, pair := range group {
 				keys[j] = pair.Key
 			}
 			eg.Go(func() error {
@@ -303,12 +282,59 @@ func TestConsistency(t *testing.T, provider func() (loadingcache.CacheStorage[ui
 			t.Fatal(err)
 		}
 
-		for i, pattern := range patterns {
-			if df := cmp.Diff(pattern.pairs, results[i]); df != "" {
+		for i, group := range groups {
+			if df := cmp.Diff(group, results[i], opts); df != "" {
 				t.Errorf("pattern[%d] entry diff=%s", i, df)
 			}
 		}
 	})
+
+	t.Run("DeleteAndDeleteMulti", func(t *testing.T) {
+		t.Parallel()
+
+		storage, release := provider()
+		defer release()
+
+		expiresAt := time.Now().Add(time.Hour)
+		entries := make([]*loadingcache.CacheEntry[K, V], len(patterns))
+		keys := make([]K, len(patterns))
+		for i, pattern := range patterns {
+			entries[i] = &loadingcache.CacheEntry[K, V]{Entry: pattern, ExpiresAt: expiresAt}
+			keys[i] = pattern.Key
+		}
+		if err := storage.SetMulti(t.Context(), entries); err != nil {
+			t.Fatal(err)
+		}
+
+		// delete the first entry by Delete, and then the even entries by DeleteMulti
+		// including the already deleted one, which must be a no-op
+		if err := storage.Delete(t.Context(), keys[0]); err != nil {
+			t.Fatal(err)
+		}
+		var deleted []K
+		for i := 0; i < len(keys); i += 2 {
+			deleted = append(deleted, keys[i])
+		}
+		if err := storage.DeleteMulti(t.Context(), deleted); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := storage.GetMulti(t.Context(), keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, pattern := range patterns {
+			if i%2 == 0 {
+				if results[i] != nil {
+					t.Errorf("pattern[%d] key=%v must be deleted", i, pattern.Key)
+				}
+			} else if results[i] == nil {
+				t.Errorf("pattern[%d] key=%v is missing", i, pattern.Key)
+			} else if df := cmp.Diff(pattern, results[i].Entry, opts); df != "" {
+				t.Errorf("pattern[%d] key=%v entry diff=%s", i, pattern.Key, df)
+			}
+		}
+	})
 }
 
 type FixedClock struct {
@@ -462,6 +488,73 @@ func TestExpiration(t *testing.T, provider func(loadingcache.Clock) (loadingcach
 	})
 }
 
+// TestNeverExpiration is the counterpart of TestExpiration for the storages that never expire the entries,
+// such as the ones configured with expiration.NeverExpirationPolicy.
+// It verifies that the entries, including the negative caches, are still returned long after their expiration time.
+func TestNeverExpiration(t *testing.T, provider func(loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func())) {
+	t.Run("NeverExpiration", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("SetAndGet", func(t *testing.T) {
+			t.Parallel()
+
+			base := time.Now()
+			clock := &FixedClock{Time: base}
+			storage, release := provider(clock)
+			defer release()
+
+			expected := &loadingcache.CacheEntry[uint8, int8]{
+				Entry:     loadingcache.Entry[uint8, int8]{Key: 1, Value: 1},
+				ExpiresAt: base.Add(time.Hour),
+			}
+			if err := storage.Set(t.Context(), expected); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, d := range []time.Duration{0, time.Hour, time.Hour + time.Second, 365 * 24 * time.Hour} {
+				clock.Time = base.Add(d)
+				cacheEntry, err := storage.Get(t.Context(), 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if df := cmp.Diff(expected, cacheEntry); df != "" {
+					t.Errorf("entry diff at +%s=%s", d, df)
+				}
+			}
+		})
+
+		t.Run("SetMultiAndGetMulti", func(t *testing.T) {
+			t.Parallel()
+
+			base := time.Now()
+			clock := &FixedClock{Time: base}
+			storage, release := provider(clock)
+			defer release()
+
+			keys := []uint8{1, 2, 3}
+			testEntries := []*loadingcache.CacheEntry[uint8, int8]{
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: base.Add(time.Second)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: base.Add(time.Hour)},
+				{Entry: loadingcache.Entry[uint8, int8]{Key: 3}, ExpiresAt: base.Add(time.Minute), NegativeCache: true},
+			}
+			if err := storage.SetMulti(t.Context(), testEntries); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, d := range []time.Duration{0, time.Hour, time.Hour + time.Second, 365 * 24 * time.Hour} {
+				clock.Time = base.Add(d)
+				entries, err := storage.GetMulti(t.Context(), keys)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if df := cmp.Diff(testEntries, entries); df != "" {
+					t.Errorf("entries diff at +%s=%s", d, df)
+				}
+			}
+		})
+	})
+}
+
 func TestNegativeCache(t *testing.T, provider func(loadingcache.Clock) (loadingcache.CacheStorage[uint8, int8], func())) {
 	t.Run("NegativeCache", func(t *testing.T) {
 		t.Parallel()
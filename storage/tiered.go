@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*TieredStorage[uint8, struct{}])(nil)
+
+// TieredStorage is a composite loadingcache.CacheStorage stacking a small fast storage in front of a larger slower one,
+// e.g. a memstorage in front of a remote storage.
+// The reads check the L1 first and then the L2, and the entries found only in the L2 are promoted into the L1
+// with their original expiration times. The writes and the deletes go to both tiers.
+//
+// The L2 is written before the L1, and deleted before the L1, so that a failed operation never leaves the L1
+// newer than the L2. However, the promotions are not synchronized with the writes and the deletes:
+// a read concurrent with a delete may find the entry in the L2 before the delete and promote it into the L1 after that,
+// so the deleted entry may be served from the L1 until it expires. Likewise, a read concurrent with a write may overwrite
+// the L1 with the older entry.
+type TieredStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// L1 is the fast storage checked first.
+	L1 loadingcache.CacheStorage[K, V]
+
+	// L2 is the slow storage checked for the keys missing in the L1.
+	L2 loadingcache.CacheStorage[K, V]
+
+	// Clock is the clock to check the expiration of the entries found in the L2. The default is loadingcache.SystemClock.
+	// The entries already expired are treated as missing and never promoted, since the L2 may return them
+	// due to its own clock skews or its coarse expiration.
+	Clock loadingcache.Clock
+
+	// OnPromoteError is an optional function that is called with the error of the promotion into the L1.
+	// The failed promotion does not fail the read, and the entries found in the L2 are returned anyway.
+	OnPromoteError func(error)
+}
+
+// Get retrieves the value associated with the given key from the L1, or from the L2 if it is missing in the L1.
+// The entry found in the L2 is stored into the L1 before it is returned.
+// If the promotion fails, it returns the entry anyway and passes the error to OnPromoteError.
+func (s *TieredStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	return promoteGet(ctx, s.L1, s.L2, key, s.isLive(s.now()), s.OnPromoteError)
+}
+
+// GetMulti retrieves multiple entries from the L1, and the missing ones from the L2.
+// The entries found in the L2 are stored into the L1 by a SetMulti call before they are returned.
+// If the promotion fails, it returns the entries anyway and passes the error to OnPromoteError.
+func (s *TieredStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	return promoteGetMulti(ctx, s.L1, s.L2, keys, s.isLive(s.now()), s.OnPromoteError)
+}
+
+// Set stores the entry to the L2, and then to the L1.
+func (s *TieredStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	if err := s.L2.Set(ctx, entry); err != nil {
+		return err
+	}
+	return s.L1.Set(ctx, entry)
+}
+
+// SetMulti stores multiple entries to the L2, and then to the L1.
+func (s *TieredStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	if err := s.L2.SetMulti(ctx, entries); err != nil {
+		return err
+	}
+	return s.L1.SetMulti(ctx, entries)
+}
+
+// Delete removes the entry by its key from the L2, and then from the L1.
+func (s *TieredStorage[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.L2.Delete(ctx, key); err != nil {
+		return err
+	}
+	return s.L1.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the L2, and then from the L1.
+func (s *TieredStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if err := s.L2.DeleteMulti(ctx, keys); err != nil {
+		return err
+	}
+	return s.L1.DeleteMulti(ctx, keys)
+}
+
+// now returns the current time of the clock.
+func (s *TieredStorage[K, V]) now() time.Time {
+	if s.Clock == nil {
+		return loadingcache.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
+
+// isLive returns the function reporting whether the entry found in the L2 is not expired yet at now.
+func (s *TieredStorage[K, V]) isLive(now time.Time) func(*loadingcache.CacheEntry[K, V]) bool {
+	return func(entry *loadingcache.CacheEntry[K, V]) bool {
+		return entry.ExpiresAt.After(now)
+	}
+}
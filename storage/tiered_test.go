@@ -0,0 +1,167 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/expiration"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+func TestTieredStorage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	entry := func(key uint8, value int8, expiresAt time.Time) *loadingcache.CacheEntry[uint8, int8] {
+		return &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: key, Value: value}, ExpiresAt: expiresAt}
+	}
+
+	l1 := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock))}
+	// the L2 never expires the entries by itself, so it returns the expired ones as a coarse remote storage may do
+	l2 := &recordingStorage{CacheStorage: memstorage.NewInMemoryStorage(
+		memstorage.WithClock[uint8, int8](clock),
+		memstorage.WithExpirationPolicy[uint8, int8](expiration.NeverExpirationPolicy{}),
+	)}
+	s := &storage.TieredStorage[uint8, int8]{L1: l1, L2: l2, Clock: clock}
+
+	// the writes go to both tiers
+	if err := s.Set(t.Context(), entry(1, 1, now.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{entry(2, 2, now.Add(time.Hour))}); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]loadingcache.CacheStorage[uint8, int8]{"L1": l1, "L2": l2} {
+		entries, err := tier.GetMulti(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(1, 1, now.Add(time.Hour)), entry(2, 2, now.Add(time.Hour))}, entries); diff != "" {
+			t.Errorf("unexpected entries of the %s (-want +got):\n%s", name, diff)
+		}
+	}
+
+	// the entries only in the L2 are promoted with their original expiration times
+	if err := l2.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+		entry(3, 3, now.Add(time.Minute)),
+		entry(4, 4, now.Add(2*time.Minute)),
+		entry(5, 5, now.Add(-time.Second)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(t.Context(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entry(3, 3, now.Add(time.Minute)), got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	if promoted, err := l1.Get(t.Context(), 3); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(entry(3, 3, now.Add(time.Minute)), promoted); diff != "" {
+		t.Errorf("the entry must be promoted to the L1 (-want +got):\n%s", diff)
+	}
+
+	// the expired entries of the L2 are treated as missing
+	if got, err := s.Get(t.Context(), 5); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Errorf("the expired entry must not be returned: %+v", got)
+	}
+
+	// the partial hits of the L1 are completed by the L2
+	l1.getMultiCalls, l1.setMultiCalls, l2.getMultiCalls = nil, nil, nil
+	entries, err := s.GetMulti(t.Context(), []uint8{1, 4, 5, 6, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{entry(1, 1, now.Add(time.Hour)), entry(4, 4, now.Add(2*time.Minute)), nil, nil, entry(3, 3, now.Add(time.Minute))}, entries); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{4, 5, 6}}, l2.getMultiCalls); diff != "" {
+		t.Errorf("unexpected GetMulti calls of the L2 (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]uint8{{4}}, l1.setMultiCalls); diff != "" {
+		t.Errorf("unexpected promotions (-want +got):\n%s", diff)
+	}
+
+	// the L2 is not read if all the keys hit the L1
+	l2.getMultiCalls = nil
+	if _, err := s.GetMulti(t.Context(), []uint8{1, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if len(l2.getMultiCalls) != 0 {
+		t.Errorf("the L2 must not be read: %v", l2.getMultiCalls)
+	}
+
+	// the deletes go to both tiers
+	if err := s.Delete(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMulti(t.Context(), []uint8{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]loadingcache.CacheStorage[uint8, int8]{"L1": l1, "L2": l2, "tiered": s} {
+		entries, err := tier.GetMulti(t.Context(), []uint8{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{nil, nil, nil}, entries); diff != "" {
+			t.Errorf("the entries must be deleted from the %s (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestTieredStorage_Consistency(t *testing.T) {
+	t.Parallel()
+
+	storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		return &storage.TieredStorage[uint8, int8]{
+			L1: memstorage.NewInMemoryStorage[uint8, int8](),
+			L2: memstorage.NewInMemoryStorage[uint8, int8](),
+		}, func() {}
+	})
+}
+
+func TestTieredStorage_PromoteError(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	stored := &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Hour)}
+	l2 := memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock))
+	if err := l2.Set(t.Context(), stored); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	s := &storage.TieredStorage[uint8, int8]{
+		L1:    &storage.ReadOnlyStorage[uint8, int8]{Storage: memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock))},
+		L2:    l2,
+		Clock: clock,
+		OnPromoteError: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	// the entries found in the L2 are returned even if the promotion fails
+	if got, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(stored, got); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+	if got, err := s.GetMulti(t.Context(), []uint8{1, 2}); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff([]*loadingcache.CacheEntry[uint8, int8]{stored, nil}, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]error{storage.ErrReadOnly, storage.ErrReadOnly}, errs, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("unexpected promotion errors (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*TransformStorage[uint8, struct{}, struct{}])(nil)
+
+// TransformStorage is a decorator for a loadingcache.CacheStorage that transforms the values at the storage boundary.
+// It allows the underlying storage to keep a compact representation of the values, such as the interned strings
+// or the packed structs, while exposing the rich type to the callers.
+// The values are encoded on the way to the underlying storage, and decoded on the way back.
+// The expiration times and the negative caches are preserved as they are, and the values of the negative caches
+// are never transformed since they must be the zero values.
+type TransformStorage[K loadingcache.KeyConstraint, OuterV loadingcache.ValueConstraint, InnerV loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, InnerV]
+
+	// Encode transforms the value into the representation of the underlying storage.
+	Encode func(OuterV) InnerV
+
+	// Decode transforms the representation of the underlying storage into the value. It is the inverse of Encode.
+	Decode func(InnerV) OuterV
+}
+
+// Get retrieves the entry associated with the given key from the underlying storage, and decodes its value.
+func (s *TransformStorage[K, OuterV, InnerV]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, OuterV], error) {
+	entry, err := s.Storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(entry), nil
+}
+
+// GetMulti retrieves multiple entries from the underlying storage, and decodes their values.
+func (s *TransformStorage[K, OuterV, InnerV]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, OuterV], error) {
+	entries, err := s.Storage.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*loadingcache.CacheEntry[K, OuterV], len(entries))
+	for i, entry := range entries {
+		result[i] = s.decode(entry)
+	}
+	return result, nil
+}
+
+// Set encodes the value of the entry, and stores it to the underlying storage.
+func (s *TransformStorage[K, OuterV, InnerV]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, OuterV]) error {
+	return s.Storage.Set(ctx, s.encode(entry))
+}
+
+// SetMulti encodes the values of multiple entries, and stores them to the underlying storage.
+func (s *TransformStorage[K, OuterV, InnerV]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, OuterV]) error {
+	encoded := make([]*loadingcache.CacheEntry[K, InnerV], len(entries))
+	for i, entry := range entries {
+		encoded[i] = s.encode(entry)
+	}
+	return s.Storage.SetMulti(ctx, encoded)
+}
+
+// Delete removes the entry by its key from the underlying storage.
+func (s *TransformStorage[K, OuterV, InnerV]) Delete(ctx context.Context, key K) error {
+	return s.Storage.Delete(ctx, key)
+}
+
+// DeleteMulti removes multiple entries from the underlying storage.
+func (s *TransformStorage[K, OuterV, InnerV]) DeleteMulti(ctx context.Context, keys []K) error {
+	return s.Storage.DeleteMulti(ctx, keys)
+}
+
+// encode returns the entry of the underlying storage with the encoded value.
+func (s *TransformStorage[K, OuterV, InnerV]) encode(entry *loadingcache.CacheEntry[K, OuterV]) *loadingcache.CacheEntry[K, InnerV] {
+	if entry == nil {
+		return nil
+	}
+
+	encoded := &loadingcache.CacheEntry[K, InnerV]{
+		Entry:          loadingcache.Entry[K, InnerV]{Key: entry.Key},
+		ExpiresAt:      entry.ExpiresAt,
+		NegativeCache:  entry.NegativeCache,
+		NegativeReason: entry.NegativeReason,
+	}
+	if !entry.NegativeCache {
+		encoded.Value = s.Encode(entry.Value)
+	}
+	return encoded
+}
+
+// decode returns the entry with the decoded value of the entry of the underlying storage.
+func (s *TransformStorage[K, OuterV, InnerV]) decode(entry *loadingcache.CacheEntry[K, InnerV]) *loadingcache.CacheEntry[K, OuterV] {
+	if entry == nil {
+		return nil
+	}
+
+	decoded := &loadingcache.CacheEntry[K, OuterV]{
+		Entry:          loadingcache.Entry[K, OuterV]{Key: entry.Key},
+		ExpiresAt:      entry.ExpiresAt,
+		NegativeCache:  entry.NegativeCache,
+		NegativeReason: entry.NegativeReason,
+	}
+	if !entry.NegativeCache {
+		decoded.Value = s.Decode(entry.Value)
+	}
+	return decoded
+}
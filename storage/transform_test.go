@@ -0,0 +1,97 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type point struct {
+	X, Y int16
+}
+
+func packPoint(p point) uint32 {
+	return uint32(uint16(p.X))<<16 | uint32(uint16(p.Y))
+}
+
+func unpackPoint(v uint32) point {
+	return point{X: int16(v >> 16), Y: int16(v)}
+}
+
+func TestTransformStorage(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	base := memstorage.NewInMemoryStorage[string, uint32]()
+	s := &storage.TransformStorage[string, point, uint32]{
+		Storage: base,
+		Encode:  packPoint,
+		Decode:  unpackPoint,
+	}
+
+	entries := []*loadingcache.CacheEntry[string, point]{
+		{Entry: loadingcache.Entry[string, point]{Key: "a", Value: point{X: 1, Y: -2}}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[string, point]{Key: "b"}, ExpiresAt: expiresAt, NegativeCache: true, NegativeReason: loadingcache.NegativeCachePermanent},
+	}
+	if err := s.SetMulti(t.Context(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(t.Context(), &loadingcache.CacheEntry[string, point]{Entry: loadingcache.Entry[string, point]{Key: "c", Value: point{X: -300, Y: 400}}, ExpiresAt: expiresAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the values round-trip through the packed representation
+	got, err := s.GetMulti(t.Context(), []string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*loadingcache.CacheEntry[string, point]{
+		entries[0],
+		entries[1],
+		{Entry: loadingcache.Entry[string, point]{Key: "c", Value: point{X: -300, Y: 400}}, ExpiresAt: expiresAt},
+		nil,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+	entry, err := s.Get(t.Context(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entries[0], entry); diff != "" {
+		t.Errorf("unexpected entry (-want +got):\n%s", diff)
+	}
+
+	// the underlying storage has the packed values
+	raw, err := base.GetMulti(t.Context(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*loadingcache.CacheEntry[string, uint32]{
+		{Entry: loadingcache.Entry[string, uint32]{Key: "a", Value: 0x0001fffe}, ExpiresAt: expiresAt},
+		{Entry: loadingcache.Entry[string, uint32]{Key: "b"}, ExpiresAt: expiresAt, NegativeCache: true, NegativeReason: loadingcache.NegativeCachePermanent},
+	}, raw); diff != "" {
+		t.Errorf("unexpected underlying entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestTransformStorage_Consistency(t *testing.T) {
+	t.Parallel()
+
+	storagetest.TestConsistency(t, func() (loadingcache.CacheStorage[uint8, int8], func()) {
+		return &storage.TransformStorage[uint8, int8, string]{
+			Storage: memstorage.NewInMemoryStorage[uint8, string](),
+			Encode: func(v int8) string {
+				return string(rune(v) + 0x100)
+			},
+			Decode: func(v string) int8 {
+				return int8([]rune(v)[0] - 0x100)
+			},
+		}, func() {}
+	})
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	loadingcache "github.com/karupanerura/loading-cache"
+)
+
+var _ loadingcache.CacheStorage[uint8, struct{}] = (*TTLObserverStorage[uint8, struct{}])(nil)
+
+// TTLObserverStorage is a decorator for a loadingcache.CacheStorage that observes the TTLs of the entries to tune them.
+// It reports the remaining TTL of each hit of Get and GetMulti, including the negative caches, to OnRemainingTTL.
+// The reads and the writes pass through unchanged.
+//
+// It also reports the realized lifetime of each entry to OnLifetime, which is the time from its write until it is found
+// missing by a read. The decorator cannot see the evictions of the underlying storage, so the lifetime of an entry
+// evicted before its expiration is overestimated up to the read, and the lifetime of an expired entry is capped
+// at its expiration time. The entries that are never read after they are gone are not reported.
+// To do so, it keeps the write time of each written key until it is found missing, only if OnLifetime is set.
+type TTLObserverStorage[K loadingcache.KeyConstraint, V loadingcache.ValueConstraint] struct {
+	// Storage is the underlying storage that this decorator wraps.
+	Storage loadingcache.CacheStorage[K, V]
+
+	// Clock is the clock to measure the TTLs. The default is loadingcache.SystemClock.
+	Clock loadingcache.Clock
+
+	// OnRemainingTTL is an optional function that is called with the remaining TTL of each hit.
+	OnRemainingTTL func(key K, remaining time.Duration)
+
+	// OnLifetime is an optional function that is called with the realized lifetime of each entry found missing.
+	OnLifetime func(key K, lifetime time.Duration)
+
+	mu      sync.Mutex
+	written map[K]writtenEntry
+}
+
+// writtenEntry is the write time and the expiration time of a written entry.
+type writtenEntry struct {
+	writtenAt time.Time
+	expiresAt time.Time
+}
+
+// Get retrieves the value associated with the given key from the underlying storage and observes its TTL.
+func (s *TTLObserverStorage[K, V]) Get(ctx context.Context, key K) (*loadingcache.CacheEntry[K, V], error) {
+	entry, err := s.Storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s.observe(s.now(), key, entry)
+	return entry, nil
+}
+
+// GetMulti retrieves multiple entries from the underlying storage and observes their TTLs.
+func (s *TTLObserverStorage[K, V]) GetMulti(ctx context.Context, keys []K) ([]*loadingcache.CacheEntry[K, V], error) {
+	entries, err := s.Storage.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for i, entry := range entries {
+		s.observe(now, keys[i], entry)
+	}
+	return entries, nil
+}
+
+// Set stores the given entry in the underlying storage, and keeps its write time to observe its lifetime.
+func (s *TTLObserverStorage[K, V]) Set(ctx context.Context, entry *loadingcache.CacheEntry[K, V]) error {
+	if err := s.Storage.Set(ctx, entry); err != nil {
+		return err
+	}
+	s.record(s.now(), entry)
+	return nil
+}
+
+// SetMulti stores multiple entries in the underlying storage, and keeps their write times to observe their lifetimes.
+func (s *TTLObserverStorage[K, V]) SetMulti(ctx context.Context, entries []*loadingcache.CacheEntry[K, V]) error {
+	if err := s.Storage.SetMulti(ctx, entries); err != nil {
+		return err
+	}
+
+	now := s.now()
+	for _, entry := range entries {
+		s.record(now, entry)
+	}
+	return nil
+}
+
+// Delete removes the entry by its key from the underlying storage.
+// The lifetime of the deleted entry is not reported, since it is ended explicitly rather than by the expiration or the eviction.
+func (s *TTLObserverStorage[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.Storage.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.forget(key)
+	return nil
+}
+
+// DeleteMulti removes multiple entries from the underlying storage.
+// The lifetimes of the deleted entries are not reported.
+func (s *TTLObserverStorage[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	if err := s.Storage.DeleteMulti(ctx, keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s.forget(key)
+	}
+	return nil
+}
+
+// now returns the current time of the clock.
+func (s *TTLObserverStorage[K, V]) now() time.Time {
+	if s.Clock == nil {
+		return loadingcache.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
+
+// observe reports the remaining TTL of the hit, or the lifetime of the written entry found missing.
+func (s *TTLObserverStorage[K, V]) observe(now time.Time, key K, entry *loadingcache.CacheEntry[K, V]) {
+	if entry != nil {
+		if s.OnRemainingTTL != nil {
+			s.OnRemainingTTL(key, entry.ExpiresAt.Sub(now))
+		}
+		return
+	}
+	if s.OnLifetime == nil {
+		return
+	}
+
+	s.mu.Lock()
+	written, ok := s.written[key]
+	delete(s.written, key)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	end := now
+	if written.expiresAt.Before(end) {
+		end = written.expiresAt
+	}
+	s.OnLifetime(key, end.Sub(written.writtenAt))
+}
+
+// record keeps the write time of the entry if OnLifetime is set. The nil entry is ignored.
+func (s *TTLObserverStorage[K, V]) record(now time.Time, entry *loadingcache.CacheEntry[K, V]) {
+	if s.OnLifetime == nil || entry == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.written == nil {
+		s.written = map[K]writtenEntry{}
+	}
+	s.written[entry.Key] = writtenEntry{writtenAt: now, expiresAt: entry.ExpiresAt}
+}
+
+// forget drops the write time of the key.
+func (s *TTLObserverStorage[K, V]) forget(key K) {
+	if s.OnLifetime == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.written, key)
+}
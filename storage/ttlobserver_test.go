@@ -0,0 +1,105 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/storage"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+	"github.com/karupanerura/loading-cache/storage/storagetest"
+)
+
+type ttlSample struct {
+	Key uint8
+	TTL time.Duration
+}
+
+func TestTTLObserverStorage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	var remaining, lifetimes []ttlSample
+	s := &storage.TTLObserverStorage[uint8, int8]{
+		Storage: memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock)),
+		Clock:   clock,
+		OnRemainingTTL: func(key uint8, ttl time.Duration) {
+			remaining = append(remaining, ttlSample{Key: key, TTL: ttl})
+		},
+		OnLifetime: func(key uint8, lifetime time.Duration) {
+			lifetimes = append(lifetimes, ttlSample{Key: key, TTL: lifetime})
+		},
+	}
+
+	if err := s.Set(t.Context(), &loadingcache.CacheEntry[uint8, int8]{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 2, Value: 2}, ExpiresAt: now.Add(2 * time.Hour)},
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 3}, ExpiresAt: now.Add(3 * time.Hour), NegativeCache: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Time = now.Add(10 * time.Minute)
+	if _, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetMulti(t.Context(), []uint8{2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]ttlSample{
+		{Key: 1, TTL: 50 * time.Minute},
+		{Key: 2, TTL: 110 * time.Minute},
+		{Key: 3, TTL: 170 * time.Minute},
+	}, remaining); diff != "" {
+		t.Errorf("unexpected remaining TTLs (-want +got):\n%s", diff)
+	}
+	if lifetimes != nil {
+		t.Errorf("unexpected lifetimes for the live entries: %v", lifetimes)
+	}
+
+	// the expired entry is reported once with its lifetime capped at the expiration time
+	clock.Time = now.Add(90 * time.Minute)
+	for range 2 {
+		if _, err := s.Get(t.Context(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]ttlSample{{Key: 1, TTL: time.Hour}}, lifetimes); diff != "" {
+		t.Errorf("unexpected lifetimes (-want +got):\n%s", diff)
+	}
+}
+
+func TestTTLObserverStorage_SetMultiWithNilEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &storagetest.FixedClock{Time: now}
+	var lifetimes []ttlSample
+	s := &storage.TTLObserverStorage[uint8, int8]{
+		Storage: memstorage.NewInMemoryStorage(memstorage.WithClock[uint8, int8](clock)),
+		Clock:   clock,
+		OnLifetime: func(key uint8, lifetime time.Duration) {
+			lifetimes = append(lifetimes, ttlSample{Key: key, TTL: lifetime})
+		},
+	}
+
+	if err := s.SetMulti(t.Context(), []*loadingcache.CacheEntry[uint8, int8]{
+		nil,
+		{Entry: loadingcache.Entry[uint8, int8]{Key: 1, Value: 1}, ExpiresAt: now.Add(time.Hour)},
+		nil,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Time = now.Add(2 * time.Hour)
+	if _, err := s.Get(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]ttlSample{{Key: 1, TTL: time.Hour}}, lifetimes); diff != "" {
+		t.Errorf("unexpected lifetimes (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,47 @@
+package loadingcache
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutLoadingCache is a wrapper of LoadingCache that bounds each operation by a timeout.
+type TimeoutLoadingCache[K KeyConstraint, V ValueConstraint] struct {
+	cache   *LoadingCache[K, V]
+	timeout time.Duration
+}
+
+// WithOperationTimeout wraps the cache to bound each operation by the timeout.
+// Each operation runs with a child context of the caller's context with the timeout,
+// so the earlier deadline of the caller's context is respected as well.
+// The loader and the storage must respect the context cancellation.
+func WithOperationTimeout[K KeyConstraint, V ValueConstraint](cache *LoadingCache[K, V], timeout time.Duration) *TimeoutLoadingCache[K, V] {
+	return &TimeoutLoadingCache[K, V]{
+		cache:   cache,
+		timeout: timeout,
+	}
+}
+
+// GetOrLoad calls LoadingCache.GetOrLoad with the timeout.
+// It returns context.DeadlineExceeded if the operation does not complete in time.
+func (c *TimeoutLoadingCache[K, V]) GetOrLoad(ctx context.Context, key K) (*Entry[K, V], error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.cache.GetOrLoad(ctx, key)
+}
+
+// GetOrLoadMulti calls LoadingCache.GetOrLoadMulti with the timeout.
+// It returns context.DeadlineExceeded if the operation does not complete in time.
+func (c *TimeoutLoadingCache[K, V]) GetOrLoadMulti(ctx context.Context, keys []K) ([]*Entry[K, V], error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.cache.GetOrLoadMulti(ctx, keys)
+}
+
+// GetOrLoadMultiCacheEntries calls LoadingCache.GetOrLoadMultiCacheEntries with the timeout.
+// It returns context.DeadlineExceeded if the operation does not complete in time.
+func (c *TimeoutLoadingCache[K, V]) GetOrLoadMultiCacheEntries(ctx context.Context, keys []K) ([]*CacheEntry[K, V], error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.cache.GetOrLoadMultiCacheEntries(ctx, keys)
+}
@@ -0,0 +1,85 @@
+package loadingcache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader/pureloader"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
+)
+
+func TestWithOperationTimeout(t *testing.T) {
+	t.Parallel()
+
+	newCache := func(delay time.Duration) *loadingcache.LoadingCache[uint8, string] {
+		s := memstorage.NewInMemoryStorage[uint8, string]()
+		src := source.FromGet(func(ctx context.Context, key uint8) (*loadingcache.CacheEntry[uint8, string], error) {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return &loadingcache.CacheEntry[uint8, string]{
+				Entry:     loadingcache.Entry[uint8, string]{Key: key, Value: "value"},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		})
+		return &loadingcache.LoadingCache[uint8, string]{
+			Loader:  pureloader.NewPureLoader(s, src),
+			Storage: s,
+		}
+	}
+
+	t.Run("Slow", func(t *testing.T) {
+		t.Parallel()
+
+		cache := loadingcache.WithOperationTimeout(newCache(time.Minute), 10*time.Millisecond)
+		if _, err := cache.GetOrLoad(t.Context(), 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GetOrLoad: expected context.DeadlineExceeded, got %v", err)
+		}
+		if _, err := cache.GetOrLoadMulti(t.Context(), []uint8{1, 2}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GetOrLoadMulti: expected context.DeadlineExceeded, got %v", err)
+		}
+		if _, err := cache.GetOrLoadMultiCacheEntries(t.Context(), []uint8{1, 2}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GetOrLoadMultiCacheEntries: expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("CallerDeadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		cache := loadingcache.WithOperationTimeout(newCache(time.Minute), time.Hour)
+		if _, err := cache.GetOrLoad(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Fast", func(t *testing.T) {
+		t.Parallel()
+
+		cache := loadingcache.WithOperationTimeout(newCache(0), time.Minute)
+		entry, err := cache.GetOrLoad(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&loadingcache.Entry[uint8, string]{Key: 1, Value: "value"}, entry); diff != "" {
+			t.Errorf("unexpected entry (-want +got):\n%s", diff)
+		}
+
+		entries, err := cache.GetOrLoadMulti(t.Context(), []uint8{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*loadingcache.Entry[uint8, string]{{Key: 1, Value: "value"}, {Key: 2, Value: "value"}}, entries); diff != "" {
+			t.Errorf("unexpected entries (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -26,48 +26,124 @@ func (NopValueCloner[V]) CloneValue(v V) V {
 	return v
 }
 
+// Immutable is a marker interface for the immutable value types.
+// DefaultValueCloner returns ImmutableValueCloner for the value types implementing it, so the storages and the loaders
+// using the default value cloner (e.g. memstorage and singleflightloader) share the values as ImmutableValues does.
+type Immutable interface {
+	Immutable()
+}
+
+// ImmutableValueCloner is a value cloner for the immutable values, which never clones values as NopValueCloner does.
+// It also declares that the values are never modified after they are loaded, so the storages and the loaders
+// given it may share the entries between the storage and the readers instead of copying them.
+// Use ImmutableValues to create it, or implement Immutable by the value type to make it the default.
+type ImmutableValueCloner[V ValueConstraint] struct{}
+
+// CloneValue returns the input value.
+func (ImmutableValueCloner[V]) CloneValue(v V) V {
+	return v
+}
+
+// ImmutableValues returns the preset value cloner for the immutable values.
+// Pass it to both the storage and the loader (e.g. memstorage.WithCloner and singleflightloader.WithCloner)
+// to avoid the redundant copies of the values and the entries on the load and the store paths.
+// The callers must treat the returned entries and their values as read-only.
+func ImmutableValues[V ValueConstraint]() ValueCloner[V] {
+	return ImmutableValueCloner[V]{}
+}
+
 // DefaultValueCloner returns a default cloner for the given value type.
-// It returns a NopValueCloner if the value type does not have Clone or DeepCopy method.
-// The value type must implement Clone or DeepCopy method.
+// It selects the first applicable strategy in the order of ImmutableStrategy, CloneMethodStrategy, DeepCopyMethodStrategy
+// and PrimitiveStrategy: ImmutableValueCloner for the Immutable types, the Clone method, the DeepCopy method,
+// or NopValueCloner for the primitive types.
+// It panics if none of them is applicable. Use ResolveValueCloner to configure the strategies.
 func DefaultValueCloner[V ValueConstraint]() ValueCloner[V] {
-	var zero V
-	return defaultValueClonerAny[V](zero)
+	cloner, ok := ResolveValueCloner(ImmutableStrategy[V](), CloneMethodStrategy[V](), DeepCopyMethodStrategy[V](), PrimitiveStrategy[V]())
+	if !ok {
+		panic("value type does not have Clone or DeepCopy method")
+	}
+	return cloner
+}
+
+// ValueClonerStrategy is a strategy to select the value cloner for the value type V.
+// It returns false if it is not applicable to V.
+type ValueClonerStrategy[V ValueConstraint] func() (ValueCloner[V], bool)
+
+// ResolveValueCloner returns the value cloner of the first applicable strategy in the given order.
+// It returns false if none of them is applicable.
+func ResolveValueCloner[V ValueConstraint](strategies ...ValueClonerStrategy[V]) (ValueCloner[V], bool) {
+	for _, strategy := range strategies {
+		if cloner, ok := strategy(); ok {
+			return cloner, true
+		}
+	}
+	return nil, false
+}
+
+// ImmutableStrategy returns the strategy applicable to the value types implementing Immutable,
+// which never clones the values by ImmutableValueCloner.
+func ImmutableStrategy[V ValueConstraint]() ValueClonerStrategy[V] {
+	return func() (ValueCloner[V], bool) {
+		var zero V
+		if _, ok := any(zero).(Immutable); !ok {
+			return nil, false
+		}
+		return ImmutableValueCloner[V]{}, true
+	}
 }
 
-func defaultValueClonerAny[V ValueConstraint](v any) ValueCloner[V] {
+// CloneMethodStrategy returns the strategy applicable to the value types with the Clone() V method, which clones the values by it.
+func CloneMethodStrategy[V ValueConstraint]() ValueClonerStrategy[V] {
 	type cloner interface {
 		Clone() V
 	}
+	return func() (ValueCloner[V], bool) {
+		var zero V
+		if _, ok := any(zero).(cloner); !ok {
+			return nil, false
+		}
+		return ValueClonerFunc[V](func(v V) V {
+			return any(v).(cloner).Clone()
+		}), true
+	}
+}
+
+// DeepCopyMethodStrategy returns the strategy applicable to the value types with the DeepCopy() V method, which clones the values by it.
+func DeepCopyMethodStrategy[V ValueConstraint]() ValueClonerStrategy[V] {
 	type deepCopier interface {
 		DeepCopy() V
 	}
-
-	switch v.(type) {
-	case cloner:
-		return ValueClonerFunc[V](func(v V) V {
-			var a any = v
-			return a.(cloner).Clone()
-		})
-
-	case deepCopier:
+	return func() (ValueCloner[V], bool) {
+		var zero V
+		if _, ok := any(zero).(deepCopier); !ok {
+			return nil, false
+		}
 		return ValueClonerFunc[V](func(v V) V {
-			var a any = v
-			return a.(deepCopier).DeepCopy()
-		})
+			return any(v).(deepCopier).DeepCopy()
+		}), true
+	}
+}
 
-	default:
-		return defaultValueClonerReflect[V](reflect.ValueOf(v).Type())
+// PrimitiveStrategy returns the strategy applicable to the primitive value types such as the numbers and the strings,
+// which never clones the values by NopValueCloner.
+func PrimitiveStrategy[V ValueConstraint]() ValueClonerStrategy[V] {
+	return func() (ValueCloner[V], bool) {
+		switch reflect.TypeFor[V]().Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Uintptr, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
+			reflect.String, reflect.UnsafePointer:
+			return NopValueCloner[V]{}, true
+		default:
+			return nil, false
+		}
 	}
 }
 
-func defaultValueClonerReflect[V ValueConstraint](typ reflect.Type) ValueCloner[V] {
-	switch typ.Kind() {
-	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Uintptr, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
-		reflect.String, reflect.UnsafePointer:
-		return NopValueCloner[V]{}
-	default:
-		panic("value type does not have Clone or DeepCopy method")
+// FixedStrategy returns the strategy always applicable with the given cloner, e.g. a cloner registered for the value type
+// or a cloner by serialization. Put it last as the fallback of the other strategies.
+func FixedStrategy[V ValueConstraint](cloner ValueCloner[V]) ValueClonerStrategy[V] {
+	return func() (ValueCloner[V], bool) {
+		return cloner, cloner != nil
 	}
 }
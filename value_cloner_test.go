@@ -1,9 +1,14 @@
 package loadingcache_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	loadingcache "github.com/karupanerura/loading-cache"
+	"github.com/karupanerura/loading-cache/loader/singleflightloader"
+	"github.com/karupanerura/loading-cache/source"
+	"github.com/karupanerura/loading-cache/storage/memstorage"
 )
 
 // Test structs with different cloning behaviors
@@ -87,6 +92,99 @@ func TestDefaultClonerWithNoSpecialMethod(t *testing.T) {
 	loadingcache.DefaultValueCloner[*SimpleStruct]()
 }
 
+// multiClonerStruct supports both Clone and DeepCopy, recording which one made the copy.
+type multiClonerStruct struct {
+	ClonedBy string
+}
+
+func (s *multiClonerStruct) Clone() *multiClonerStruct {
+	return &multiClonerStruct{ClonedBy: "Clone"}
+}
+
+func (s *multiClonerStruct) DeepCopy() *multiClonerStruct {
+	return &multiClonerStruct{ClonedBy: "DeepCopy"}
+}
+
+func TestResolveValueCloner(t *testing.T) {
+	t.Parallel()
+
+	fixed := loadingcache.FixedStrategy[*multiClonerStruct](loadingcache.ValueClonerFunc[*multiClonerStruct](func(*multiClonerStruct) *multiClonerStruct {
+		return &multiClonerStruct{ClonedBy: "Fixed"}
+	}))
+	tests := []struct {
+		name       string
+		strategies []loadingcache.ValueClonerStrategy[*multiClonerStruct]
+		want       string
+		wantOK     bool
+	}{
+		{
+			name:       "the first applicable strategy wins",
+			strategies: []loadingcache.ValueClonerStrategy[*multiClonerStruct]{loadingcache.DeepCopyMethodStrategy[*multiClonerStruct](), loadingcache.CloneMethodStrategy[*multiClonerStruct](), fixed},
+			want:       "DeepCopy",
+			wantOK:     true,
+		},
+		{
+			name:       "the inapplicable strategies are skipped",
+			strategies: []loadingcache.ValueClonerStrategy[*multiClonerStruct]{loadingcache.PrimitiveStrategy[*multiClonerStruct](), fixed, loadingcache.CloneMethodStrategy[*multiClonerStruct]()},
+			want:       "Fixed",
+			wantOK:     true,
+		},
+		{
+			name:       "none is applicable",
+			strategies: []loadingcache.ValueClonerStrategy[*multiClonerStruct]{loadingcache.PrimitiveStrategy[*multiClonerStruct](), loadingcache.FixedStrategy[*multiClonerStruct](nil)},
+			wantOK:     false,
+		},
+		{
+			name:   "no strategies",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cloner, ok := loadingcache.ResolveValueCloner(tt.strategies...)
+			if ok != tt.wantOK {
+				t.Fatalf("ResolveValueCloner() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := cloner.CloneValue(&multiClonerStruct{}).ClonedBy; got != tt.want {
+				t.Errorf("cloned by %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := loadingcache.ResolveValueCloner(loadingcache.PrimitiveStrategy[int]()); !ok {
+		t.Error("PrimitiveStrategy must be applicable to int")
+	}
+	if _, ok := loadingcache.ResolveValueCloner(loadingcache.CloneMethodStrategy[*TestDeepCopyerStruct]()); ok {
+		t.Error("CloneMethodStrategy must not be applicable to the type without Clone")
+	}
+}
+
+type immutableStruct struct {
+	Value int
+}
+
+func (*immutableStruct) Immutable() {}
+
+func TestDefaultClonerWithImmutable(t *testing.T) {
+	t.Parallel()
+
+	cloner := loadingcache.DefaultValueCloner[*immutableStruct]()
+	if _, ok := cloner.(loadingcache.ImmutableValueCloner[*immutableStruct]); !ok {
+		t.Errorf("Expected ImmutableValueCloner for the Immutable type, got %T", cloner)
+	}
+
+	original := &immutableStruct{Value: 42}
+	if cloned := cloner.CloneValue(original); cloned != original {
+		t.Error("Expected the same pointer for the Immutable type")
+	}
+}
+
 func TestDefaultClonerImplementation(t *testing.T) {
 	t.Parallel()
 
@@ -120,3 +218,44 @@ func TestDefaultClonerImplementation(t *testing.T) {
 		t.Error("Expected NopValueCloner for type with no special methods")
 	}
 }
+
+func BenchmarkImmutableValues(b *testing.B) {
+	src := &source.FunctionsSource[int, *TestClonerStruct]{
+		GetMultiFunc: func(_ context.Context, keys []int) ([]*loadingcache.CacheEntry[int, *TestClonerStruct], error) {
+			entries := make([]*loadingcache.CacheEntry[int, *TestClonerStruct], len(keys))
+			for i, key := range keys {
+				entries[i] = &loadingcache.CacheEntry[int, *TestClonerStruct]{
+					Entry:     loadingcache.Entry[int, *TestClonerStruct]{Key: key, Value: &TestClonerStruct{Value: key}},
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			return entries, nil
+		},
+	}
+
+	keys := make([]int, 16)
+	for i := range keys {
+		keys[i] = i
+	}
+	for _, bc := range []struct {
+		name   string
+		cloner loadingcache.ValueCloner[*TestClonerStruct]
+	}{
+		{name: "Default", cloner: loadingcache.DefaultValueCloner[*TestClonerStruct]()},
+		{name: "Immutable", cloner: loadingcache.ImmutableValues[*TestClonerStruct]()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			s := memstorage.NewInMemoryStorage(memstorage.WithCloner[int](bc.cloner))
+			loader := singleflightloader.NewSingleFlightLoader(s, src, singleflightloader.WithCloner[int](bc.cloner))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := loader.LoadAndStoreMulti(b.Context(), keys); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := s.GetMulti(b.Context(), keys); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}